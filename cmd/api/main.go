@@ -11,6 +11,7 @@ import (
 	"github.com/gin-gonic/gin"
 	"github.com/nikomkinds/SchoolSchedule/internal/config"
 	"github.com/nikomkinds/SchoolSchedule/internal/handlers"
+	"github.com/nikomkinds/SchoolSchedule/internal/models"
 	"github.com/nikomkinds/SchoolSchedule/internal/repositories"
 	"github.com/nikomkinds/SchoolSchedule/internal/repositories/postgres"
 	"github.com/nikomkinds/SchoolSchedule/internal/services"
@@ -43,14 +44,32 @@ func main() {
 	teacherRepo := repositories.NewTeacherRepository(db)
 	classRepo := repositories.NewClassRepository(db)
 	scheduleRepo := repositories.NewScheduleRepository(db)
+	academicYearRepo := repositories.NewAcademicYearRepository(db)
+	bellScheduleRepo := repositories.NewBellScheduleRepository(db)
+	shiftRepo := repositories.NewShiftRepository(db)
+	planningRepo := repositories.NewPlanningRepository(db)
+	substitutionRepo := repositories.NewSubstitutionRepository(db)
+	availabilityRepo := repositories.NewTeacherAvailabilityRepository(db)
+	auditRepo := repositories.NewAuditRepository(db)
+	calendarFeedRepo := repositories.NewCalendarFeedRepository(db)
+	importRepo := repositories.NewImportRepository(db)
 
 	// ================= SERVICES =====================
+	auditService := services.NewAuditService(auditRepo)
 	authService := services.NewAuthService(authRepo, db, cfg.JWTSecret)
-	classroomService := services.NewClassroomService(classroomRepo)
-	subjectService := services.NewSubjectService(subjectRepo)
-	teacherService := services.NewTeacherService(teacherRepo)
-	classService := services.NewClassService(classRepo)
-	scheduleService := services.NewScheduleService(scheduleRepo)
+	subjectService := services.NewSubjectService(subjectRepo, auditService)
+	teacherService := services.NewTeacherService(teacherRepo, auditService)
+	classService := services.NewClassService(classRepo, auditService)
+	scheduleService := services.NewScheduleService(scheduleRepo, bellScheduleRepo, planningRepo, shiftRepo, academicYearRepo, auditService)
+	academicYearService := services.NewAcademicYearService(academicYearRepo)
+	bellScheduleService := services.NewBellScheduleService(bellScheduleRepo)
+	shiftService := services.NewShiftService(shiftRepo)
+	substitutionService := services.NewSubstitutionService(substitutionRepo, academicYearRepo, scheduleService)
+	classroomService := services.NewClassroomService(classroomRepo, scheduleService, substitutionService, auditService)
+	availabilityService := services.NewTeacherAvailabilityService(availabilityRepo)
+	calendarService := services.NewCalendarService(calendarFeedRepo, substitutionRepo, academicYearRepo, scheduleService)
+	exportService := services.NewExportService(scheduleService)
+	importService := services.NewImportService(importRepo, teacherRepo, classRepo, subjectRepo, classroomRepo, auditService)
 
 	// ================= HANDLERS =====================
 	authHandler := handlers.NewAuthHandler(authService)
@@ -59,9 +78,19 @@ func main() {
 	teacherHandler := handlers.NewTeacherHandler(teacherService)
 	classHandler := handlers.NewClassHandler(classService)
 	scheduleHandler := handlers.NewScheduleHandler(scheduleService)
+	academicYearHandler := handlers.NewAcademicYearHandler(academicYearService)
+	bellScheduleHandler := handlers.NewBellScheduleHandler(bellScheduleService)
+	shiftHandler := handlers.NewShiftHandler(shiftService)
+	substitutionHandler := handlers.NewSubstitutionHandler(substitutionService)
+	availabilityHandler := handlers.NewTeacherAvailabilityHandler(availabilityService)
+	auditHandler := handlers.NewAuditHandler(auditService)
+	calendarHandler := handlers.NewCalendarHandler(calendarService)
+	exportHandler := handlers.NewExportHandler(exportService)
+	importHandler := handlers.NewImportHandler(importService)
 
 	// ================= ROUTER (GIN) ================
 	router := gin.Default()
+	router.Use(utils.RequestIDMiddleware())
 	api := router.Group("/api")
 
 	// ---------- AUTH ----------
@@ -69,6 +98,10 @@ func main() {
 	auth.POST("/login", authHandler.Login)
 	auth.POST("/refresh", authHandler.Refresh)
 
+	// ---------- CALENDAR SUBSCRIPTIONS ----------
+	// Polled by calendar apps, which cannot send the auth cookie; the token in the URL is the credential
+	api.GET("/calendar/:token", calendarHandler.Feed)
+
 	// ---------- PROTECTED ----------
 	protected := api.Group("/")
 	protected.Use(utils.AuthMiddleware(cfg.JWTSecret))
@@ -76,13 +109,16 @@ func main() {
 	// ---------- CLASSROOMS ----------
 	classrooms := protected.Group("/classrooms")
 	classrooms.GET("", classroomHandler.GetAll)
+	classrooms.GET("/free", classroomHandler.FreeRooms)
 	classrooms.POST("", classroomHandler.Create)
+	classrooms.PUT("/:id", classroomHandler.Update)
 	classrooms.DELETE("/:id", classroomHandler.Delete)
 
 	// ---------- SUBJECTS ----------
 	subjects := protected.Group("/subjects")
 	subjects.GET("", subjectHandler.GetAll)
 	subjects.POST("", subjectHandler.Create)
+	subjects.PUT("/:id", subjectHandler.Update)
 	subjects.DELETE("/:id", subjectHandler.Delete)
 
 	// ---------- TEACHERS ----------
@@ -92,6 +128,9 @@ func main() {
 	users.POST("/Teachers", teacherHandler.Create)
 	users.DELETE("/Teachers/:id", teacherHandler.Delete)
 	users.PATCH("/Teachers/bulk", teacherHandler.BulkUpdate)
+	users.GET("/Teachers/:id/availability", availabilityHandler.Get)
+	users.PUT("/Teachers/:id/availability", availabilityHandler.Save)
+	users.DELETE("/Teachers/:id/availability", availabilityHandler.Delete)
 
 	// ---------- CLASSES ----------
 	classes := protected.Group("/classes")
@@ -105,9 +144,95 @@ func main() {
 	schedule.GET("", scheduleHandler.GetSchedule)
 	schedule.PUT("", scheduleHandler.UpdateScheduleForTeacher)
 	schedule.POST("/generate", scheduleHandler.GenerateSchedule)
-	schedule.GET("/:id", scheduleHandler.GetScheduleByID)
+	schedule.GET("/calendar", substitutionHandler.GetCalendar)
+	schedule.GET("/free-slots", scheduleHandler.FindFreeSlots)
+	schedule.GET("/compare", scheduleHandler.CompareSchedules)
+	schedule.GET("/all", scheduleHandler.ListSchedules)
+	schedule.GET("/export/ics", calendarHandler.ExportICS)
 	schedule.POST("", scheduleHandler.CreateSchedule)
-	schedule.DELETE("/:id", scheduleHandler.DeleteSchedule)
+
+	// Routes of one schedule check the user's access to it: view, edit or owner
+	view := scheduleHandler.RequireAccess(models.ScheduleAccessView)
+	edit := scheduleHandler.RequireAccess(models.ScheduleAccessEdit)
+	owner := scheduleHandler.RequireAccess(models.ScheduleAccessOwner)
+	schedule.GET("/:id", view, scheduleHandler.GetScheduleByID)
+	schedule.POST("/:id/fill-rooms", edit, scheduleHandler.FillRooms)
+	schedule.POST("/:id/lessons", edit, scheduleHandler.AddLesson)
+	schedule.PUT("/:id/lessons/:lessonId", edit, scheduleHandler.UpdateLesson)
+	schedule.POST("/:id/lessons/rearrange", edit, scheduleHandler.RearrangeLessons)
+	schedule.POST("/:id/lessons/:lessonId/move", edit, scheduleHandler.MoveLesson)
+	schedule.POST("/:id/lessons/:lessonId/swap", edit, scheduleHandler.SwapLessons)
+	schedule.DELETE("/:id/lessons/:lessonId", edit, scheduleHandler.DeleteLesson)
+	schedule.GET("/:id/export/pdf", view, exportHandler.ExportPDF)
+	schedule.GET("/:id/export/xlsx", view, exportHandler.ExportXLSX)
+	schedule.GET("/:id/revisions", view, scheduleHandler.ListRevisions)
+	schedule.GET("/:id/revisions/:version", view, scheduleHandler.GetRevision)
+	schedule.POST("/:id/revisions/:version/restore", edit, scheduleHandler.RestoreRevision)
+	schedule.DELETE("/:id", owner, scheduleHandler.DeleteSchedule)
+	schedule.PATCH("/:id", edit, scheduleHandler.RenameSchedule)
+	schedule.POST("/:id/clone", view, scheduleHandler.CloneSchedule)
+	schedule.POST("/:id/activate", owner, scheduleHandler.ActivateSchedule)
+	schedule.GET("/:id/transitions", view, scheduleHandler.ListTransitions)
+	schedule.POST("/:id/transitions", view, scheduleHandler.TransitionSchedule)
+	schedule.GET("/:id/shares", owner, scheduleHandler.ListShares)
+	schedule.POST("/:id/shares", owner, scheduleHandler.ShareSchedule)
+	schedule.DELETE("/:id/shares/:shareId", owner, scheduleHandler.DeleteShare)
+
+	// ---------- ACADEMIC CALENDAR ----------
+	academicYears := protected.Group("/academic-years")
+	academicYears.GET("", academicYearHandler.GetAll)
+	academicYears.POST("", academicYearHandler.Create)
+	academicYears.PUT("/:id", academicYearHandler.Update)
+	academicYears.DELETE("/:id", academicYearHandler.Delete)
+	academicYears.POST("/:id/terms", academicYearHandler.CreateTerm)
+	academicYears.POST("/:id/holidays", academicYearHandler.CreateHoliday)
+
+	terms := protected.Group("/terms")
+	terms.GET("/current", academicYearHandler.GetCurrentTerm)
+	terms.PUT("/:id", academicYearHandler.UpdateTerm)
+	terms.DELETE("/:id", academicYearHandler.DeleteTerm)
+
+	protected.DELETE("/holidays/:id", academicYearHandler.DeleteHoliday)
+
+	// ---------- BELL SCHEDULES ----------
+	bellSchedules := protected.Group("/bell-schedules")
+	bellSchedules.GET("", bellScheduleHandler.GetAll)
+	bellSchedules.POST("", bellScheduleHandler.Create)
+	bellSchedules.PUT("/:id", bellScheduleHandler.Update)
+	bellSchedules.DELETE("/:id", bellScheduleHandler.Delete)
+
+	// ---------- SHIFTS ----------
+	shifts := protected.Group("/shifts")
+	shifts.GET("", shiftHandler.GetAll)
+	shifts.POST("", shiftHandler.Create)
+	shifts.PUT("/:id", shiftHandler.Update)
+	shifts.DELETE("/:id", shiftHandler.Delete)
+	shifts.PUT("/:id/classes", shiftHandler.AssignClasses)
+
+	// ---------- SUBSTITUTIONS ----------
+	absences := protected.Group("/absences")
+	absences.GET("", substitutionHandler.GetAbsences)
+	absences.POST("", substitutionHandler.CreateAbsence)
+	absences.DELETE("/:id", substitutionHandler.DeleteAbsence)
+	absences.GET("/:id/lessons", substitutionHandler.GetAbsenceLessons)
+
+	overrides := protected.Group("/lesson-overrides")
+	overrides.GET("", substitutionHandler.GetOverrides)
+	overrides.POST("", substitutionHandler.SaveOverride)
+	overrides.GET("/suggestions", substitutionHandler.SuggestSubstitutes)
+	overrides.DELETE("/:id", substitutionHandler.DeleteOverride)
+
+	calendarFeeds := protected.Group("/calendar-feeds")
+	calendarFeeds.GET("", calendarHandler.ListFeeds)
+	calendarFeeds.POST("", calendarHandler.CreateFeed)
+	calendarFeeds.DELETE("/:id", calendarHandler.DeleteFeed)
+
+	// ---------- IMPORT ----------
+	protected.POST("/import/:entity", importHandler.Import)
+
+	// ---------- ADMIN ----------
+	admin := protected.Group("/admin")
+	admin.GET("/audit", auditHandler.List)
 
 	// ================= SERVER ======================
 	addr := cfg.ServHost + ":" + cfg.ServPort
@@ -0,0 +1,246 @@
+package handlers
+
+import (
+	"database/sql"
+	"errors"
+	"net/http"
+
+	"github.com/gin-gonic/gin"
+	"github.com/google/uuid"
+	"github.com/nikomkinds/SchoolSchedule/internal/models"
+	"github.com/nikomkinds/SchoolSchedule/internal/services"
+)
+
+type AcademicYearHandler struct {
+	service services.AcademicYearService
+}
+
+func NewAcademicYearHandler(service services.AcademicYearService) *AcademicYearHandler {
+	return &AcademicYearHandler{service: service}
+}
+
+// GetAll implements ep: GET /academic-years
+func (h *AcademicYearHandler) GetAll(c *gin.Context) {
+	ctx := c.Request.Context()
+
+	years, err := h.service.GetAll(ctx)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "failed to load academic years", "details": err.Error()})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{"data": years})
+}
+
+// Create implements ep: POST /academic-years
+func (h *AcademicYearHandler) Create(c *gin.Context) {
+	var req models.AcademicYearRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "invalid payload"})
+		return
+	}
+
+	ctx := c.Request.Context()
+	year, err := h.service.Create(ctx, req)
+	if err != nil {
+		respondCalendarError(c, err, "failed to create academic year")
+		return
+	}
+
+	c.JSON(http.StatusCreated, gin.H{"data": year})
+}
+
+// Update implements ep: PUT /academic-years/:id
+func (h *AcademicYearHandler) Update(c *gin.Context) {
+	id, err := uuid.Parse(c.Param("id"))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "invalid id"})
+		return
+	}
+
+	var req models.AcademicYearRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "invalid payload"})
+		return
+	}
+
+	ctx := c.Request.Context()
+	year, err := h.service.Update(ctx, id, req)
+	if err != nil {
+		respondCalendarError(c, err, "failed to update academic year")
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{"data": year})
+}
+
+// Delete implements ep: DELETE /academic-years/:id
+func (h *AcademicYearHandler) Delete(c *gin.Context) {
+	id, err := uuid.Parse(c.Param("id"))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "invalid id"})
+		return
+	}
+
+	ctx := c.Request.Context()
+	if err := h.service.Delete(ctx, id); err != nil {
+		respondCalendarError(c, err, "failed to delete academic year")
+		return
+	}
+
+	c.Status(http.StatusNoContent)
+}
+
+// GetCurrentTerm implements ep: GET /terms/current?date=YYYY-MM-DD
+func (h *AcademicYearHandler) GetCurrentTerm(c *gin.Context) {
+	date, ok := parseDateQuery(c, "date")
+	if !ok {
+		return
+	}
+
+	ctx := c.Request.Context()
+	term, err := h.service.GetTermByDate(ctx, date)
+	if err != nil {
+		respondCalendarError(c, err, "failed to load term")
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{"data": term})
+}
+
+// CreateTerm implements ep: POST /academic-years/:id/terms
+func (h *AcademicYearHandler) CreateTerm(c *gin.Context) {
+	yearID, err := uuid.Parse(c.Param("id"))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "invalid id"})
+		return
+	}
+
+	var req models.AcademicTermRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "invalid payload"})
+		return
+	}
+
+	ctx := c.Request.Context()
+	term, err := h.service.CreateTerm(ctx, yearID, req)
+	if err != nil {
+		respondCalendarError(c, err, "failed to create term")
+		return
+	}
+
+	c.JSON(http.StatusCreated, gin.H{"data": term})
+}
+
+// UpdateTerm implements ep: PUT /terms/:id
+func (h *AcademicYearHandler) UpdateTerm(c *gin.Context) {
+	id, err := uuid.Parse(c.Param("id"))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "invalid id"})
+		return
+	}
+
+	var req models.AcademicTermRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "invalid payload"})
+		return
+	}
+
+	ctx := c.Request.Context()
+	term, err := h.service.UpdateTerm(ctx, id, req)
+	if err != nil {
+		respondCalendarError(c, err, "failed to update term")
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{"data": term})
+}
+
+// DeleteTerm implements ep: DELETE /terms/:id
+func (h *AcademicYearHandler) DeleteTerm(c *gin.Context) {
+	id, err := uuid.Parse(c.Param("id"))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "invalid id"})
+		return
+	}
+
+	ctx := c.Request.Context()
+	if err := h.service.DeleteTerm(ctx, id); err != nil {
+		respondCalendarError(c, err, "failed to delete term")
+		return
+	}
+
+	c.Status(http.StatusNoContent)
+}
+
+// CreateHoliday implements ep: POST /academic-years/:id/holidays
+func (h *AcademicYearHandler) CreateHoliday(c *gin.Context) {
+	yearID, err := uuid.Parse(c.Param("id"))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "invalid id"})
+		return
+	}
+
+	var req models.HolidayRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "invalid payload"})
+		return
+	}
+
+	ctx := c.Request.Context()
+	holiday, err := h.service.CreateHoliday(ctx, yearID, req)
+	if err != nil {
+		respondCalendarError(c, err, "failed to create holiday")
+		return
+	}
+
+	c.JSON(http.StatusCreated, gin.H{"data": holiday})
+}
+
+// DeleteHoliday implements ep: DELETE /holidays/:id
+func (h *AcademicYearHandler) DeleteHoliday(c *gin.Context) {
+	id, err := uuid.Parse(c.Param("id"))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "invalid id"})
+		return
+	}
+
+	ctx := c.Request.Context()
+	if err := h.service.DeleteHoliday(ctx, id); err != nil {
+		respondCalendarError(c, err, "failed to delete holiday")
+		return
+	}
+
+	c.Status(http.StatusNoContent)
+}
+
+// respondCalendarError maps academic calendar errors to HTTP statuses
+func respondCalendarError(c *gin.Context, err error, message string) {
+	switch {
+	case errors.Is(err, sql.ErrNoRows):
+		c.JSON(http.StatusNotFound, gin.H{"error": "not found"})
+	case errors.Is(err, services.ErrInvalidDateRange),
+		errors.Is(err, services.ErrOutsideAcademicYear),
+		errors.Is(err, services.ErrInvalidTermType),
+		errors.Is(err, services.ErrTermsOverlap):
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+	default:
+		c.JSON(http.StatusInternalServerError, gin.H{"error": message, "details": err.Error()})
+	}
+}
+
+// parseDateQuery reads an optional YYYY-MM-DD query parameter, defaulting to today.
+// On a malformed value it writes 400 and returns false.
+func parseDateQuery(c *gin.Context, name string) (models.Date, bool) {
+	raw := c.Query(name)
+	if raw == "" {
+		return models.Today(), true
+	}
+
+	date, err := models.ParseDate(raw)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "invalid " + name + ", expected YYYY-MM-DD"})
+		return models.Date{}, false
+	}
+	return date, true
+}
@@ -0,0 +1,84 @@
+package handlers
+
+import (
+	"net/http"
+	"strconv"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"github.com/google/uuid"
+	"github.com/nikomkinds/SchoolSchedule/internal/models"
+	"github.com/nikomkinds/SchoolSchedule/internal/services"
+)
+
+type AuditHandler struct {
+	service services.AuditService
+}
+
+func NewAuditHandler(service services.AuditService) *AuditHandler {
+	return &AuditHandler{service: service}
+}
+
+// List implements ep: GET /admin/audit?entity=&entityId=&from=&to=&limit=
+func (h *AuditHandler) List(c *gin.Context) {
+	if c.GetString("role") != models.RoleAdmin {
+		c.JSON(http.StatusForbidden, gin.H{"error": "admin only"})
+		return
+	}
+
+	filter := models.AuditFilter{Entity: c.Query("entity")}
+	if raw := c.Query("entityId"); raw != "" {
+		id, err := uuid.Parse(raw)
+		if err != nil {
+			c.JSON(http.StatusBadRequest, gin.H{"error": "invalid entityId"})
+			return
+		}
+		filter.EntityID = &id
+	}
+	var ok bool
+	if filter.From, ok = parseTimeQuery(c, "from", false); !ok {
+		return
+	}
+	if filter.To, ok = parseTimeQuery(c, "to", true); !ok {
+		return
+	}
+	if raw := c.Query("limit"); raw != "" {
+		limit, err := strconv.Atoi(raw)
+		if err != nil || limit < 1 {
+			c.JSON(http.StatusBadRequest, gin.H{"error": "invalid limit"})
+			return
+		}
+		filter.Limit = limit
+	}
+
+	ctx := c.Request.Context()
+	entries, err := h.service.List(ctx, filter)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "failed to load audit log", "details": err.Error()})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{"data": entries})
+}
+
+// parseTimeQuery reads an optional RFC 3339 time or YYYY-MM-DD date from the query; a date as the end
+// of a range includes the whole day. On a malformed value it writes 400 and returns false.
+func parseTimeQuery(c *gin.Context, name string, end bool) (*time.Time, bool) {
+	raw := c.Query(name)
+	if raw == "" {
+		return nil, true
+	}
+	if t, err := time.Parse(time.RFC3339, raw); err == nil {
+		return &t, true
+	}
+	date, err := models.ParseDate(raw)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "invalid " + name + ", expected RFC 3339 time or YYYY-MM-DD"})
+		return nil, false
+	}
+	t := date.Time
+	if end {
+		t = t.AddDate(0, 0, 1)
+	}
+	return &t, true
+}
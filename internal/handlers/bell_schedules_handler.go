@@ -0,0 +1,107 @@
+package handlers
+
+import (
+	"database/sql"
+	"errors"
+	"net/http"
+
+	"github.com/gin-gonic/gin"
+	"github.com/google/uuid"
+	"github.com/nikomkinds/SchoolSchedule/internal/models"
+	"github.com/nikomkinds/SchoolSchedule/internal/services"
+)
+
+type BellScheduleHandler struct {
+	service services.BellScheduleService
+}
+
+func NewBellScheduleHandler(service services.BellScheduleService) *BellScheduleHandler {
+	return &BellScheduleHandler{service: service}
+}
+
+// GetAll implements ep: GET /bell-schedules
+func (h *BellScheduleHandler) GetAll(c *gin.Context) {
+	ctx := c.Request.Context()
+
+	bells, err := h.service.GetAll(ctx)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "failed to load bell schedules", "details": err.Error()})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{"data": bells})
+}
+
+// Create implements ep: POST /bell-schedules
+func (h *BellScheduleHandler) Create(c *gin.Context) {
+	var req models.BellScheduleRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "invalid payload"})
+		return
+	}
+
+	ctx := c.Request.Context()
+	bell, err := h.service.Create(ctx, req)
+	if err != nil {
+		if errors.Is(err, services.ErrInvalidBellSchedule) {
+			c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+			return
+		}
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "failed to create bell schedule", "details": err.Error()})
+		return
+	}
+
+	c.JSON(http.StatusCreated, gin.H{"data": bell})
+}
+
+// Update implements ep: PUT /bell-schedules/:id
+func (h *BellScheduleHandler) Update(c *gin.Context) {
+	id, err := uuid.Parse(c.Param("id"))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "invalid id"})
+		return
+	}
+
+	var req models.BellScheduleRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "invalid payload"})
+		return
+	}
+
+	ctx := c.Request.Context()
+	bell, err := h.service.Update(ctx, id, req)
+	if err != nil {
+		switch {
+		case errors.Is(err, sql.ErrNoRows):
+			c.JSON(http.StatusNotFound, gin.H{"error": "bell schedule not found"})
+		case errors.Is(err, services.ErrInvalidBellSchedule):
+			c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		default:
+			c.JSON(http.StatusInternalServerError, gin.H{"error": "failed to update bell schedule", "details": err.Error()})
+		}
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{"data": bell})
+}
+
+// Delete implements ep: DELETE /bell-schedules/:id
+func (h *BellScheduleHandler) Delete(c *gin.Context) {
+	id, err := uuid.Parse(c.Param("id"))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "invalid id"})
+		return
+	}
+
+	ctx := c.Request.Context()
+	if err := h.service.Delete(ctx, id); err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			c.JSON(http.StatusNotFound, gin.H{"error": "bell schedule not found"})
+			return
+		}
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "failed to delete bell schedule", "details": err.Error()})
+		return
+	}
+
+	c.Status(http.StatusNoContent)
+}
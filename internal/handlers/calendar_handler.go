@@ -0,0 +1,156 @@
+package handlers
+
+import (
+	"database/sql"
+	"errors"
+	"net/http"
+	"strings"
+
+	"github.com/gin-gonic/gin"
+	"github.com/google/uuid"
+	"github.com/nikomkinds/SchoolSchedule/internal/models"
+	"github.com/nikomkinds/SchoolSchedule/internal/services"
+)
+
+const icsContentType = "text/calendar; charset=utf-8"
+
+type CalendarHandler struct {
+	service services.CalendarService
+}
+
+func NewCalendarHandler(service services.CalendarService) *CalendarHandler {
+	return &CalendarHandler{service: service}
+}
+
+// ExportICS implements ep: GET /schedule/export/ics?termId=&teacherId=|classId=|roomId=
+func (h *CalendarHandler) ExportICS(c *gin.Context) {
+	actor, ok := actorFrom(c)
+	if !ok {
+		return
+	}
+	var target models.CalendarTarget
+	for name, dst := range map[string]**uuid.UUID{
+		"termId":    &target.TermID,
+		"teacherId": &target.TeacherID,
+		"classId":   &target.ClassID,
+		"roomId":    &target.ClassroomID,
+	} {
+		if raw := c.Query(name); raw != "" {
+			id, err := uuid.Parse(raw)
+			if err != nil {
+				c.JSON(http.StatusBadRequest, gin.H{"error": "invalid " + name})
+				return
+			}
+			*dst = &id
+		}
+	}
+
+	ctx := c.Request.Context()
+	data, err := h.service.ExportICS(ctx, actor.UserID, target)
+	if err != nil {
+		respondCalendarExportError(c, err)
+		return
+	}
+
+	c.Header("Content-Disposition", `attachment; filename="schedule.ics"`)
+	c.Data(http.StatusOK, icsContentType, data)
+}
+
+// ListFeeds implements ep: GET /calendar-feeds
+func (h *CalendarHandler) ListFeeds(c *gin.Context) {
+	actor, ok := actorFrom(c)
+	if !ok {
+		return
+	}
+
+	ctx := c.Request.Context()
+	feeds, err := h.service.ListFeeds(ctx, actor.UserID)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "failed to load calendar feeds", "details": err.Error()})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{"data": feeds})
+}
+
+// CreateFeed implements ep: POST /calendar-feeds
+func (h *CalendarHandler) CreateFeed(c *gin.Context) {
+	actor, ok := actorFrom(c)
+	if !ok {
+		return
+	}
+	var req models.CalendarTarget
+	// The body is optional: a teacher subscribes to the own timetable
+	if err := c.ShouldBindJSON(&req); err != nil && err.Error() != "EOF" {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "invalid payload"})
+		return
+	}
+
+	ctx := c.Request.Context()
+	feed, err := h.service.CreateFeed(ctx, actor.UserID, req)
+	if err != nil {
+		respondCalendarExportError(c, err)
+		return
+	}
+
+	scheme := "http"
+	if c.Request.TLS != nil || c.GetHeader("X-Forwarded-Proto") == "https" {
+		scheme = "https"
+	}
+	feed.URL = scheme + "://" + c.Request.Host + "/api/calendar/" + feed.Token + ".ics"
+	c.JSON(http.StatusCreated, gin.H{"data": feed})
+}
+
+// DeleteFeed implements ep: DELETE /calendar-feeds/:id
+func (h *CalendarHandler) DeleteFeed(c *gin.Context) {
+	id, err := uuid.Parse(c.Param("id"))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "invalid id"})
+		return
+	}
+	actor, ok := actorFrom(c)
+	if !ok {
+		return
+	}
+
+	ctx := c.Request.Context()
+	if err := h.service.DeleteFeed(ctx, actor.UserID, id); err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			c.JSON(http.StatusNotFound, gin.H{"error": "calendar feed not found"})
+			return
+		}
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "failed to delete calendar feed", "details": err.Error()})
+		return
+	}
+
+	c.Status(http.StatusNoContent)
+}
+
+// Feed implements ep: GET /calendar/:token (public, the token is the credential)
+func (h *CalendarHandler) Feed(c *gin.Context) {
+	token := strings.TrimSuffix(c.Param("token"), ".ics")
+
+	ctx := c.Request.Context()
+	data, err := h.service.FeedICS(ctx, token)
+	if err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			c.JSON(http.StatusNotFound, gin.H{"error": "calendar not found"})
+			return
+		}
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "failed to render calendar", "details": err.Error()})
+		return
+	}
+
+	c.Data(http.StatusOK, icsContentType, data)
+}
+
+func respondCalendarExportError(c *gin.Context, err error) {
+	switch {
+	case errors.Is(err, services.ErrInvalidCalendarTarget):
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+	case errors.Is(err, sql.ErrNoRows):
+		c.JSON(http.StatusNotFound, gin.H{"error": "term not found"})
+	default:
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "failed to export calendar", "details": err.Error()})
+	}
+}
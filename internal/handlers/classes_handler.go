@@ -19,17 +19,36 @@ func NewClassHandler(service services.ClassService) *ClassHandler {
 	return &ClassHandler{service: service}
 }
 
-// GetAll implements ep: GET /classes
+// GetAll implements ep: GET /classes?termId=...
 func (h *ClassHandler) GetAll(c *gin.Context) {
+	// Optional term: study plans are resolved for that term
+	var termID *uuid.UUID
+	if raw := c.Query("termId"); raw != "" {
+		id, err := uuid.Parse(raw)
+		if err != nil {
+			c.JSON(http.StatusBadRequest, gin.H{"error": "invalid termId"})
+			return
+		}
+		termID = &id
+	}
+
 	ctx := c.Request.Context()
 
-	data, err := h.service.GetAll(ctx)
+	// The tag is read first: if classes change meanwhile, a write based on this response gets 412
+	etag, err := h.service.ETag(ctx)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "failed to load classes", "details": err.Error()})
+		return
+	}
+
+	data, err := h.service.GetAll(ctx, termID)
 	if err != nil {
 		c.JSON(http.StatusInternalServerError, gin.H{"error": "failed to load classes", "details": err.Error()})
 
 		return
 	}
 
+	setETag(c, etag)
 	c.JSON(http.StatusOK, gin.H{"data": data})
 }
 
@@ -85,13 +104,27 @@ func (h *ClassHandler) BulkUpdate(c *gin.Context) {
 		return
 	}
 
+	etag, ok := requireIfMatch(c)
+	if !ok {
+		return
+	}
+
 	ctx := c.Request.Context()
-	updated, err := h.service.BulkUpdate(ctx, req.Data)
+	updated, newETag, err := h.service.BulkUpdate(ctx, req.Data, etag)
 	if err != nil {
+		if respondVersionError(c, err) {
+			return
+		}
+		if errors.Is(err, services.ErrInvalidHours) {
+			c.JSON(http.StatusBadRequest, gin.H{"error": "invalid hours", "details": err.Error()})
+			return
+		}
 		c.JSON(http.StatusInternalServerError, gin.H{"error": "failed to update classes"})
 		return
 	}
 
+	setETag(c, newETag)
+
 	c.JSON(http.StatusOK, gin.H{
 		"message": "Classes successfully updated",
 		"updated": updated,
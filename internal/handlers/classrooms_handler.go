@@ -1,7 +1,11 @@
 package handlers
 
 import (
+	"database/sql"
+	"errors"
 	"net/http"
+	"strconv"
+	"strings"
 
 	"github.com/gin-gonic/gin"
 	"github.com/google/uuid"
@@ -40,13 +44,130 @@ func (h *ClassroomHandler) Create(c *gin.Context) {
 	}
 
 	ctx := c.Request.Context()
-	resp, err := h.service.Create(ctx, req.Name)
+	resp, err := h.service.Create(ctx, req)
 	if err != nil {
+		if errors.Is(err, services.ErrInvalidClassroom) {
+			c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+			return
+		}
 		c.JSON(http.StatusInternalServerError, gin.H{"error": "failed to create classroom"})
 		return
 	}
 
-	c.JSON(http.StatusCreated, gin.H{"id": resp.ID, "name": resp.Name})
+	c.JSON(http.StatusCreated, gin.H{
+		"id":        resp.ID,
+		"name":      resp.Name,
+		"capacity":  resp.Capacity,
+		"equipment": resp.Equipment,
+		"building":  resp.Building,
+		"floor":     resp.Floor,
+	})
+}
+
+// Update implements ep: PUT /classrooms/:id
+func (h *ClassroomHandler) Update(c *gin.Context) {
+	id, err := uuid.Parse(c.Param("id"))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "invalid id"})
+		return
+	}
+
+	var req models.CreateClassroomRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "invalid request body"})
+		return
+	}
+
+	ctx := c.Request.Context()
+	classroom, err := h.service.Update(ctx, id, req)
+	if err != nil {
+		switch {
+		case errors.Is(err, sql.ErrNoRows):
+			c.JSON(http.StatusNotFound, gin.H{"error": "classroom not found"})
+		case errors.Is(err, services.ErrInvalidClassroom):
+			c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		default:
+			c.JSON(http.StatusInternalServerError, gin.H{"error": "failed to update classroom", "details": err.Error()})
+		}
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{"data": classroom})
+}
+
+// FreeRooms implements ep: GET /classrooms/free?dayOfWeek=TUESDAY&lesson=4&capacity=28&equipment=projector&teacherId=...&classId=...
+func (h *ClassroomHandler) FreeRooms(c *gin.Context) {
+	userID := c.GetString("userID")
+	if userID == "" {
+		c.JSON(http.StatusUnauthorized, gin.H{"error": "unauthorized"})
+		return
+	}
+
+	date, ok := parseDateQuery(c, "date")
+	if !ok {
+		return
+	}
+	filter := models.FreeRoomsFilter{Date: date, DayOfWeek: date.DayOfWeek()}
+	if day := c.Query("dayOfWeek"); day != "" {
+		filter.DayOfWeek = models.DayOfWeekNumber(day)
+	}
+
+	ints := []struct {
+		name string
+		dest *int
+	}{
+		{"lesson", &filter.LessonFrom},
+		{"lessonFrom", &filter.LessonFrom},
+		{"lessonTo", &filter.LessonTo},
+		{"capacity", &filter.MinCapacity},
+	}
+	for _, p := range ints {
+		raw := c.Query(p.name)
+		if raw == "" {
+			continue
+		}
+		n, err := strconv.Atoi(raw)
+		if err != nil {
+			c.JSON(http.StatusBadRequest, gin.H{"error": "invalid " + p.name})
+			return
+		}
+		*p.dest = n
+	}
+	if filter.LessonTo == 0 {
+		filter.LessonTo = filter.LessonFrom
+	}
+	if raw := c.Query("equipment"); raw != "" {
+		filter.Equipment = strings.Split(raw, ",")
+	}
+	if raw := c.Query("teacherId"); raw != "" {
+		id, err := uuid.Parse(raw)
+		if err != nil {
+			c.JSON(http.StatusBadRequest, gin.H{"error": "invalid teacherId"})
+			return
+		}
+		filter.TeacherID = &id
+	}
+	if raw := c.Query("classId"); raw != "" {
+		id, err := uuid.Parse(raw)
+		if err != nil {
+			c.JSON(http.StatusBadRequest, gin.H{"error": "invalid classId"})
+			return
+		}
+		filter.ClassID = &id
+	}
+
+	ctx := c.Request.Context()
+	rooms, err := h.service.FreeRooms(ctx, uuid.MustParse(userID), filter)
+	if err != nil {
+		if errors.Is(err, services.ErrInvalidRoomFilter) {
+			c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+			return
+		}
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "failed to find free rooms", "details": err.Error()})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{"data": rooms})
 }
 
 // Delete implements ep: DELETE /classrooms/:id
@@ -0,0 +1,59 @@
+package handlers
+
+import (
+	"errors"
+	"net/http"
+	"strconv"
+	"strings"
+
+	"github.com/gin-gonic/gin"
+	"github.com/nikomkinds/SchoolSchedule/internal/services"
+)
+
+// requireIfMatch returns the tag of the If-Match header without quotes.
+// Writes of versioned data must send it: without the header 428 is returned.
+func requireIfMatch(c *gin.Context) (string, bool) {
+	raw := strings.TrimSpace(c.GetHeader("If-Match"))
+	if raw == "" {
+		c.JSON(http.StatusPreconditionRequired, gin.H{"error": "If-Match header is required, send the ETag of the data you edited"})
+		return "", false
+	}
+	return strings.Trim(strings.TrimPrefix(raw, "W/"), `"`), true
+}
+
+func setETag(c *gin.Context, tag string) {
+	c.Header("ETag", `"`+tag+`"`)
+}
+
+// expectedVersion converts a schedule tag into the expected version: nil for "*",
+// an impossible version for a malformed tag so that it never matches
+func expectedVersion(tag string) *int {
+	if tag == services.AnyVersion {
+		return nil
+	}
+	v, err := strconv.Atoi(tag)
+	if err != nil {
+		v = -1
+	}
+	return &v
+}
+
+// respondVersionError answers 412 to writes based on an outdated version. For schedules the
+// current version is sent as ETag together with the changes made since the client's version.
+func respondVersionError(c *gin.Context, err error) bool {
+	var scheduleErr *services.ScheduleVersionError
+	switch {
+	case errors.As(err, &scheduleErr):
+		setETag(c, strconv.Itoa(scheduleErr.Version))
+		c.JSON(http.StatusPreconditionFailed, gin.H{
+			"error":   "schedule was changed by someone else",
+			"version": scheduleErr.Version,
+			"diff":    scheduleErr.Diff,
+		})
+	case errors.Is(err, services.ErrVersionMismatch):
+		c.JSON(http.StatusPreconditionFailed, gin.H{"error": "data was changed by someone else, reload it and retry"})
+	default:
+		return false
+	}
+	return true
+}
@@ -0,0 +1,93 @@
+package handlers
+
+import (
+	"database/sql"
+	"errors"
+	"net/http"
+	"net/url"
+	"strings"
+
+	"github.com/gin-gonic/gin"
+	"github.com/google/uuid"
+	"github.com/nikomkinds/SchoolSchedule/internal/models"
+	"github.com/nikomkinds/SchoolSchedule/internal/services"
+)
+
+type ExportHandler struct {
+	service services.ExportService
+}
+
+func NewExportHandler(service services.ExportService) *ExportHandler {
+	return &ExportHandler{service: service}
+}
+
+// ExportPDF implements ep: GET /schedule/:id/export/pdf?target=class|teacher|room&id=&size=A4|A3&format=pdf|zip
+func (h *ExportHandler) ExportPDF(c *gin.Context) {
+	scheduleID, err := uuid.Parse(c.Param("id"))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "invalid id"})
+		return
+	}
+	req := models.TimetableExportRequest{
+		Target:   c.DefaultQuery("target", models.ExportTargetClass),
+		PageSize: strings.ToUpper(c.Query("size")),
+	}
+	if raw := c.Query("id"); raw != "" {
+		id, err := uuid.Parse(raw)
+		if err != nil {
+			c.JSON(http.StatusBadRequest, gin.H{"error": "invalid id"})
+			return
+		}
+		req.ID = &id
+	}
+	switch c.DefaultQuery("format", "pdf") {
+	case "pdf":
+	case "zip":
+		req.Zip = true
+	default:
+		c.JSON(http.StatusBadRequest, gin.H{"error": "format must be pdf or zip"})
+		return
+	}
+
+	ctx := c.Request.Context()
+	file, err := h.service.ExportPDF(ctx, scheduleID, req)
+	if err != nil {
+		respondExportError(c, err)
+		return
+	}
+	sendFile(c, file)
+}
+
+// ExportXLSX implements ep: GET /schedule/:id/export/xlsx
+func (h *ExportHandler) ExportXLSX(c *gin.Context) {
+	scheduleID, err := uuid.Parse(c.Param("id"))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "invalid id"})
+		return
+	}
+
+	ctx := c.Request.Context()
+	file, err := h.service.ExportXLSX(ctx, scheduleID)
+	if err != nil {
+		respondExportError(c, err)
+		return
+	}
+	sendFile(c, file)
+}
+
+// sendFile sends a generated file as a download, its name may be non-ASCII
+func sendFile(c *gin.Context, file *models.ExportFile) {
+	c.Header("Content-Disposition", "attachment; filename*=UTF-8''"+url.PathEscape(file.Name))
+	c.Data(http.StatusOK, file.ContentType, file.Data)
+}
+
+func respondExportError(c *gin.Context, err error) {
+	switch {
+	case errors.Is(err, services.ErrInvalidExport):
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+	case errors.Is(err, sql.ErrNoRows):
+		c.JSON(http.StatusNotFound, gin.H{"error": "timetable not found"})
+	default:
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "failed to export timetable", "details": err.Error()})
+	}
+}
@@ -0,0 +1,77 @@
+package handlers
+
+import (
+	"errors"
+	"io"
+	"net/http"
+
+	"github.com/gin-gonic/gin"
+	"github.com/nikomkinds/SchoolSchedule/internal/models"
+	"github.com/nikomkinds/SchoolSchedule/internal/services"
+)
+
+// maxImportSize limits the upload of an import file
+const maxImportSize = 10 << 20
+
+type ImportHandler struct {
+	service services.ImportService
+}
+
+func NewImportHandler(service services.ImportService) *ImportHandler {
+	return &ImportHandler{service: service}
+}
+
+// Import implements ep: POST /import/:entity?mode=dry-run|apply with the CSV or XLSX file in the "file" form field
+func (h *ImportHandler) Import(c *gin.Context) {
+	if role := c.GetString("role"); role != models.RoleAdmin && role != models.RoleScheduler {
+		c.JSON(http.StatusForbidden, gin.H{"error": "only admins and schedulers can import"})
+		return
+	}
+	var dryRun bool
+	switch c.DefaultQuery("mode", "dry-run") {
+	case "dry-run":
+		dryRun = true
+	case "apply":
+	default:
+		c.JSON(http.StatusBadRequest, gin.H{"error": "mode must be dry-run or apply"})
+		return
+	}
+
+	c.Request.Body = http.MaxBytesReader(c.Writer, c.Request.Body, maxImportSize)
+	header, err := c.FormFile("file")
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "file is required (up to 10 MB)", "details": err.Error()})
+		return
+	}
+	file, err := header.Open()
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "failed to read file", "details": err.Error()})
+		return
+	}
+	defer file.Close()
+	data, err := io.ReadAll(file)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "failed to read file", "details": err.Error()})
+		return
+	}
+
+	ctx := c.Request.Context()
+	report, err := h.service.Import(ctx, c.Param("entity"), header.Filename, data, dryRun)
+	switch {
+	case errors.Is(err, services.ErrImportRejected):
+		c.JSON(http.StatusUnprocessableEntity, gin.H{"error": err.Error(), "data": report})
+		return
+	case errors.Is(err, services.ErrInvalidImport):
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	case err != nil:
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "failed to import", "details": err.Error()})
+		return
+	}
+
+	status := http.StatusOK
+	if !dryRun {
+		status = http.StatusCreated
+	}
+	c.JSON(status, gin.H{"data": report})
+}
@@ -0,0 +1,131 @@
+package handlers
+
+import (
+	"database/sql"
+	"errors"
+	"net/http"
+
+	"github.com/gin-gonic/gin"
+	"github.com/google/uuid"
+	"github.com/nikomkinds/SchoolSchedule/internal/models"
+	"github.com/nikomkinds/SchoolSchedule/internal/services"
+)
+
+// RequireAccess lets a request to /schedule/:id... through only if the user has at least the given
+// access to the schedule: models.ScheduleAccessView, ScheduleAccessEdit or ScheduleAccessOwner
+func (h *ScheduleHandler) RequireAccess(need string) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		scheduleID, err := uuid.Parse(c.Param("id"))
+		if err != nil {
+			c.AbortWithStatusJSON(http.StatusBadRequest, gin.H{"error": "invalid id"})
+			return
+		}
+		actor, ok := actorFrom(c)
+		if !ok {
+			c.Abort()
+			return
+		}
+
+		if err := h.service.CheckScheduleAccess(c.Request.Context(), scheduleID, actor, need); err != nil {
+			respondAccessError(c, err)
+			c.Abort()
+			return
+		}
+		c.Next()
+	}
+}
+
+// ListShares implements ep: GET /schedule/:id/shares
+func (h *ScheduleHandler) ListShares(c *gin.Context) {
+	id, err := uuid.Parse(c.Param("id"))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "invalid id"})
+		return
+	}
+
+	ctx := c.Request.Context()
+	shares, err := h.service.ListShares(ctx, id)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "failed to load shares", "details": err.Error()})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{"data": shares})
+}
+
+// ShareSchedule implements ep: POST /schedule/:id/shares
+func (h *ScheduleHandler) ShareSchedule(c *gin.Context) {
+	id, err := uuid.Parse(c.Param("id"))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "invalid id"})
+		return
+	}
+
+	var req models.ShareScheduleRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "invalid payload"})
+		return
+	}
+
+	ctx := c.Request.Context()
+	share, err := h.service.ShareSchedule(ctx, id, req)
+	if err != nil {
+		if errors.Is(err, services.ErrInvalidShare) {
+			c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+			return
+		}
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "failed to share schedule", "details": err.Error()})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{"data": share})
+}
+
+// DeleteShare implements ep: DELETE /schedule/:id/shares/:shareId
+func (h *ScheduleHandler) DeleteShare(c *gin.Context) {
+	id, err := uuid.Parse(c.Param("id"))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "invalid id"})
+		return
+	}
+	shareID, err := uuid.Parse(c.Param("shareId"))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "invalid share id"})
+		return
+	}
+
+	ctx := c.Request.Context()
+	if err := h.service.DeleteShare(ctx, id, shareID); err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			c.JSON(http.StatusNotFound, gin.H{"error": "share not found"})
+			return
+		}
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "failed to delete share", "details": err.Error()})
+		return
+	}
+
+	c.Status(http.StatusNoContent)
+}
+
+// actorFrom reads the authenticated user set by the auth middleware; without one it writes 401 and returns false
+func actorFrom(c *gin.Context) (models.Actor, bool) {
+	userID, err := uuid.Parse(c.GetString("userID"))
+	if err != nil {
+		c.JSON(http.StatusUnauthorized, gin.H{"error": "unauthorized"})
+		return models.Actor{}, false
+	}
+	return models.Actor{UserID: userID, Role: c.GetString("role")}, true
+}
+
+func respondAccessError(c *gin.Context, err error) {
+	switch {
+	case errors.Is(err, sql.ErrNoRows):
+		c.JSON(http.StatusNotFound, gin.H{"error": "schedule not found"})
+	case errors.Is(err, services.ErrScheduleForbidden):
+		c.JSON(http.StatusForbidden, gin.H{"error": err.Error()})
+	case errors.Is(err, services.ErrScheduleLocked):
+		c.JSON(http.StatusConflict, gin.H{"error": err.Error()})
+	default:
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "failed to check schedule access", "details": err.Error()})
+	}
+}
@@ -2,7 +2,9 @@ package handlers
 
 import (
 	"database/sql"
+	"errors"
 	"net/http"
+	"strconv"
 	"strings" // Добавлен импорт strings
 
 	"github.com/gin-gonic/gin"
@@ -19,7 +21,7 @@ func NewScheduleHandler(service services.ScheduleService) *ScheduleHandler {
 	return &ScheduleHandler{service: service}
 }
 
-// GetSchedule implements ep: GET /schedule
+// GetSchedule implements ep: GET /schedule?date=YYYY-MM-DD or GET /schedule?week=YYYY-MM-DD
 func (h *ScheduleHandler) GetSchedule(c *gin.Context) {
 	userID := c.GetString("userID")
 	if userID == "" {
@@ -28,7 +30,42 @@ func (h *ScheduleHandler) GetSchedule(c *gin.Context) {
 	}
 
 	ctx := c.Request.Context()
-	schedule, err := h.service.GetSchedule(ctx, uuid.MustParse(userID))
+
+	// week is any date of a calendar week; odd/even-week lessons are resolved to that week's parity
+	if c.Query("week") != "" {
+		date, ok := parseDateQuery(c, "week")
+		if !ok {
+			return
+		}
+		week, err := h.service.GetScheduleWeek(ctx, uuid.MustParse(userID), date)
+		if err != nil {
+			c.JSON(http.StatusInternalServerError, gin.H{"error": "failed to load schedule", "details": err.Error()})
+			return
+		}
+		c.JSON(http.StatusOK, week)
+		return
+	}
+
+	// The active schedule is the one whose term contains the date (today by default)
+	date, ok := parseDateQuery(c, "date")
+	if !ok {
+		return
+	}
+
+	// The version is read first: if the schedule changes meanwhile, a PUT based on this response gets 412
+	activeID, err := h.service.GetActiveScheduleID(ctx, uuid.MustParse(userID), date)
+	if err == nil {
+		var active *models.Schedule
+		if active, err = h.service.GetScheduleByID(ctx, activeID); err == nil {
+			setETag(c, strconv.Itoa(active.Version))
+		}
+	}
+	if err != nil && !errors.Is(err, sql.ErrNoRows) {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "failed to load schedule", "details": err.Error()})
+		return
+	}
+
+	schedule, err := h.service.GetSchedule(ctx, uuid.MustParse(userID), date)
 	if err != nil {
 		c.JSON(http.StatusInternalServerError, gin.H{"error": "failed to load schedule", "details": err.Error()})
 		return
@@ -40,7 +77,8 @@ func (h *ScheduleHandler) GetSchedule(c *gin.Context) {
 // UpdateScheduleForTeacher implements ep: PUT /schedule
 func (h *ScheduleHandler) UpdateScheduleForTeacher(c *gin.Context) {
 	var payload struct {
-		Data []models.ScheduleSlotInput `json:"data"`
+		Data    []models.ScheduleSlotInput `json:"data"`
+		Comment string                     `json:"comment"` // Stored with the schedule revision
 	}
 	if err := c.ShouldBindJSON(&payload); err != nil {
 		c.JSON(http.StatusBadRequest, gin.H{"error": "invalid payload structure, expected {  [...] }"})
@@ -90,37 +128,46 @@ func (h *ScheduleHandler) UpdateScheduleForTeacher(c *gin.Context) {
 	}
 	userUUID := uuid.MustParse(userID)
 
-	allSchedules, err := h.service.GetAllSchedules(ctx, userUUID)
-	if err != nil {
+	activeScheduleID, err := h.service.GetActiveScheduleID(ctx, userUUID, models.Today())
+	if err != nil && err != sql.ErrNoRows {
 		c.JSON(http.StatusInternalServerError, gin.H{"error": "failed to identify schedule to update", "details": err.Error()})
 		return
 	}
 
-	var activeScheduleID uuid.UUID
-	for _, s := range allSchedules {
-		if s.IsActive {
-			activeScheduleID = s.ID
-			break
-		}
+	// PUT /schedule only replaces the schedule active today; it is not created implicitly, as a schedule
+	// created here would not be the one resolved once a published or term-bound one appears
+	if activeScheduleID == uuid.Nil {
+		c.JSON(http.StatusConflict, gin.H{
+			"error":   "no active schedule to update",
+			"details": "create a schedule with POST /schedule and make it active with POST /schedule/:id/activate",
+		})
+		return
 	}
 
-	if activeScheduleID == uuid.Nil {
-		newSchedule := models.Schedule{Name: "Расписание", IsActive: true}
-		created, err := h.service.CreateSchedule(ctx, userUUID, newSchedule, nil)
-		if err != nil {
-			c.JSON(http.StatusInternalServerError, gin.H{"error": "failed to create initial schedule", "details": err.Error()})
-			return
-		}
-		activeScheduleID = created.ID
+	// The active schedule may be a published or shared one; it is only replaced if the client
+	// edited its current version
+	actor := models.Actor{UserID: userUUID, Role: c.GetString("role")}
+	if err := h.service.CheckScheduleAccess(ctx, activeScheduleID, actor, models.ScheduleAccessEdit); err != nil {
+		respondAccessError(c, err)
+		return
+	}
+	etag, ok := requireIfMatch(c)
+	if !ok {
+		return
 	}
+	version := expectedVersion(etag)
 
 	// Передаем в сервис уже обновленный payload.Data, где DayOfWeekInt заполнен и DayOfWeek в нижнем регистре
-	err = h.service.UpdateSchedule(ctx, activeScheduleID, nil, payload.Data)
+	newVersion, err := h.service.UpdateSchedule(ctx, activeScheduleID, nil, payload.Data, version, revisionNote(c, payload.Comment))
 	if err != nil {
+		if respondScheduleError(c, err) || respondVersionError(c, err) {
+			return
+		}
 		c.JSON(http.StatusInternalServerError, gin.H{"error": "failed to update schedule", "details": err.Error()})
 		return
 	}
 
+	setETag(c, strconv.Itoa(newVersion))
 	c.JSON(http.StatusOK, gin.H{"message": "Schedule successfully saved"})
 }
 
@@ -134,7 +181,7 @@ func (h *ScheduleHandler) GenerateSchedule(c *gin.Context) {
 	}
 
 	ctx := c.Request.Context()
-	schedule, err := h.service.GenerateSchedule(ctx, req)
+	result, err := h.service.GenerateSchedule(ctx, req)
 	if err != nil {
 		// Could return a 400 with conflict details if generation fails due to constraints
 		c.JSON(http.StatusBadRequest, gin.H{
@@ -144,10 +191,11 @@ func (h *ScheduleHandler) GenerateSchedule(c *gin.Context) {
 		return
 	}
 
-	c.JSON(http.StatusOK, gin.H{"data": schedule})
+	// Lessons that did not fit are reported next to the timetable instead of failing the whole generation
+	c.JSON(http.StatusOK, gin.H{"data": result.Data, "unplaced": result.Unplaced})
 }
 
-// GetScheduleByID implements ep: GET /schedule/:id
+// GetScheduleByID implements ep: GET /schedule/:id?date=YYYY-MM-DD or GET /schedule/:id?week=YYYY-MM-DD
 func (h *ScheduleHandler) GetScheduleByID(c *gin.Context) {
 	idStr := c.Param("id")
 	id, err := uuid.Parse(idStr)
@@ -156,8 +204,22 @@ func (h *ScheduleHandler) GetScheduleByID(c *gin.Context) {
 		return
 	}
 
+	// The same filters as GET /schedule: the date picks the bell times, the week also the lessons of its parity
 	ctx := c.Request.Context()
-	schedule, err := h.service.GetScheduleByID(ctx, id)
+	var schedule *models.ScheduleContent
+	if c.Query("week") != "" {
+		date, ok := parseDateQuery(c, "week")
+		if !ok {
+			return
+		}
+		schedule, err = h.service.GetScheduleContentWeek(ctx, id, date)
+	} else {
+		date, ok := parseDateQuery(c, "date")
+		if !ok {
+			return
+		}
+		schedule, err = h.service.GetScheduleContent(ctx, id, date)
+	}
 	if err != nil {
 		if err == sql.ErrNoRows {
 			c.JSON(http.StatusNotFound, gin.H{"error": "schedule not found"})
@@ -167,13 +229,107 @@ func (h *ScheduleHandler) GetScheduleByID(c *gin.Context) {
 		return
 	}
 
+	setETag(c, strconv.Itoa(schedule.Version))
 	c.JSON(http.StatusOK, gin.H{"data": schedule})
 }
 
+// FindFreeSlots implements ep: GET /schedule/free-slots?teacherIds=...&classIds=...&groupIds=...&days=MONDAY,TUESDAY
+func (h *ScheduleHandler) FindFreeSlots(c *gin.Context) {
+	userID := c.GetString("userID")
+	if userID == "" {
+		c.JSON(http.StatusUnauthorized, gin.H{"error": "unauthorized"})
+		return
+	}
+
+	date, ok := parseDateQuery(c, "date")
+	if !ok {
+		return
+	}
+	req := models.FreeSlotsRequest{Date: date}
+	for _, p := range []struct {
+		name string
+		dest *[]uuid.UUID
+	}{{"teacherIds", &req.TeacherIDs}, {"classIds", &req.ClassIDs}, {"groupIds", &req.GroupIDs}} {
+		ids, ok := parseIDListQuery(c, p.name)
+		if !ok {
+			return
+		}
+		*p.dest = ids
+	}
+	if raw := c.Query("days"); raw != "" {
+		for _, name := range strings.Split(raw, ",") {
+			req.Days = append(req.Days, models.DayOfWeekNumber(name))
+		}
+	}
+
+	ctx := c.Request.Context()
+	slots, err := h.service.FindFreeSlots(ctx, uuid.MustParse(userID), req)
+	if err != nil {
+		if errors.Is(err, services.ErrInvalidFreeSlotsRequest) {
+			c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+			return
+		}
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "failed to find free slots", "details": err.Error()})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{"data": slots})
+}
+
+// parseIDListQuery reads a comma-separated list of UUIDs; on a malformed value it writes 400 and returns false
+func parseIDListQuery(c *gin.Context, name string) ([]uuid.UUID, bool) {
+	raw := c.Query(name)
+	if raw == "" {
+		return nil, true
+	}
+	var ids []uuid.UUID
+	for _, part := range strings.Split(raw, ",") {
+		id, err := uuid.Parse(strings.TrimSpace(part))
+		if err != nil {
+			c.JSON(http.StatusBadRequest, gin.H{"error": "invalid " + name})
+			return nil, false
+		}
+		ids = append(ids, id)
+	}
+	return ids, true
+}
+
+// FillRooms implements ep: POST /schedule/:id/fill-rooms?comment=...
+func (h *ScheduleHandler) FillRooms(c *gin.Context) {
+	id, err := uuid.Parse(c.Param("id"))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "invalid id"})
+		return
+	}
+
+	etag, ok := requireIfMatch(c)
+	if !ok {
+		return
+	}
+
+	ctx := c.Request.Context()
+	result, version, err := h.service.FillRooms(ctx, id, expectedVersion(etag), revisionNote(c, c.Query("comment")))
+	if err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			c.JSON(http.StatusNotFound, gin.H{"error": "schedule not found"})
+			return
+		}
+		if respondScheduleError(c, err) || respondVersionError(c, err) {
+			return
+		}
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "failed to fill rooms", "details": err.Error()})
+		return
+	}
+
+	setETag(c, strconv.Itoa(version))
+	c.JSON(http.StatusOK, result)
+}
+
 // CreateSchedule implements ep: POST /schedule
 func (h *ScheduleHandler) CreateSchedule(c *gin.Context) {
 	var req struct {
 		Name          string                     `json:"name"`
+		TermID        *uuid.UUID                 `json:"termId"`
 		ScheduleSlots []models.ScheduleSlotInput `json:"scheduleSlots"`
 	}
 	if err := c.ShouldBindJSON(&req); err != nil {
@@ -191,12 +347,16 @@ func (h *ScheduleHandler) CreateSchedule(c *gin.Context) {
 
 	ctx := c.Request.Context()
 	newSchedule := models.Schedule{
-		Name: req.Name,
-		// Set other fields as necessary, e.g., IsActive, AcademicYear from context/body
+		Name:   req.Name,
+		TermID: req.TermID, // A schedule bound to a term becomes active for the dates of that term
+		// Set other fields as necessary, e.g., IsActive from context/body
 		IsActive: false, // Usually not active when created
 	}
 	created, err := h.service.CreateSchedule(ctx, userUUID, newSchedule, req.ScheduleSlots)
 	if err != nil {
+		if respondScheduleError(c, err) {
+			return
+		}
 		c.JSON(http.StatusInternalServerError, gin.H{"error": "failed to create schedule", "details": err.Error()})
 		return
 	}
@@ -219,8 +379,142 @@ func (h *ScheduleHandler) DeleteSchedule(c *gin.Context) {
 			c.JSON(http.StatusNotFound, gin.H{"error": "schedule not found"})
 			return
 		}
+		if errors.Is(err, services.ErrScheduleInUse) {
+			c.JSON(http.StatusConflict, gin.H{"error": err.Error()})
+			return
+		}
 		c.JSON(http.StatusInternalServerError, gin.H{"error": "failed to delete schedule", "details": err.Error()})
 		return
 	}
 	c.Status(http.StatusNoContent)
 }
+
+// ListSchedules implements ep: GET /schedule/all
+func (h *ScheduleHandler) ListSchedules(c *gin.Context) {
+	actor, ok := actorFrom(c)
+	if !ok {
+		return
+	}
+
+	ctx := c.Request.Context()
+	schedules, err := h.service.GetAllSchedules(ctx, actor)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "failed to load schedules", "details": err.Error()})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{"data": schedules})
+}
+
+// CloneSchedule implements ep: POST /schedule/:id/clone
+func (h *ScheduleHandler) CloneSchedule(c *gin.Context) {
+	id, err := uuid.Parse(c.Param("id"))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "invalid id"})
+		return
+	}
+
+	var req models.CloneScheduleRequest
+	// The body is optional
+	if err := c.ShouldBindJSON(&req); err != nil && err.Error() != "EOF" {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "invalid payload"})
+		return
+	}
+
+	userID := c.GetString("userID")
+	if userID == "" {
+		c.JSON(http.StatusUnauthorized, gin.H{"error": "unauthorized"})
+		return
+	}
+
+	ctx := c.Request.Context()
+	clone, err := h.service.CloneSchedule(ctx, uuid.MustParse(userID), id, req)
+	if err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			c.JSON(http.StatusNotFound, gin.H{"error": "schedule not found"})
+			return
+		}
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "failed to clone schedule", "details": err.Error()})
+		return
+	}
+
+	c.JSON(http.StatusCreated, gin.H{"data": clone})
+}
+
+// RenameSchedule implements ep: PATCH /schedule/:id
+func (h *ScheduleHandler) RenameSchedule(c *gin.Context) {
+	id, err := uuid.Parse(c.Param("id"))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "invalid id"})
+		return
+	}
+
+	var req models.RenameScheduleRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "invalid payload"})
+		return
+	}
+
+	ctx := c.Request.Context()
+	schedule, err := h.service.RenameSchedule(ctx, id, req.Name)
+	if err != nil {
+		switch {
+		case errors.Is(err, services.ErrEmptyScheduleName):
+			c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		case errors.Is(err, sql.ErrNoRows):
+			c.JSON(http.StatusNotFound, gin.H{"error": "schedule not found"})
+		default:
+			c.JSON(http.StatusInternalServerError, gin.H{"error": "failed to rename schedule", "details": err.Error()})
+		}
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{"data": schedule})
+}
+
+// ActivateSchedule implements ep: POST /schedule/:id/activate
+func (h *ScheduleHandler) ActivateSchedule(c *gin.Context) {
+	id, err := uuid.Parse(c.Param("id"))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "invalid id"})
+		return
+	}
+
+	ctx := c.Request.Context()
+	schedule, err := h.service.ActivateSchedule(ctx, id)
+	if err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			c.JSON(http.StatusNotFound, gin.H{"error": "schedule not found"})
+			return
+		}
+		if errors.Is(err, services.ErrActivationNoEffect) {
+			c.JSON(http.StatusConflict, gin.H{"error": err.Error()})
+			return
+		}
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "failed to activate schedule", "details": err.Error()})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{"data": schedule})
+}
+
+// respondScheduleError writes 400 for schedule validation errors (conflicts, bad week patterns)
+// and 409 when the schedule left the draft status before the edit was saved
+func respondScheduleError(c *gin.Context, err error) bool {
+	var conflict *services.ConflictError
+	switch {
+	case errors.As(err, &conflict):
+		c.JSON(http.StatusBadRequest, models.ConflictResponse{
+			Error:       "Конфликт расписания",
+			Details:     conflict.Details,
+			Suggestions: conflict.Suggestions,
+		})
+	case errors.Is(err, services.ErrInvalidWeekPattern):
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+	case errors.Is(err, services.ErrScheduleLocked):
+		c.JSON(http.StatusConflict, gin.H{"error": err.Error()})
+	default:
+		return false
+	}
+	return true
+}
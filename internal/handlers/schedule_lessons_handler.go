@@ -0,0 +1,211 @@
+package handlers
+
+import (
+	"database/sql"
+	"errors"
+	"net/http"
+	"strconv"
+
+	"github.com/gin-gonic/gin"
+	"github.com/google/uuid"
+	"github.com/nikomkinds/SchoolSchedule/internal/models"
+	"github.com/nikomkinds/SchoolSchedule/internal/services"
+)
+
+// AddLesson implements ep: POST /schedule/:id/lessons
+func (h *ScheduleHandler) AddLesson(c *gin.Context) {
+	scheduleID, err := uuid.Parse(c.Param("id"))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "invalid id"})
+		return
+	}
+
+	var req models.ScheduleLessonRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "invalid payload"})
+		return
+	}
+
+	etag, ok := requireIfMatch(c)
+	if !ok {
+		return
+	}
+
+	ctx := c.Request.Context()
+	slot, version, err := h.service.AddLesson(ctx, scheduleID, req, expectedVersion(etag), revisionNote(c, req.Comment))
+	if err != nil {
+		respondLessonError(c, err, "failed to add lesson")
+		return
+	}
+
+	setETag(c, strconv.Itoa(version))
+	c.JSON(http.StatusCreated, gin.H{"data": slot})
+}
+
+// UpdateLesson implements ep: PUT /schedule/:id/lessons/:lessonId
+func (h *ScheduleHandler) UpdateLesson(c *gin.Context) {
+	scheduleID, lessonID, ok := parseLessonPath(c)
+	if !ok {
+		return
+	}
+
+	var req models.ScheduleLessonRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "invalid payload"})
+		return
+	}
+
+	etag, ok := requireIfMatch(c)
+	if !ok {
+		return
+	}
+
+	ctx := c.Request.Context()
+	slot, version, err := h.service.UpdateLesson(ctx, scheduleID, lessonID, req, expectedVersion(etag), revisionNote(c, req.Comment))
+	if err != nil {
+		respondLessonError(c, err, "failed to update lesson")
+		return
+	}
+
+	setETag(c, strconv.Itoa(version))
+	c.JSON(http.StatusOK, gin.H{"data": slot})
+}
+
+// MoveLesson implements ep: POST /schedule/:id/lessons/:lessonId/move
+func (h *ScheduleHandler) MoveLesson(c *gin.Context) {
+	scheduleID, lessonID, ok := parseLessonPath(c)
+	if !ok {
+		return
+	}
+
+	var req models.MoveLessonRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "invalid payload"})
+		return
+	}
+
+	etag, ok := requireIfMatch(c)
+	if !ok {
+		return
+	}
+
+	ctx := c.Request.Context()
+	slot, version, err := h.service.MoveLesson(ctx, scheduleID, lessonID, req, expectedVersion(etag), revisionNote(c, req.Comment))
+	if err != nil {
+		respondLessonError(c, err, "failed to move lesson")
+		return
+	}
+
+	setETag(c, strconv.Itoa(version))
+	c.JSON(http.StatusOK, gin.H{"data": slot})
+}
+
+// SwapLessons implements ep: POST /schedule/:id/lessons/:lessonId/swap
+func (h *ScheduleHandler) SwapLessons(c *gin.Context) {
+	scheduleID, lessonID, ok := parseLessonPath(c)
+	if !ok {
+		return
+	}
+
+	var req models.SwapLessonsRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "invalid payload"})
+		return
+	}
+
+	etag, ok := requireIfMatch(c)
+	if !ok {
+		return
+	}
+
+	ctx := c.Request.Context()
+	slots, version, err := h.service.SwapLessons(ctx, scheduleID, lessonID, req, expectedVersion(etag), revisionNote(c, req.Comment))
+	if err != nil {
+		respondLessonError(c, err, "failed to swap lessons")
+		return
+	}
+
+	setETag(c, strconv.Itoa(version))
+	c.JSON(http.StatusOK, gin.H{"data": slots})
+}
+
+// RearrangeLessons implements ep: POST /schedule/:id/lessons/rearrange
+func (h *ScheduleHandler) RearrangeLessons(c *gin.Context) {
+	scheduleID, err := uuid.Parse(c.Param("id"))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "invalid id"})
+		return
+	}
+
+	var req models.RearrangeLessonsRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "invalid payload"})
+		return
+	}
+
+	etag, ok := requireIfMatch(c)
+	if !ok {
+		return
+	}
+
+	ctx := c.Request.Context()
+	slots, version, err := h.service.RearrangeLessons(ctx, scheduleID, req, expectedVersion(etag), revisionNote(c, req.Comment))
+	if err != nil {
+		respondLessonError(c, err, "failed to move lessons")
+		return
+	}
+
+	setETag(c, strconv.Itoa(version))
+	c.JSON(http.StatusOK, gin.H{"data": slots})
+}
+
+// DeleteLesson implements ep: DELETE /schedule/:id/lessons/:lessonId?comment=...
+func (h *ScheduleHandler) DeleteLesson(c *gin.Context) {
+	scheduleID, lessonID, ok := parseLessonPath(c)
+	if !ok {
+		return
+	}
+
+	etag, ok := requireIfMatch(c)
+	if !ok {
+		return
+	}
+
+	ctx := c.Request.Context()
+	version, err := h.service.DeleteLesson(ctx, scheduleID, lessonID, expectedVersion(etag), revisionNote(c, c.Query("comment")))
+	if err != nil {
+		respondLessonError(c, err, "failed to delete lesson")
+		return
+	}
+
+	setETag(c, strconv.Itoa(version))
+	c.Status(http.StatusNoContent)
+}
+
+func parseLessonPath(c *gin.Context) (uuid.UUID, uuid.UUID, bool) {
+	scheduleID, err := uuid.Parse(c.Param("id"))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "invalid id"})
+		return uuid.Nil, uuid.Nil, false
+	}
+	lessonID, err := uuid.Parse(c.Param("lessonId"))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "invalid lesson id"})
+		return uuid.Nil, uuid.Nil, false
+	}
+	return scheduleID, lessonID, true
+}
+
+func respondLessonError(c *gin.Context, err error, message string) {
+	if respondScheduleError(c, err) || respondVersionError(c, err) {
+		return
+	}
+	switch {
+	case errors.Is(err, sql.ErrNoRows):
+		c.JSON(http.StatusNotFound, gin.H{"error": "schedule or lesson not found"})
+	case errors.Is(err, services.ErrInvalidLesson):
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+	default:
+		c.JSON(http.StatusInternalServerError, gin.H{"error": message, "details": err.Error()})
+	}
+}
@@ -0,0 +1,175 @@
+package handlers
+
+import (
+	"database/sql"
+	"errors"
+	"net/http"
+	"strconv"
+
+	"github.com/gin-gonic/gin"
+	"github.com/google/uuid"
+	"github.com/nikomkinds/SchoolSchedule/internal/models"
+)
+
+// ListRevisions implements ep: GET /schedule/:id/revisions
+func (h *ScheduleHandler) ListRevisions(c *gin.Context) {
+	scheduleID, err := uuid.Parse(c.Param("id"))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "invalid id"})
+		return
+	}
+
+	ctx := c.Request.Context()
+	revisions, err := h.service.ListRevisions(ctx, scheduleID)
+	if err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			c.JSON(http.StatusNotFound, gin.H{"error": "schedule not found"})
+			return
+		}
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "failed to load revisions", "details": err.Error()})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{"data": revisions})
+}
+
+// GetRevision implements ep: GET /schedule/:id/revisions/:version
+func (h *ScheduleHandler) GetRevision(c *gin.Context) {
+	scheduleID, version, ok := parseRevisionPath(c)
+	if !ok {
+		return
+	}
+
+	ctx := c.Request.Context()
+	days, err := h.service.GetRevision(ctx, scheduleID, version)
+	if err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			c.JSON(http.StatusNotFound, gin.H{"error": "revision not found"})
+			return
+		}
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "failed to load revision", "details": err.Error()})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{"data": days})
+}
+
+// RestoreRevision implements ep: POST /schedule/:id/revisions/:version/restore
+func (h *ScheduleHandler) RestoreRevision(c *gin.Context) {
+	scheduleID, version, ok := parseRevisionPath(c)
+	if !ok {
+		return
+	}
+
+	var req models.RestoreRevisionRequest
+	// The body is optional
+	if err := c.ShouldBindJSON(&req); err != nil && err.Error() != "EOF" {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "invalid payload"})
+		return
+	}
+
+	etag, ok := requireIfMatch(c)
+	if !ok {
+		return
+	}
+
+	ctx := c.Request.Context()
+	newVersion, err := h.service.RestoreRevision(ctx, scheduleID, version, expectedVersion(etag), revisionNote(c, req.Comment))
+	if err != nil {
+		if respondScheduleError(c, err) || respondVersionError(c, err) {
+			return
+		}
+		if errors.Is(err, sql.ErrNoRows) {
+			c.JSON(http.StatusNotFound, gin.H{"error": "revision not found"})
+			return
+		}
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "failed to restore revision", "details": err.Error()})
+		return
+	}
+
+	setETag(c, strconv.Itoa(newVersion))
+	c.JSON(http.StatusOK, gin.H{"data": gin.H{"version": newVersion, "restoredFrom": version}})
+}
+
+// CompareSchedules implements ep: GET /schedule/compare?from=<id>&to=<id>&fromVersion=N&toVersion=N
+func (h *ScheduleHandler) CompareSchedules(c *gin.Context) {
+	from, ok := parseScheduleRef(c, "from", "fromVersion", uuid.Nil)
+	if !ok {
+		return
+	}
+	// Without to, two versions of the same schedule are compared
+	to, ok := parseScheduleRef(c, "to", "toVersion", from.ScheduleID)
+	if !ok {
+		return
+	}
+
+	actor, ok := actorFrom(c)
+	if !ok {
+		return
+	}
+	ctx := c.Request.Context()
+	for _, id := range []uuid.UUID{from.ScheduleID, to.ScheduleID} {
+		if err := h.service.CheckScheduleAccess(ctx, id, actor, models.ScheduleAccessView); err != nil {
+			respondAccessError(c, err)
+			return
+		}
+	}
+
+	diff, err := h.service.CompareSchedules(ctx, from, to)
+	if err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			c.JSON(http.StatusNotFound, gin.H{"error": "schedule or revision not found"})
+			return
+		}
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "failed to compare schedules", "details": err.Error()})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{"data": diff})
+}
+
+// parseScheduleRef reads a schedule ID and an optional version from the query; on a malformed
+// value it writes 400 and returns false. A missing ID falls back to def unless def is uuid.Nil.
+func parseScheduleRef(c *gin.Context, idName, versionName string, def uuid.UUID) (models.ScheduleRef, bool) {
+	ref := models.ScheduleRef{ScheduleID: def}
+	if raw := c.Query(idName); raw != "" || def == uuid.Nil {
+		id, err := uuid.Parse(raw)
+		if err != nil {
+			c.JSON(http.StatusBadRequest, gin.H{"error": "invalid " + idName})
+			return ref, false
+		}
+		ref.ScheduleID = id
+	}
+	if raw := c.Query(versionName); raw != "" {
+		version, err := strconv.Atoi(raw)
+		if err != nil || version < 1 {
+			c.JSON(http.StatusBadRequest, gin.H{"error": "invalid " + versionName})
+			return ref, false
+		}
+		ref.Version = &version
+	}
+	return ref, true
+}
+
+func parseRevisionPath(c *gin.Context) (uuid.UUID, int, bool) {
+	scheduleID, err := uuid.Parse(c.Param("id"))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "invalid id"})
+		return uuid.Nil, 0, false
+	}
+	version, err := strconv.Atoi(c.Param("version"))
+	if err != nil || version < 1 {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "invalid version"})
+		return uuid.Nil, 0, false
+	}
+	return scheduleID, version, true
+}
+
+// revisionNote attributes a schedule change to the current user
+func revisionNote(c *gin.Context, comment string) models.RevisionNote {
+	note := models.RevisionNote{Comment: comment}
+	if userID, err := uuid.Parse(c.GetString("userID")); err == nil {
+		note.AuthorID = &userID
+	}
+	return note
+}
@@ -1,6 +1,8 @@
 package handlers
 
 import (
+	"database/sql"
+	"errors"
 	"github.com/nikomkinds/SchoolSchedule/internal/models"
 	"net/http"
 
@@ -18,7 +20,7 @@ func NewSubjectHandler(service services.SubjectService) *SubjectHandler {
 }
 
 func (h *SubjectHandler) GetAll(c *gin.Context) {
-	subjects, err := h.service.GetAll()
+	subjects, err := h.service.GetAll(c.Request.Context())
 	if err != nil {
 		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to load subjects"})
 		return
@@ -26,10 +28,7 @@ func (h *SubjectHandler) GetAll(c *gin.Context) {
 
 	var data []models.CreateSubjectResponse
 	for _, subject := range subjects {
-		data = append(data, models.CreateSubjectResponse{
-			ID:   subject.ID,
-			Name: subject.Name,
-		})
+		data = append(data, subjectResponse(subject))
 	}
 
 	c.JSON(http.StatusOK, gin.H{
@@ -45,18 +44,46 @@ func (h *SubjectHandler) Create(c *gin.Context) {
 		return
 	}
 
-	subject, err := h.service.Create(req.Name)
+	subject, err := h.service.Create(c.Request.Context(), req)
 	if err != nil {
+		if errors.Is(err, services.ErrInvalidSubject) {
+			c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+			return
+		}
 		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to create subject"})
 		return
 	}
 
-	resp := models.CreateSubjectResponse{
-		ID:   subject.ID,
-		Name: subject.Name,
+	c.JSON(http.StatusCreated, subjectResponse(subject))
+}
+
+func (h *SubjectHandler) Update(c *gin.Context) {
+	id, err := uuid.Parse(c.Param("id"))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid subject id"})
+		return
+	}
+
+	var req models.CreateSubjectRequest
+	if err := c.ShouldBindJSON(&req); err != nil || req.Name == "" {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid payload"})
+		return
 	}
 
-	c.JSON(http.StatusCreated, resp)
+	subject, err := h.service.Update(c.Request.Context(), id, req)
+	if err != nil {
+		switch {
+		case errors.Is(err, sql.ErrNoRows):
+			c.JSON(http.StatusNotFound, gin.H{"error": "Subject not found"})
+		case errors.Is(err, services.ErrInvalidSubject):
+			c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		default:
+			c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to update subject"})
+		}
+		return
+	}
+
+	c.JSON(http.StatusOK, subjectResponse(subject))
 }
 
 func (h *SubjectHandler) Delete(c *gin.Context) {
@@ -68,7 +95,7 @@ func (h *SubjectHandler) Delete(c *gin.Context) {
 		return
 	}
 
-	err = h.service.Delete(id)
+	err = h.service.Delete(c.Request.Context(), id)
 	if err != nil {
 		c.JSON(http.StatusNotFound, gin.H{"error": "Subject not found"})
 		return
@@ -76,3 +103,21 @@ func (h *SubjectHandler) Delete(c *gin.Context) {
 
 	c.Status(http.StatusNoContent)
 }
+
+func subjectResponse(subject models.Subject) models.CreateSubjectResponse {
+	required := subject.RequiredEquipment
+	if required == nil {
+		required = []string{}
+	}
+	classrooms := subject.ClassroomIDs
+	if classrooms == nil {
+		classrooms = []uuid.UUID{}
+	}
+	return models.CreateSubjectResponse{
+		ID:                subject.ID,
+		Name:              subject.Name,
+		ShortName:         subject.ShortName,
+		RequiredEquipment: required,
+		ClassroomIDs:      classrooms,
+	}
+}
@@ -0,0 +1,256 @@
+package handlers
+
+import (
+	"database/sql"
+	"errors"
+	"net/http"
+
+	"github.com/gin-gonic/gin"
+	"github.com/google/uuid"
+	"github.com/nikomkinds/SchoolSchedule/internal/models"
+	"github.com/nikomkinds/SchoolSchedule/internal/services"
+)
+
+type SubstitutionHandler struct {
+	service services.SubstitutionService
+}
+
+func NewSubstitutionHandler(service services.SubstitutionService) *SubstitutionHandler {
+	return &SubstitutionHandler{service: service}
+}
+
+// GetAbsences implements ep: GET /absences?from=YYYY-MM-DD&to=YYYY-MM-DD
+func (h *SubstitutionHandler) GetAbsences(c *gin.Context) {
+	actor, ok := actorFrom(c)
+	if !ok {
+		return
+	}
+	from, to, ok := parseRangeQuery(c)
+	if !ok {
+		return
+	}
+
+	ctx := c.Request.Context()
+	absences, err := h.service.GetAbsences(ctx, actor, from, to)
+	if err != nil {
+		respondSubstitutionError(c, err, "failed to load absences")
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{"data": absences})
+}
+
+// CreateAbsence implements ep: POST /absences
+func (h *SubstitutionHandler) CreateAbsence(c *gin.Context) {
+	actor, ok := actorFrom(c)
+	if !ok {
+		return
+	}
+	var req models.TeacherAbsenceRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "invalid payload"})
+		return
+	}
+
+	ctx := c.Request.Context()
+	absence, err := h.service.CreateAbsence(ctx, actor, req)
+	if err != nil {
+		respondSubstitutionError(c, err, "failed to create absence")
+		return
+	}
+
+	c.JSON(http.StatusCreated, gin.H{"data": absence})
+}
+
+// DeleteAbsence implements ep: DELETE /absences/:id
+func (h *SubstitutionHandler) DeleteAbsence(c *gin.Context) {
+	actor, ok := actorFrom(c)
+	if !ok {
+		return
+	}
+	id, err := uuid.Parse(c.Param("id"))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "invalid id"})
+		return
+	}
+
+	ctx := c.Request.Context()
+	if err := h.service.DeleteAbsence(ctx, actor, id); err != nil {
+		respondSubstitutionError(c, err, "failed to delete absence")
+		return
+	}
+
+	c.Status(http.StatusNoContent)
+}
+
+// GetAbsenceLessons implements ep: GET /absences/:id/lessons
+func (h *SubstitutionHandler) GetAbsenceLessons(c *gin.Context) {
+	userID := c.GetString("userID")
+	if userID == "" {
+		c.JSON(http.StatusUnauthorized, gin.H{"error": "unauthorized"})
+		return
+	}
+	id, err := uuid.Parse(c.Param("id"))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "invalid id"})
+		return
+	}
+
+	ctx := c.Request.Context()
+	days, err := h.service.GetAbsenceLessons(ctx, uuid.MustParse(userID), id)
+	if err != nil {
+		respondSubstitutionError(c, err, "failed to load affected lessons")
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{"data": days})
+}
+
+// GetOverrides implements ep: GET /lesson-overrides?from=YYYY-MM-DD&to=YYYY-MM-DD
+func (h *SubstitutionHandler) GetOverrides(c *gin.Context) {
+	actor, ok := actorFrom(c)
+	if !ok {
+		return
+	}
+	from, to, ok := parseRangeQuery(c)
+	if !ok {
+		return
+	}
+
+	ctx := c.Request.Context()
+	overrides, err := h.service.GetOverrides(ctx, actor, from, to)
+	if err != nil {
+		respondSubstitutionError(c, err, "failed to load lesson overrides")
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{"data": overrides})
+}
+
+// SaveOverride implements ep: POST /lesson-overrides
+func (h *SubstitutionHandler) SaveOverride(c *gin.Context) {
+	actor, ok := actorFrom(c)
+	if !ok {
+		return
+	}
+
+	var req models.LessonOverrideRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "invalid payload"})
+		return
+	}
+
+	ctx := c.Request.Context()
+	override, err := h.service.SaveOverride(ctx, actor, req)
+	if err != nil {
+		respondSubstitutionError(c, err, "failed to save lesson override")
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{"data": override})
+}
+
+// DeleteOverride implements ep: DELETE /lesson-overrides/:id
+func (h *SubstitutionHandler) DeleteOverride(c *gin.Context) {
+	actor, ok := actorFrom(c)
+	if !ok {
+		return
+	}
+	id, err := uuid.Parse(c.Param("id"))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "invalid id"})
+		return
+	}
+
+	ctx := c.Request.Context()
+	if err := h.service.DeleteOverride(ctx, actor, id); err != nil {
+		respondSubstitutionError(c, err, "failed to delete lesson override")
+		return
+	}
+
+	c.Status(http.StatusNoContent)
+}
+
+// SuggestSubstitutes implements ep: GET /lesson-overrides/suggestions?lessonId=...&date=YYYY-MM-DD
+func (h *SubstitutionHandler) SuggestSubstitutes(c *gin.Context) {
+	actor, ok := actorFrom(c)
+	if !ok {
+		return
+	}
+	lessonID, err := uuid.Parse(c.Query("lessonId"))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "invalid lessonId"})
+		return
+	}
+	date, ok := parseDateQuery(c, "date")
+	if !ok {
+		return
+	}
+
+	ctx := c.Request.Context()
+	candidates, err := h.service.SuggestSubstitutes(ctx, actor, lessonID, date)
+	if err != nil {
+		respondSubstitutionError(c, err, "failed to suggest substitutes")
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{"data": candidates})
+}
+
+// GetCalendar implements ep: GET /schedule/calendar?from=YYYY-MM-DD&to=YYYY-MM-DD
+func (h *SubstitutionHandler) GetCalendar(c *gin.Context) {
+	userID := c.GetString("userID")
+	if userID == "" {
+		c.JSON(http.StatusUnauthorized, gin.H{"error": "unauthorized"})
+		return
+	}
+	from, to, ok := parseRangeQuery(c)
+	if !ok {
+		return
+	}
+
+	ctx := c.Request.Context()
+	days, err := h.service.GetCalendar(ctx, uuid.MustParse(userID), from, to)
+	if err != nil {
+		respondSubstitutionError(c, err, "failed to load calendar")
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{"data": days})
+}
+
+func respondSubstitutionError(c *gin.Context, err error, message string) {
+	if respondScheduleError(c, err) {
+		return
+	}
+	switch {
+	case errors.Is(err, sql.ErrNoRows):
+		c.JSON(http.StatusNotFound, gin.H{"error": "not found"})
+	case errors.Is(err, services.ErrScheduleForbidden), errors.Is(err, services.ErrAbsenceForbidden):
+		c.JSON(http.StatusForbidden, gin.H{"error": err.Error()})
+	case errors.Is(err, services.ErrInvalidDateRange),
+		errors.Is(err, services.ErrCalendarRangeTooLong),
+		errors.Is(err, services.ErrInvalidOverride),
+		errors.Is(err, services.ErrLessonNotOnDate):
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+	default:
+		c.JSON(http.StatusInternalServerError, gin.H{"error": message, "details": err.Error()})
+	}
+}
+
+// parseRangeQuery reads the from/to query parameters: from defaults to today, to defaults to a week after from.
+// On a malformed value it writes 400 and returns false.
+func parseRangeQuery(c *gin.Context) (models.Date, models.Date, bool) {
+	from, ok := parseDateQuery(c, "from")
+	if !ok {
+		return models.Date{}, models.Date{}, false
+	}
+	if c.Query("to") == "" {
+		return from, from.AddDays(6), true
+	}
+	to, ok := parseDateQuery(c, "to")
+	if !ok {
+		return models.Date{}, models.Date{}, false
+	}
+	return from, to, true
+}
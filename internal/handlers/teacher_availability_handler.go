@@ -0,0 +1,90 @@
+package handlers
+
+import (
+	"database/sql"
+	"errors"
+	"net/http"
+
+	"github.com/gin-gonic/gin"
+	"github.com/google/uuid"
+	"github.com/nikomkinds/SchoolSchedule/internal/models"
+	"github.com/nikomkinds/SchoolSchedule/internal/services"
+)
+
+type TeacherAvailabilityHandler struct {
+	service services.TeacherAvailabilityService
+}
+
+func NewTeacherAvailabilityHandler(service services.TeacherAvailabilityService) *TeacherAvailabilityHandler {
+	return &TeacherAvailabilityHandler{service: service}
+}
+
+// Get implements ep: GET /users/Teachers/:id/availability
+func (h *TeacherAvailabilityHandler) Get(c *gin.Context) {
+	id, err := uuid.Parse(c.Param("id"))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "invalid id"})
+		return
+	}
+
+	ctx := c.Request.Context()
+	availability, err := h.service.GetByTeacher(ctx, id)
+	if err != nil {
+		respondAvailabilityError(c, err, "failed to load availability")
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{"data": availability})
+}
+
+// Save implements ep: PUT /users/Teachers/:id/availability
+func (h *TeacherAvailabilityHandler) Save(c *gin.Context) {
+	id, err := uuid.Parse(c.Param("id"))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "invalid id"})
+		return
+	}
+
+	var req models.TeacherAvailabilityRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "invalid payload"})
+		return
+	}
+
+	ctx := c.Request.Context()
+	availability, err := h.service.Save(ctx, id, req)
+	if err != nil {
+		respondAvailabilityError(c, err, "failed to save availability")
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{"data": availability})
+}
+
+// Delete implements ep: DELETE /users/Teachers/:id/availability
+func (h *TeacherAvailabilityHandler) Delete(c *gin.Context) {
+	id, err := uuid.Parse(c.Param("id"))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "invalid id"})
+		return
+	}
+
+	ctx := c.Request.Context()
+	if err := h.service.Delete(ctx, id); err != nil {
+		respondAvailabilityError(c, err, "failed to clear availability")
+		return
+	}
+
+	c.Status(http.StatusNoContent)
+}
+
+func respondAvailabilityError(c *gin.Context, err error, message string) {
+	switch {
+	case errors.Is(err, sql.ErrNoRows):
+		c.JSON(http.StatusNotFound, gin.H{"error": "teacher not found"})
+	case errors.Is(err, services.ErrInvalidAvailability):
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+	default:
+		c.JSON(http.StatusInternalServerError, gin.H{"error": message, "details": err.Error()})
+	}
+}
@@ -1,6 +1,7 @@
 package handlers
 
 import (
+	"errors"
 	"net/http"
 
 	"github.com/gin-gonic/gin"
@@ -20,12 +21,19 @@ func NewTeacherHandler(service services.TeacherService) *TeacherHandler {
 // GetAllFull implements ep: GET /users/Teachers
 func (h *TeacherHandler) GetAllFull(c *gin.Context) {
 	ctx := c.Request.Context()
+	// The tag is read first: if teachers change meanwhile, a write based on this response gets 412
+	etag, err := h.service.ETag(ctx)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "failed to load teachers"})
+		return
+	}
 	teachers, err := h.service.GetAllFull(ctx)
 	if err != nil {
 		c.JSON(http.StatusInternalServerError, gin.H{"error": "failed to load teachers"})
 		return
 	}
 
+	setETag(c, etag)
 	c.JSON(http.StatusOK, gin.H{"data": teachers})
 }
 
@@ -91,13 +99,27 @@ func (h *TeacherHandler) BulkUpdate(c *gin.Context) {
 		return
 	}
 
+	etag, ok := requireIfMatch(c)
+	if !ok {
+		return
+	}
+
 	ctx := c.Request.Context()
-	updated, err := h.service.BulkUpdate(ctx, req.Data)
+	updated, newETag, err := h.service.BulkUpdate(ctx, req.Data, etag)
 	if err != nil {
+		if respondVersionError(c, err) {
+			return
+		}
+		if errors.Is(err, services.ErrInvalidHours) {
+			c.JSON(http.StatusBadRequest, gin.H{"error": "invalid hours", "details": err.Error()})
+			return
+		}
 		c.JSON(http.StatusInternalServerError, gin.H{"error": "failed to update teachers"})
 		return
 	}
 
+	setETag(c, newETag)
+
 	c.JSON(http.StatusOK, gin.H{
 		"message": "Teachers successfully updated",
 		"updated": updated,
@@ -0,0 +1,161 @@
+package models
+
+import (
+	"database/sql/driver"
+	"encoding/json"
+	"fmt"
+	"strings"
+	"time"
+)
+
+// DateLayout is the wire format of calendar dates ("2024-09-01")
+const DateLayout = "2006-01-02"
+
+// Date represents a calendar date without time of day.
+// It is serialized as "YYYY-MM-DD" and maps to a postgres DATE column.
+type Date struct {
+	time.Time
+}
+
+// NewDate truncates t to midnight UTC of the same calendar day
+func NewDate(t time.Time) Date {
+	return Date{time.Date(t.Year(), t.Month(), t.Day(), 0, 0, 0, 0, time.UTC)}
+}
+
+// ParseDate parses a "YYYY-MM-DD" string
+func ParseDate(s string) (Date, error) {
+	t, err := time.Parse(DateLayout, s)
+	if err != nil {
+		return Date{}, err
+	}
+	return Date{t}, nil
+}
+
+// Today returns the current calendar date
+func Today() Date {
+	return NewDate(time.Now())
+}
+
+func (d Date) String() string {
+	return d.Format(DateLayout)
+}
+
+// AddDays returns the date shifted by n days
+func (d Date) AddDays(n int) Date {
+	return Date{d.Time.AddDate(0, 0, n)}
+}
+
+// Between reports whether d lies within [from, to] inclusive
+func (d Date) Between(from, to Date) bool {
+	return !d.Before(from.Time) && !d.After(to.Time)
+}
+
+// DayOfWeek returns the internal day number (1=Monday ... 7=Sunday)
+func (d Date) DayOfWeek() int {
+	wd := int(d.Time.Weekday())
+	if wd == 0 {
+		return 7
+	}
+	return wd
+}
+
+func (d Date) MarshalJSON() ([]byte, error) {
+	return json.Marshal(d.String())
+}
+
+func (d *Date) UnmarshalJSON(data []byte) error {
+	var s string
+	if err := json.Unmarshal(data, &s); err != nil {
+		return err
+	}
+	parsed, err := ParseDate(s)
+	if err != nil {
+		return fmt.Errorf("invalid date %q, expected YYYY-MM-DD", s)
+	}
+	*d = parsed
+	return nil
+}
+
+// Scan implements sql.Scanner
+func (d *Date) Scan(src interface{}) error {
+	switch v := src.(type) {
+	case time.Time:
+		*d = NewDate(v)
+		return nil
+	case string:
+		parsed, err := ParseDate(v)
+		if err != nil {
+			return err
+		}
+		*d = parsed
+		return nil
+	case []byte:
+		parsed, err := ParseDate(string(v))
+		if err != nil {
+			return err
+		}
+		*d = parsed
+		return nil
+	default:
+		return fmt.Errorf("cannot scan %T into Date", src)
+	}
+}
+
+// Value implements driver.Valuer
+func (d Date) Value() (driver.Value, error) {
+	return d.String(), nil
+}
+
+// WeekStart returns the Monday of the week containing d
+func (d Date) WeekStart() Date {
+	return d.AddDays(1 - d.DayOfWeek())
+}
+
+var dayNumbers = map[string]int{
+	"monday":    1,
+	"tuesday":   2,
+	"wednesday": 3,
+	"thursday":  4,
+	"friday":    5,
+	"saturday":  6,
+	"sunday":    7,
+}
+
+// DayOfWeekNumber converts a day name ("monday", "MONDAY") into the internal day number (1-7), 0 if unknown
+func DayOfWeekNumber(name string) int {
+	return dayNumbers[strings.ToLower(name)]
+}
+
+// Week patterns of a lesson: every week or only odd/even weeks of the academic year
+const (
+	WeekEvery = "every"
+	WeekOdd   = "odd"
+	WeekEven  = "even"
+)
+
+// ValidWeekPattern reports whether p is a known week pattern; empty means every week
+func ValidWeekPattern(p string) bool {
+	switch p {
+	case "", WeekEvery, WeekOdd, WeekEven:
+		return true
+	}
+	return false
+}
+
+// WeekParity returns WeekOdd or WeekEven for the week containing d.
+// Weeks are counted from the week containing anchor (week 1, odd).
+func (d Date) WeekParity(anchor Date) string {
+	weeks := int(d.WeekStart().Sub(anchor.WeekStart().Time).Hours()/24) / 7
+	if weeks < 0 {
+		weeks = -weeks
+	}
+	if weeks%2 == 0 {
+		return WeekOdd
+	}
+	return WeekEven
+}
+
+// WeekMatches reports whether a lesson with the week pattern takes place in a week of the given parity
+func WeekMatches(pattern, parity string) bool {
+	return pattern == "" || pattern == WeekEvery || pattern == parity
+}
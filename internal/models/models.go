@@ -1,8 +1,10 @@
 package models
 
 import (
-	"github.com/google/uuid"
+	"encoding/json"
 	"time"
+
+	"github.com/google/uuid"
 )
 
 // User represents the base user in the system
@@ -20,6 +22,13 @@ func (u *User) DisplayName() string {
 	return u.Email
 }
 
+// User roles as stored in users.role and sent in the JWT
+const (
+	RoleAdmin     = "admin"
+	RoleScheduler = "scheduler"
+	RoleTeacher   = "teacher"
+)
+
 // Teacher represents a teacher in the system
 type Teacher struct {
 	ID                   uuid.UUID  `json:"id" db:"id"`
@@ -52,18 +61,41 @@ type Classroom struct {
 	ID        uuid.UUID `json:"id" db:"id"`
 	Name      string    `json:"name" db:"name"`
 	Capacity  *int      `json:"capacity,omitempty" db:"capacity"`
-	Equipment *string   `json:"equipment,omitempty" db:"equipment"`
+	Equipment []string  `json:"equipment,omitempty" db:"equipment_tags"` // equipment tags, see Equipment* constants
+	Building  *string   `json:"building,omitempty" db:"building"`
+	Floor     *int      `json:"floor,omitempty" db:"floor"`
 	CreatedAt time.Time `json:"created_at" db:"created_at"`
 	UpdatedAt time.Time `json:"updated_at" db:"updated_at"`
 }
 
+// Classroom equipment tags; subjects require them and classrooms provide them
+const (
+	EquipmentLab       = "lab"
+	EquipmentComputers = "computers"
+	EquipmentGym       = "gym"
+	EquipmentProjector = "projector"
+)
+
+// ValidEquipment reports whether tag is a known equipment tag
+func ValidEquipment(tag string) bool {
+	switch tag {
+	case EquipmentLab, EquipmentComputers, EquipmentGym, EquipmentProjector:
+		return true
+	}
+	return false
+}
+
 // Subject represents a school subject
 type Subject struct {
 	ID        uuid.UUID `json:"id" db:"id"`
 	Name      string    `json:"name" db:"name"`
 	ShortName *string   `json:"shortName,omitempty" db:"short_name"`
-	CreatedAt time.Time `json:"created_at" db:"created_at"`
-	UpdatedAt time.Time `json:"updated_at" db:"updated_at"`
+	// RequiredEquipment lists equipment tags the lesson room must have
+	RequiredEquipment []string `json:"requiredEquipment,omitempty" db:"required_equipment"`
+	// ClassroomIDs are rooms suited to the subject, preferred by the room assigner
+	ClassroomIDs []uuid.UUID `json:"classroomIds,omitempty"`
+	CreatedAt    time.Time   `json:"created_at" db:"created_at"`
+	UpdatedAt    time.Time   `json:"updated_at" db:"updated_at"`
 }
 
 // Class represents a school class (e.g., "5A", "11Б")
@@ -74,6 +106,8 @@ type Class struct {
 	TotalStudents     *int       `json:"totalStudents,omitempty" db:"total_students"`
 	HomeroomTeacherID *uuid.UUID `json:"-" db:"homeroom_teacher_id"` // Internal, not for frontend
 	HomeroomTeacher   *Teacher   `json:"classTeacher,omitempty"`     // Expanded for frontend
+	ShiftID           *uuid.UUID `json:"-" db:"shift_id"`            // Internal, not for frontend
+	Shift             *Shift     `json:"shift,omitempty"`            // Expanded for frontend
 	CreatedAt         time.Time  `json:"created_at" db:"created_at"`
 	UpdatedAt         time.Time  `json:"updated_at" db:"updated_at"`
 	// Expanded fields for frontend
@@ -81,6 +115,17 @@ type Class struct {
 	Groups   []ClassGroup             `json:"groups"`
 }
 
+// Shift represents a school shift (e.g., first shift in the morning, second in the afternoon)
+type Shift struct {
+	ID          uuid.UUID `json:"id" db:"id"`
+	Name        string    `json:"name" db:"name"`
+	Number      int       `json:"number" db:"number"`
+	FirstLesson int       `json:"firstLesson" db:"first_lesson"` // Lesson numbers available to the shift, inclusive
+	LastLesson  int       `json:"lastLesson" db:"last_lesson"`
+	CreatedAt   time.Time `json:"created_at" db:"created_at"`
+	UpdatedAt   time.Time `json:"updated_at" db:"updated_at"`
+}
+
 // ClassGroup represents a subgroup within a class (e.g., "Group 1", "Boys", "Girls")
 type ClassGroup struct {
 	ID          uuid.UUID `json:"id" db:"id"`
@@ -94,16 +139,17 @@ type ClassGroup struct {
 
 // StudyPlan represents the study plan for a class (which subjects and how many hours per week)
 type StudyPlan struct {
-	ID                uuid.UUID `json:"id" db:"id"`
-	ClassID           uuid.UUID `json:"-" db:"class_id"`
-	SubjectID         uuid.UUID `json:"-" db:"subject_id"`
-	Subject           *Subject  `json:"subject"` // Expanded for frontend
-	HoursPerWeek      int       `json:"hoursPerWeek" db:"hours_per_week"`
-	SplitEnabled      bool      `json:"splitEnabled" db:"split_enabled"`
-	SplitGroupsCount  *int      `json:"splitGroupsCount,omitempty" db:"split_groups_count"`
-	CrossClassAllowed bool      `json:"crossClassAllowed,omitempty" db:"cross_class_allowed"`
-	CreatedAt         time.Time `json:"created_at" db:"created_at"`
-	UpdatedAt         time.Time `json:"updated_at" db:"updated_at"`
+	ID                uuid.UUID  `json:"id" db:"id"`
+	ClassID           uuid.UUID  `json:"-" db:"class_id"`
+	SubjectID         uuid.UUID  `json:"-" db:"subject_id"`
+	TermID            *uuid.UUID `json:"termId,omitempty" db:"term_id"`    // If nil, the plan applies to every term
+	Subject           *Subject   `json:"subject"`                          // Expanded for frontend
+	HoursPerWeek      float64    `json:"hoursPerWeek" db:"hours_per_week"` // 0.5 means once every two weeks
+	SplitEnabled      bool       `json:"splitEnabled" db:"split_enabled"`
+	SplitGroupsCount  *int       `json:"splitGroupsCount,omitempty" db:"split_groups_count"`
+	CrossClassAllowed bool       `json:"crossClassAllowed,omitempty" db:"cross_class_allowed"`
+	CreatedAt         time.Time  `json:"created_at" db:"created_at"`
+	UpdatedAt         time.Time  `json:"updated_at" db:"updated_at"`
 }
 
 // TeacherSubject represents the qualification of a teacher (which subjects they can teach)
@@ -124,21 +170,207 @@ type TeacherWorkload struct {
 	ClassID      uuid.UUID   `json:"-" db:"class_id"`
 	SubjectID    uuid.UUID   `json:"-" db:"subject_id"`
 	GroupID      *uuid.UUID  `json:"-" db:"group_id"`
-	Group        *ClassGroup `json:"group,omitempty"` // Expanded for frontend
-	HoursPerWeek int         `json:"hours" db:"hours_per_week"`
+	Group        *ClassGroup `json:"group,omitempty"`           // Expanded for frontend
+	HoursPerWeek float64     `json:"hours" db:"hours_per_week"` // 0.5 means once every two weeks
 	CreatedAt    time.Time   `json:"created_at" db:"created_at"`
 	UpdatedAt    time.Time   `json:"updated_at" db:"updated_at"`
 }
 
 // Schedule represents a named schedule (e.g., "Main Schedule", "Winter Schedule")
 type Schedule struct {
-	ID           uuid.UUID  `json:"id" db:"id"`
-	UserID       *uuid.UUID `json:"userId,omitempty" db:"user_id"` // Owner of the schedule
-	Name         string     `json:"name" db:"name"`
-	AcademicYear *string    `json:"academicYear,omitempty" db:"academic_year"`
-	IsActive     bool       `json:"isActive" db:"is_active"` // Active schedule for this user
-	CreatedAt    time.Time  `json:"created_at" db:"created_at"`
-	UpdatedAt    time.Time  `json:"updated_at" db:"updated_at"`
+	ID       uuid.UUID  `json:"id" db:"id"`
+	UserID   *uuid.UUID `json:"userId,omitempty" db:"user_id"` // Owner of the schedule
+	Name     string     `json:"name" db:"name"`
+	TermID   *uuid.UUID `json:"termId,omitempty" db:"term_id"` // Term the schedule is valid for
+	IsActive bool       `json:"isActive" db:"is_active"`       // Fallback for schedules without a term
+	Version  int        `json:"version" db:"version"`          // Incremented on every change, sent as ETag
+	Status   string     `json:"status" db:"status"`            // ScheduleStatus* value
+	// Schedule this one was copied from, e.g. the published schedule a new draft changes
+	DerivedFrom *uuid.UUID `json:"derivedFrom,omitempty" db:"derived_from"`
+	Access      string     `json:"access,omitempty"` // ScheduleAccess* of the requesting user, in lists
+	CreatedAt   time.Time  `json:"created_at" db:"created_at"`
+	UpdatedAt   time.Time  `json:"updated_at" db:"updated_at"`
+}
+
+// Actor is the authenticated user making a request
+type Actor struct {
+	UserID uuid.UUID
+	Role   string
+}
+
+// ShareScheduleRequest represents the request body for sharing a schedule with a user or a role
+type ShareScheduleRequest struct {
+	UserID     *uuid.UUID `json:"userId"`
+	Role       *string    `json:"role"`
+	Permission string     `json:"permission"` // "view" | "edit"
+}
+
+// Schedule statuses in the order of sign-off. Only drafts are edited; a published schedule is the
+// official timetable everyone sees, archived is one replaced by a newer published schedule.
+const (
+	ScheduleStatusDraft     = "draft"
+	ScheduleStatusReview    = "review"
+	ScheduleStatusApproved  = "approved"
+	ScheduleStatusPublished = "published"
+	ScheduleStatusArchived  = "archived"
+)
+
+// ScheduleTransition is a recorded status change of a schedule
+type ScheduleTransition struct {
+	ID         uuid.UUID  `json:"id" db:"id"`
+	ScheduleID uuid.UUID  `json:"scheduleId" db:"schedule_id"`
+	FromStatus string     `json:"fromStatus" db:"from_status"`
+	ToStatus   string     `json:"toStatus" db:"to_status"`
+	ActorID    *uuid.UUID `json:"actorId,omitempty" db:"actor_id"`
+	Actor      *string    `json:"actor,omitempty"` // Email of the actor
+	Comment    *string    `json:"comment,omitempty" db:"comment"`
+	CreatedAt  time.Time  `json:"createdAt" db:"created_at"`
+}
+
+// ScheduleTransitionRequest represents the request body for changing the status of a schedule
+type ScheduleTransitionRequest struct {
+	Action  string `json:"action"` // "submit" | "withdraw" | "approve" | "reject" | "reopen" | "publish"
+	Comment string `json:"comment"`
+}
+
+// Access levels to a schedule, from the weakest; each includes the previous ones
+const (
+	ScheduleAccessView  = "view"
+	ScheduleAccessEdit  = "edit"
+	ScheduleAccessOwner = "owner" // Sharing, publishing, activating and deleting
+)
+
+// ScheduleShare grants a user, or every user with a role, access to a schedule
+type ScheduleShare struct {
+	ID         uuid.UUID  `json:"id" db:"id"`
+	ScheduleID uuid.UUID  `json:"scheduleId" db:"schedule_id"`
+	UserID     *uuid.UUID `json:"userId,omitempty" db:"user_id"`
+	Role       *string    `json:"role,omitempty" db:"role"`
+	Permission string     `json:"permission" db:"permission"` // ScheduleAccessView or ScheduleAccessEdit
+	CreatedAt  time.Time  `json:"createdAt" db:"created_at"`
+}
+
+// ScheduleContent is a named schedule with its timetable. For one calendar week WeekStart and Parity
+// are set and only the lessons of that week are kept.
+type ScheduleContent struct {
+	Schedule
+	ScheduleSlots []ScheduleDay `json:"scheduleSlots"`
+	WeekStart     *Date         `json:"weekStart,omitempty"`
+	Parity        string        `json:"weekParity,omitempty"`
+}
+
+// AcademicYear represents a school year (e.g., "2024/2025")
+type AcademicYear struct {
+	ID        uuid.UUID `json:"id" db:"id"`
+	Name      string    `json:"name" db:"name"`
+	StartDate Date      `json:"startDate" db:"start_date"`
+	EndDate   Date      `json:"endDate" db:"end_date"`
+	CreatedAt time.Time `json:"created_at" db:"created_at"`
+	UpdatedAt time.Time `json:"updated_at" db:"updated_at"`
+	// Expanded fields for frontend
+	Terms    []AcademicTerm `json:"terms"`
+	Holidays []Holiday      `json:"holidays"`
+}
+
+// AcademicTerm represents a part of an academic year (quarter, trimester or semester)
+type AcademicTerm struct {
+	ID             uuid.UUID `json:"id" db:"id"`
+	AcademicYearID uuid.UUID `json:"academicYearId" db:"academic_year_id"`
+	Name           string    `json:"name" db:"name"`
+	Type           string    `json:"type" db:"term_type"` // "quarter" | "trimester" | "semester"
+	Number         int       `json:"number" db:"number"`
+	StartDate      Date      `json:"startDate" db:"start_date"`
+	EndDate        Date      `json:"endDate" db:"end_date"`
+	CreatedAt      time.Time `json:"created_at" db:"created_at"`
+	UpdatedAt      time.Time `json:"updated_at" db:"updated_at"`
+}
+
+// Holiday represents a non-working period inside an academic year (vacations, public holidays)
+type Holiday struct {
+	ID             uuid.UUID `json:"id" db:"id"`
+	AcademicYearID uuid.UUID `json:"academicYearId" db:"academic_year_id"`
+	Name           string    `json:"name" db:"name"`
+	StartDate      Date      `json:"startDate" db:"start_date"`
+	EndDate        Date      `json:"endDate" db:"end_date"`
+	CreatedAt      time.Time `json:"created_at" db:"created_at"`
+}
+
+// Availability statuses of a teacher's lesson slot
+const (
+	AvailabilityUnavailable = "unavailable" // hard: no lessons may be placed
+	AvailabilityPreferred   = "preferred"   // soft: the generator tries these lessons first
+)
+
+// AvailabilitySlot marks one lesson of a weekday, or the whole day if LessonNumber is nil
+type AvailabilitySlot struct {
+	DayOfWeek    string `json:"dayOfWeek"`
+	LessonNumber *int   `json:"lessonNumber,omitempty"`
+	Status       string `json:"status"`
+}
+
+// TeacherAvailability is the weekly availability grid of a teacher with daily load limits; nil limit means no limit
+type TeacherAvailability struct {
+	TeacherID             uuid.UUID          `json:"teacherId"`
+	MaxLessonsPerDay      *int               `json:"maxLessonsPerDay"`
+	MaxConsecutiveLessons *int               `json:"maxConsecutiveLessons"`
+	Slots                 []AvailabilitySlot `json:"slots"`
+}
+
+// TeacherAbsence represents a period a teacher is away (sick leave, training)
+type TeacherAbsence struct {
+	ID        uuid.UUID     `json:"id" db:"id"`
+	TeacherID uuid.UUID     `json:"teacherId" db:"teacher_id"`
+	Teacher   *LightTeacher `json:"teacher,omitempty"` // Expanded for frontend
+	StartDate Date          `json:"startDate" db:"start_date"`
+	EndDate   Date          `json:"endDate" db:"end_date"`
+	Reason    *string       `json:"reason,omitempty" db:"reason"`
+	CreatedAt time.Time     `json:"created_at" db:"created_at"`
+}
+
+// Lesson override actions
+const (
+	OverrideCancel     = "cancel"
+	OverrideSubstitute = "substitute"
+	OverrideMove       = "move"
+)
+
+// LessonOverride changes one lesson of the weekly timetable on one date only
+type LessonOverride struct {
+	ID                  uuid.UUID     `json:"id" db:"id"`
+	LessonID            uuid.UUID     `json:"lessonId" db:"lesson_id"`
+	Date                Date          `json:"date" db:"date"`
+	Action              string        `json:"action" db:"action"` // "cancel" | "substitute" | "move"
+	AbsenceID           *uuid.UUID    `json:"absenceId,omitempty" db:"absence_id"`
+	SubstituteTeacherID *uuid.UUID    `json:"-" db:"substitute_teacher_id"`
+	SubstituteTeacher   *LightTeacher `json:"substituteTeacher,omitempty"` // Expanded for frontend
+	RoomID              *uuid.UUID    `json:"-" db:"room_id"`
+	Room                *Classroom    `json:"room,omitempty"`                                   // Expanded for frontend
+	NewDate             *Date         `json:"newDate,omitempty" db:"new_date"`                  // Target date of a moved lesson
+	NewLessonNumber     *int          `json:"newLessonNumber,omitempty" db:"new_lesson_number"` // Target lesson number of a moved lesson
+	Comment             *string       `json:"comment,omitempty" db:"comment"`
+	CreatedAt           time.Time     `json:"created_at" db:"created_at"`
+}
+
+// SubstituteCandidate is a teacher who could replace an absent colleague in a lesson, with the ranking factors
+type SubstituteCandidate struct {
+	Teacher             LightTeacher `json:"teacher"`
+	Score               int          `json:"score"`
+	Qualified           bool         `json:"qualified"`           // Has the subject in teacher_subjects
+	TeachesClass        bool         `json:"teachesClass"`        // Already teaches the class
+	LessonsThatDay      int          `json:"lessonsThatDay"`      // Own lessons on the date before the substitution
+	WindowsAdded        int          `json:"windowsAdded"`        // Change in free periods between lessons on the date
+	WeeklyHours         float64      `json:"weeklyHours"`         // Current weekly load from teacher_workload
+	RecentSubstitutions int          `json:"recentSubstitutions"` // Substitutions taken in the last 30 days
+	Explanation         []string     `json:"explanation"`
+}
+
+// CalendarDay is one date of the calendar view: the weekly timetable with the date's overrides applied
+type CalendarDay struct {
+	Date       Date          `json:"date"`
+	DayOfWeek  string        `json:"dayOfWeek"`
+	WeekParity string        `json:"weekParity"`
+	Holiday    *string       `json:"holiday,omitempty"` // Name of the holiday, no lessons on this date
+	Slots      []ScheduleDay `json:"slots"`
 }
 
 // ScheduleSlot represents a time slot in the schedule (day + lesson number)
@@ -150,18 +382,44 @@ type ScheduleSlot struct {
 	CreatedAt    time.Time `json:"created_at" db:"created_at"`
 }
 
+// BellSchedule represents lesson times. DayOfWeek, grade band and Date narrow down where it applies;
+// the most specific matching bell schedule wins.
+type BellSchedule struct {
+	ID        uuid.UUID    `json:"id" db:"id"`
+	Name      string       `json:"name" db:"name"`
+	DayOfWeek *string      `json:"dayOfWeek,omitempty" db:"day_of_week"` // If nil, applies to every day
+	ShiftID   *uuid.UUID   `json:"shiftId,omitempty" db:"shift_id"`      // If set, applies to classes of the shift only
+	GradeFrom *int         `json:"gradeFrom,omitempty" db:"grade_from"`  // Grade band, inclusive
+	GradeTo   *int         `json:"gradeTo,omitempty" db:"grade_to"`
+	Date      *Date        `json:"date,omitempty" db:"date"` // Single date override (e.g. shortened pre-holiday day)
+	Lessons   []BellLesson `json:"lessons"`
+	CreatedAt time.Time    `json:"created_at" db:"created_at"`
+	UpdatedAt time.Time    `json:"updated_at" db:"updated_at"`
+}
+
+// BellLesson represents start and end time ("08:30") of a lesson number
+type BellLesson struct {
+	LessonNumber int    `json:"lessonNumber" db:"lesson_number"`
+	StartTime    string `json:"startTime" db:"start_time"`
+	EndTime      string `json:"endTime" db:"end_time"`
+}
+
 // ScheduleLesson represents a lesson in the schedule
 type ScheduleLesson struct {
-	ID        uuid.UUID `json:"id" db:"id"`
-	SlotID    uuid.UUID `json:"-" db:"slot_id"`
-	SubjectID uuid.UUID `json:"-" db:"subject_id"`
-	Subject   *Subject  `json:"subject"` // Expanded for frontend
-	CreatedAt time.Time `json:"created_at" db:"created_at"`
-	UpdatedAt time.Time `json:"updated_at" db:"updated_at"`
+	ID          uuid.UUID `json:"id" db:"id"`
+	SlotID      uuid.UUID `json:"-" db:"slot_id"`
+	SubjectID   uuid.UUID `json:"-" db:"subject_id"`
+	Subject     *Subject  `json:"subject"`                       // Expanded for frontend
+	WeekPattern string    `json:"weekPattern" db:"week_pattern"` // "every" | "odd" | "even"
+	StartTime   *string   `json:"startTime,omitempty"`           // Resolved from bell schedules for the participants' grade
+	EndTime     *string   `json:"endTime,omitempty"`
+	CreatedAt   time.Time `json:"created_at" db:"created_at"`
+	UpdatedAt   time.Time `json:"updated_at" db:"updated_at"`
 	// Expanded fields for frontend
 	Teachers     []Teacher           `json:"teachers"`
 	Rooms        []Classroom         `json:"rooms"`
 	Participants []LessonParticipant `json:"participants"`
+	Override     *LessonOverride     `json:"override,omitempty"` // Set in the calendar view if the lesson is changed on the date
 }
 
 // LessonParticipant represents which class/group participates in a lesson
@@ -185,13 +443,14 @@ type TeacherClassHour struct {
 	Class   Class   `json:"class"`
 	Subject Subject `json:"subject"`
 	GroupID *string `json:"groupId,omitempty"` // Using string ID for frontend
-	Hours   int     `json:"hours"`
+	Hours   float64 `json:"hours"`             // May be fractional for biweekly lessons
 }
 
 // ClassSubjectAssignment is a helper struct for Class's subjects list
 type ClassSubjectAssignment struct {
 	Subject      Subject            `json:"subject"`
-	HoursPerWeek int                `json:"hoursPerWeek"`
+	TermID       *uuid.UUID         `json:"termId,omitempty"` // If nil, the assignment applies to every term
+	HoursPerWeek float64            `json:"hoursPerWeek"`     // May be fractional for biweekly subjects
 	Split        *ClassSubjectSplit `json:"split,omitempty"`
 }
 
@@ -201,10 +460,19 @@ type ClassSubjectSplit struct {
 	CrossClassAllowed *bool `json:"crossClassAllowed,omitempty"`
 }
 
+// ScheduleWeek is the timetable of a calendar week resolved to its parity
+type ScheduleWeek struct {
+	WeekStart Date          `json:"weekStart"`
+	Parity    string        `json:"weekParity"` // "odd" | "even"
+	Days      []ScheduleDay `json:"data"`
+}
+
 // ScheduleDay represents a day in the schedule response
 type ScheduleDay struct {
 	DayOfWeek    string           `json:"dayOfWeek"`
 	LessonNumber int              `json:"lessonNumber"`
+	StartTime    *string          `json:"startTime,omitempty"` // Resolved from bell schedules
+	EndTime      *string          `json:"endTime,omitempty"`
 	Lessons      []ScheduleLesson `json:"lessons"`
 }
 
@@ -218,12 +486,132 @@ type ScheduleSlotInput struct {
 
 // LessonInput represents input for a lesson
 type LessonInput struct {
+	ID           string             `json:"id,omitempty"` // Lesson being kept on a full save; new lessons have none
 	Subject      SubjectInput       `json:"subject"`
+	WeekPattern  string             `json:"weekPattern,omitempty"` // "every" (default) | "odd" | "even"
 	Teachers     []TeacherInput     `json:"teachers"`
 	Rooms        []ClassroomInput   `json:"rooms"`
 	Participants []ParticipantInput `json:"participants"`
 }
 
+// ScheduleLessonRequest represents the request body for adding or updating a single lesson of a named schedule
+type ScheduleLessonRequest struct {
+	DayOfWeek    string `json:"dayOfWeek"`
+	LessonNumber int    `json:"lessonNumber"`
+	Comment      string `json:"comment"` // Stored with the schedule revision
+	LessonInput
+}
+
+// MoveLessonRequest represents the request body for moving a lesson to another slot
+type MoveLessonRequest struct {
+	DayOfWeek    string `json:"dayOfWeek"`
+	LessonNumber int    `json:"lessonNumber"`
+	Comment      string `json:"comment"`
+}
+
+// LessonMove puts a lesson of a named schedule into another slot
+type LessonMove struct {
+	LessonID     uuid.UUID `json:"lessonId"`
+	DayOfWeek    string    `json:"dayOfWeek"`
+	LessonNumber int       `json:"lessonNumber"`
+}
+
+// RearrangeLessonsRequest represents the request body for applying several moves at once
+type RearrangeLessonsRequest struct {
+	Moves   []LessonMove `json:"moves"`
+	Comment string       `json:"comment"`
+}
+
+// SwapLessonsRequest represents the request body for swapping the slots of two lessons
+type SwapLessonsRequest struct {
+	LessonID uuid.UUID `json:"lessonId"`
+	Comment  string    `json:"comment"`
+}
+
+// Kinds of move suggestions
+const (
+	SuggestionSlot  = "slot"  // the lesson goes to another free slot
+	SuggestionChain = "chain" // the lesson goes where requested, the lessons in the way move elsewhere
+)
+
+// MoveSuggestion is a set of moves that makes a rejected move legal.
+// Moves can be sent as is to POST /schedule/:id/lessons/rearrange.
+type MoveSuggestion struct {
+	Kind  string       `json:"kind"`
+	Moves []LessonMove `json:"moves"`
+}
+
+// RevisionNote is who made a schedule change and why; it is stored with the revision the change creates
+type RevisionNote struct {
+	AuthorID *uuid.UUID
+	Comment  string
+}
+
+// ScheduleRevision is a stored version of a schedule's timetable
+type ScheduleRevision struct {
+	Version   int        `json:"version"`
+	AuthorID  *uuid.UUID `json:"authorId,omitempty"`
+	Author    *string    `json:"author,omitempty"` // Email of the author
+	Comment   *string    `json:"comment,omitempty"`
+	CreatedAt time.Time  `json:"createdAt"`
+}
+
+// CloneScheduleRequest represents the request body for duplicating a schedule
+type CloneScheduleRequest struct {
+	Name   string     `json:"name"`   // Defaults to the source name with " (копия)"
+	TermID *uuid.UUID `json:"termId"` // Defaults to the term of the source
+}
+
+// RenameScheduleRequest represents the request body for renaming a schedule
+type RenameScheduleRequest struct {
+	Name string `json:"name"`
+}
+
+// RestoreRevisionRequest represents the request body for restoring a schedule revision
+type RestoreRevisionRequest struct {
+	Comment string `json:"comment"`
+}
+
+// ScheduleRef points at a schedule as of a stored version, or as it is now if Version is nil
+type ScheduleRef struct {
+	ScheduleID uuid.UUID
+	Version    *int
+}
+
+// ScheduleDiff lists the lessons that differ between two versions of a schedule, or between two schedules
+type ScheduleDiff struct {
+	FromScheduleID *uuid.UUID   `json:"fromScheduleId,omitempty"`
+	ToScheduleID   *uuid.UUID   `json:"toScheduleId,omitempty"`
+	FromVersion    int          `json:"fromVersion"`
+	ToVersion      int          `json:"toVersion"`
+	Added          []LessonDiff `json:"added"`
+	Removed        []LessonDiff `json:"removed"`
+	Changed        []LessonDiff `json:"changed"` // Same subject and classes in the slot, other teachers, rooms or weeks
+	Moved          []LessonDiff `json:"moved"`   // Same subject and classes in another slot, possibly with other teachers, rooms or weeks
+	// Teachers and classes of all differing lessons, on either side: the people whose timetable changed
+	AffectedTeachers []uuid.UUID `json:"affectedTeachers"`
+	AffectedClasses  []uuid.UUID `json:"affectedClasses"`
+}
+
+// LessonDiff is a lesson of a slot in a schedule diff. Before is set for changed and moved lessons,
+// From* for moved ones.
+type LessonDiff struct {
+	DayOfWeek        string          `json:"dayOfWeek"`
+	LessonNumber     int             `json:"lessonNumber"`
+	Lesson           ScheduleLesson  `json:"lesson"`
+	Before           *ScheduleLesson `json:"before,omitempty"`
+	FromDayOfWeek    string          `json:"fromDayOfWeek,omitempty"`
+	FromLessonNumber int             `json:"fromLessonNumber,omitempty"`
+	Changes          []string        `json:"changes,omitempty"` // What differs from Before: LessonChange* values
+}
+
+// Parts of a lesson reported in LessonDiff.Changes
+const (
+	LessonChangeTeachers    = "teachers"
+	LessonChangeRooms       = "rooms"
+	LessonChangeWeekPattern = "weekPattern"
+)
+
 // SubjectInput represents input for a subject
 type SubjectInput struct {
 	ID   string `json:"id"`
@@ -294,20 +682,83 @@ type ConflictDetail struct {
 
 // ConflictResponse represents a schedule conflict response
 type ConflictResponse struct {
-	Error   string           `json:"error"`
-	Details []ConflictDetail `json:"details"`
+	Error       string           `json:"error"`
+	Details     []ConflictDetail `json:"details"`
+	Suggestions []MoveSuggestion `json:"suggestions,omitempty"` // Only for lesson moves
 }
 
 // GenerateScheduleRequest represents the request body for schedule generation
 type GenerateScheduleRequest struct {
-	Algorithm        *string `json:"algorithm,omitempty"`
-	MaxLessonsPerDay *int    `json:"maxLessonsPerDay,omitempty"`
+	Algorithm        *string    `json:"algorithm,omitempty"`
+	MaxLessonsPerDay *int       `json:"maxLessonsPerDay,omitempty"`
+	DaysPerWeek      *int       `json:"daysPerWeek,omitempty"` // 5 or 6, default 5
+	TermID           *uuid.UUID `json:"termId,omitempty"`      // Study plans of this term, default is the current term
 	Priorities       *struct {
 		BalanceWorkload *bool `json:"balanceWorkload,omitempty"`
 		MinimizeGaps    *bool `json:"minimizeGaps,omitempty"`
 	} `json:"priorities,omitempty"`
 }
 
+// FreeSlotsRequest lists teachers, classes and groups who all have to be free; empty Days means the school week
+type FreeSlotsRequest struct {
+	Date       Date // resolves the active schedule
+	TeacherIDs []uuid.UUID
+	ClassIDs   []uuid.UUID
+	GroupIDs   []uuid.UUID
+	Days       []int // 1 = Monday
+}
+
+// FreeSlot is a lesson slot where all requested participants are free
+type FreeSlot struct {
+	DayOfWeek    string `json:"dayOfWeek"`
+	LessonNumber int    `json:"lessonNumber"`
+	// WindowsAdded is how many free periods the slot adds to the participants' days in total; negative if it fills gaps
+	WindowsAdded int `json:"windowsAdded"`
+}
+
+// RoomAssignment is a lesson the fill-rooms operation picked a room for, or could not
+type RoomAssignment struct {
+	LessonID     uuid.UUID  `json:"lessonId"`
+	DayOfWeek    string     `json:"dayOfWeek"`
+	LessonNumber int        `json:"lessonNumber"`
+	Subject      *Subject   `json:"subject"`
+	Room         *Classroom `json:"room,omitempty"`
+}
+
+// FillRoomsResult lists lessons that got a room and lessons left without one
+type FillRoomsResult struct {
+	Assigned   []RoomAssignment `json:"data"`
+	Unassigned []RoomAssignment `json:"unassigned"`
+}
+
+// GenerateScheduleResult represents the generated timetable and the lessons that could not be placed
+type GenerateScheduleResult struct {
+	Data     []ScheduleDay    `json:"data"`
+	Unplaced []UnplacedLesson `json:"unplaced"`
+}
+
+// UnplacedLesson describes a lesson from the workload the generator could not fit into the timetable
+type UnplacedLesson struct {
+	Teacher *LightTeacher `json:"teacher,omitempty"`
+	Class   ClassInput    `json:"class"`
+	Subject SubjectInput  `json:"subject"`
+	GroupID *string       `json:"groupId,omitempty"`
+	Reason  string        `json:"reason"`
+}
+
+// PlanningData is the school data the conflict checker and the schedule generator work on
+type PlanningData struct {
+	Classes      []Class
+	Teachers     []Teacher
+	Classrooms   []Classroom
+	Subjects     []Subject
+	StudyPlans   []StudyPlan
+	Workloads    []TeacherWorkload
+	Shifts       []Shift
+	Bells        []BellSchedule
+	Availability []TeacherAvailability
+}
+
 // BulkUpdateClassesRequest represents the request body for bulk class update
 type BulkUpdateClassesRequest struct {
 	Data []Class `json:"data"`
@@ -324,24 +775,235 @@ type BulkUpdateResponse struct {
 	Updated int    `json:"updated"`
 }
 
-// CreateClassroomRequest represents the request body for classroom create
+// CreateClassroomRequest represents the request body for classroom create/update
 type CreateClassroomRequest struct {
-	Name string `json:"name"`
+	Name      string   `json:"name"`
+	Capacity  *int     `json:"capacity"`
+	Equipment []string `json:"equipment"`
+	Building  *string  `json:"building"`
+	Floor     *int     `json:"floor"`
 }
 
-// CreateClassroomResponse represents the response for classroom create
-type CreateClassroomResponse struct {
-	ID   uuid.UUID `json:"id"`
-	Name string    `json:"name"`
+// FreeRoomsFilter narrows down the free-room search over the active schedule
+type FreeRoomsFilter struct {
+	Date        Date       // resolves the active schedule; the day of its week whose overrides apply
+	DayOfWeek   int        // 1 = Monday
+	LessonFrom  int        // first lesson number of the range
+	LessonTo    int        // last lesson number of the range
+	ClassID     *uuid.UUID // times the range by the bells of this class's shift
+	MinCapacity int        // 0 means any
+	Equipment   []string   // all tags are required
+	TeacherID   *uuid.UUID // sort by closeness to this teacher's room
 }
 
-// CreateSubjectRequest represents the request body for subject create
+// CreateClassroomResponse represents the response for classroom create
+type CreateClassroomResponse struct {
+	ID        uuid.UUID `json:"id"`
+	Name      string    `json:"name"`
+	Capacity  *int      `json:"capacity,omitempty"`
+	Equipment []string  `json:"equipment,omitempty"`
+	Building  *string   `json:"building,omitempty"`
+	Floor     *int      `json:"floor,omitempty"`
+}
+
+// AcademicYearRequest represents the request body for academic year create/update
+type AcademicYearRequest struct {
+	Name      string `json:"name" binding:"required"`
+	StartDate Date   `json:"startDate"`
+	EndDate   Date   `json:"endDate"`
+}
+
+// AcademicTermRequest represents the request body for term create/update
+type AcademicTermRequest struct {
+	Name      string `json:"name" binding:"required"`
+	Type      string `json:"type" binding:"required"`
+	Number    int    `json:"number"`
+	StartDate Date   `json:"startDate"`
+	EndDate   Date   `json:"endDate"`
+}
+
+// HolidayRequest represents the request body for holiday create
+type HolidayRequest struct {
+	Name      string `json:"name" binding:"required"`
+	StartDate Date   `json:"startDate"`
+	EndDate   Date   `json:"endDate"`
+}
+
+// BellScheduleRequest represents the request body for bell schedule create/update
+type BellScheduleRequest struct {
+	Name      string       `json:"name" binding:"required"`
+	DayOfWeek *string      `json:"dayOfWeek"`
+	ShiftID   *uuid.UUID   `json:"shiftId"`
+	GradeFrom *int         `json:"gradeFrom"`
+	GradeTo   *int         `json:"gradeTo"`
+	Date      *Date        `json:"date"`
+	Lessons   []BellLesson `json:"lessons"`
+}
+
+// TeacherAbsenceRequest represents the request body for recording a teacher absence
+type TeacherAbsenceRequest struct {
+	TeacherID uuid.UUID `json:"teacherId" binding:"required"`
+	StartDate Date      `json:"startDate"`
+	EndDate   Date      `json:"endDate"`
+	Reason    *string   `json:"reason"`
+}
+
+// LessonOverrideRequest represents the request body for a per-date lesson override
+type LessonOverrideRequest struct {
+	LessonID            uuid.UUID  `json:"lessonId" binding:"required"`
+	Date                Date       `json:"date"`
+	Action              string     `json:"action" binding:"required"`
+	AbsenceID           *uuid.UUID `json:"absenceId"`
+	SubstituteTeacherID *uuid.UUID `json:"substituteTeacherId"`
+	RoomID              *uuid.UUID `json:"roomId"`
+	NewDate             *Date      `json:"newDate"`
+	NewLessonNumber     *int       `json:"newLessonNumber"`
+	Comment             *string    `json:"comment"`
+}
+
+// TeacherAvailabilityRequest represents the request body for replacing a teacher's availability
+type TeacherAvailabilityRequest struct {
+	MaxLessonsPerDay      *int               `json:"maxLessonsPerDay"`
+	MaxConsecutiveLessons *int               `json:"maxConsecutiveLessons"`
+	Slots                 []AvailabilitySlot `json:"slots"`
+}
+
+// ShiftRequest represents the request body for shift create/update
+type ShiftRequest struct {
+	Name        string `json:"name" binding:"required"`
+	Number      int    `json:"number" binding:"required"`
+	FirstLesson int    `json:"firstLesson"`
+	LastLesson  int    `json:"lastLesson" binding:"required"`
+}
+
+// AssignShiftClassesRequest represents the request body for assigning classes to a shift
+type AssignShiftClassesRequest struct {
+	ClassIDs []uuid.UUID `json:"classIds"`
+}
+
+// CreateSubjectRequest represents the request body for subject create/update
 type CreateSubjectRequest struct {
-	Name string `json:"name"`
+	Name              string      `json:"name"`
+	ShortName         *string     `json:"shortName"` // Printed in timetable cells
+	RequiredEquipment []string    `json:"requiredEquipment"`
+	ClassroomIDs      []uuid.UUID `json:"classroomIds"`
 }
 
 // CreateSubjectResponse represents the response for subject create
 type CreateSubjectResponse struct {
-	ID   uuid.UUID `json:"id"`
-	Name string    `json:"name"`
+	ID                uuid.UUID   `json:"id"`
+	Name              string      `json:"name"`
+	ShortName         *string     `json:"shortName"`
+	RequiredEquipment []string    `json:"requiredEquipment"`
+	ClassroomIDs      []uuid.UUID `json:"classroomIds"`
+}
+
+// Audited entities
+const (
+	AuditEntityClass     = "class"
+	AuditEntityTeacher   = "teacher"
+	AuditEntitySubject   = "subject"
+	AuditEntityClassroom = "classroom"
+	AuditEntitySchedule  = "schedule"
+)
+
+// Audit actions common to all entities; schedules have more specific ones
+const (
+	AuditActionCreate = "create"
+	AuditActionUpdate = "update"
+	AuditActionDelete = "delete"
+)
+
+// AuditEntry is a recorded write: who did what to which entity, with its state before and after
+type AuditEntry struct {
+	ID        uuid.UUID       `json:"id" db:"id"`
+	ActorID   *uuid.UUID      `json:"actorId,omitempty" db:"actor_id"`
+	Actor     *string         `json:"actor,omitempty"` // Email of the actor
+	Action    string          `json:"action" db:"action"`
+	Entity    string          `json:"entity" db:"entity"`
+	EntityID  *uuid.UUID      `json:"entityId,omitempty" db:"entity_id"`
+	Before    json.RawMessage `json:"before,omitempty" db:"before"` // null for a created entity
+	After     json.RawMessage `json:"after,omitempty" db:"after"`   // null for a deleted entity
+	RequestID *string         `json:"requestId,omitempty" db:"request_id"`
+	CreatedAt time.Time       `json:"createdAt" db:"created_at"`
+}
+
+// AuditFilter selects audit entries; zero fields do not filter
+type AuditFilter struct {
+	Entity   string
+	EntityID *uuid.UUID
+	From     *time.Time // Inclusive
+	To       *time.Time // Exclusive
+	Limit    int
+}
+
+// CalendarTarget selects whose timetable a calendar shows: exactly one of a teacher, a class or a room
+type CalendarTarget struct {
+	TermID      *uuid.UUID `json:"termId,omitempty"` // nil: the term containing today
+	TeacherID   *uuid.UUID `json:"teacherId,omitempty"`
+	ClassID     *uuid.UUID `json:"classId,omitempty"`
+	ClassroomID *uuid.UUID `json:"roomId,omitempty"`
+}
+
+// CalendarFeed is a subscription URL calendar apps poll without logging in
+type CalendarFeed struct {
+	ID     uuid.UUID `json:"id" db:"id"`
+	UserID uuid.UUID `json:"userId" db:"user_id"`
+	CalendarTarget
+	Token      string     `json:"-"`             // Only known right after creation
+	URL        string     `json:"url,omitempty"` // Only returned on creation
+	CreatedAt  time.Time  `json:"createdAt" db:"created_at"`
+	LastUsedAt *time.Time `json:"lastUsedAt,omitempty" db:"last_used_at"`
+}
+
+// Whose timetables an export contains
+const (
+	ExportTargetClass   = "class"
+	ExportTargetTeacher = "teacher"
+	ExportTargetRoom    = "room"
+)
+
+// Page sizes of printed timetables
+const (
+	PageSizeA4 = "A4"
+	PageSizeA3 = "A3"
+)
+
+// TimetableExportRequest selects the timetables of a schedule to print
+type TimetableExportRequest struct {
+	Target   string     // ExportTarget* value
+	ID       *uuid.UUID // nil: every class, teacher or room of the schedule
+	PageSize string     // PageSize* value, A4 by default
+	Zip      bool       // One PDF per timetable in a ZIP archive instead of one multi-page PDF
+}
+
+// ExportFile is a generated file to download
+type ExportFile struct {
+	Name        string
+	ContentType string
+	Data        []byte
+}
+
+// Entity types of a bulk import, as in the URL
+const (
+	ImportTeachers   = "teachers"
+	ImportClasses    = "classes"
+	ImportSubjects   = "subjects"
+	ImportClassrooms = "classrooms"
+)
+
+// ImportError is a problem with a row of an imported file
+type ImportError struct {
+	Row     int    `json:"row"`              // Row of the file, the header being row 1
+	Column  string `json:"column,omitempty"` // Empty for problems of the whole row
+	Message string `json:"message"`
+}
+
+// ImportReport is the outcome of a bulk import or of its dry run
+type ImportReport struct {
+	Entity  string        `json:"entity"`
+	DryRun  bool          `json:"dryRun"`
+	Rows    int           `json:"rows"`    // Data rows read, blank rows skipped
+	Created int           `json:"created"` // Entities created; 0 on a dry run or if the file has errors
+	Errors  []ImportError `json:"errors"`
 }
@@ -0,0 +1,344 @@
+package repositories
+
+import (
+	"context"
+	"database/sql"
+
+	"github.com/google/uuid"
+	"github.com/lib/pq"
+	"github.com/nikomkinds/SchoolSchedule/internal/models"
+)
+
+type AcademicYearRepository interface {
+	// GetAll loads all academic years with their terms and holidays
+	GetAll(ctx context.Context) ([]models.AcademicYear, error)
+	// GetByID loads a single academic year with its terms and holidays
+	GetByID(ctx context.Context, id uuid.UUID) (*models.AcademicYear, error)
+	Create(ctx context.Context, year models.AcademicYear) (*models.AcademicYear, error)
+	Update(ctx context.Context, year models.AcademicYear) error
+	Delete(ctx context.Context, id uuid.UUID) error
+	// GetYearByDate finds the academic year that contains the given date (without terms and holidays)
+	GetYearByDate(ctx context.Context, date models.Date) (*models.AcademicYear, error)
+
+	GetTermByID(ctx context.Context, id uuid.UUID) (*models.AcademicTerm, error)
+	// GetTermByDate finds the term that contains the given date
+	GetTermByDate(ctx context.Context, date models.Date) (*models.AcademicTerm, error)
+	CreateTerm(ctx context.Context, term models.AcademicTerm) (*models.AcademicTerm, error)
+	UpdateTerm(ctx context.Context, term models.AcademicTerm) error
+	DeleteTerm(ctx context.Context, id uuid.UUID) error
+
+	CreateHoliday(ctx context.Context, holiday models.Holiday) (*models.Holiday, error)
+	DeleteHoliday(ctx context.Context, id uuid.UUID) error
+	// GetHolidaysBetween loads holidays that intersect the [from, to] range
+	GetHolidaysBetween(ctx context.Context, from, to models.Date) ([]models.Holiday, error)
+}
+
+type academicYearRepository struct {
+	db *sql.DB
+}
+
+func NewAcademicYearRepository(db *sql.DB) AcademicYearRepository {
+	return &academicYearRepository{db: db}
+}
+
+func (r *academicYearRepository) GetAll(ctx context.Context) ([]models.AcademicYear, error) {
+	const q = `
+		SELECT id, name, start_date, end_date, created_at, updated_at
+		FROM academic_years
+		ORDER BY start_date DESC
+	`
+
+	rows, err := r.db.QueryContext(ctx, q)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	years := make([]models.AcademicYear, 0)
+	for rows.Next() {
+		var y models.AcademicYear
+		if err := rows.Scan(&y.ID, &y.Name, &y.StartDate, &y.EndDate, &y.CreatedAt, &y.UpdatedAt); err != nil {
+			return nil, err
+		}
+		years = append(years, y)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+
+	for i := range years {
+		if err := r.loadYearDetails(ctx, &years[i]); err != nil {
+			return nil, err
+		}
+	}
+
+	return years, nil
+}
+
+func (r *academicYearRepository) GetByID(ctx context.Context, id uuid.UUID) (*models.AcademicYear, error) {
+	const q = `
+		SELECT id, name, start_date, end_date, created_at, updated_at
+		FROM academic_years
+		WHERE id = $1
+	`
+
+	var y models.AcademicYear
+	err := r.db.QueryRowContext(ctx, q, id).Scan(&y.ID, &y.Name, &y.StartDate, &y.EndDate, &y.CreatedAt, &y.UpdatedAt)
+	if err != nil {
+		return nil, err
+	}
+
+	if err := r.loadYearDetails(ctx, &y); err != nil {
+		return nil, err
+	}
+
+	return &y, nil
+}
+
+// loadYearDetails fills terms and holidays of an academic year
+func (r *academicYearRepository) loadYearDetails(ctx context.Context, y *models.AcademicYear) error {
+	const termsQuery = `
+		SELECT id, academic_year_id, name, term_type, number, start_date, end_date, created_at, updated_at
+		FROM academic_terms
+		WHERE academic_year_id = $1
+		ORDER BY start_date
+	`
+
+	rows, err := r.db.QueryContext(ctx, termsQuery, y.ID)
+	if err != nil {
+		return err
+	}
+	defer rows.Close()
+
+	y.Terms = []models.AcademicTerm{}
+	for rows.Next() {
+		t, err := scanTerm(rows)
+		if err != nil {
+			return err
+		}
+		y.Terms = append(y.Terms, *t)
+	}
+	if err := rows.Err(); err != nil {
+		return err
+	}
+
+	const holidaysQuery = `
+		SELECT id, academic_year_id, name, start_date, end_date, created_at
+		FROM holidays
+		WHERE academic_year_id = $1
+		ORDER BY start_date
+	`
+
+	hRows, err := r.db.QueryContext(ctx, holidaysQuery, y.ID)
+	if err != nil {
+		return err
+	}
+	defer hRows.Close()
+
+	y.Holidays = []models.Holiday{}
+	for hRows.Next() {
+		var h models.Holiday
+		if err := hRows.Scan(&h.ID, &h.AcademicYearID, &h.Name, &h.StartDate, &h.EndDate, &h.CreatedAt); err != nil {
+			return err
+		}
+		y.Holidays = append(y.Holidays, h)
+	}
+	return hRows.Err()
+}
+
+type rowScanner interface {
+	Scan(dest ...interface{}) error
+}
+
+func scanTerm(row rowScanner) (*models.AcademicTerm, error) {
+	var t models.AcademicTerm
+	if err := row.Scan(
+		&t.ID, &t.AcademicYearID, &t.Name, &t.Type, &t.Number,
+		&t.StartDate, &t.EndDate, &t.CreatedAt, &t.UpdatedAt,
+	); err != nil {
+		return nil, err
+	}
+	return &t, nil
+}
+
+func (r *academicYearRepository) Create(ctx context.Context, year models.AcademicYear) (*models.AcademicYear, error) {
+	const q = `
+		INSERT INTO academic_years (name, start_date, end_date)
+		VALUES ($1, $2, $3)
+		RETURNING id, created_at, updated_at
+	`
+
+	err := r.db.QueryRowContext(ctx, q, year.Name, year.StartDate, year.EndDate).Scan(&year.ID, &year.CreatedAt, &year.UpdatedAt)
+	if err != nil {
+		return nil, err
+	}
+
+	year.Terms = []models.AcademicTerm{}
+	year.Holidays = []models.Holiday{}
+	return &year, nil
+}
+
+func (r *academicYearRepository) Update(ctx context.Context, year models.AcademicYear) error {
+	res, err := r.db.ExecContext(ctx, `
+		UPDATE academic_years SET name = $1, start_date = $2, end_date = $3, updated_at = now()
+		WHERE id = $4
+	`, year.Name, year.StartDate, year.EndDate, year.ID)
+	if err != nil {
+		return err
+	}
+	return expectAffected(res)
+}
+
+func (r *academicYearRepository) Delete(ctx context.Context, id uuid.UUID) error {
+	res, err := r.db.ExecContext(ctx, `DELETE FROM academic_years WHERE id = $1`, id)
+	if err != nil {
+		return err
+	}
+	return expectAffected(res)
+}
+
+func (r *academicYearRepository) GetTermByID(ctx context.Context, id uuid.UUID) (*models.AcademicTerm, error) {
+	const q = `
+		SELECT id, academic_year_id, name, term_type, number, start_date, end_date, created_at, updated_at
+		FROM academic_terms
+		WHERE id = $1
+	`
+	return scanTerm(r.db.QueryRowContext(ctx, q, id))
+}
+
+func (r *academicYearRepository) GetYearByDate(ctx context.Context, date models.Date) (*models.AcademicYear, error) {
+	const q = `
+		SELECT id, name, start_date, end_date, created_at, updated_at
+		FROM academic_years
+		WHERE $1::date BETWEEN start_date AND end_date
+		ORDER BY start_date DESC
+		LIMIT 1
+	`
+
+	var y models.AcademicYear
+	err := r.db.QueryRowContext(ctx, q, date).Scan(&y.ID, &y.Name, &y.StartDate, &y.EndDate, &y.CreatedAt, &y.UpdatedAt)
+	if err != nil {
+		return nil, err
+	}
+	return &y, nil
+}
+
+func (r *academicYearRepository) GetTermByDate(ctx context.Context, date models.Date) (*models.AcademicTerm, error) {
+	const q = `
+		SELECT id, academic_year_id, name, term_type, number, start_date, end_date, created_at, updated_at
+		FROM academic_terms
+		WHERE $1::date BETWEEN start_date AND end_date
+		ORDER BY start_date DESC
+		LIMIT 1
+	`
+	return scanTerm(r.db.QueryRowContext(ctx, q, date))
+}
+
+func (r *academicYearRepository) CreateTerm(ctx context.Context, term models.AcademicTerm) (*models.AcademicTerm, error) {
+	const q = `
+		INSERT INTO academic_terms (academic_year_id, name, term_type, number, start_date, end_date)
+		VALUES ($1, $2, $3, $4, $5, $6)
+		RETURNING id, created_at, updated_at
+	`
+
+	err := r.db.QueryRowContext(ctx, q,
+		term.AcademicYearID, term.Name, term.Type, term.Number, term.StartDate, term.EndDate,
+	).Scan(&term.ID, &term.CreatedAt, &term.UpdatedAt)
+	if err != nil {
+		return nil, err
+	}
+	return &term, nil
+}
+
+func (r *academicYearRepository) UpdateTerm(ctx context.Context, term models.AcademicTerm) error {
+	res, err := r.db.ExecContext(ctx, `
+		UPDATE academic_terms
+		SET name = $1, term_type = $2, number = $3, start_date = $4, end_date = $5, updated_at = now()
+		WHERE id = $6
+	`, term.Name, term.Type, term.Number, term.StartDate, term.EndDate, term.ID)
+	if err != nil {
+		return err
+	}
+	return expectAffected(res)
+}
+
+func (r *academicYearRepository) DeleteTerm(ctx context.Context, id uuid.UUID) error {
+	res, err := r.db.ExecContext(ctx, `DELETE FROM academic_terms WHERE id = $1`, id)
+	if err != nil {
+		return err
+	}
+	return expectAffected(res)
+}
+
+func (r *academicYearRepository) CreateHoliday(ctx context.Context, holiday models.Holiday) (*models.Holiday, error) {
+	const q = `
+		INSERT INTO holidays (academic_year_id, name, start_date, end_date)
+		VALUES ($1, $2, $3, $4)
+		RETURNING id, created_at
+	`
+
+	err := r.db.QueryRowContext(ctx, q,
+		holiday.AcademicYearID, holiday.Name, holiday.StartDate, holiday.EndDate,
+	).Scan(&holiday.ID, &holiday.CreatedAt)
+	if err != nil {
+		return nil, err
+	}
+	return &holiday, nil
+}
+
+func (r *academicYearRepository) DeleteHoliday(ctx context.Context, id uuid.UUID) error {
+	res, err := r.db.ExecContext(ctx, `DELETE FROM holidays WHERE id = $1`, id)
+	if err != nil {
+		return err
+	}
+	return expectAffected(res)
+}
+
+func (r *academicYearRepository) GetHolidaysBetween(ctx context.Context, from, to models.Date) ([]models.Holiday, error) {
+	const q = `
+		SELECT id, academic_year_id, name, start_date, end_date, created_at
+		FROM holidays
+		WHERE start_date <= $2 AND end_date >= $1
+		ORDER BY start_date
+	`
+
+	rows, err := r.db.QueryContext(ctx, q, from, to)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	out := make([]models.Holiday, 0)
+	for rows.Next() {
+		var h models.Holiday
+		if err := rows.Scan(&h.ID, &h.AcademicYearID, &h.Name, &h.StartDate, &h.EndDate, &h.CreatedAt); err != nil {
+			return nil, err
+		}
+		out = append(out, h)
+	}
+	return out, rows.Err()
+}
+
+// uuidArray passes IDs as a uuid[] parameter; nil IDs are NULL
+func uuidArray(ids []uuid.UUID) interface{} {
+	if ids == nil {
+		return pq.StringArray(nil)
+	}
+	s := make([]string, len(ids))
+	for i, id := range ids {
+		s[i] = id.String()
+	}
+	return pq.StringArray(s)
+}
+
+// expectAffected converts "zero rows affected" into sql.ErrNoRows
+func expectAffected(res sql.Result) error {
+	n, err := res.RowsAffected()
+	if err != nil {
+		return err
+	}
+	if n == 0 {
+		return sql.ErrNoRows
+	}
+	return nil
+}
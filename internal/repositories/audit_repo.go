@@ -0,0 +1,95 @@
+package repositories
+
+import (
+	"context"
+	"database/sql"
+	"fmt"
+	"strings"
+
+	"github.com/nikomkinds/SchoolSchedule/internal/models"
+)
+
+type AuditRepository interface {
+	// Insert stores an audit entry
+	Insert(ctx context.Context, entry models.AuditEntry) error
+	// List loads the audit entries matching the filter with the email of the actor, newest first
+	List(ctx context.Context, filter models.AuditFilter) ([]models.AuditEntry, error)
+}
+
+type auditRepository struct {
+	db *sql.DB
+}
+
+func NewAuditRepository(db *sql.DB) AuditRepository {
+	return &auditRepository{db: db}
+}
+
+func (r *auditRepository) Insert(ctx context.Context, entry models.AuditEntry) error {
+	_, err := r.db.ExecContext(ctx, `
+		INSERT INTO audit_log (actor_id, action, entity, entity_id, before, after, request_id)
+		VALUES ($1, $2, $3, $4, $5, $6, $7)
+	`, entry.ActorID, entry.Action, entry.Entity, entry.EntityID,
+		nullJSON(entry.Before), nullJSON(entry.After), entry.RequestID)
+	return err
+}
+
+func (r *auditRepository) List(ctx context.Context, filter models.AuditFilter) ([]models.AuditEntry, error) {
+	var (
+		where []string
+		args  []interface{}
+	)
+	add := func(cond string, arg interface{}) {
+		args = append(args, arg)
+		where = append(where, fmt.Sprintf(cond, len(args)))
+	}
+	if filter.Entity != "" {
+		add("a.entity = $%d", filter.Entity)
+	}
+	if filter.EntityID != nil {
+		add("a.entity_id = $%d", *filter.EntityID)
+	}
+	if filter.From != nil {
+		add("a.created_at >= $%d", *filter.From)
+	}
+	if filter.To != nil {
+		add("a.created_at < $%d", *filter.To)
+	}
+
+	q := `
+		SELECT a.id, a.actor_id, u.email, a.action, a.entity, a.entity_id, a.before, a.after, a.request_id, a.created_at
+		FROM audit_log a
+		LEFT JOIN users u ON u.id = a.actor_id`
+	if len(where) > 0 {
+		q += "\n\t\tWHERE " + strings.Join(where, " AND ")
+	}
+	args = append(args, filter.Limit)
+	q += fmt.Sprintf("\n\t\tORDER BY a.created_at DESC, a.id\n\t\tLIMIT $%d", len(args))
+
+	rows, err := r.db.QueryContext(ctx, q, args...)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	entries := []models.AuditEntry{}
+	for rows.Next() {
+		var (
+			e             models.AuditEntry
+			before, after []byte
+		)
+		if err := rows.Scan(&e.ID, &e.ActorID, &e.Actor, &e.Action, &e.Entity, &e.EntityID, &before, &after, &e.RequestID, &e.CreatedAt); err != nil {
+			return nil, err
+		}
+		e.Before, e.After = before, after
+		entries = append(entries, e)
+	}
+	return entries, rows.Err()
+}
+
+// nullJSON stores an empty snapshot as NULL rather than invalid JSON
+func nullJSON(raw []byte) interface{} {
+	if len(raw) == 0 {
+		return nil
+	}
+	return string(raw)
+}
@@ -0,0 +1,253 @@
+package repositories
+
+import (
+	"context"
+	"database/sql"
+	"fmt"
+
+	"github.com/google/uuid"
+	"github.com/nikomkinds/SchoolSchedule/internal/models"
+)
+
+type BellScheduleRepository interface {
+	// GetAll loads all bell schedules with their lesson times
+	GetAll(ctx context.Context) ([]models.BellSchedule, error)
+	GetByID(ctx context.Context, id uuid.UUID) (*models.BellSchedule, error)
+	// Create inserts a bell schedule together with its lesson times
+	Create(ctx context.Context, bell models.BellSchedule) (*models.BellSchedule, error)
+	// Update updates a bell schedule and replaces its lesson times
+	Update(ctx context.Context, bell models.BellSchedule) error
+	Delete(ctx context.Context, id uuid.UUID) error
+}
+
+type bellScheduleRepository struct {
+	db *sql.DB
+}
+
+func NewBellScheduleRepository(db *sql.DB) BellScheduleRepository {
+	return &bellScheduleRepository{db: db}
+}
+
+func (r *bellScheduleRepository) GetAll(ctx context.Context) ([]models.BellSchedule, error) {
+	const q = `
+		SELECT id, name, day_of_week, shift_id, grade_from, grade_to, date, created_at, updated_at
+		FROM bell_schedules
+		ORDER BY date NULLS FIRST, day_of_week NULLS FIRST, shift_id NULLS FIRST, grade_from NULLS FIRST, name
+	`
+
+	rows, err := r.db.QueryContext(ctx, q)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	list := make([]models.BellSchedule, 0)
+	index := make(map[uuid.UUID]int)
+	for rows.Next() {
+		b, err := scanBellSchedule(rows)
+		if err != nil {
+			return nil, err
+		}
+		index[b.ID] = len(list)
+		list = append(list, *b)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+
+	const lessonsQuery = `
+		SELECT bell_schedule_id, lesson_number,
+		       to_char(start_time, 'HH24:MI'), to_char(end_time, 'HH24:MI')
+		FROM bell_schedule_lessons
+		ORDER BY bell_schedule_id, lesson_number
+	`
+
+	lRows, err := r.db.QueryContext(ctx, lessonsQuery)
+	if err != nil {
+		return nil, err
+	}
+	defer lRows.Close()
+
+	for lRows.Next() {
+		var bellID uuid.UUID
+		var l models.BellLesson
+		if err := lRows.Scan(&bellID, &l.LessonNumber, &l.StartTime, &l.EndTime); err != nil {
+			return nil, err
+		}
+		if i, ok := index[bellID]; ok {
+			list[i].Lessons = append(list[i].Lessons, l)
+		}
+	}
+
+	return list, lRows.Err()
+}
+
+func (r *bellScheduleRepository) GetByID(ctx context.Context, id uuid.UUID) (*models.BellSchedule, error) {
+	const q = `
+		SELECT id, name, day_of_week, shift_id, grade_from, grade_to, date, created_at, updated_at
+		FROM bell_schedules
+		WHERE id = $1
+	`
+
+	b, err := scanBellSchedule(r.db.QueryRowContext(ctx, q, id))
+	if err != nil {
+		return nil, err
+	}
+
+	const lessonsQuery = `
+		SELECT lesson_number, to_char(start_time, 'HH24:MI'), to_char(end_time, 'HH24:MI')
+		FROM bell_schedule_lessons
+		WHERE bell_schedule_id = $1
+		ORDER BY lesson_number
+	`
+
+	rows, err := r.db.QueryContext(ctx, lessonsQuery, id)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	for rows.Next() {
+		var l models.BellLesson
+		if err := rows.Scan(&l.LessonNumber, &l.StartTime, &l.EndTime); err != nil {
+			return nil, err
+		}
+		b.Lessons = append(b.Lessons, l)
+	}
+
+	return b, rows.Err()
+}
+
+func scanBellSchedule(row rowScanner) (*models.BellSchedule, error) {
+	var b models.BellSchedule
+	var day sql.NullInt64
+	var gradeFrom, gradeTo sql.NullInt64
+
+	if err := row.Scan(&b.ID, &b.Name, &day, &b.ShiftID, &gradeFrom, &gradeTo, &b.Date, &b.CreatedAt, &b.UpdatedAt); err != nil {
+		return nil, err
+	}
+
+	if day.Valid {
+		d := dayOfWeekToString(int(day.Int64))
+		b.DayOfWeek = &d
+	}
+	if gradeFrom.Valid {
+		v := int(gradeFrom.Int64)
+		b.GradeFrom = &v
+	}
+	if gradeTo.Valid {
+		v := int(gradeTo.Int64)
+		b.GradeTo = &v
+	}
+	b.Lessons = []models.BellLesson{}
+
+	return &b, nil
+}
+
+// bellDayValue converts an optional day name into a nullable column value
+func bellDayValue(day *string) (interface{}, error) {
+	if day == nil {
+		return nil, nil
+	}
+	n := stringToDayOfWeek(*day)
+	if n == 0 {
+		return nil, fmt.Errorf("invalid day of week: %s", *day)
+	}
+	return n, nil
+}
+
+func (r *bellScheduleRepository) Create(ctx context.Context, bell models.BellSchedule) (*models.BellSchedule, error) {
+	day, err := bellDayValue(bell.DayOfWeek)
+	if err != nil {
+		return nil, err
+	}
+
+	tx, err := r.db.BeginTx(ctx, nil)
+	if err != nil {
+		return nil, err
+	}
+	defer func() {
+		if err != nil {
+			_ = tx.Rollback()
+		}
+	}()
+
+	var id uuid.UUID
+	err = tx.QueryRowContext(ctx, `
+		INSERT INTO bell_schedules (name, day_of_week, shift_id, grade_from, grade_to, date)
+		VALUES ($1, $2, $3, $4, $5, $6)
+		RETURNING id
+	`, bell.Name, day, bell.ShiftID, bell.GradeFrom, bell.GradeTo, bell.Date).Scan(&id)
+	if err != nil {
+		return nil, err
+	}
+
+	if err = insertBellLessons(ctx, tx, id, bell.Lessons); err != nil {
+		return nil, err
+	}
+
+	if err = tx.Commit(); err != nil {
+		return nil, err
+	}
+
+	return r.GetByID(ctx, id)
+}
+
+func (r *bellScheduleRepository) Update(ctx context.Context, bell models.BellSchedule) error {
+	day, err := bellDayValue(bell.DayOfWeek)
+	if err != nil {
+		return err
+	}
+
+	tx, err := r.db.BeginTx(ctx, nil)
+	if err != nil {
+		return err
+	}
+	defer func() {
+		if err != nil {
+			_ = tx.Rollback()
+		}
+	}()
+
+	res, err := tx.ExecContext(ctx, `
+		UPDATE bell_schedules
+		SET name = $1, day_of_week = $2, shift_id = $3, grade_from = $4, grade_to = $5, date = $6, updated_at = now()
+		WHERE id = $7
+	`, bell.Name, day, bell.ShiftID, bell.GradeFrom, bell.GradeTo, bell.Date, bell.ID)
+	if err != nil {
+		return err
+	}
+	if err = expectAffected(res); err != nil {
+		return err
+	}
+
+	if _, err = tx.ExecContext(ctx, `DELETE FROM bell_schedule_lessons WHERE bell_schedule_id = $1`, bell.ID); err != nil {
+		return err
+	}
+	if err = insertBellLessons(ctx, tx, bell.ID, bell.Lessons); err != nil {
+		return err
+	}
+
+	return tx.Commit()
+}
+
+func insertBellLessons(ctx context.Context, tx *sql.Tx, bellID uuid.UUID, lessons []models.BellLesson) error {
+	for _, l := range lessons {
+		_, err := tx.ExecContext(ctx, `
+			INSERT INTO bell_schedule_lessons (bell_schedule_id, lesson_number, start_time, end_time)
+			VALUES ($1, $2, $3, $4)
+		`, bellID, l.LessonNumber, l.StartTime, l.EndTime)
+		if err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+func (r *bellScheduleRepository) Delete(ctx context.Context, id uuid.UUID) error {
+	res, err := r.db.ExecContext(ctx, `DELETE FROM bell_schedules WHERE id = $1`, id)
+	if err != nil {
+		return err
+	}
+	return expectAffected(res)
+}
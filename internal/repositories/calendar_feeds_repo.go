@@ -0,0 +1,95 @@
+package repositories
+
+import (
+	"context"
+	"database/sql"
+
+	"github.com/google/uuid"
+	"github.com/nikomkinds/SchoolSchedule/internal/models"
+)
+
+type CalendarFeedRepository interface {
+	// List loads the feeds of a user, newest first
+	List(ctx context.Context, userID uuid.UUID) ([]models.CalendarFeed, error)
+	// Create stores a feed under the hash of its token
+	Create(ctx context.Context, feed models.CalendarFeed, tokenHash string) (*models.CalendarFeed, error)
+	// Delete deletes a feed of a user
+	Delete(ctx context.Context, userID, id uuid.UUID) error
+	// UseByTokenHash finds a feed by the hash of its token and marks it used
+	UseByTokenHash(ctx context.Context, tokenHash string) (*models.CalendarFeed, error)
+	// TeacherIDByUser returns the teacher record of a user; sql.ErrNoRows if the user is not a teacher
+	TeacherIDByUser(ctx context.Context, userID uuid.UUID) (uuid.UUID, error)
+}
+
+type calendarFeedRepository struct {
+	db *sql.DB
+}
+
+func NewCalendarFeedRepository(db *sql.DB) CalendarFeedRepository {
+	return &calendarFeedRepository{db: db}
+}
+
+const calendarFeedColumns = `id, user_id, term_id, teacher_id, class_id, classroom_id, created_at, last_used_at`
+
+func scanCalendarFeed(row rowScanner) (*models.CalendarFeed, error) {
+	var f models.CalendarFeed
+	err := row.Scan(&f.ID, &f.UserID, &f.TermID, &f.TeacherID, &f.ClassID, &f.ClassroomID, &f.CreatedAt, &f.LastUsedAt)
+	if err != nil {
+		return nil, err
+	}
+	return &f, nil
+}
+
+func (r *calendarFeedRepository) List(ctx context.Context, userID uuid.UUID) ([]models.CalendarFeed, error) {
+	rows, err := r.db.QueryContext(ctx, `
+		SELECT `+calendarFeedColumns+`
+		FROM calendar_feeds
+		WHERE user_id = $1
+		ORDER BY created_at DESC
+	`, userID)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	feeds := []models.CalendarFeed{}
+	for rows.Next() {
+		f, err := scanCalendarFeed(rows)
+		if err != nil {
+			return nil, err
+		}
+		feeds = append(feeds, *f)
+	}
+	return feeds, rows.Err()
+}
+
+func (r *calendarFeedRepository) Create(ctx context.Context, feed models.CalendarFeed, tokenHash string) (*models.CalendarFeed, error) {
+	row := r.db.QueryRowContext(ctx, `
+		INSERT INTO calendar_feeds (user_id, token_hash, term_id, teacher_id, class_id, classroom_id)
+		VALUES ($1, $2, $3, $4, $5, $6)
+		RETURNING `+calendarFeedColumns,
+		feed.UserID, tokenHash, feed.TermID, feed.TeacherID, feed.ClassID, feed.ClassroomID)
+	return scanCalendarFeed(row)
+}
+
+func (r *calendarFeedRepository) Delete(ctx context.Context, userID, id uuid.UUID) error {
+	res, err := r.db.ExecContext(ctx, `DELETE FROM calendar_feeds WHERE id = $1 AND user_id = $2`, id, userID)
+	if err != nil {
+		return err
+	}
+	return expectAffected(res)
+}
+
+func (r *calendarFeedRepository) UseByTokenHash(ctx context.Context, tokenHash string) (*models.CalendarFeed, error) {
+	row := r.db.QueryRowContext(ctx, `
+		UPDATE calendar_feeds SET last_used_at = now()
+		WHERE token_hash = $1
+		RETURNING `+calendarFeedColumns, tokenHash)
+	return scanCalendarFeed(row)
+}
+
+func (r *calendarFeedRepository) TeacherIDByUser(ctx context.Context, userID uuid.UUID) (uuid.UUID, error) {
+	var id uuid.UUID
+	err := r.db.QueryRowContext(ctx, `SELECT id FROM teachers WHERE user_id = $1`, userID).Scan(&id)
+	return id, err
+}
@@ -9,10 +9,16 @@ import (
 )
 
 type ClassRepository interface {
-	GetAll(ctx context.Context) ([]models.Class, error)
+	// GetAll loads classes; if termID is set, study plans are resolved for that term
+	GetAll(ctx context.Context, termID *uuid.UUID) ([]models.Class, error)
+	// GetByIDs loads the given classes with all rows of their study plans; unknown IDs are skipped
+	GetByIDs(ctx context.Context, ids []uuid.UUID) ([]models.Class, error)
 	Create(ctx context.Context, name string, grade int) (*models.Class, error)
 	Delete(ctx context.Context, id uuid.UUID) error
-	BulkUpdate(ctx context.Context, items []models.Class) (int, error)
+	// BulkUpdate updates classes if the table still has the expected tag; it returns the new tag
+	BulkUpdate(ctx context.Context, items []models.Class, etag string) (int, string, error)
+	// ETag returns the version tag of all classes
+	ETag(ctx context.Context) (string, error)
 }
 
 type classRepository struct {
@@ -23,15 +29,30 @@ func NewClassRepository(db *sql.DB) ClassRepository {
 	return &classRepository{db: db}
 }
 
-func (r *classRepository) GetAll(ctx context.Context) ([]models.Class, error) {
+func (r *classRepository) GetAll(ctx context.Context, termID *uuid.UUID) ([]models.Class, error) {
+	return r.getClasses(ctx, termID, nil)
+}
+
+func (r *classRepository) GetByIDs(ctx context.Context, ids []uuid.UUID) ([]models.Class, error) {
+	if ids == nil {
+		ids = []uuid.UUID{}
+	}
+	return r.getClasses(ctx, nil, ids)
+}
+
+// getClasses loads classes with their study plans and groups; nil ids loads all of them
+func (r *classRepository) getClasses(ctx context.Context, termID *uuid.UUID, ids []uuid.UUID) ([]models.Class, error) {
 	const q = `
-		SELECT c.id, c.name, t.id, t.first_name, t.last_name, t.patronymic
+		SELECT c.id, c.name, c.grade_level, t.id, t.first_name, t.last_name, t.patronymic,
+		       sh.id, sh.name, sh.number, sh.first_lesson, sh.last_lesson
 		FROM classes c
 		LEFT JOIN teachers t ON t.id = c.homeroom_teacher_id
+		LEFT JOIN shifts sh ON sh.id = c.shift_id
+		WHERE $1::uuid[] IS NULL OR c.id = ANY($1::uuid[])
 		ORDER BY c.name
 	`
 
-	rows, err := r.db.QueryContext(ctx, q)
+	rows, err := r.db.QueryContext(ctx, q, uuidArray(ids))
 	if err != nil {
 		return nil, err
 	}
@@ -43,11 +64,24 @@ func (r *classRepository) GetAll(ctx context.Context) ([]models.Class, error) {
 		var c models.Class
 		var teacherID sql.NullString
 		var first, last, patron sql.NullString
+		var shiftName sql.NullString
+		var shiftNumber, shiftFirst, shiftLast sql.NullInt64
 
-		if err := rows.Scan(&c.ID, &c.Name, &teacherID, &first, &last, &patron); err != nil {
+		if err := rows.Scan(&c.ID, &c.Name, &c.GradeLevel, &teacherID, &first, &last, &patron,
+			&c.ShiftID, &shiftName, &shiftNumber, &shiftFirst, &shiftLast); err != nil {
 			return nil, err
 		}
 
+		if c.ShiftID != nil {
+			c.Shift = &models.Shift{
+				ID:          *c.ShiftID,
+				Name:        shiftName.String,
+				Number:      int(shiftNumber.Int64),
+				FirstLesson: int(shiftFirst.Int64),
+				LastLesson:  int(shiftLast.Int64),
+			}
+		}
+
 		if teacherID.Valid {
 			tid, _ := uuid.Parse(teacherID.String)
 			c.HomeroomTeacher = &models.Teacher{
@@ -64,7 +98,7 @@ func (r *classRepository) GetAll(ctx context.Context) ([]models.Class, error) {
 		}
 
 		// subjects
-		subjects, err := r.loadClassSubjects(ctx, c.ID)
+		subjects, err := r.loadClassSubjects(ctx, c.ID, termID)
 		if err != nil {
 			return nil, err
 		}
@@ -86,17 +120,27 @@ func (r *classRepository) GetAll(ctx context.Context) ([]models.Class, error) {
 	return res, nil
 }
 
-func (r *classRepository) loadClassSubjects(ctx context.Context, classID uuid.UUID) ([]models.ClassSubjectAssignment, error) {
+// loadClassSubjects loads the study plan of a class.
+// Without a term all rows are returned; with a term, term-specific rows override the general ones.
+func (r *classRepository) loadClassSubjects(ctx context.Context, classID uuid.UUID, termID *uuid.UUID) ([]models.ClassSubjectAssignment, error) {
 	const q = `
-		SELECT cs.subject_id, s.name, cs.hours_per_week,
+		SELECT cs.subject_id, s.name, cs.term_id, cs.hours_per_week,
 		       cs.split_groups_count, cs.cross_class_allowed
 		FROM class_subjects cs
 		JOIN subjects s ON s.id = cs.subject_id
 		WHERE cs.class_id = $1
-		ORDER BY s.name
+		  AND (
+		      $2::uuid IS NULL
+		      OR cs.term_id = $2
+		      OR (cs.term_id IS NULL AND NOT EXISTS (
+		          SELECT 1 FROM class_subjects o
+		          WHERE o.class_id = cs.class_id AND o.subject_id = cs.subject_id AND o.term_id = $2
+		      ))
+		  )
+		ORDER BY s.name, cs.term_id NULLS FIRST
 	`
 
-	rows, err := r.db.QueryContext(ctx, q, classID)
+	rows, err := r.db.QueryContext(ctx, q, classID, termID)
 	if err != nil {
 		return nil, err
 	}
@@ -106,11 +150,12 @@ func (r *classRepository) loadClassSubjects(ctx context.Context, classID uuid.UU
 	for rows.Next() {
 		var subjID uuid.UUID
 		var subjName string
-		var hours int
+		var subjTermID *uuid.UUID
+		var hours float64
 		var groupsCount sql.NullInt32
 		var crossClass sql.NullBool
 
-		if err := rows.Scan(&subjID, &subjName, &hours, &groupsCount, &crossClass); err != nil {
+		if err := rows.Scan(&subjID, &subjName, &subjTermID, &hours, &groupsCount, &crossClass); err != nil {
 			return nil, err
 		}
 
@@ -119,6 +164,7 @@ func (r *classRepository) loadClassSubjects(ctx context.Context, classID uuid.UU
 				ID:   subjID,
 				Name: subjName,
 			},
+			TermID:       subjTermID,
 			HoursPerWeek: hours,
 		}
 
@@ -203,13 +249,21 @@ func (r *classRepository) Delete(ctx context.Context, id uuid.UUID) error {
 	return nil
 }
 
-func (r *classRepository) BulkUpdate(ctx context.Context, items []models.Class) (int, error) {
+func (r *classRepository) ETag(ctx context.Context) (string, error) {
+	return tableETag(ctx, r.db, "classes")
+}
+
+func (r *classRepository) BulkUpdate(ctx context.Context, items []models.Class, etag string) (int, string, error) {
 	tx, err := r.db.BeginTx(ctx, nil)
 	if err != nil {
-		return 0, err
+		return 0, "", err
 	}
 
 	updated := 0
+	if err := checkTableETag(ctx, tx, "classes", etag); err != nil {
+		tx.Rollback()
+		return 0, "", err
+	}
 
 	for _, c := range items {
 		// Update base class
@@ -221,12 +275,12 @@ func (r *classRepository) BulkUpdate(ctx context.Context, items []models.Class)
 		}
 
 		res, err := tx.ExecContext(ctx, `
-			UPDATE classes SET name = $1, homeroom_teacher_id = $2
+			UPDATE classes SET name = $1, homeroom_teacher_id = $2, version = version + 1
 			WHERE id = $3
 		`, c.Name, teacherID, c.ID)
 		if err != nil {
 			tx.Rollback()
-			return updated, err
+			return updated, "", err
 		}
 
 		n, _ := res.RowsAffected()
@@ -237,7 +291,7 @@ func (r *classRepository) BulkUpdate(ctx context.Context, items []models.Class)
 		// Replace subjects
 		if _, err := tx.ExecContext(ctx, `DELETE FROM class_subjects WHERE class_id = $1`, c.ID); err != nil {
 			tx.Rollback()
-			return updated, err
+			return updated, "", err
 		}
 
 		for _, subj := range c.Subjects {
@@ -250,20 +304,20 @@ func (r *classRepository) BulkUpdate(ctx context.Context, items []models.Class)
 			}
 
 			_, err := tx.ExecContext(ctx, `
-				INSERT INTO class_subjects (class_id, subject_id, hours_per_week,
+				INSERT INTO class_subjects (class_id, subject_id, term_id, hours_per_week,
 				                            split_groups_count, cross_class_allowed)
-				VALUES ($1, $2, $3, $4, $5)
-			`, c.ID, subj.Subject.ID, subj.HoursPerWeek, groupsCount, crossClass)
+				VALUES ($1, $2, $3, $4, $5, $6)
+			`, c.ID, subj.Subject.ID, subj.TermID, subj.HoursPerWeek, groupsCount, crossClass)
 			if err != nil {
 				tx.Rollback()
-				return updated, err
+				return updated, "", err
 			}
 		}
 
 		// Replace groups
 		if _, err := tx.ExecContext(ctx, `DELETE FROM class_groups WHERE class_id = $1`, c.ID); err != nil {
 			tx.Rollback()
-			return updated, err
+			return updated, "", err
 		}
 
 		for _, gr := range c.Groups {
@@ -273,14 +327,19 @@ func (r *classRepository) BulkUpdate(ctx context.Context, items []models.Class)
 			`, gr.ID, c.ID, gr.Name, gr.Size)
 			if err != nil {
 				tx.Rollback()
-				return updated, err
+				return updated, "", err
 			}
 		}
 	}
 
+	newTag, err := tableETag(ctx, tx, "classes")
+	if err != nil {
+		tx.Rollback()
+		return updated, "", err
+	}
 	if err := tx.Commit(); err != nil {
-		return updated, err
+		return updated, "", err
 	}
 
-	return updated, nil
+	return updated, newTag, nil
 }
@@ -5,13 +5,18 @@ import (
 	"database/sql"
 
 	"github.com/google/uuid"
+	"github.com/lib/pq"
 	"github.com/nikomkinds/SchoolSchedule/internal/models"
 )
 
 type ClassroomRepository interface {
 	GetAll(ctx context.Context) ([]*models.Classroom, error)
-	Create(ctx context.Context, name string) (*models.Classroom, error)
+	GetByID(ctx context.Context, id uuid.UUID) (*models.Classroom, error)
+	Create(ctx context.Context, classroom models.Classroom) (*models.Classroom, error)
+	Update(ctx context.Context, classroom models.Classroom) error
 	Delete(ctx context.Context, id uuid.UUID) error
+	// GetTeacherRoom returns the teacher's own classroom, nil if the teacher has none
+	GetTeacherRoom(ctx context.Context, teacherID uuid.UUID) (*models.Classroom, error)
 }
 
 type classroomRepository struct {
@@ -24,7 +29,7 @@ func NewClassroomRepository(db *sql.DB) ClassroomRepository {
 
 func (r *classroomRepository) GetAll(ctx context.Context) ([]*models.Classroom, error) {
 	const query = `
-		SELECT id, name
+		SELECT id, name, capacity, equipment_tags, building, floor
 		FROM classrooms
 		ORDER BY name
 	`
@@ -38,24 +43,42 @@ func (r *classroomRepository) GetAll(ctx context.Context) ([]*models.Classroom,
 	list := make([]*models.Classroom, 0)
 	for rows.Next() {
 		var c models.Classroom
-		if err := rows.Scan(&c.ID, &c.Name); err != nil {
+		if err := rows.Scan(&c.ID, &c.Name, &c.Capacity, pq.Array(&c.Equipment), &c.Building, &c.Floor); err != nil {
 			return nil, err
 		}
 		list = append(list, &c)
 	}
 
-	return list, nil
+	return list, rows.Err()
 }
 
-func (r *classroomRepository) Create(ctx context.Context, name string) (*models.Classroom, error) {
+func (r *classroomRepository) GetByID(ctx context.Context, id uuid.UUID) (*models.Classroom, error) {
 	const query = `
-		INSERT INTO classrooms (name)
-		VALUES ($1)
-		RETURNING id, name
+		SELECT id, name, capacity, equipment_tags, building, floor
+		FROM classrooms
+		WHERE id = $1
+	`
+
+	var c models.Classroom
+	err := r.db.QueryRowContext(ctx, query, id).Scan(&c.ID, &c.Name, &c.Capacity, pq.Array(&c.Equipment), &c.Building, &c.Floor)
+	if err != nil {
+		return nil, err
+	}
+
+	return &c, nil
+}
+
+func (r *classroomRepository) Create(ctx context.Context, classroom models.Classroom) (*models.Classroom, error) {
+	const query = `
+		INSERT INTO classrooms (name, capacity, equipment_tags, building, floor)
+		VALUES ($1, $2, $3, $4, $5)
+		RETURNING id, name, capacity, equipment_tags, building, floor
 	`
 
 	var c models.Classroom
-	err := r.db.QueryRowContext(ctx, query, name).Scan(&c.ID, &c.Name)
+	err := r.db.QueryRowContext(ctx, query, classroom.Name, classroom.Capacity, pq.Array(equipmentTags(classroom.Equipment)),
+		classroom.Building, classroom.Floor).
+		Scan(&c.ID, &c.Name, &c.Capacity, pq.Array(&c.Equipment), &c.Building, &c.Floor)
 	if err != nil {
 		return nil, err
 	}
@@ -63,6 +86,20 @@ func (r *classroomRepository) Create(ctx context.Context, name string) (*models.
 	return &c, nil
 }
 
+func (r *classroomRepository) Update(ctx context.Context, classroom models.Classroom) error {
+	const query = `
+		UPDATE classrooms
+		SET name = $2, capacity = $3, equipment_tags = $4, building = $5, floor = $6
+		WHERE id = $1
+	`
+	res, err := r.db.ExecContext(ctx, query, classroom.ID, classroom.Name, classroom.Capacity, pq.Array(equipmentTags(classroom.Equipment)),
+		classroom.Building, classroom.Floor)
+	if err != nil {
+		return err
+	}
+	return expectAffected(res)
+}
+
 func (r *classroomRepository) Delete(ctx context.Context, id uuid.UUID) error {
 	const query = `
 		DELETE FROM classrooms
@@ -71,3 +108,31 @@ func (r *classroomRepository) Delete(ctx context.Context, id uuid.UUID) error {
 	_, err := r.db.ExecContext(ctx, query, id)
 	return err
 }
+
+func (r *classroomRepository) GetTeacherRoom(ctx context.Context, teacherID uuid.UUID) (*models.Classroom, error) {
+	const query = `
+		SELECT c.id, c.name, c.capacity, c.equipment_tags, c.building, c.floor
+		FROM teachers t
+		JOIN classrooms c ON c.id = t.classroom_id
+		WHERE t.id = $1
+	`
+
+	var c models.Classroom
+	err := r.db.QueryRowContext(ctx, query, teacherID).Scan(&c.ID, &c.Name, &c.Capacity, pq.Array(&c.Equipment), &c.Building, &c.Floor)
+	if err == sql.ErrNoRows {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	return &c, nil
+}
+
+// equipmentTags turns nil into an empty set: the columns are NOT NULL
+func equipmentTags(tags []string) []string {
+	if tags == nil {
+		return []string{}
+	}
+	return tags
+}
@@ -0,0 +1,154 @@
+package repositories
+
+import (
+	"context"
+	"database/sql"
+
+	"github.com/lib/pq"
+	"github.com/nikomkinds/SchoolSchedule/internal/models"
+)
+
+// ImportRepository creates the entities of a bulk import. Each method inserts all the items in one
+// transaction, with their qualifications, study plans or classroom links, and sets their IDs.
+type ImportRepository interface {
+	CreateClassrooms(ctx context.Context, items []models.Classroom) error
+	CreateSubjects(ctx context.Context, items []models.Subject) error
+	CreateClasses(ctx context.Context, items []models.Class) error
+	CreateTeachers(ctx context.Context, items []models.Teacher) error
+}
+
+type importRepository struct {
+	db *sql.DB
+}
+
+func NewImportRepository(db *sql.DB) ImportRepository {
+	return &importRepository{db: db}
+}
+
+// inTx runs fn in a transaction, committed if fn succeeds
+func (r *importRepository) inTx(ctx context.Context, fn func(tx *sql.Tx) error) error {
+	tx, err := r.db.BeginTx(ctx, nil)
+	if err != nil {
+		return err
+	}
+	defer func() {
+		if err != nil {
+			_ = tx.Rollback()
+		}
+	}()
+
+	if err = fn(tx); err != nil {
+		return err
+	}
+	err = tx.Commit()
+	return err
+}
+
+func (r *importRepository) CreateClassrooms(ctx context.Context, items []models.Classroom) error {
+	return r.inTx(ctx, func(tx *sql.Tx) error {
+		for i := range items {
+			c := &items[i]
+			err := tx.QueryRowContext(ctx, `
+				INSERT INTO classrooms (name, capacity, equipment_tags, building, floor)
+				VALUES ($1, $2, $3, $4, $5)
+				RETURNING id
+			`, c.Name, c.Capacity, pq.Array(equipmentTags(c.Equipment)), c.Building, c.Floor).Scan(&c.ID)
+			if err != nil {
+				return err
+			}
+		}
+		return nil
+	})
+}
+
+func (r *importRepository) CreateSubjects(ctx context.Context, items []models.Subject) error {
+	return r.inTx(ctx, func(tx *sql.Tx) error {
+		for i := range items {
+			s := &items[i]
+			err := tx.QueryRowContext(ctx, `
+				INSERT INTO subjects (name, short_name, required_equipment)
+				VALUES ($1, NULLIF($2, ''), $3)
+				RETURNING id
+			`, s.Name, s.ShortName, pq.Array(equipmentTags(s.RequiredEquipment))).Scan(&s.ID)
+			if err != nil {
+				return err
+			}
+			for _, classroomID := range s.ClassroomIDs {
+				_, err := tx.ExecContext(ctx, `
+					INSERT INTO subject_classrooms (subject_id, classroom_id)
+					VALUES ($1, $2)
+					ON CONFLICT DO NOTHING
+				`, s.ID, classroomID)
+				if err != nil {
+					return err
+				}
+			}
+		}
+		return nil
+	})
+}
+
+func (r *importRepository) CreateClasses(ctx context.Context, items []models.Class) error {
+	return r.inTx(ctx, func(tx *sql.Tx) error {
+		for i := range items {
+			c := &items[i]
+			err := tx.QueryRowContext(ctx, `
+				INSERT INTO classes (name, grade_level, total_students)
+				VALUES ($1, $2, $3)
+				RETURNING id
+			`, c.Name, c.GradeLevel, c.TotalStudents).Scan(&c.ID)
+			if err != nil {
+				return err
+			}
+			for _, subj := range c.Subjects {
+				var groupsCount interface{}
+				if subj.Split != nil {
+					groupsCount = subj.Split.GroupsCount
+				}
+				_, err := tx.ExecContext(ctx, `
+					INSERT INTO class_subjects (class_id, subject_id, hours_per_week, split_groups_count)
+					VALUES ($1, $2, $3, $4)
+				`, c.ID, subj.Subject.ID, subj.HoursPerWeek, groupsCount)
+				if err != nil {
+					return err
+				}
+			}
+		}
+		return nil
+	})
+}
+
+func (r *importRepository) CreateTeachers(ctx context.Context, items []models.Teacher) error {
+	return r.inTx(ctx, func(tx *sql.Tx) error {
+		for i := range items {
+			t := &items[i]
+			err := tx.QueryRowContext(ctx, `
+				INSERT INTO teachers (first_name, last_name, patronymic, workload_hours_per_week)
+				VALUES ($1, $2, $3, $4)
+				RETURNING id
+			`, t.FirstName, t.LastName, t.Patronymic, t.WorkloadHoursPerWeek).Scan(&t.ID)
+			if err != nil {
+				return err
+			}
+			for _, tsa := range t.Subjects {
+				_, err := tx.ExecContext(ctx, `
+					INSERT INTO teacher_subjects (teacher_id, subject_id, preferred_hours_per_week)
+					VALUES ($1, $2, $3)
+				`, t.ID, tsa.Subject.ID, tsa.HoursPerWeek)
+				if err != nil {
+					return err
+				}
+			}
+			for _, ch := range t.ClassHours {
+				_, err := tx.ExecContext(ctx, `
+					INSERT INTO teacher_workload (teacher_id, class_id, subject_id, hours_per_week)
+					VALUES ($1, $2, $3, $4)
+				`, t.ID, ch.Class.ID, ch.Subject.ID, ch.Hours)
+				if err != nil {
+					return err
+				}
+			}
+		}
+		return nil
+	})
+}
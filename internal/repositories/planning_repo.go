@@ -0,0 +1,234 @@
+package repositories
+
+import (
+	"context"
+	"database/sql"
+
+	"github.com/google/uuid"
+	"github.com/lib/pq"
+	"github.com/nikomkinds/SchoolSchedule/internal/models"
+)
+
+// PlanningRepository loads school data in bulk for the conflict checker and the schedule generator
+type PlanningRepository interface {
+	// GetPlanningData loads classes, teachers, classrooms, subjects, study plans, workload and teacher availability.
+	// If termID is set, study plans are resolved for that term.
+	GetPlanningData(ctx context.Context, termID *uuid.UUID) (*models.PlanningData, error)
+}
+
+type planningRepository struct {
+	db *sql.DB
+}
+
+func NewPlanningRepository(db *sql.DB) PlanningRepository {
+	return &planningRepository{db: db}
+}
+
+func (r *planningRepository) GetPlanningData(ctx context.Context, termID *uuid.UUID) (*models.PlanningData, error) {
+	data := &models.PlanningData{}
+	var err error
+
+	if data.Classes, err = r.loadClasses(ctx); err != nil {
+		return nil, err
+	}
+	if data.Teachers, err = r.loadTeachers(ctx); err != nil {
+		return nil, err
+	}
+	if data.Classrooms, err = r.loadClassrooms(ctx); err != nil {
+		return nil, err
+	}
+	if data.Subjects, err = r.loadSubjects(ctx); err != nil {
+		return nil, err
+	}
+	if data.StudyPlans, err = r.loadStudyPlans(ctx, termID); err != nil {
+		return nil, err
+	}
+	if data.Workloads, err = r.loadWorkloads(ctx); err != nil {
+		return nil, err
+	}
+	if data.Availability, err = loadTeacherAvailability(ctx, r.db, nil); err != nil {
+		return nil, err
+	}
+
+	return data, nil
+}
+
+func (r *planningRepository) loadClasses(ctx context.Context) ([]models.Class, error) {
+	rows, err := r.db.QueryContext(ctx, `
+		SELECT id, name, grade_level, total_students, shift_id
+		FROM classes
+		ORDER BY grade_level, name
+	`)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var out []models.Class
+	index := make(map[uuid.UUID]int)
+	for rows.Next() {
+		var c models.Class
+		var total sql.NullInt64
+		if err := rows.Scan(&c.ID, &c.Name, &c.GradeLevel, &total, &c.ShiftID); err != nil {
+			return nil, err
+		}
+		if total.Valid {
+			n := int(total.Int64)
+			c.TotalStudents = &n
+		}
+		index[c.ID] = len(out)
+		out = append(out, c)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+
+	gRows, err := r.db.QueryContext(ctx, `
+		SELECT id, class_id, name, size
+		FROM class_groups
+		ORDER BY name
+	`)
+	if err != nil {
+		return nil, err
+	}
+	defer gRows.Close()
+
+	for gRows.Next() {
+		var g models.ClassGroup
+		var size sql.NullInt64
+		if err := gRows.Scan(&g.ID, &g.ClassID, &g.Name, &size); err != nil {
+			return nil, err
+		}
+		if size.Valid {
+			n := int(size.Int64)
+			g.Size = &n
+		}
+		if i, ok := index[g.ClassID]; ok {
+			out[i].Groups = append(out[i].Groups, g)
+		}
+	}
+
+	return out, gRows.Err()
+}
+
+func (r *planningRepository) loadTeachers(ctx context.Context) ([]models.Teacher, error) {
+	rows, err := r.db.QueryContext(ctx, `
+		SELECT id, first_name, last_name, patronymic, classroom_id, workload_hours_per_week
+		FROM teachers
+		ORDER BY last_name, first_name
+	`)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var out []models.Teacher
+	for rows.Next() {
+		var t models.Teacher
+		var patron sql.NullString
+		var workload sql.NullInt64
+		if err := rows.Scan(&t.ID, &t.FirstName, &t.LastName, &patron, &t.ClassroomID, &workload); err != nil {
+			return nil, err
+		}
+		if patron.Valid {
+			t.Patronymic = &patron.String
+		}
+		t.WorkloadHoursPerWeek = int(workload.Int64)
+		out = append(out, t)
+	}
+	return out, rows.Err()
+}
+
+func (r *planningRepository) loadClassrooms(ctx context.Context) ([]models.Classroom, error) {
+	rows, err := r.db.QueryContext(ctx, `SELECT id, name, capacity, equipment_tags FROM classrooms ORDER BY name`)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var out []models.Classroom
+	for rows.Next() {
+		var c models.Classroom
+		if err := rows.Scan(&c.ID, &c.Name, &c.Capacity, pq.Array(&c.Equipment)); err != nil {
+			return nil, err
+		}
+		out = append(out, c)
+	}
+	return out, rows.Err()
+}
+
+func (r *planningRepository) loadSubjects(ctx context.Context) ([]models.Subject, error) {
+	rows, err := r.db.QueryContext(ctx, subjectQuery+` GROUP BY s.id ORDER BY s.name`)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var out []models.Subject
+	for rows.Next() {
+		s, err := scanSubject(rows)
+		if err != nil {
+			return nil, err
+		}
+		out = append(out, s)
+	}
+	return out, rows.Err()
+}
+
+// loadStudyPlans loads study plans of all classes; term-specific rows override the general ones
+func (r *planningRepository) loadStudyPlans(ctx context.Context, termID *uuid.UUID) ([]models.StudyPlan, error) {
+	rows, err := r.db.QueryContext(ctx, `
+		SELECT cs.id, cs.class_id, cs.subject_id, cs.term_id, cs.hours_per_week,
+		       cs.split_groups_count, cs.cross_class_allowed
+		FROM class_subjects cs
+		WHERE cs.term_id = $1::uuid
+		   OR (cs.term_id IS NULL AND NOT EXISTS (
+		       SELECT 1 FROM class_subjects o
+		       WHERE o.class_id = cs.class_id AND o.subject_id = cs.subject_id AND o.term_id = $1
+		   ))
+	`, termID)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var out []models.StudyPlan
+	for rows.Next() {
+		var p models.StudyPlan
+		var groupsCount sql.NullInt64
+		var crossClass sql.NullBool
+		if err := rows.Scan(&p.ID, &p.ClassID, &p.SubjectID, &p.TermID, &p.HoursPerWeek, &groupsCount, &crossClass); err != nil {
+			return nil, err
+		}
+		if groupsCount.Valid {
+			n := int(groupsCount.Int64)
+			p.SplitEnabled = true
+			p.SplitGroupsCount = &n
+		}
+		p.CrossClassAllowed = crossClass.Bool
+		out = append(out, p)
+	}
+	return out, rows.Err()
+}
+
+func (r *planningRepository) loadWorkloads(ctx context.Context) ([]models.TeacherWorkload, error) {
+	rows, err := r.db.QueryContext(ctx, `
+		SELECT id, teacher_id, class_id, subject_id, group_id, hours_per_week
+		FROM teacher_workload
+		ORDER BY hours_per_week DESC
+	`)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var out []models.TeacherWorkload
+	for rows.Next() {
+		var w models.TeacherWorkload
+		if err := rows.Scan(&w.ID, &w.TeacherID, &w.ClassID, &w.SubjectID, &w.GroupID, &w.HoursPerWeek); err != nil {
+			return nil, err
+		}
+		out = append(out, w)
+	}
+	return out, rows.Err()
+}
@@ -0,0 +1,409 @@
+package repositories
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"fmt"
+	"sort"
+	"strings"
+
+	"github.com/google/uuid"
+	"github.com/nikomkinds/SchoolSchedule/internal/models"
+)
+
+// LessonCheck inspects the timetable after a lesson edit, before it is committed.
+// lessonIDs are the added, updated or moved lessons; an error rolls the edit back and is returned as is.
+type LessonCheck func(days []models.ScheduleDay, lessonIDs []uuid.UUID) error
+
+// AddLesson adds a lesson to the slot of a named schedule, creating the slot if needed
+func (r *scheduleRepository) AddLesson(ctx context.Context, scheduleID uuid.UUID, day, lessonNumber int,
+	lesson models.LessonInput, version *int, note models.RevisionNote, check LessonCheck) (uuid.UUID, int, error) {
+	var lessonID uuid.UUID
+	newVersion, err := r.editLessons(ctx, scheduleID, version, note, func(tx *sql.Tx) ([]uuid.UUID, error) {
+		slotID, err := slotFor(ctx, tx, scheduleID, day, lessonNumber)
+		if err != nil {
+			return nil, err
+		}
+		lessonID, err = insertLesson(ctx, tx, slotID, lesson)
+		return []uuid.UUID{lessonID}, err
+	}, check)
+	return lessonID, newVersion, err
+}
+
+// UpdateLesson replaces the content of a lesson and puts it into the given slot; the lesson keeps its ID
+func (r *scheduleRepository) UpdateLesson(ctx context.Context, scheduleID, lessonID uuid.UUID, day, lessonNumber int,
+	lesson models.LessonInput, version *int, note models.RevisionNote, check LessonCheck) (int, error) {
+	return r.editLessons(ctx, scheduleID, version, note, func(tx *sql.Tx) ([]uuid.UUID, error) {
+		oldSlotID, err := lessonSlot(ctx, tx, scheduleID, lessonID)
+		if err != nil {
+			return nil, err
+		}
+		slotID, err := slotFor(ctx, tx, scheduleID, day, lessonNumber)
+		if err != nil {
+			return nil, err
+		}
+		if err := replaceLesson(ctx, tx, lessonID, slotID, lesson); err != nil {
+			return nil, err
+		}
+		return []uuid.UUID{lessonID}, dropEmptySlot(ctx, tx, oldSlotID)
+	}, check)
+}
+
+// MoveLessons moves lessons of a named schedule to other slots together, so lessons may swap places
+func (r *scheduleRepository) MoveLessons(ctx context.Context, scheduleID uuid.UUID, moves []models.LessonMove, version *int, note models.RevisionNote, check LessonCheck) (int, error) {
+	return r.editLessons(ctx, scheduleID, version, note, func(tx *sql.Tx) ([]uuid.UUID, error) {
+		lessonIDs := make([]uuid.UUID, 0, len(moves))
+		oldSlots := make([]uuid.UUID, 0, len(moves))
+		for _, m := range moves {
+			day := stringToDayOfWeek(m.DayOfWeek)
+			if day == 0 {
+				return nil, fmt.Errorf("invalid day of week: %s", m.DayOfWeek)
+			}
+			oldSlotID, err := lessonSlot(ctx, tx, scheduleID, m.LessonID)
+			if err != nil {
+				return nil, err
+			}
+			slotID, err := slotFor(ctx, tx, scheduleID, day, m.LessonNumber)
+			if err != nil {
+				return nil, err
+			}
+			if _, err := tx.ExecContext(ctx, `UPDATE schedule_lessons SET slot_id = $1 WHERE id = $2`, slotID, m.LessonID); err != nil {
+				return nil, err
+			}
+			lessonIDs = append(lessonIDs, m.LessonID)
+			oldSlots = append(oldSlots, oldSlotID)
+		}
+		// Empty slots are dropped only now: a swapped lesson may still be moved into one of them
+		for _, slotID := range oldSlots {
+			if err := dropEmptySlot(ctx, tx, slotID); err != nil {
+				return nil, err
+			}
+		}
+		return lessonIDs, nil
+	}, check)
+}
+
+// DeleteLesson deletes a lesson of a named schedule and its slot if no lessons are left there
+func (r *scheduleRepository) DeleteLesson(ctx context.Context, scheduleID, lessonID uuid.UUID, version *int, note models.RevisionNote) (int, error) {
+	return r.editLessons(ctx, scheduleID, version, note, func(tx *sql.Tx) ([]uuid.UUID, error) {
+		slotID, err := lessonSlot(ctx, tx, scheduleID, lessonID)
+		if err != nil {
+			return nil, err
+		}
+		if _, err := tx.ExecContext(ctx, `DELETE FROM schedule_lessons WHERE id = $1`, lessonID); err != nil {
+			return nil, err
+		}
+		return nil, dropEmptySlot(ctx, tx, slotID)
+	}, nil)
+}
+
+// editLessons runs one lesson edit in a transaction and returns the new version. The schedule row is
+// locked for the duration, so concurrent edits of the same schedule are applied one after another and
+// each check sees the timetable it is committed into. With version set, an edit of a schedule changed
+// in the meantime returns ErrVersionMismatch.
+func (r *scheduleRepository) editLessons(ctx context.Context, scheduleID uuid.UUID, version *int, note models.RevisionNote,
+	edit func(tx *sql.Tx) ([]uuid.UUID, error), check LessonCheck) (int, error) {
+	tx, err := r.db.BeginTx(ctx, nil)
+	if err != nil {
+		return 0, err
+	}
+	defer func() {
+		if err != nil {
+			_ = tx.Rollback()
+		}
+	}()
+
+	// The status is checked under the lock: a schedule submitted meanwhile is no longer edited
+	var (
+		current int
+		status  string
+	)
+	err = tx.QueryRowContext(ctx, `SELECT version, status FROM schedules WHERE id = $1 FOR UPDATE`, scheduleID).Scan(&current, &status)
+	if err != nil {
+		return 0, err
+	}
+	if status != models.ScheduleStatusDraft {
+		err = fmt.Errorf("%w: the schedule is %s", ErrScheduleLocked, status)
+		return 0, err
+	}
+	if version != nil && *version != current {
+		err = ErrVersionMismatch
+		return 0, err
+	}
+
+	lessonIDs, err := edit(tx)
+	if err != nil {
+		return 0, err
+	}
+
+	if check != nil {
+		var days []models.ScheduleDay
+		if days, err = r.withTx(tx).loadScheduleDays(ctx, scheduleID); err != nil {
+			return 0, err
+		}
+		if err = check(days, lessonIDs); err != nil {
+			return 0, err
+		}
+	}
+
+	newVersion, err := r.newRevision(ctx, tx, scheduleID, note)
+	if err != nil {
+		return 0, err
+	}
+	if err = tx.Commit(); err != nil {
+		return 0, err
+	}
+	return newVersion, nil
+}
+
+// lessonSlot returns the slot of a lesson, sql.ErrNoRows if the lesson is not part of the schedule
+func lessonSlot(ctx context.Context, tx *sql.Tx, scheduleID, lessonID uuid.UUID) (uuid.UUID, error) {
+	var slotID uuid.UUID
+	err := tx.QueryRowContext(ctx, `
+		SELECT sl.slot_id
+		FROM schedule_lessons sl
+		JOIN schedule_slots ss ON ss.id = sl.slot_id
+		WHERE sl.id = $1 AND ss.schedule_id = $2
+	`, lessonID, scheduleID).Scan(&slotID)
+	return slotID, err
+}
+
+// slotFor returns the slot of the schedule at the given day and lesson number, creating it if missing
+func slotFor(ctx context.Context, tx *sql.Tx, scheduleID uuid.UUID, day, lessonNumber int) (uuid.UUID, error) {
+	var slotID uuid.UUID
+	err := tx.QueryRowContext(ctx, `
+		SELECT id FROM schedule_slots
+		WHERE schedule_id = $1 AND day_of_week = $2 AND lesson_number = $3
+		LIMIT 1
+	`, scheduleID, day, lessonNumber).Scan(&slotID)
+	if !errors.Is(err, sql.ErrNoRows) {
+		return slotID, err
+	}
+
+	err = tx.QueryRowContext(ctx, `
+		INSERT INTO schedule_slots (schedule_id, day_of_week, lesson_number)
+		VALUES ($1, $2, $3)
+		RETURNING id
+	`, scheduleID, day, lessonNumber).Scan(&slotID)
+	return slotID, err
+}
+
+// dropEmptySlot deletes a slot that has no lessons left
+func dropEmptySlot(ctx context.Context, tx *sql.Tx, slotID uuid.UUID) error {
+	_, err := tx.ExecContext(ctx, `
+		DELETE FROM schedule_slots ss
+		WHERE ss.id = $1 AND NOT EXISTS (SELECT 1 FROM schedule_lessons sl WHERE sl.slot_id = ss.id)
+	`, slotID)
+	return err
+}
+
+// insertLesson inserts a lesson with its teachers, rooms and participants into a slot
+func insertLesson(ctx context.Context, tx *sql.Tx, slotID uuid.UUID, lesson models.LessonInput) (uuid.UUID, error) {
+	subjectID, err := uuid.Parse(lesson.Subject.ID)
+	if err != nil {
+		return uuid.Nil, fmt.Errorf("invalid subject ID: %w", err)
+	}
+
+	var lessonID uuid.UUID
+	err = tx.QueryRowContext(ctx, `
+		INSERT INTO schedule_lessons (slot_id, subject_id, week_pattern)
+		VALUES ($1, $2, $3)
+		RETURNING id
+	`, slotID, subjectID, weekPatternValue(lesson.WeekPattern)).Scan(&lessonID)
+	if err != nil {
+		return uuid.Nil, err
+	}
+	return lessonID, insertLessonDetails(ctx, tx, lessonID, lesson)
+}
+
+// replaceLesson puts a stored lesson into the slot with the given content; the lesson keeps its ID,
+// and with it its overrides on single dates
+func replaceLesson(ctx context.Context, tx *sql.Tx, lessonID, slotID uuid.UUID, lesson models.LessonInput) error {
+	subjectID, err := uuid.Parse(lesson.Subject.ID)
+	if err != nil {
+		return fmt.Errorf("invalid subject ID: %w", err)
+	}
+
+	_, err = tx.ExecContext(ctx, `
+		UPDATE schedule_lessons SET slot_id = $1, subject_id = $2, week_pattern = $3
+		WHERE id = $4
+	`, slotID, subjectID, weekPatternValue(lesson.WeekPattern), lessonID)
+	if err != nil {
+		return err
+	}
+	// Participant groups go with their participants (ON DELETE CASCADE)
+	for _, q := range []string{
+		`DELETE FROM lesson_teachers WHERE lesson_id = $1`,
+		`DELETE FROM lesson_rooms WHERE lesson_id = $1`,
+		`DELETE FROM lesson_participants WHERE lesson_id = $1`,
+	} {
+		if _, err := tx.ExecContext(ctx, q, lessonID); err != nil {
+			return err
+		}
+	}
+	return insertLessonDetails(ctx, tx, lessonID, lesson)
+}
+
+// storedLesson is a lesson of a schedule being saved as a whole, identified for matching with the input
+type storedLesson struct {
+	id  uuid.UUID
+	key string
+}
+
+// lessonKey identifies a lesson by its slot, subject and classes, for input lessons that come without an ID
+func lessonKey(day, lessonNumber int, subjectID string, classIDs []string) string {
+	sort.Strings(classIDs)
+	return fmt.Sprintf("%d/%d/%s/%s", day, lessonNumber, subjectID, strings.Join(classIDs, ","))
+}
+
+func inputLessonKey(day, lessonNumber int, lesson models.LessonInput) string {
+	subjectID := lesson.Subject.ID
+	if id, err := uuid.Parse(subjectID); err == nil {
+		subjectID = id.String()
+	}
+	classIDs := make([]string, 0, len(lesson.Participants))
+	for _, p := range lesson.Participants {
+		if id, err := uuid.Parse(p.Class.ID); err == nil {
+			classIDs = append(classIDs, id.String())
+		}
+	}
+	return lessonKey(day, lessonNumber, subjectID, classIDs)
+}
+
+// matchLessons pairs the input lessons of a full save with the stored ones, so that a lesson which is
+// kept keeps its ID: by the ID sent with it first, then by slot, subject and classes. It returns the
+// stored ID for every input lesson, [slot][lesson], uuid.Nil for new lessons.
+func matchLessons(stored []storedLesson, slots []models.ScheduleSlotInput) [][]uuid.UUID {
+	free := make(map[uuid.UUID]bool, len(stored))
+	for _, l := range stored {
+		free[l.id] = true
+	}
+
+	out := make([][]uuid.UUID, len(slots))
+	for i, slot := range slots {
+		out[i] = make([]uuid.UUID, len(slot.Lessons))
+		for j, lesson := range slot.Lessons {
+			if id, err := uuid.Parse(lesson.ID); err == nil && free[id] {
+				out[i][j] = id
+				free[id] = false
+			}
+		}
+	}
+	for i, slot := range slots {
+		day := stringToDayOfWeek(slot.DayOfWeek)
+		for j, lesson := range slot.Lessons {
+			if out[i][j] != uuid.Nil {
+				continue
+			}
+			key := inputLessonKey(day, slot.LessonNumber, lesson)
+			for _, l := range stored {
+				if free[l.id] && l.key == key {
+					out[i][j] = l.id
+					free[l.id] = false
+					break
+				}
+			}
+		}
+	}
+	return out
+}
+
+// storedLessons loads the lessons of a schedule for matchLessons
+func storedLessons(ctx context.Context, tx *sql.Tx, scheduleID uuid.UUID) ([]storedLesson, error) {
+	rows, err := tx.QueryContext(ctx, `
+		SELECT sl.id, ss.day_of_week, ss.lesson_number, sl.subject_id,
+		       COALESCE((SELECT string_agg(lp.class_id::text, ',') FROM lesson_participants lp WHERE lp.lesson_id = sl.id), '')
+		FROM schedule_lessons sl
+		JOIN schedule_slots ss ON ss.id = sl.slot_id
+		WHERE ss.schedule_id = $1
+		ORDER BY ss.day_of_week, ss.lesson_number, sl.created_at, sl.id
+	`, scheduleID)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var out []storedLesson
+	for rows.Next() {
+		var (
+			l                 storedLesson
+			day, lessonNumber int
+			subjectID         uuid.UUID
+			classes           string
+		)
+		if err := rows.Scan(&l.id, &day, &lessonNumber, &subjectID, &classes); err != nil {
+			return nil, err
+		}
+		var classIDs []string
+		if classes != "" {
+			classIDs = strings.Split(classes, ",")
+		}
+		l.key = lessonKey(day, lessonNumber, subjectID.String(), classIDs)
+		out = append(out, l)
+	}
+	return out, rows.Err()
+}
+
+// insertLessonDetails inserts lesson_teachers, lesson_rooms, lesson_participants and lesson_participant_groups
+func insertLessonDetails(ctx context.Context, tx *sql.Tx, lessonID uuid.UUID, lesson models.LessonInput) error {
+	for _, teacherInput := range lesson.Teachers {
+		teacherID, err := uuid.Parse(teacherInput.ID)
+		if err != nil {
+			return fmt.Errorf("invalid teacher ID: %w", err)
+		}
+		_, err = tx.ExecContext(ctx, `
+			INSERT INTO lesson_teachers (lesson_id, teacher_id)
+			VALUES ($1, $2)
+		`, lessonID, teacherID)
+		if err != nil {
+			return err
+		}
+	}
+
+	for _, roomInput := range lesson.Rooms {
+		roomID, err := uuid.Parse(roomInput.ID)
+		if err != nil {
+			return fmt.Errorf("invalid room ID: %w", err)
+		}
+		_, err = tx.ExecContext(ctx, `
+			INSERT INTO lesson_rooms (lesson_id, classroom_id)
+			VALUES ($1, $2)
+		`, lessonID, roomID)
+		if err != nil {
+			return err
+		}
+	}
+
+	for _, participantInput := range lesson.Participants {
+		classID, err := uuid.Parse(participantInput.Class.ID)
+		if err != nil {
+			return fmt.Errorf("invalid class ID: %w", err)
+		}
+
+		var participantID uuid.UUID
+		err = tx.QueryRowContext(ctx, `
+			INSERT INTO lesson_participants (lesson_id, class_id)
+			VALUES ($1, $2)
+			RETURNING id
+		`, lessonID, classID).Scan(&participantID)
+		if err != nil {
+			return err
+		}
+
+		for _, groupIDStr := range participantInput.GroupIDs {
+			groupID, err := uuid.Parse(groupIDStr)
+			if err != nil {
+				return fmt.Errorf("invalid group ID: %w", err)
+			}
+			_, err = tx.ExecContext(ctx, `
+				INSERT INTO lesson_participant_groups (participant_id, group_id)
+				VALUES ($1, $2)
+			`, participantID, groupID)
+			if err != nil {
+				return err
+			}
+		}
+	}
+	return nil
+}
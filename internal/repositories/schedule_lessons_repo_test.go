@@ -0,0 +1,105 @@
+package repositories
+
+import (
+	"testing"
+
+	"github.com/google/uuid"
+	"github.com/nikomkinds/SchoolSchedule/internal/models"
+)
+
+func TestMatchLessonsKeepsOverriddenLessons(t *testing.T) {
+	math, art := uuid.New(), uuid.New()
+	classA, classB := uuid.New(), uuid.New()
+	overridden, other, removed := uuid.New(), uuid.New(), uuid.New()
+
+	stored := []storedLesson{
+		{id: overridden, key: lessonKey(1, 1, math.String(), []string{classA.String()})},
+		{id: other, key: lessonKey(1, 1, math.String(), []string{classB.String()})},
+		{id: removed, key: lessonKey(2, 3, art.String(), []string{classA.String()})},
+	}
+	lesson := func(id uuid.UUID, subject uuid.UUID, class uuid.UUID) models.LessonInput {
+		l := models.LessonInput{
+			Subject:      models.SubjectInput{ID: subject.String()},
+			Participants: []models.ParticipantInput{{Class: models.ClassInput{ID: class.String()}}},
+		}
+		if id != uuid.Nil {
+			l.ID = id.String()
+		}
+		return l
+	}
+
+	tests := []struct {
+		name  string
+		slots []models.ScheduleSlotInput
+		want  [][]uuid.UUID
+	}{
+		{
+			name: "PUT of the loaded timetable with IDs",
+			slots: []models.ScheduleSlotInput{
+				{DayOfWeek: "MONDAY", LessonNumber: 1, Lessons: []models.LessonInput{
+					lesson(other, math, classB), lesson(overridden, math, classA),
+				}},
+			},
+			want: [][]uuid.UUID{{other, overridden}},
+		},
+		{
+			name: "PUT without IDs matches by slot, subject and classes",
+			slots: []models.ScheduleSlotInput{
+				{DayOfWeek: "monday", LessonNumber: 1, Lessons: []models.LessonInput{
+					lesson(uuid.Nil, math, classA), lesson(uuid.Nil, art, classB),
+				}},
+			},
+			want: [][]uuid.UUID{{overridden, uuid.Nil}},
+		},
+		{
+			name: "restore moves a kept lesson to another slot",
+			slots: []models.ScheduleSlotInput{
+				{DayOfWeek: "TUESDAY", LessonNumber: 4, Lessons: []models.LessonInput{lesson(overridden, math, classA)}},
+				{DayOfWeek: "MONDAY", LessonNumber: 1, Lessons: []models.LessonInput{lesson(uuid.Nil, math, classA)}},
+			},
+			want: [][]uuid.UUID{{overridden}, {uuid.Nil}},
+		},
+		{
+			name: "an ID is claimed once",
+			slots: []models.ScheduleSlotInput{
+				{DayOfWeek: "MONDAY", LessonNumber: 1, Lessons: []models.LessonInput{
+					lesson(overridden, math, classA), lesson(overridden, math, classA),
+				}},
+			},
+			want: [][]uuid.UUID{{overridden, uuid.Nil}},
+		},
+		{
+			name: "unknown IDs are new lessons",
+			slots: []models.ScheduleSlotInput{
+				{DayOfWeek: "WEDNESDAY", LessonNumber: 2, Lessons: []models.LessonInput{lesson(uuid.New(), art, classA)}},
+			},
+			want: [][]uuid.UUID{{uuid.Nil}},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got := matchLessons(stored, tt.slots)
+			if len(got) != len(tt.want) {
+				t.Fatalf("got %d slots, want %d", len(got), len(tt.want))
+			}
+			for i := range tt.want {
+				if len(got[i]) != len(tt.want[i]) {
+					t.Fatalf("slot %d: got %d lessons, want %d", i, len(got[i]), len(tt.want[i]))
+				}
+				for j := range tt.want[i] {
+					if got[i][j] != tt.want[i][j] {
+						t.Errorf("slot %d lesson %d: matched %s, want %s", i, j, got[i][j], tt.want[i][j])
+					}
+				}
+			}
+		})
+	}
+}
+
+func TestLessonKeyIgnoresClassOrder(t *testing.T) {
+	a, b := uuid.NewString(), uuid.NewString()
+	if lessonKey(1, 2, "s", []string{a, b}) != lessonKey(1, 2, "s", []string{b, a}) {
+		t.Error("lesson key depends on the order of classes")
+	}
+}
@@ -0,0 +1,94 @@
+package repositories
+
+import (
+	"context"
+	"database/sql"
+	"encoding/json"
+
+	"github.com/google/uuid"
+	"github.com/nikomkinds/SchoolSchedule/internal/models"
+)
+
+// GetRevision loads the timetable of a schedule as of the given version
+func (r *scheduleRepository) GetRevision(ctx context.Context, scheduleID uuid.UUID, version int) ([]models.ScheduleDay, error) {
+	var content []byte
+	err := r.db.QueryRowContext(ctx, `
+		SELECT content FROM schedule_revisions
+		WHERE schedule_id = $1 AND version = $2
+	`, scheduleID, version).Scan(&content)
+	if err != nil {
+		return nil, err
+	}
+
+	var days []models.ScheduleDay
+	if err := json.Unmarshal(content, &days); err != nil {
+		return nil, err
+	}
+	return days, nil
+}
+
+// ListRevisions loads the revisions of a schedule without their content, newest first
+func (r *scheduleRepository) ListRevisions(ctx context.Context, scheduleID uuid.UUID) ([]models.ScheduleRevision, error) {
+	var exists bool
+	err := r.db.QueryRowContext(ctx, `SELECT EXISTS (SELECT 1 FROM schedules WHERE id = $1)`, scheduleID).Scan(&exists)
+	if err != nil {
+		return nil, err
+	}
+	if !exists {
+		return nil, sql.ErrNoRows
+	}
+
+	rows, err := r.db.QueryContext(ctx, `
+		SELECT sr.version, sr.author_id, u.email, sr.comment, sr.created_at
+		FROM schedule_revisions sr
+		LEFT JOIN users u ON u.id = sr.author_id
+		WHERE sr.schedule_id = $1
+		ORDER BY sr.version DESC
+	`, scheduleID)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	revisions := []models.ScheduleRevision{}
+	for rows.Next() {
+		var rev models.ScheduleRevision
+		if err := rows.Scan(&rev.Version, &rev.AuthorID, &rev.Author, &rev.Comment, &rev.CreatedAt); err != nil {
+			return nil, err
+		}
+		revisions = append(revisions, rev)
+	}
+	return revisions, rows.Err()
+}
+
+// newRevision increments the schedule version and stores the timetable as of that version.
+// It must run in the transaction that changed the timetable.
+func (r *scheduleRepository) newRevision(ctx context.Context, tx *sql.Tx, scheduleID uuid.UUID, note models.RevisionNote) (int, error) {
+	var version int
+	err := tx.QueryRowContext(ctx, `
+		UPDATE schedules SET version = version + 1, updated_at = now()
+		WHERE id = $1
+		RETURNING version
+	`, scheduleID).Scan(&version)
+	if err != nil {
+		return 0, err
+	}
+	return version, r.saveRevision(ctx, tx, scheduleID, version, note)
+}
+
+// saveRevision stores the timetable as seen by the transaction under the given version, with its author and comment
+func (r *scheduleRepository) saveRevision(ctx context.Context, tx *sql.Tx, scheduleID uuid.UUID, version int, note models.RevisionNote) error {
+	days, err := r.withTx(tx).loadScheduleDays(ctx, scheduleID)
+	if err != nil {
+		return err
+	}
+	content, err := json.Marshal(days)
+	if err != nil {
+		return err
+	}
+	_, err = tx.ExecContext(ctx, `
+		INSERT INTO schedule_revisions (schedule_id, version, content, author_id, comment)
+		VALUES ($1, $2, $3, $4, NULLIF($5, ''))
+	`, scheduleID, version, content, note.AuthorID, note.Comment)
+	return err
+}
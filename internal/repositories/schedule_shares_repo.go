@@ -0,0 +1,94 @@
+package repositories
+
+import (
+	"context"
+	"database/sql"
+
+	"github.com/google/uuid"
+	"github.com/nikomkinds/SchoolSchedule/internal/models"
+)
+
+// ScheduleAccess returns the strongest access a user has to a schedule: owner, a share with the user
+// or the role, or view of a published schedule
+func (r *scheduleRepository) ScheduleAccess(ctx context.Context, scheduleID, userID uuid.UUID, role string) (string, string, error) {
+	var (
+		owner     bool
+		status    string
+		permShare sql.NullString
+	)
+	err := r.db.QueryRowContext(ctx, `
+		SELECT s.user_id IS NOT DISTINCT FROM $2, s.status,
+		       (SELECT sh.permission FROM schedule_shares sh
+		        WHERE sh.schedule_id = s.id AND (sh.user_id = $2 OR sh.role = $3)
+		        ORDER BY sh.permission = 'edit' DESC
+		        LIMIT 1)
+		FROM schedules s
+		WHERE s.id = $1
+	`, scheduleID, userID, role).Scan(&owner, &status, &permShare)
+	if err != nil {
+		return "", "", err
+	}
+
+	switch {
+	case owner:
+		return models.ScheduleAccessOwner, status, nil
+	case permShare.Valid:
+		return permShare.String, status, nil
+	case status == models.ScheduleStatusPublished:
+		return models.ScheduleAccessView, status, nil
+	}
+	return "", status, nil
+}
+
+// ListShares loads the shares of a schedule, user shares first
+func (r *scheduleRepository) ListShares(ctx context.Context, scheduleID uuid.UUID) ([]models.ScheduleShare, error) {
+	rows, err := r.db.QueryContext(ctx, `
+		SELECT id, schedule_id, user_id, role, permission, created_at
+		FROM schedule_shares
+		WHERE schedule_id = $1
+		ORDER BY role NULLS FIRST, created_at
+	`, scheduleID)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	shares := []models.ScheduleShare{}
+	for rows.Next() {
+		var sh models.ScheduleShare
+		if err := rows.Scan(&sh.ID, &sh.ScheduleID, &sh.UserID, &sh.Role, &sh.Permission, &sh.CreatedAt); err != nil {
+			return nil, err
+		}
+		shares = append(shares, sh)
+	}
+	return shares, rows.Err()
+}
+
+// SaveShare inserts a share, or updates the permission of the existing share with the same user or role
+func (r *scheduleRepository) SaveShare(ctx context.Context, share models.ScheduleShare) (*models.ScheduleShare, error) {
+	// The conflict target must match one of the partial unique indexes
+	conflict := `(schedule_id, user_id) WHERE user_id IS NOT NULL`
+	if share.Role != nil {
+		conflict = `(schedule_id, role) WHERE role IS NOT NULL`
+	}
+
+	err := r.db.QueryRowContext(ctx, `
+		INSERT INTO schedule_shares (schedule_id, user_id, role, permission)
+		VALUES ($1, $2, $3, $4)
+		ON CONFLICT `+conflict+` DO UPDATE SET permission = EXCLUDED.permission
+		RETURNING id, created_at
+	`, share.ScheduleID, share.UserID, share.Role, share.Permission).Scan(&share.ID, &share.CreatedAt)
+	if err != nil {
+		return nil, err
+	}
+	return &share, nil
+}
+
+// DeleteShare deletes a share of a schedule
+func (r *scheduleRepository) DeleteShare(ctx context.Context, scheduleID, shareID uuid.UUID) error {
+	res, err := r.db.ExecContext(ctx, `DELETE FROM schedule_shares WHERE id = $1 AND schedule_id = $2`, shareID, scheduleID)
+	if err != nil {
+		return err
+	}
+	return expectAffected(res)
+}
@@ -0,0 +1,104 @@
+package repositories
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+
+	"github.com/google/uuid"
+	"github.com/nikomkinds/SchoolSchedule/internal/models"
+)
+
+// ErrStatusMismatch is returned when a schedule is not in the status a transition starts from
+var ErrStatusMismatch = errors.New("status mismatch")
+
+// TransitionSchedule changes the status of a schedule and records the change in one transaction.
+// Publishing archives the schedule published before for the same term (or without a term);
+// an archived schedule is no longer the active one of its owner.
+func (r *scheduleRepository) TransitionSchedule(ctx context.Context, scheduleID uuid.UUID, from, to string, actorID *uuid.UUID, comment string) error {
+	tx, err := r.db.BeginTx(ctx, nil)
+	if err != nil {
+		return err
+	}
+	defer func() {
+		if err != nil {
+			_ = tx.Rollback()
+		}
+	}()
+
+	var (
+		status string
+		termID *uuid.UUID
+	)
+	err = tx.QueryRowContext(ctx, `SELECT status, term_id FROM schedules WHERE id = $1 FOR UPDATE`, scheduleID).Scan(&status, &termID)
+	if err != nil {
+		return err
+	}
+	if status != from {
+		err = ErrStatusMismatch
+		return err
+	}
+
+	if to == models.ScheduleStatusPublished {
+		_, err = tx.ExecContext(ctx, `
+			WITH archived AS (
+				UPDATE schedules SET status = 'archived', is_active = false, updated_at = now()
+				WHERE status = 'published' AND term_id IS NOT DISTINCT FROM $1 AND id <> $2
+				RETURNING id
+			)
+			INSERT INTO schedule_transitions (schedule_id, from_status, to_status, actor_id, comment)
+			SELECT id, 'published', 'archived', $3, 'Заменено новым опубликованным расписанием'
+			FROM archived
+		`, termID, scheduleID, actorID)
+		if err != nil {
+			return err
+		}
+	}
+
+	_, err = tx.ExecContext(ctx, `UPDATE schedules SET status = $2, updated_at = now() WHERE id = $1`, scheduleID, to)
+	if err != nil {
+		return err
+	}
+	_, err = tx.ExecContext(ctx, `
+		INSERT INTO schedule_transitions (schedule_id, from_status, to_status, actor_id, comment)
+		VALUES ($1, $2, $3, $4, NULLIF($5, ''))
+	`, scheduleID, from, to, actorID, comment)
+	if err != nil {
+		return err
+	}
+	return tx.Commit()
+}
+
+// ListTransitions loads the status changes of a schedule with the email of the actor, oldest first.
+// sql.ErrNoRows is returned if the schedule does not exist.
+func (r *scheduleRepository) ListTransitions(ctx context.Context, scheduleID uuid.UUID) ([]models.ScheduleTransition, error) {
+	var exists bool
+	if err := r.db.QueryRowContext(ctx, `SELECT EXISTS(SELECT 1 FROM schedules WHERE id = $1)`, scheduleID).Scan(&exists); err != nil {
+		return nil, err
+	}
+	if !exists {
+		return nil, sql.ErrNoRows
+	}
+
+	rows, err := r.db.QueryContext(ctx, `
+		SELECT t.id, t.schedule_id, t.from_status, t.to_status, t.actor_id, u.email, t.comment, t.created_at
+		FROM schedule_transitions t
+		LEFT JOIN users u ON u.id = t.actor_id
+		WHERE t.schedule_id = $1
+		ORDER BY t.created_at, t.id
+	`, scheduleID)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	transitions := []models.ScheduleTransition{}
+	for rows.Next() {
+		var t models.ScheduleTransition
+		if err := rows.Scan(&t.ID, &t.ScheduleID, &t.FromStatus, &t.ToStatus, &t.ActorID, &t.Actor, &t.Comment, &t.CreatedAt); err != nil {
+			return nil, err
+		}
+		transitions = append(transitions, t)
+	}
+	return transitions, rows.Err()
+}
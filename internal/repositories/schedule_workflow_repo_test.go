@@ -0,0 +1,93 @@
+package repositories
+
+import (
+	"context"
+	"database/sql"
+	"os"
+	"testing"
+
+	"github.com/google/uuid"
+	_ "github.com/lib/pq"
+	"github.com/nikomkinds/SchoolSchedule/internal/models"
+)
+
+// testDB connects to the database named by TEST_DATABASE_URL with all migrations applied;
+// the test is skipped without it
+func testDB(t *testing.T) *sql.DB {
+	t.Helper()
+	dsn := os.Getenv("TEST_DATABASE_URL")
+	if dsn == "" {
+		t.Skip("TEST_DATABASE_URL is not set")
+	}
+	db, err := sql.Open("postgres", dsn)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := db.Ping(); err != nil {
+		t.Fatal(err)
+	}
+	t.Cleanup(func() { _ = db.Close() })
+	return db
+}
+
+func TestPublishingSuccessorReplacesArchivedActiveSchedule(t *testing.T) {
+	db := testDB(t)
+	ctx := context.Background()
+	repo := NewScheduleRepository(db)
+
+	var userID uuid.UUID
+	err := db.QueryRowContext(ctx, `
+		INSERT INTO users (email, password_hash, role) VALUES ($1, 'x', 'admin') RETURNING id
+	`, "archive-"+uuid.NewString()+"@test.local").Scan(&userID)
+	if err != nil {
+		t.Fatal(err)
+	}
+	t.Cleanup(func() {
+		_, _ = db.ExecContext(ctx, `DELETE FROM schedules WHERE user_id = $1`, userID)
+		_, _ = db.ExecContext(ctx, `DELETE FROM users WHERE id = $1`, userID)
+	})
+
+	publish := func(id uuid.UUID) {
+		t.Helper()
+		steps := []string{models.ScheduleStatusDraft, models.ScheduleStatusReview, models.ScheduleStatusApproved, models.ScheduleStatusPublished}
+		for i := 1; i < len(steps); i++ {
+			if err := repo.TransitionSchedule(ctx, id, steps[i-1], steps[i], &userID, ""); err != nil {
+				t.Fatalf("%s -> %s: %v", steps[i-1], steps[i], err)
+			}
+		}
+	}
+
+	old, err := repo.CreateSchedule(ctx, userID, models.Schedule{Name: "old"}, nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := repo.ActivateSchedule(ctx, old.ID); err != nil {
+		t.Fatal(err)
+	}
+	publish(old.ID)
+
+	successor, err := repo.CreateSchedule(ctx, userID, models.Schedule{Name: "successor", DerivedFrom: &old.ID}, nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	publish(successor.ID)
+
+	var (
+		status   string
+		isActive bool
+	)
+	if err := db.QueryRowContext(ctx, `SELECT status, is_active FROM schedules WHERE id = $1`, old.ID).Scan(&status, &isActive); err != nil {
+		t.Fatal(err)
+	}
+	if status != models.ScheduleStatusArchived || isActive {
+		t.Errorf("replaced schedule is %s, active %v; want archived and inactive", status, isActive)
+	}
+
+	got, err := repo.GetActiveScheduleID(ctx, userID, models.Today())
+	if err != nil {
+		t.Fatal(err)
+	}
+	if got != successor.ID {
+		t.Errorf("resolved %s, want the successor %s (archived %s)", got, successor.ID, old.ID)
+	}
+}
@@ -3,6 +3,7 @@ package repositories
 import (
 	"context"
 	"database/sql"
+	"errors"
 	"fmt"
 	"strings"
 
@@ -11,238 +12,135 @@ import (
 )
 
 type ScheduleRepository interface {
-	// GetSchedule loads the active schedule for a specific user
-	GetSchedule(ctx context.Context, userID uuid.UUID) ([]models.ScheduleDay, error)
+	// GetActiveScheduleID resolves the schedule of a user that is active on the given date
+	GetActiveScheduleID(ctx context.Context, userID uuid.UUID, date models.Date) (uuid.UUID, error)
+	// GetPublishedScheduleID resolves the published school timetable valid on the given date
+	GetPublishedScheduleID(ctx context.Context, date models.Date) (uuid.UUID, error)
 	// GetScheduleByID loads a specific named schedule by its ID
 	GetScheduleByID(ctx context.Context, scheduleID uuid.UUID) (*models.Schedule, error)
-	// GetAllSchedules loads all schedules for a specific user
-	GetAllSchedules(ctx context.Context, userID uuid.UUID) ([]models.Schedule, error)
+	// GetAllSchedules loads the schedules a user sees: own ones, those shared with the user or role, and published ones
+	GetAllSchedules(ctx context.Context, userID uuid.UUID, role string) ([]models.Schedule, error)
 	// CreateSchedule creates a new named schedule and its associated slots/lessons
 	CreateSchedule(ctx context.Context, userID uuid.UUID, schedule models.Schedule, slots []models.ScheduleSlotInput) (*models.Schedule, error)
-	// UpdateSchedule updates the main schedule table and replaces its slots/lessons
-	UpdateSchedule(ctx context.Context, scheduleID uuid.UUID, name *string, slots []models.ScheduleSlotInput) error
-	// DeleteSchedule deletes a schedule and all its associated data
+	// UpdateSchedule updates the main schedule table and replaces its slots/lessons.
+	// With an expected version set, ErrVersionMismatch is returned if the schedule has another one.
+	// It returns the new version.
+	UpdateSchedule(ctx context.Context, scheduleID uuid.UUID, name *string, slots []models.ScheduleSlotInput, version *int, note models.RevisionNote) (int, error)
+	// DeleteSchedule deletes a draft or archived schedule and all its associated data;
+	// ErrScheduleInUse for a schedule in review, approved or published
 	DeleteSchedule(ctx context.Context, scheduleID uuid.UUID) error
-	// GenerateSchedule generates a schedule based on study plans and workload (stub for now)
-	GenerateSchedule(ctx context.Context, req models.GenerateScheduleRequest) ([]models.ScheduleDay, error)
+	// RenameSchedule changes the name of a schedule
+	RenameSchedule(ctx context.Context, scheduleID uuid.UUID, name string) error
+	// ActivateSchedule marks a schedule active and all other schedules of its owner inactive in one statement
+	ActivateSchedule(ctx context.Context, scheduleID uuid.UUID) error
+	// TransitionSchedule moves a schedule from one status to another and records the change; it returns
+	// ErrStatusMismatch if the schedule is no longer in the from status
+	TransitionSchedule(ctx context.Context, scheduleID uuid.UUID, from, to string, actorID *uuid.UUID, comment string) error
+	// ListTransitions loads the status history of a schedule, oldest first
+	ListTransitions(ctx context.Context, scheduleID uuid.UUID) ([]models.ScheduleTransition, error)
+	// ScheduleAccess returns the access a user has to a schedule as owner, through shares or as published,
+	// "" if none, along with the status of the schedule; sql.ErrNoRows if the schedule does not exist
+	ScheduleAccess(ctx context.Context, scheduleID, userID uuid.UUID, role string) (access, status string, err error)
+	// ListShares loads the shares of a schedule
+	ListShares(ctx context.Context, scheduleID uuid.UUID) ([]models.ScheduleShare, error)
+	// SaveShare creates the share of a schedule with a user or role, or changes its permission
+	SaveShare(ctx context.Context, share models.ScheduleShare) (*models.ScheduleShare, error)
+	// DeleteShare deletes a share of a schedule
+	DeleteShare(ctx context.Context, scheduleID, shareID uuid.UUID) error
+	// GetScheduleDays loads all slots with their lessons of a named schedule
+	GetScheduleDays(ctx context.Context, scheduleID uuid.UUID) ([]models.ScheduleDay, error)
+	// AddLessonRooms adds a room to each lesson of a schedule: lesson ID → classroom ID. With version set, a schedule
+	// changed in the meantime is not updated and ErrVersionMismatch is returned. Returns the new version.
+	AddLessonRooms(ctx context.Context, scheduleID uuid.UUID, rooms map[uuid.UUID]uuid.UUID, version *int, note models.RevisionNote) (int, error)
+	// GetRevision loads the timetable of a schedule as of the given version
+	GetRevision(ctx context.Context, scheduleID uuid.UUID, version int) ([]models.ScheduleDay, error)
+	// ListRevisions loads the revisions of a schedule without their content, newest first
+	ListRevisions(ctx context.Context, scheduleID uuid.UUID) ([]models.ScheduleRevision, error)
+	// Lesson edits check the version like UpdateSchedule and return the new version of the schedule
+
+	// AddLesson adds a lesson to a slot of a named schedule and returns its ID
+	AddLesson(ctx context.Context, scheduleID uuid.UUID, day, lessonNumber int, lesson models.LessonInput, version *int, note models.RevisionNote, check LessonCheck) (uuid.UUID, int, error)
+	// UpdateLesson replaces a lesson of a named schedule, possibly in another slot
+	UpdateLesson(ctx context.Context, scheduleID, lessonID uuid.UUID, day, lessonNumber int, lesson models.LessonInput, version *int, note models.RevisionNote, check LessonCheck) (int, error)
+	// MoveLessons moves lessons of a named schedule to other slots in one transaction
+	MoveLessons(ctx context.Context, scheduleID uuid.UUID, moves []models.LessonMove, version *int, note models.RevisionNote, check LessonCheck) (int, error)
+	// DeleteLesson deletes a lesson of a named schedule
+	DeleteLesson(ctx context.Context, scheduleID, lessonID uuid.UUID, version *int, note models.RevisionNote) (int, error)
 }
 
 type scheduleRepository struct {
 	db *sql.DB
+	q  querier // db, or the transaction of a lesson edit so its checks see the uncommitted changes
+}
+
+// querier is the part of *sql.DB and *sql.Tx the timetable loaders need
+type querier interface {
+	QueryContext(ctx context.Context, query string, args ...interface{}) (*sql.Rows, error)
 }
 
 func NewScheduleRepository(db *sql.DB) ScheduleRepository {
-	return &scheduleRepository{db: db}
+	return &scheduleRepository{db: db, q: db}
 }
 
-// GetSchedule loads the complete schedule from the *active* named schedule for a specific user
-func (r *scheduleRepository) GetSchedule(ctx context.Context, userID uuid.UUID) ([]models.ScheduleDay, error) {
-	// First, find the active schedule ID for this user
-	var activeScheduleID uuid.UUID
-	err := r.db.QueryRowContext(ctx, `SELECT id FROM schedules WHERE user_id = $1 AND is_active = true LIMIT 1`, userID).Scan(&activeScheduleID)
-	if err != nil {
-		if err == sql.ErrNoRows {
-			// If no active schedule exists, return empty schedule
-			return []models.ScheduleDay{}, nil
-		}
-		return nil, fmt.Errorf("failed to find active schedule: %w", err)
-	}
+// withTx returns a copy of the repository whose loaders read through the transaction
+func (r *scheduleRepository) withTx(tx *sql.Tx) *scheduleRepository {
+	return &scheduleRepository{db: r.db, q: tx}
+}
 
-	// Now, query all lessons within the active schedule (not filtered by teacher)
+// GetActiveScheduleID resolves the active schedule by date among the user's schedules bound to the term
+// containing the date or activated without a term, and the published schedules for that term or without one.
+// Archived schedules are never resolved. A schedule the user activated wins, then a published one, then one
+// bound to the term.
+func (r *scheduleRepository) GetActiveScheduleID(ctx context.Context, userID uuid.UUID, date models.Date) (uuid.UUID, error) {
 	const q = `
-		SELECT 
-			ss.day_of_week,
-			ss.lesson_number,
-			sl.id as lesson_id,
-			s.id as subject_id,
-			s.name as subject_name,
-			t.id as teacher_id,
-			t.first_name,
-			t.last_name,
-			t.patronymic,
-			cr.id as classroom_id,
-			cr.name as classroom_name,
-			c.id as class_id,
-			c.name as class_name,
-			cg.id as group_id,
-			cg.name as group_name
-		FROM schedule_slots ss
-		JOIN schedule_lessons sl ON sl.slot_id = ss.id
-		JOIN lesson_teachers lt ON lt.lesson_id = sl.id
-		JOIN teachers t ON t.id = lt.teacher_id
-		JOIN subjects s ON s.id = sl.subject_id
-		JOIN lesson_participants lp ON lp.lesson_id = sl.id
-		JOIN classes c ON c.id = lp.class_id
-		LEFT JOIN lesson_rooms lr ON lr.lesson_id = sl.id
-		LEFT JOIN classrooms cr ON cr.id = lr.classroom_id
-		LEFT JOIN lesson_participant_groups lpg ON lp.id = lpg.participant_id
-		LEFT JOIN class_groups cg ON lpg.group_id = cg.id
-		WHERE ss.schedule_id = $1
-		ORDER BY ss.day_of_week, ss.lesson_number, sl.id, t.last_name, t.first_name, c.name, cg.name
+		SELECT s.id
+		FROM schedules s
+		LEFT JOIN academic_terms t ON t.id = s.term_id
+		WHERE (
+		      s.user_id = $1
+		      AND s.status <> 'archived'
+		      AND (
+		          ($2::date BETWEEN t.start_date AND t.end_date)
+		          OR (s.term_id IS NULL AND s.is_active = true)
+		      )
+		  ) OR (
+		      s.status = 'published'
+		      AND (s.term_id IS NULL OR $2::date BETWEEN t.start_date AND t.end_date)
+		  )
+		ORDER BY (s.user_id = $1 AND s.is_active) IS TRUE DESC, (s.status = 'published') DESC,
+		         (s.term_id IS NOT NULL) DESC, s.updated_at DESC
+		LIMIT 1
 	`
 
-	rows, err := r.db.QueryContext(ctx, q, activeScheduleID)
-	if err != nil {
-		return nil, err
+	var id uuid.UUID
+	if err := r.db.QueryRowContext(ctx, q, userID, date).Scan(&id); err != nil {
+		return uuid.Nil, err
 	}
-	defer rows.Close()
-
-	// dayLessonKey → map[lessonNumber][]ScheduleLesson
-	scheduleMap := make(map[string]map[int][]models.ScheduleLesson)
-
-	for rows.Next() {
-		var (
-			dayOfWeek, lessonNumber int
-
-			lessonID    uuid.UUID
-			subjectID   uuid.UUID
-			subjectName string
-
-			teacherUUID                                          uuid.UUID
-			teacherFirstName, teacherLastName, teacherPatronymic sql.NullString
-
-			classroomID   sql.NullString
-			classroomName sql.NullString
-
-			classID   sql.NullString
-			className sql.NullString
-
-			groupID   sql.NullString
-			groupName sql.NullString
-		)
-
-		if err := rows.Scan(
-			&dayOfWeek, &lessonNumber,
-			&lessonID, &subjectID, &subjectName,
-			&teacherUUID, &teacherFirstName, &teacherLastName, &teacherPatronymic,
-			&classroomID, &classroomName,
-			&classID, &className,
-			&groupID, &groupName,
-		); err != nil {
-			return nil, err
-		}
-
-		dayLessonKey := fmt.Sprintf("%d-%d", dayOfWeek, lessonNumber)
-
-		dayMap, ok := scheduleMap[dayLessonKey]
-		if !ok {
-			dayMap = make(map[int][]models.ScheduleLesson)
-			scheduleMap[dayLessonKey] = dayMap
-		}
-
-		lessons := dayMap[lessonNumber]
-
-		// Find lesson in slice
-		lessonIdx := -1
-		for i, l := range lessons {
-			if l.ID == lessonID {
-				lessonIdx = i
-				break
-			}
-		}
-
-		if lessonIdx == -1 {
-			lessons = append(lessons, models.ScheduleLesson{
-				ID: lessonID,
-				Subject: &models.Subject{
-					ID:   subjectID,
-					Name: subjectName,
-				},
-				Teachers:     []models.Teacher{},
-				Rooms:        []models.Classroom{},
-				Participants: []models.LessonParticipant{},
-			})
-			lessonIdx = len(lessons) - 1
-		}
-
-		lesson := &lessons[lessonIdx]
-
-		// -------- Add teacher --------
-		var patronymic *string
-		if teacherPatronymic.Valid {
-			p := teacherPatronymic.String
-			patronymic = &p
-		}
-
-		lesson.Teachers = append(lesson.Teachers, models.Teacher{
-			ID:         teacherUUID,
-			FirstName:  teacherFirstName.String,
-			LastName:   teacherLastName.String,
-			Patronymic: patronymic,
-		})
-
-		// -------- Add room (with uuid.Parse) --------
-		if classroomID.Valid {
-			rid, err := uuid.Parse(classroomID.String)
-			if err == nil {
-				// avoid duplicates
-				found := false
-				for _, r := range lesson.Rooms {
-					if r.ID == rid {
-						found = true
-						break
-					}
-				}
-				if !found {
-					lesson.Rooms = append(lesson.Rooms, models.Classroom{
-						ID:   rid,
-						Name: classroomName.String,
-					})
-				}
-			}
-		}
-
-		// -------- Add participant (class + group) --------
-		var participantClassUUID uuid.UUID
-		if classID.Valid {
-			participantClassUUID, _ = uuid.Parse(classID.String)
-		}
-
-		participantIdx := -1
-		for i, p := range lesson.Participants {
-			if p.ClassID == participantClassUUID {
-				participantIdx = i
-				break
-			}
-		}
-
-		// If participant does not exist — create
-		if participantIdx == -1 {
-			lesson.Participants = append(lesson.Participants, models.LessonParticipant{
-				ClassID: participantClassUUID,
-				Class: &models.Class{
-					ID:   participantClassUUID,
-					Name: className.String,
-				},
-				GroupIDs: []uuid.UUID{},
-			})
-			participantIdx = len(lesson.Participants) - 1
-		}
+	return id, nil
+}
 
-		// Add group if exists
-		if groupID.Valid {
-			gid, err := uuid.Parse(groupID.String)
-			if err == nil {
-				found := false
-				for _, existing := range lesson.Participants[participantIdx].GroupIDs {
-					if existing == gid {
-						found = true
-						break
-					}
-				}
-				if !found {
-					lesson.Participants[participantIdx].GroupIDs =
-						append(lesson.Participants[participantIdx].GroupIDs, gid)
-				}
-			}
-		}
+// GetPublishedScheduleID prefers a published schedule bound to the term containing the date to one without a term
+func (r *scheduleRepository) GetPublishedScheduleID(ctx context.Context, date models.Date) (uuid.UUID, error) {
+	const q = `
+		SELECT s.id
+		FROM schedules s
+		LEFT JOIN academic_terms t ON t.id = s.term_id
+		WHERE s.status = 'published'
+		  AND (s.term_id IS NULL OR $1::date BETWEEN t.start_date AND t.end_date)
+		ORDER BY (s.term_id IS NOT NULL) DESC, s.updated_at DESC
+		LIMIT 1
+	`
 
-		// Write back lesson slice
-		dayMap[lessonNumber] = lessons
+	var id uuid.UUID
+	if err := r.db.QueryRowContext(ctx, q, date).Scan(&id); err != nil {
+		return uuid.Nil, err
 	}
+	return id, nil
+}
 
-	// -------- Now convert scheduleMap into []ScheduleDay --------
-	// Secondary query to detect all slots within the active schedule
+// loadScheduleDays loads all slots with their lessons of a named schedule
+func (r *scheduleRepository) loadScheduleDays(ctx context.Context, scheduleID uuid.UUID) ([]models.ScheduleDay, error) {
+	// Slots with their lesson IDs; the lessons are loaded with all details per slot
 	const lessonIDsQuery = `
 		SELECT 
 			ss.day_of_week,
@@ -254,7 +152,7 @@ func (r *scheduleRepository) GetSchedule(ctx context.Context, userID uuid.UUID)
 		ORDER BY ss.day_of_week, ss.lesson_number
 	`
 
-	lessonRows, err := r.db.QueryContext(ctx, lessonIDsQuery, activeScheduleID)
+	lessonRows, err := r.q.QueryContext(ctx, lessonIDsQuery, scheduleID)
 	if err != nil {
 		return nil, err
 	}
@@ -358,13 +256,13 @@ func (r *scheduleRepository) loadLessonsForIDs(ctx context.Context, lessonIDs []
 
 	q := fmt.Sprintf(`
 		SELECT 
-			sl.id, sl.subject_id, s.name as subject_name
+			sl.id, sl.subject_id, s.name as subject_name, s.short_name, sl.week_pattern
 		FROM schedule_lessons sl
 		JOIN subjects s ON s.id = sl.subject_id
 		WHERE sl.id IN %s
 	`, inClause)
 
-	rows, err := r.db.QueryContext(ctx, q, args...)
+	rows, err := r.q.QueryContext(ctx, q, args...)
 	if err != nil {
 		return nil, err
 	}
@@ -375,17 +273,20 @@ func (r *scheduleRepository) loadLessonsForIDs(ctx context.Context, lessonIDs []
 
 	for rows.Next() {
 		var lessonID, subjectID uuid.UUID
-		var subjectName string
-		if err := rows.Scan(&lessonID, &subjectID, &subjectName); err != nil {
+		var subjectName, weekPattern string
+		var shortName *string
+		if err := rows.Scan(&lessonID, &subjectID, &subjectName, &shortName, &weekPattern); err != nil {
 			return nil, err
 		}
 
 		lesson := &models.ScheduleLesson{
 			ID: lessonID,
 			Subject: &models.Subject{
-				ID:   subjectID,
-				Name: subjectName,
+				ID:        subjectID,
+				Name:      subjectName,
+				ShortName: shortName,
 			},
+			WeekPattern:  weekPattern,
 			Teachers:     []models.Teacher{},
 			Rooms:        []models.Classroom{},
 			Participants: []models.LessonParticipant{},
@@ -465,7 +366,7 @@ func (r *scheduleRepository) loadTeachersForLessons(ctx context.Context, lessonI
 		ORDER BY lt.lesson_id, t.last_name, t.first_name
 	`, inClause)
 
-	rows, err := r.db.QueryContext(ctx, q, args...)
+	rows, err := r.q.QueryContext(ctx, q, args...)
 	if err != nil {
 		return nil, err
 	}
@@ -526,7 +427,7 @@ func (r *scheduleRepository) loadRoomsForLessons(ctx context.Context, lessonIDs
 		ORDER BY lr.lesson_id, cr.name
 	`, inClause)
 
-	rows, err := r.db.QueryContext(ctx, q, args...)
+	rows, err := r.q.QueryContext(ctx, q, args...)
 	if err != nil {
 		return nil, err
 	}
@@ -572,14 +473,14 @@ func (r *scheduleRepository) loadParticipantsForLessons(ctx context.Context, les
 
 	q := fmt.Sprintf(`
 		SELECT 
-			lp.lesson_id, lp.class_id, c.name as class_name
+			lp.id, lp.lesson_id, lp.class_id, c.name as class_name, c.grade_level, c.shift_id
 		FROM lesson_participants lp
 		JOIN classes c ON c.id = lp.class_id
 		WHERE lp.lesson_id IN %s
 		ORDER BY lp.lesson_id, c.name
 	`, inClause)
 
-	rows, err := r.db.QueryContext(ctx, q, args...)
+	rows, err := r.q.QueryContext(ctx, q, args...)
 	if err != nil {
 		return nil, err
 	}
@@ -587,19 +488,24 @@ func (r *scheduleRepository) loadParticipantsForLessons(ctx context.Context, les
 
 	participantsMap := make(map[uuid.UUID][]models.LessonParticipant)
 	for rows.Next() {
-		var lessonID, classID uuid.UUID
+		var participantID, lessonID, classID uuid.UUID
 		var className string
+		var gradeLevel int
+		var shiftID *uuid.UUID
 
-		if err := rows.Scan(&lessonID, &classID, &className); err != nil {
+		if err := rows.Scan(&participantID, &lessonID, &classID, &className, &gradeLevel, &shiftID); err != nil {
 			return nil, err
 		}
 
 		participant := models.LessonParticipant{
+			ID:       participantID,
 			LessonID: lessonID,
 			ClassID:  classID,
 			Class: &models.Class{
-				ID:   classID,
-				Name: className,
+				ID:         classID,
+				Name:       className,
+				GradeLevel: gradeLevel,
+				ShiftID:    shiftID,
 			},
 			GroupIDs: []uuid.UUID{}, // Will populate next
 		}
@@ -638,7 +544,7 @@ func (r *scheduleRepository) loadParticipantsForLessons(ctx context.Context, les
 			ORDER BY participant_id, group_id
 		`, inClauseP)
 
-		rowsGroups, err := r.db.QueryContext(ctx, qGroups, argsP...)
+		rowsGroups, err := r.q.QueryContext(ctx, qGroups, argsP...)
 		if err != nil {
 			return nil, err
 		}
@@ -666,53 +572,81 @@ func (r *scheduleRepository) loadParticipantsForLessons(ctx context.Context, les
 	return participantsMap, nil
 }
 
+// GetScheduleDays loads all slots with their lessons of a named schedule
+func (r *scheduleRepository) GetScheduleDays(ctx context.Context, scheduleID uuid.UUID) ([]models.ScheduleDay, error) {
+	return r.loadScheduleDays(ctx, scheduleID)
+}
+
+// AddLessonRooms adds a room to each lesson in one transaction
+func (r *scheduleRepository) AddLessonRooms(ctx context.Context, scheduleID uuid.UUID, rooms map[uuid.UUID]uuid.UUID, version *int, note models.RevisionNote) (int, error) {
+	return r.editLessons(ctx, scheduleID, version, note, func(tx *sql.Tx) ([]uuid.UUID, error) {
+		for lessonID, classroomID := range rooms {
+			_, err := tx.ExecContext(ctx, `
+				INSERT INTO lesson_rooms (lesson_id, classroom_id)
+				VALUES ($1, $2)
+			`, lessonID, classroomID)
+			if err != nil {
+				return nil, err
+			}
+		}
+		return nil, nil
+	}, nil)
+}
+
 // GetScheduleByID loads a specific named schedule by its ID
 func (r *scheduleRepository) GetScheduleByID(ctx context.Context, scheduleID uuid.UUID) (*models.Schedule, error) {
 	// For this endpoint, we might just return the schedule header info
 	// and let the frontend call GET /schedule for the actual data if needed.
 	// Or load slots/lessons. Let's load the header for now as per spec.
-	const q = `SELECT id, user_id, name, academic_year, is_active, created_at, updated_at FROM schedules WHERE id = $1`
+	const q = `SELECT id, user_id, name, term_id, is_active, version, status, derived_from, created_at, updated_at FROM schedules WHERE id = $1`
 	row := r.db.QueryRowContext(ctx, q, scheduleID)
 
 	var s models.Schedule
-	var academicYear sql.NullString
-	err := row.Scan(&s.ID, &s.UserID, &s.Name, &academicYear, &s.IsActive, &s.CreatedAt, &s.UpdatedAt)
+	err := row.Scan(&s.ID, &s.UserID, &s.Name, &s.TermID, &s.IsActive, &s.Version, &s.Status, &s.DerivedFrom, &s.CreatedAt, &s.UpdatedAt)
 	if err != nil {
 		if err == sql.ErrNoRows {
 			return nil, sql.ErrNoRows
 		}
 		return nil, err
 	}
-	if academicYear.Valid {
-		s.AcademicYear = &academicYear.String
-	}
 
 	return &s, nil
 }
 
-// GetAllSchedules loads all schedules for a specific user
-func (r *scheduleRepository) GetAllSchedules(ctx context.Context, userID uuid.UUID) ([]models.Schedule, error) {
-	const q = `SELECT id, user_id, name, academic_year, is_active, created_at, updated_at FROM schedules WHERE user_id = $1 ORDER BY name`
+// GetAllSchedules loads the schedules a user sees with the user's access to each
+func (r *scheduleRepository) GetAllSchedules(ctx context.Context, userID uuid.UUID, role string) ([]models.Schedule, error) {
+	const q = `
+		SELECT s.id, s.user_id, s.name, s.term_id, s.is_active, s.version, s.status, s.derived_from, s.created_at, s.updated_at,
+		       CASE
+		           WHEN s.user_id = $1 THEN 'owner'
+		           WHEN sh.edit THEN 'edit'
+		           ELSE 'view'
+		       END
+		FROM schedules s
+		LEFT JOIN LATERAL (
+		    SELECT bool_or(permission = 'edit') AS edit
+		    FROM schedule_shares
+		    WHERE schedule_id = s.id AND (user_id = $1 OR role = $2)
+		) sh ON true
+		WHERE s.user_id = $1 OR sh.edit IS NOT NULL OR s.status = 'published'
+		ORDER BY s.name
+	`
 
-	rows, err := r.db.QueryContext(ctx, q, userID)
+	rows, err := r.db.QueryContext(ctx, q, userID, role)
 	if err != nil {
 		return nil, err
 	}
 	defer rows.Close()
 
-	var schedules []models.Schedule
+	schedules := []models.Schedule{}
 	for rows.Next() {
 		var s models.Schedule
-		var academicYear sql.NullString
-		if err := rows.Scan(&s.ID, &s.UserID, &s.Name, &academicYear, &s.IsActive, &s.CreatedAt, &s.UpdatedAt); err != nil {
+		if err := rows.Scan(&s.ID, &s.UserID, &s.Name, &s.TermID, &s.IsActive, &s.Version, &s.Status, &s.DerivedFrom, &s.CreatedAt, &s.UpdatedAt, &s.Access); err != nil {
 			return nil, err
 		}
-		if academicYear.Valid {
-			s.AcademicYear = &academicYear.String
-		}
 		schedules = append(schedules, s)
 	}
-	return schedules, nil
+	return schedules, rows.Err()
 }
 
 // CreateSchedule creates a new named schedule and its associated slots/lessons
@@ -730,10 +664,10 @@ func (r *scheduleRepository) CreateSchedule(ctx context.Context, userID uuid.UUI
 	// 1. Insert into schedules table
 	var newID uuid.UUID
 	err = tx.QueryRowContext(ctx, `
-		INSERT INTO schedules (user_id, name, academic_year, is_active)
-		VALUES ($1, $2, $3, $4)
+		INSERT INTO schedules (user_id, name, term_id, is_active, derived_from)
+		VALUES ($1, $2, $3, $4, $5)
 		RETURNING id
-	`, userID, schedule.Name, schedule.AcademicYear, schedule.IsActive).Scan(&newID)
+	`, userID, schedule.Name, schedule.TermID, schedule.IsActive, schedule.DerivedFrom).Scan(&newID)
 	if err != nil {
 		return nil, err
 	}
@@ -757,85 +691,15 @@ func (r *scheduleRepository) CreateSchedule(ctx context.Context, userID uuid.UUI
 		}
 
 		for _, lessonInput := range slotInput.Lessons {
-			subjectID, err := uuid.Parse(lessonInput.Subject.ID)
-			if err != nil {
-				return nil, fmt.Errorf("invalid subject ID: %w", err)
-			}
-
-			var lessonID uuid.UUID
-			err = tx.QueryRowContext(ctx, `
-				INSERT INTO schedule_lessons (slot_id, subject_id)
-				VALUES ($1, $2)
-				RETURNING id
-			`, slotID, subjectID).Scan(&lessonID)
-			if err != nil {
+			if _, err = insertLesson(ctx, tx, slotID, lessonInput); err != nil {
 				return nil, err
 			}
-
-			// Insert lesson_teachers
-			for _, teacherInput := range lessonInput.Teachers {
-				teacherID, err := uuid.Parse(teacherInput.ID)
-				if err != nil {
-					return nil, fmt.Errorf("invalid teacher ID: %w", err)
-				}
-				_, err = tx.ExecContext(ctx, `
-					INSERT INTO lesson_teachers (lesson_id, teacher_id)
-					VALUES ($1, $2)
-				`, lessonID, teacherID)
-				if err != nil {
-					return nil, err
-				}
-			}
-
-			// Insert lesson_rooms
-			for _, roomInput := range lessonInput.Rooms {
-				roomID, err := uuid.Parse(roomInput.ID)
-				if err != nil {
-					return nil, fmt.Errorf("invalid room ID: %w", err)
-				}
-				_, err = tx.ExecContext(ctx, `
-					INSERT INTO lesson_rooms (lesson_id, classroom_id)
-					VALUES ($1, $2)
-				`, lessonID, roomID)
-				if err != nil {
-					return nil, err
-				}
-			}
-
-			// Insert lesson_participants and lesson_participant_groups
-			for _, participantInput := range lessonInput.Participants {
-				classID, err := uuid.Parse(participantInput.Class.ID)
-				if err != nil {
-					return nil, fmt.Errorf("invalid class ID: %w", err)
-				}
-
-				var participantID uuid.UUID
-				err = tx.QueryRowContext(ctx, `
-					INSERT INTO lesson_participants (lesson_id, class_id)
-					VALUES ($1, $2)
-					RETURNING id
-				`, lessonID, classID).Scan(&participantID)
-				if err != nil {
-					return nil, err
-				}
-
-				for _, groupIDStr := range participantInput.GroupIDs {
-					groupID, err := uuid.Parse(groupIDStr)
-					if err != nil {
-						return nil, fmt.Errorf("invalid group ID: %w", err)
-					}
-					_, err = tx.ExecContext(ctx, `
-						INSERT INTO lesson_participant_groups (participant_id, group_id)
-						VALUES ($1, $2)
-					`, participantID, groupID)
-					if err != nil {
-						return nil, err
-					}
-				}
-			}
 		}
 	}
 
+	if err = r.saveRevision(ctx, tx, newID, 1, models.RevisionNote{AuthorID: &userID}); err != nil {
+		return nil, err
+	}
 	if err := tx.Commit(); err != nil {
 		return nil, err
 	}
@@ -849,6 +713,14 @@ func (r *scheduleRepository) CreateSchedule(ctx context.Context, userID uuid.UUI
 	return createdSchedule, nil
 }
 
+// weekPatternValue defaults an empty week pattern to every week
+func weekPatternValue(p string) string {
+	if p == "" {
+		return models.WeekEvery
+	}
+	return p
+}
+
 // stringToDayOfWeek converts string day to internal integer (1-6), case-insensitive
 func stringToDayOfWeek(day string) int {
 	lowerDay := strings.ToLower(day)
@@ -870,11 +742,13 @@ func stringToDayOfWeek(day string) int {
 	}
 }
 
-// UpdateSchedule updates the main schedule table and replaces its slots/lessons
-func (r *scheduleRepository) UpdateSchedule(ctx context.Context, scheduleID uuid.UUID, name *string, slots []models.ScheduleSlotInput) error {
+// UpdateSchedule updates the main schedule table and replaces its slots/lessons.
+// Lessons that are kept, by ID or by slot, subject and classes, are updated in place rather than
+// recreated, so that their overrides on single dates survive the save.
+func (r *scheduleRepository) UpdateSchedule(ctx context.Context, scheduleID uuid.UUID, name *string, slots []models.ScheduleSlotInput, version *int, note models.RevisionNote) (int, error) {
 	tx, err := r.db.BeginTx(ctx, nil)
 	if err != nil {
-		return err
+		return 0, err
 	}
 	defer func() {
 		if err != nil {
@@ -882,128 +756,103 @@ func (r *scheduleRepository) UpdateSchedule(ctx context.Context, scheduleID uuid
 		}
 	}()
 
+	// 0. Lock the schedule, check it is still a draft and the version the client based its changes on
+	var (
+		current int
+		status  string
+	)
+	err = tx.QueryRowContext(ctx, `SELECT version, status FROM schedules WHERE id = $1 FOR UPDATE`, scheduleID).Scan(&current, &status)
+	if err != nil {
+		return 0, err
+	}
+	if status != models.ScheduleStatusDraft {
+		err = fmt.Errorf("%w: the schedule is %s", ErrScheduleLocked, status)
+		return 0, err
+	}
+	if version != nil && *version != current {
+		err = ErrVersionMismatch
+		return 0, err
+	}
+
 	// 1. Update schedule name if provided
 	if name != nil {
 		_, err = tx.ExecContext(ctx, `UPDATE schedules SET name = $1 WHERE id = $2`, *name, scheduleID)
 		if err != nil {
-			return err
+			return 0, err
 		}
 	}
 
-	// 2. Delete existing slots/lessons for this schedule (CASCADE should handle children)
-	_, err = tx.ExecContext(ctx, `DELETE FROM schedule_slots WHERE schedule_id = $1`, scheduleID)
-	if err != nil {
-		return err
+	// 2. Match the input with the stored lessons
+	var stored []storedLesson
+	if stored, err = storedLessons(ctx, tx, scheduleID); err != nil {
+		return 0, err
 	}
+	matched := matchLessons(stored, slots)
 
-	// 3. Insert new slots and lessons (same logic as Create)
-	for _, slotInput := range slots {
+	// 3. Update the kept lessons, insert the new ones
+	kept := make(map[uuid.UUID]bool)
+	for i, slotInput := range slots {
 		// Use stringToDayOfWeek which is now case-insensitive
 		dayNum := stringToDayOfWeek(slotInput.DayOfWeek)
 		if dayNum == 0 {
 			err = fmt.Errorf("invalid day of week: %s", slotInput.DayOfWeek)
-			return err
+			return 0, err
 		}
 
 		var slotID uuid.UUID
-		err = tx.QueryRowContext(ctx, `
-			INSERT INTO schedule_slots (schedule_id, day_of_week, lesson_number)
-			VALUES ($1, $2, $3)
-			RETURNING id
-		`, scheduleID, dayNum, slotInput.LessonNumber).Scan(&slotID)
-		if err != nil {
-			return err
+		if slotID, err = slotFor(ctx, tx, scheduleID, dayNum, slotInput.LessonNumber); err != nil {
+			return 0, err
 		}
 
-		for _, lessonInput := range slotInput.Lessons {
-			subjectID, err := uuid.Parse(lessonInput.Subject.ID)
-			if err != nil {
-				return fmt.Errorf("invalid subject ID: %w", err)
+		for j, lessonInput := range slotInput.Lessons {
+			if id := matched[i][j]; id != uuid.Nil {
+				kept[id] = true
+				err = replaceLesson(ctx, tx, id, slotID, lessonInput)
+			} else {
+				_, err = insertLesson(ctx, tx, slotID, lessonInput)
 			}
-
-			var lessonID uuid.UUID
-			err = tx.QueryRowContext(ctx, `
-				INSERT INTO schedule_lessons (slot_id, subject_id)
-				VALUES ($1, $2)
-				RETURNING id
-			`, slotID, subjectID).Scan(&lessonID)
 			if err != nil {
-				return err
-			}
-
-			// Insert lesson_teachers
-			for _, teacherInput := range lessonInput.Teachers {
-				teacherID, err := uuid.Parse(teacherInput.ID)
-				if err != nil {
-					return fmt.Errorf("invalid teacher ID: %w", err)
-				}
-				_, err = tx.ExecContext(ctx, `
-					INSERT INTO lesson_teachers (lesson_id, teacher_id)
-					VALUES ($1, $2)
-				`, lessonID, teacherID)
-				if err != nil {
-					return err
-				}
-			}
-
-			// Insert lesson_rooms
-			for _, roomInput := range lessonInput.Rooms {
-				roomID, err := uuid.Parse(roomInput.ID)
-				if err != nil {
-					return fmt.Errorf("invalid room ID: %w", err)
-				}
-				_, err = tx.ExecContext(ctx, `
-					INSERT INTO lesson_rooms (lesson_id, classroom_id)
-					VALUES ($1, $2)
-				`, lessonID, roomID)
-				if err != nil {
-					return err
-				}
+				return 0, err
 			}
+		}
+	}
 
-			// Insert lesson_participants and lesson_participant_groups
-			for _, participantInput := range lessonInput.Participants {
-				classID, err := uuid.Parse(participantInput.Class.ID)
-				if err != nil {
-					return fmt.Errorf("invalid class ID: %w", err)
-				}
-
-				var participantID uuid.UUID
-				err = tx.QueryRowContext(ctx, `
-					INSERT INTO lesson_participants (lesson_id, class_id)
-					VALUES ($1, $2)
-					RETURNING id
-				`, lessonID, classID).Scan(&participantID)
-				if err != nil {
-					return err
-				}
-
-				for _, groupIDStr := range participantInput.GroupIDs {
-					groupID, err := uuid.Parse(groupIDStr)
-					if err != nil {
-						return fmt.Errorf("invalid group ID: %w", err)
-					}
-					_, err = tx.ExecContext(ctx, `
-						INSERT INTO lesson_participant_groups (participant_id, group_id)
-						VALUES ($1, $2)
-					`, participantID, groupID)
-					if err != nil {
-						return err
-					}
-				}
-			}
+	// 4. Delete the lessons left out (their overrides go with them) and the slots left empty
+	for _, l := range stored {
+		if kept[l.id] {
+			continue
 		}
+		if _, err = tx.ExecContext(ctx, `DELETE FROM schedule_lessons WHERE id = $1`, l.id); err != nil {
+			return 0, err
+		}
+	}
+	_, err = tx.ExecContext(ctx, `
+		DELETE FROM schedule_slots ss
+		WHERE ss.schedule_id = $1 AND NOT EXISTS (SELECT 1 FROM schedule_lessons sl WHERE sl.slot_id = ss.id)
+	`, scheduleID)
+	if err != nil {
+		return 0, err
 	}
 
+	if current, err = r.newRevision(ctx, tx, scheduleID, note); err != nil {
+		return 0, err
+	}
 	if err := tx.Commit(); err != nil {
-		return err
+		return 0, err
 	}
-	return nil
+	return current, nil
 }
 
-// DeleteSchedule deletes a schedule and all its associated data
+// ErrScheduleInUse is returned when a schedule in review, approved or published is about to be deleted
+var ErrScheduleInUse = errors.New("only a draft or archived schedule can be deleted")
+
+// DeleteSchedule deletes a schedule and all its associated data. The status is checked in the same
+// statement, so a schedule submitted or published concurrently is never deleted.
 func (r *scheduleRepository) DeleteSchedule(ctx context.Context, scheduleID uuid.UUID) error {
-	res, err := r.db.ExecContext(ctx, `DELETE FROM schedules WHERE id = $1`, scheduleID)
+	res, err := r.db.ExecContext(ctx, `
+		DELETE FROM schedules
+		WHERE id = $1 AND status IN ('draft', 'archived')
+	`, scheduleID)
 	if err != nil {
 		return err
 	}
@@ -1011,20 +860,44 @@ func (r *scheduleRepository) DeleteSchedule(ctx context.Context, scheduleID uuid
 	if err != nil {
 		return err
 	}
-	if n == 0 {
-		return sql.ErrNoRows
+	if n > 0 {
+		return nil
+	}
+
+	var status string
+	if err := r.db.QueryRowContext(ctx, `SELECT status FROM schedules WHERE id = $1`, scheduleID).Scan(&status); err != nil {
+		return err
+	}
+	return fmt.Errorf("%w: the schedule is %s", ErrScheduleInUse, status)
+}
+
+// RenameSchedule changes the name of a schedule; the timetable and its version stay as they are
+func (r *scheduleRepository) RenameSchedule(ctx context.Context, scheduleID uuid.UUID, name string) error {
+	res, err := r.db.ExecContext(ctx, `UPDATE schedules SET name = $1, updated_at = now() WHERE id = $2`, name, scheduleID)
+	if err != nil {
+		return err
 	}
-	return nil
+	return expectAffected(res)
 }
 
-// GenerateSchedule generates a schedule based on study plans and workload (stub for now)
-func (r *scheduleRepository) GenerateSchedule(ctx context.Context, req models.GenerateScheduleRequest) ([]models.ScheduleDay, error) {
-	// This is a complex business logic that would require:
-	// 1. Loading study plans (what classes need which subjects)
-	// 2. Loading teacher workload (who can teach what)
-	// 3. Loading available classrooms
-	// 4. Running an algorithm (e.g., greedy, genetic) to assign lessons to slots
-	// 5. Checking constraints (no teacher/class/group conflicts)
-	// For now, return an empty schedule as a stub.
-	return []models.ScheduleDay{}, nil
+// ActivateSchedule marks a schedule active and all other schedules of its owner inactive
+func (r *scheduleRepository) ActivateSchedule(ctx context.Context, scheduleID uuid.UUID) error {
+	res, err := r.db.ExecContext(ctx, `
+		UPDATE schedules SET is_active = (id = $1), updated_at = now()
+		WHERE user_id = (SELECT user_id FROM schedules WHERE id = $1)
+		  AND is_active <> (id = $1)
+	`, scheduleID)
+	if err != nil {
+		return err
+	}
+	if n, err := res.RowsAffected(); err != nil || n > 0 {
+		return err
+	}
+	// Nothing changed: the schedule is missing or already the only active one
+	var exists bool
+	err = r.db.QueryRowContext(ctx, `SELECT EXISTS (SELECT 1 FROM schedules WHERE id = $1)`, scheduleID).Scan(&exists)
+	if err == nil && !exists {
+		err = sql.ErrNoRows
+	}
+	return err
 }
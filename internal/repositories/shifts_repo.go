@@ -0,0 +1,134 @@
+package repositories
+
+import (
+	"context"
+	"database/sql"
+
+	"github.com/google/uuid"
+	"github.com/nikomkinds/SchoolSchedule/internal/models"
+)
+
+type ShiftRepository interface {
+	GetAll(ctx context.Context) ([]models.Shift, error)
+	GetByID(ctx context.Context, id uuid.UUID) (*models.Shift, error)
+	Create(ctx context.Context, shift models.Shift) (*models.Shift, error)
+	Update(ctx context.Context, shift models.Shift) error
+	Delete(ctx context.Context, id uuid.UUID) error
+	// AssignClasses moves the given classes into the shift
+	AssignClasses(ctx context.Context, shiftID uuid.UUID, classIDs []uuid.UUID) error
+}
+
+type shiftRepository struct {
+	db *sql.DB
+}
+
+func NewShiftRepository(db *sql.DB) ShiftRepository {
+	return &shiftRepository{db: db}
+}
+
+func (r *shiftRepository) GetAll(ctx context.Context) ([]models.Shift, error) {
+	const q = `
+		SELECT id, name, number, first_lesson, last_lesson, created_at, updated_at
+		FROM shifts
+		ORDER BY number
+	`
+
+	rows, err := r.db.QueryContext(ctx, q)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	list := make([]models.Shift, 0)
+	for rows.Next() {
+		var s models.Shift
+		if err := rows.Scan(&s.ID, &s.Name, &s.Number, &s.FirstLesson, &s.LastLesson, &s.CreatedAt, &s.UpdatedAt); err != nil {
+			return nil, err
+		}
+		list = append(list, s)
+	}
+	return list, rows.Err()
+}
+
+func (r *shiftRepository) GetByID(ctx context.Context, id uuid.UUID) (*models.Shift, error) {
+	const q = `
+		SELECT id, name, number, first_lesson, last_lesson, created_at, updated_at
+		FROM shifts
+		WHERE id = $1
+	`
+
+	var s models.Shift
+	err := r.db.QueryRowContext(ctx, q, id).Scan(&s.ID, &s.Name, &s.Number, &s.FirstLesson, &s.LastLesson, &s.CreatedAt, &s.UpdatedAt)
+	if err != nil {
+		return nil, err
+	}
+	return &s, nil
+}
+
+func (r *shiftRepository) Create(ctx context.Context, shift models.Shift) (*models.Shift, error) {
+	const q = `
+		INSERT INTO shifts (name, number, first_lesson, last_lesson)
+		VALUES ($1, $2, $3, $4)
+		RETURNING id, created_at, updated_at
+	`
+
+	err := r.db.QueryRowContext(ctx, q, shift.Name, shift.Number, shift.FirstLesson, shift.LastLesson).
+		Scan(&shift.ID, &shift.CreatedAt, &shift.UpdatedAt)
+	if err != nil {
+		return nil, err
+	}
+	return &shift, nil
+}
+
+func (r *shiftRepository) Update(ctx context.Context, shift models.Shift) error {
+	res, err := r.db.ExecContext(ctx, `
+		UPDATE shifts SET name = $1, number = $2, first_lesson = $3, last_lesson = $4, updated_at = now()
+		WHERE id = $5
+	`, shift.Name, shift.Number, shift.FirstLesson, shift.LastLesson, shift.ID)
+	if err != nil {
+		return err
+	}
+	return expectAffected(res)
+}
+
+func (r *shiftRepository) Delete(ctx context.Context, id uuid.UUID) error {
+	res, err := r.db.ExecContext(ctx, `DELETE FROM shifts WHERE id = $1`, id)
+	if err != nil {
+		return err
+	}
+	return expectAffected(res)
+}
+
+func (r *shiftRepository) AssignClasses(ctx context.Context, shiftID uuid.UUID, classIDs []uuid.UUID) error {
+	tx, err := r.db.BeginTx(ctx, nil)
+	if err != nil {
+		return err
+	}
+	defer func() {
+		if err != nil {
+			_ = tx.Rollback()
+		}
+	}()
+
+	var exists bool
+	if err = tx.QueryRowContext(ctx, `SELECT EXISTS (SELECT 1 FROM shifts WHERE id = $1)`, shiftID).Scan(&exists); err != nil {
+		return err
+	}
+	if !exists {
+		err = sql.ErrNoRows
+		return err
+	}
+
+	for _, classID := range classIDs {
+		var res sql.Result
+		res, err = tx.ExecContext(ctx, `UPDATE classes SET shift_id = $1 WHERE id = $2`, shiftID, classID)
+		if err != nil {
+			return err
+		}
+		if err = expectAffected(res); err != nil {
+			return err
+		}
+	}
+
+	return tx.Commit()
+}
@@ -1,16 +1,21 @@
 package repositories
 
 import (
+	"context"
 	"database/sql"
 	"errors"
 
 	"github.com/google/uuid"
+	"github.com/lib/pq"
 	"github.com/nikomkinds/SchoolSchedule/internal/models"
 )
 
 type SubjectRepository interface {
 	GetAll() ([]models.Subject, error)
-	Create(name string) (models.Subject, error)
+	// Create inserts a subject with its required equipment and suited classrooms
+	Create(subject models.Subject) (models.Subject, error)
+	// Update replaces the subject's name, short name, required equipment and suited classrooms
+	Update(subject models.Subject) (models.Subject, error)
 	Delete(id uuid.UUID) error
 }
 
@@ -22,8 +27,29 @@ func NewSubjectRepository(db *sql.DB) SubjectRepository {
 	return &subjectRepository{db: db}
 }
 
+const subjectQuery = `
+	SELECT s.id, s.name, s.short_name, s.required_equipment,
+	       COALESCE(array_agg(sc.classroom_id::text) FILTER (WHERE sc.classroom_id IS NOT NULL), '{}')
+	FROM subjects s
+	LEFT JOIN subject_classrooms sc ON sc.subject_id = s.id
+`
+
+func scanSubject(row rowScanner) (models.Subject, error) {
+	var s models.Subject
+	var classroomIDs []string
+	if err := row.Scan(&s.ID, &s.Name, &s.ShortName, pq.Array(&s.RequiredEquipment), pq.Array(&classroomIDs)); err != nil {
+		return s, err
+	}
+	for _, id := range classroomIDs {
+		if cid, err := uuid.Parse(id); err == nil {
+			s.ClassroomIDs = append(s.ClassroomIDs, cid)
+		}
+	}
+	return s, nil
+}
+
 func (r *subjectRepository) GetAll() ([]models.Subject, error) {
-	rows, err := r.db.Query(`SELECT id, name FROM subjects ORDER BY name`)
+	rows, err := r.db.Query(subjectQuery + ` GROUP BY s.id ORDER BY s.name`)
 	if err != nil {
 		return nil, err
 	}
@@ -32,8 +58,8 @@ func (r *subjectRepository) GetAll() ([]models.Subject, error) {
 	var subjects []models.Subject
 
 	for rows.Next() {
-		var s models.Subject
-		if err := rows.Scan(&s.ID, &s.Name); err != nil {
+		s, err := scanSubject(rows)
+		if err != nil {
 			return nil, err
 		}
 		subjects = append(subjects, s)
@@ -42,17 +68,65 @@ func (r *subjectRepository) GetAll() ([]models.Subject, error) {
 	return subjects, nil
 }
 
-func (r *subjectRepository) Create(name string) (models.Subject, error) {
-	var s models.Subject
+func (r *subjectRepository) Create(subject models.Subject) (models.Subject, error) {
+	return r.save(`
+		INSERT INTO subjects (name, required_equipment, short_name) 
+		VALUES ($1, $2, NULLIF($3, '')) 
+		RETURNING id`,
+		subject, subject.Name, pq.Array(equipmentTags(subject.RequiredEquipment)), subject.ShortName,
+	)
+}
+
+func (r *subjectRepository) Update(subject models.Subject) (models.Subject, error) {
+	return r.save(`
+		UPDATE subjects SET name = $1, required_equipment = $2, short_name = NULLIF($4, '')
+		WHERE id = $3
+		RETURNING id`,
+		subject, subject.Name, pq.Array(equipmentTags(subject.RequiredEquipment)), subject.ID, subject.ShortName,
+	)
+}
+
+// save runs the insert/update query returning the subject id and replaces its classroom links
+func (r *subjectRepository) save(query string, subject models.Subject, args ...interface{}) (models.Subject, error) {
+	ctx := context.Background()
+	tx, err := r.db.BeginTx(ctx, nil)
+	if err != nil {
+		return models.Subject{}, err
+	}
+	defer func() {
+		if err != nil {
+			_ = tx.Rollback()
+		}
+	}()
 
-	err := r.db.QueryRow(
-		`INSERT INTO subjects (name) 
-         VALUES ($1) 
-         RETURNING id, name`,
-		name,
-	).Scan(&s.ID, &s.Name)
+	var id uuid.UUID
+	if err = tx.QueryRowContext(ctx, query, args...).Scan(&id); err != nil {
+		return models.Subject{}, err
+	}
+
+	if _, err = tx.ExecContext(ctx, `DELETE FROM subject_classrooms WHERE subject_id = $1`, id); err != nil {
+		return models.Subject{}, err
+	}
+	for _, classroomID := range subject.ClassroomIDs {
+		_, err = tx.ExecContext(ctx, `
+			INSERT INTO subject_classrooms (subject_id, classroom_id)
+			VALUES ($1, $2)
+			ON CONFLICT DO NOTHING
+		`, id, classroomID)
+		if err != nil {
+			return models.Subject{}, err
+		}
+	}
 
-	return s, err
+	var s models.Subject
+	s, err = scanSubject(tx.QueryRowContext(ctx, subjectQuery+` WHERE s.id = $1 GROUP BY s.id`, id))
+	if err != nil {
+		return models.Subject{}, err
+	}
+	if err = tx.Commit(); err != nil {
+		return models.Subject{}, err
+	}
+	return s, nil
 }
 
 func (r *subjectRepository) Delete(id uuid.UUID) error {
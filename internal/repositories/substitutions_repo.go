@@ -0,0 +1,283 @@
+package repositories
+
+import (
+	"context"
+	"database/sql"
+
+	"github.com/google/uuid"
+	"github.com/nikomkinds/SchoolSchedule/internal/models"
+)
+
+type SubstitutionRepository interface {
+	// GetAbsences loads absences that intersect the [from, to] range; with userID set only those of the
+	// teacher linked to that user
+	GetAbsences(ctx context.Context, from, to models.Date, userID *uuid.UUID) ([]models.TeacherAbsence, error)
+	GetAbsenceByID(ctx context.Context, id uuid.UUID) (*models.TeacherAbsence, error)
+	CreateAbsence(ctx context.Context, absence models.TeacherAbsence) (*models.TeacherAbsence, error)
+	// DeleteAbsence deletes an absence together with the overrides recorded for it
+	DeleteAbsence(ctx context.Context, id uuid.UUID) error
+
+	// GetOverrides loads overrides whose original or target date lies in the [from, to] range
+	GetOverrides(ctx context.Context, from, to models.Date) ([]models.LessonOverride, error)
+	// GetScheduleOverrides loads overrides of lessons of the given schedules, like GetOverrides
+	GetScheduleOverrides(ctx context.Context, scheduleIDs []uuid.UUID, from, to models.Date) ([]models.LessonOverride, error)
+	GetOverrideByID(ctx context.Context, id uuid.UUID) (*models.LessonOverride, error)
+	// SaveOverride inserts an override or replaces the existing one for the same lesson and date
+	SaveOverride(ctx context.Context, override models.LessonOverride) (*models.LessonOverride, error)
+	DeleteOverride(ctx context.Context, id uuid.UUID) error
+
+	// GetSubstituteCandidates loads teachers not absent on the date with their qualification for the subject,
+	// relation to the class, weekly load and number of substitutions taken since the given date
+	GetSubstituteCandidates(ctx context.Context, subjectID, classID uuid.UUID, date, since models.Date) ([]models.SubstituteCandidate, error)
+
+	// GetLessonSlot returns the schedule, day of week and lesson number of a lesson of the weekly timetable
+	GetLessonSlot(ctx context.Context, lessonID uuid.UUID) (scheduleID uuid.UUID, day int, lessonNumber int, err error)
+}
+
+type substitutionRepository struct {
+	db *sql.DB
+}
+
+func NewSubstitutionRepository(db *sql.DB) SubstitutionRepository {
+	return &substitutionRepository{db: db}
+}
+
+const absenceColumns = `
+	a.id, a.teacher_id, t.first_name, t.last_name, t.patronymic,
+	a.start_date, a.end_date, a.reason, a.created_at
+`
+
+func scanAbsence(row rowScanner) (*models.TeacherAbsence, error) {
+	var a models.TeacherAbsence
+	var t models.LightTeacher
+	if err := row.Scan(&a.ID, &a.TeacherID, &t.FirstName, &t.LastName, &t.Patronymic,
+		&a.StartDate, &a.EndDate, &a.Reason, &a.CreatedAt); err != nil {
+		return nil, err
+	}
+	t.ID = a.TeacherID
+	a.Teacher = &t
+	return &a, nil
+}
+
+func (r *substitutionRepository) GetAbsences(ctx context.Context, from, to models.Date, userID *uuid.UUID) ([]models.TeacherAbsence, error) {
+	rows, err := r.db.QueryContext(ctx, `
+		SELECT `+absenceColumns+`
+		FROM teacher_absences a
+		JOIN teachers t ON t.id = a.teacher_id
+		WHERE a.start_date <= $2 AND a.end_date >= $1
+		  AND ($3::uuid IS NULL OR t.user_id = $3)
+		ORDER BY a.start_date, t.last_name
+	`, from, to, userID)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	list := make([]models.TeacherAbsence, 0)
+	for rows.Next() {
+		a, err := scanAbsence(rows)
+		if err != nil {
+			return nil, err
+		}
+		list = append(list, *a)
+	}
+	return list, rows.Err()
+}
+
+func (r *substitutionRepository) GetAbsenceByID(ctx context.Context, id uuid.UUID) (*models.TeacherAbsence, error) {
+	return scanAbsence(r.db.QueryRowContext(ctx, `
+		SELECT `+absenceColumns+`
+		FROM teacher_absences a
+		JOIN teachers t ON t.id = a.teacher_id
+		WHERE a.id = $1
+	`, id))
+}
+
+func (r *substitutionRepository) CreateAbsence(ctx context.Context, absence models.TeacherAbsence) (*models.TeacherAbsence, error) {
+	var id uuid.UUID
+	err := r.db.QueryRowContext(ctx, `
+		INSERT INTO teacher_absences (teacher_id, start_date, end_date, reason)
+		VALUES ($1, $2, $3, $4)
+		RETURNING id
+	`, absence.TeacherID, absence.StartDate, absence.EndDate, absence.Reason).Scan(&id)
+	if err != nil {
+		return nil, err
+	}
+	return r.GetAbsenceByID(ctx, id)
+}
+
+func (r *substitutionRepository) DeleteAbsence(ctx context.Context, id uuid.UUID) error {
+	res, err := r.db.ExecContext(ctx, `DELETE FROM teacher_absences WHERE id = $1`, id)
+	if err != nil {
+		return err
+	}
+	return expectAffected(res)
+}
+
+const overrideColumns = `
+	o.id, o.lesson_id, o.date, o.action, o.absence_id,
+	o.substitute_teacher_id, t.first_name, t.last_name, t.patronymic,
+	o.room_id, c.name,
+	o.new_date, o.new_lesson_number, o.comment, o.created_at
+`
+
+func scanOverride(row rowScanner) (*models.LessonOverride, error) {
+	var o models.LessonOverride
+	var first, last, patron, roomName sql.NullString
+	if err := row.Scan(&o.ID, &o.LessonID, &o.Date, &o.Action, &o.AbsenceID,
+		&o.SubstituteTeacherID, &first, &last, &patron,
+		&o.RoomID, &roomName,
+		&o.NewDate, &o.NewLessonNumber, &o.Comment, &o.CreatedAt); err != nil {
+		return nil, err
+	}
+
+	if o.SubstituteTeacherID != nil {
+		o.SubstituteTeacher = &models.LightTeacher{
+			ID:        *o.SubstituteTeacherID,
+			FirstName: first.String,
+			LastName:  last.String,
+		}
+		if patron.Valid {
+			o.SubstituteTeacher.Patronymic = &patron.String
+		}
+	}
+	if o.RoomID != nil {
+		o.Room = &models.Classroom{ID: *o.RoomID, Name: roomName.String}
+	}
+	return &o, nil
+}
+
+func (r *substitutionRepository) GetOverrides(ctx context.Context, from, to models.Date) ([]models.LessonOverride, error) {
+	rows, err := r.db.QueryContext(ctx, `
+		SELECT `+overrideColumns+`
+		FROM lesson_overrides o
+		LEFT JOIN teachers t ON t.id = o.substitute_teacher_id
+		LEFT JOIN classrooms c ON c.id = o.room_id
+		WHERE o.date BETWEEN $1 AND $2
+		   OR o.new_date BETWEEN $1 AND $2
+		ORDER BY o.date, o.created_at
+	`, from, to)
+	if err != nil {
+		return nil, err
+	}
+	return scanOverrides(rows)
+}
+
+func (r *substitutionRepository) GetScheduleOverrides(ctx context.Context, scheduleIDs []uuid.UUID, from, to models.Date) ([]models.LessonOverride, error) {
+	rows, err := r.db.QueryContext(ctx, `
+		SELECT `+overrideColumns+`
+		FROM lesson_overrides o
+		JOIN schedule_lessons sl ON sl.id = o.lesson_id
+		JOIN schedule_slots ss ON ss.id = sl.slot_id
+		LEFT JOIN teachers t ON t.id = o.substitute_teacher_id
+		LEFT JOIN classrooms c ON c.id = o.room_id
+		WHERE ss.schedule_id = ANY($3::uuid[])
+		  AND (o.date BETWEEN $1 AND $2 OR o.new_date BETWEEN $1 AND $2)
+		ORDER BY o.date, o.created_at
+	`, from, to, uuidArray(scheduleIDs))
+	if err != nil {
+		return nil, err
+	}
+	return scanOverrides(rows)
+}
+
+func scanOverrides(rows *sql.Rows) ([]models.LessonOverride, error) {
+	defer rows.Close()
+
+	list := make([]models.LessonOverride, 0)
+	for rows.Next() {
+		o, err := scanOverride(rows)
+		if err != nil {
+			return nil, err
+		}
+		list = append(list, *o)
+	}
+	return list, rows.Err()
+}
+
+func (r *substitutionRepository) GetOverrideByID(ctx context.Context, id uuid.UUID) (*models.LessonOverride, error) {
+	return scanOverride(r.db.QueryRowContext(ctx, `
+		SELECT `+overrideColumns+`
+		FROM lesson_overrides o
+		LEFT JOIN teachers t ON t.id = o.substitute_teacher_id
+		LEFT JOIN classrooms c ON c.id = o.room_id
+		WHERE o.id = $1
+	`, id))
+}
+
+func (r *substitutionRepository) SaveOverride(ctx context.Context, o models.LessonOverride) (*models.LessonOverride, error) {
+	var id uuid.UUID
+	err := r.db.QueryRowContext(ctx, `
+		INSERT INTO lesson_overrides (lesson_id, date, action, absence_id, substitute_teacher_id,
+		                              room_id, new_date, new_lesson_number, comment)
+		VALUES ($1, $2, $3, $4, $5, $6, $7, $8, $9)
+		ON CONFLICT (lesson_id, date) DO UPDATE
+		SET action = EXCLUDED.action,
+		    absence_id = EXCLUDED.absence_id,
+		    substitute_teacher_id = EXCLUDED.substitute_teacher_id,
+		    room_id = EXCLUDED.room_id,
+		    new_date = EXCLUDED.new_date,
+		    new_lesson_number = EXCLUDED.new_lesson_number,
+		    comment = EXCLUDED.comment
+		RETURNING id
+	`, o.LessonID, o.Date, o.Action, o.AbsenceID, o.SubstituteTeacherID,
+		o.RoomID, o.NewDate, o.NewLessonNumber, o.Comment).Scan(&id)
+	if err != nil {
+		return nil, err
+	}
+	return r.GetOverrideByID(ctx, id)
+}
+
+func (r *substitutionRepository) DeleteOverride(ctx context.Context, id uuid.UUID) error {
+	res, err := r.db.ExecContext(ctx, `DELETE FROM lesson_overrides WHERE id = $1`, id)
+	if err != nil {
+		return err
+	}
+	return expectAffected(res)
+}
+
+func (r *substitutionRepository) GetLessonSlot(ctx context.Context, lessonID uuid.UUID) (uuid.UUID, int, int, error) {
+	var (
+		scheduleID        uuid.UUID
+		day, lessonNumber int
+	)
+	err := r.db.QueryRowContext(ctx, `
+		SELECT ss.schedule_id, ss.day_of_week, ss.lesson_number
+		FROM schedule_lessons sl
+		JOIN schedule_slots ss ON ss.id = sl.slot_id
+		WHERE sl.id = $1
+	`, lessonID).Scan(&scheduleID, &day, &lessonNumber)
+	return scheduleID, day, lessonNumber, err
+}
+
+func (r *substitutionRepository) GetSubstituteCandidates(ctx context.Context, subjectID, classID uuid.UUID, date, since models.Date) ([]models.SubstituteCandidate, error) {
+	rows, err := r.db.QueryContext(ctx, `
+		SELECT t.id, t.first_name, t.last_name, t.patronymic,
+		       EXISTS (SELECT 1 FROM teacher_subjects ts WHERE ts.teacher_id = t.id AND ts.subject_id = $1),
+		       EXISTS (SELECT 1 FROM teacher_workload w WHERE w.teacher_id = t.id AND w.class_id = $2),
+		       COALESCE((SELECT SUM(w.hours_per_week) FROM teacher_workload w WHERE w.teacher_id = t.id), 0),
+		       (SELECT COUNT(*) FROM lesson_overrides o
+		        WHERE o.substitute_teacher_id = t.id AND o.date BETWEEN $4 AND $3)
+		FROM teachers t
+		WHERE NOT EXISTS (
+		    SELECT 1 FROM teacher_absences a
+		    WHERE a.teacher_id = t.id AND $3::date BETWEEN a.start_date AND a.end_date
+		)
+		ORDER BY t.last_name, t.first_name
+	`, subjectID, classID, date, since)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var list []models.SubstituteCandidate
+	for rows.Next() {
+		var c models.SubstituteCandidate
+		if err := rows.Scan(&c.Teacher.ID, &c.Teacher.FirstName, &c.Teacher.LastName, &c.Teacher.Patronymic,
+			&c.Qualified, &c.TeachesClass, &c.WeeklyHours, &c.RecentSubstitutions); err != nil {
+			return nil, err
+		}
+		list = append(list, c)
+	}
+	return list, rows.Err()
+}
@@ -0,0 +1,152 @@
+package repositories
+
+import (
+	"context"
+	"database/sql"
+
+	"github.com/google/uuid"
+	"github.com/nikomkinds/SchoolSchedule/internal/models"
+)
+
+type TeacherAvailabilityRepository interface {
+	// GetAll loads availability of teachers that have any slots or limits set
+	GetAll(ctx context.Context) ([]models.TeacherAvailability, error)
+	// GetByTeacher loads availability of one teacher; a teacher without constraints gets an empty grid
+	GetByTeacher(ctx context.Context, teacherID uuid.UUID) (*models.TeacherAvailability, error)
+	// Save replaces the teacher's limits and availability grid
+	Save(ctx context.Context, availability models.TeacherAvailability) error
+	// Delete clears the teacher's limits and availability grid
+	Delete(ctx context.Context, teacherID uuid.UUID) error
+}
+
+type teacherAvailabilityRepository struct {
+	db *sql.DB
+}
+
+func NewTeacherAvailabilityRepository(db *sql.DB) TeacherAvailabilityRepository {
+	return &teacherAvailabilityRepository{db: db}
+}
+
+func (r *teacherAvailabilityRepository) GetAll(ctx context.Context) ([]models.TeacherAvailability, error) {
+	return loadTeacherAvailability(ctx, r.db, nil)
+}
+
+func (r *teacherAvailabilityRepository) GetByTeacher(ctx context.Context, teacherID uuid.UUID) (*models.TeacherAvailability, error) {
+	var exists bool
+	if err := r.db.QueryRowContext(ctx, `SELECT EXISTS (SELECT 1 FROM teachers WHERE id = $1)`, teacherID).Scan(&exists); err != nil {
+		return nil, err
+	}
+	if !exists {
+		return nil, sql.ErrNoRows
+	}
+
+	list, err := loadTeacherAvailability(ctx, r.db, &teacherID)
+	if err != nil {
+		return nil, err
+	}
+	if len(list) == 0 {
+		return &models.TeacherAvailability{TeacherID: teacherID, Slots: []models.AvailabilitySlot{}}, nil
+	}
+	return &list[0], nil
+}
+
+func (r *teacherAvailabilityRepository) Save(ctx context.Context, a models.TeacherAvailability) error {
+	tx, err := r.db.BeginTx(ctx, nil)
+	if err != nil {
+		return err
+	}
+	defer func() {
+		if err != nil {
+			_ = tx.Rollback()
+		}
+	}()
+
+	var res sql.Result
+	res, err = tx.ExecContext(ctx, `
+		UPDATE teachers
+		SET max_lessons_per_day = $2, max_consecutive_lessons = $3
+		WHERE id = $1
+	`, a.TeacherID, a.MaxLessonsPerDay, a.MaxConsecutiveLessons)
+	if err != nil {
+		return err
+	}
+	if err = expectAffected(res); err != nil {
+		return err
+	}
+
+	if _, err = tx.ExecContext(ctx, `DELETE FROM teacher_availability WHERE teacher_id = $1`, a.TeacherID); err != nil {
+		return err
+	}
+	for _, slot := range a.Slots {
+		_, err = tx.ExecContext(ctx, `
+			INSERT INTO teacher_availability (teacher_id, day_of_week, lesson_number, status)
+			VALUES ($1, $2, $3, $4)
+		`, a.TeacherID, stringToDayOfWeek(slot.DayOfWeek), slot.LessonNumber, slot.Status)
+		if err != nil {
+			return err
+		}
+	}
+
+	return tx.Commit()
+}
+
+func (r *teacherAvailabilityRepository) Delete(ctx context.Context, teacherID uuid.UUID) error {
+	return r.Save(ctx, models.TeacherAvailability{TeacherID: teacherID})
+}
+
+// loadTeacherAvailability loads limits and grids of teachers that have any constraints, or of one teacher if teacherID is set
+func loadTeacherAvailability(ctx context.Context, db *sql.DB, teacherID *uuid.UUID) ([]models.TeacherAvailability, error) {
+	rows, err := db.QueryContext(ctx, `
+		SELECT t.id, t.max_lessons_per_day, t.max_consecutive_lessons
+		FROM teachers t
+		WHERE ($1::uuid IS NULL OR t.id = $1)
+		  AND (t.max_lessons_per_day IS NOT NULL
+		       OR t.max_consecutive_lessons IS NOT NULL
+		       OR EXISTS (SELECT 1 FROM teacher_availability a WHERE a.teacher_id = t.id))
+		ORDER BY t.last_name, t.first_name
+	`, teacherID)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var out []models.TeacherAvailability
+	index := make(map[uuid.UUID]int)
+	for rows.Next() {
+		var a models.TeacherAvailability
+		if err := rows.Scan(&a.TeacherID, &a.MaxLessonsPerDay, &a.MaxConsecutiveLessons); err != nil {
+			return nil, err
+		}
+		a.Slots = []models.AvailabilitySlot{}
+		index[a.TeacherID] = len(out)
+		out = append(out, a)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+
+	sRows, err := db.QueryContext(ctx, `
+		SELECT teacher_id, day_of_week, lesson_number, status
+		FROM teacher_availability
+		WHERE ($1::uuid IS NULL OR teacher_id = $1)
+		ORDER BY day_of_week, lesson_number NULLS FIRST
+	`, teacherID)
+	if err != nil {
+		return nil, err
+	}
+	defer sRows.Close()
+
+	for sRows.Next() {
+		var id uuid.UUID
+		var day int
+		var slot models.AvailabilitySlot
+		if err := sRows.Scan(&id, &day, &slot.LessonNumber, &slot.Status); err != nil {
+			return nil, err
+		}
+		slot.DayOfWeek = dayOfWeekToString(day)
+		if i, ok := index[id]; ok {
+			out[i].Slots = append(out[i].Slots, slot)
+		}
+	}
+	return out, sRows.Err()
+}
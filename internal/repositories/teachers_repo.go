@@ -11,10 +11,15 @@ import (
 
 type TeacherRepository interface {
 	GetAllFull(ctx context.Context) ([]models.Teacher, error)
+	// GetByIDs loads the given teachers like GetAllFull; unknown IDs are skipped
+	GetByIDs(ctx context.Context, ids []uuid.UUID) ([]models.Teacher, error)
 	GetAllLight(ctx context.Context) ([]models.LightTeacher, error)
 	Create(ctx context.Context, firstName, lastName string, patronymic *string) (*models.Teacher, error)
 	Delete(ctx context.Context, id uuid.UUID) error
-	BulkUpdate(ctx context.Context, items []models.Teacher) (int, error)
+	// BulkUpdate updates teachers if the table still has the expected tag; it returns the new tag
+	BulkUpdate(ctx context.Context, items []models.Teacher, etag string) (int, string, error)
+	// ETag returns the version tag of all teachers
+	ETag(ctx context.Context) (string, error)
 }
 
 type teacherRepository struct {
@@ -27,16 +32,29 @@ func NewTeacherRepository(db *sql.DB) TeacherRepository {
 
 // GetAllFull loads teachers with expanded fields (classRoom, class, subjects, classHours)
 func (r *teacherRepository) GetAllFull(ctx context.Context) ([]models.Teacher, error) {
+	return r.getFull(ctx, nil)
+}
+
+func (r *teacherRepository) GetByIDs(ctx context.Context, ids []uuid.UUID) ([]models.Teacher, error) {
+	if ids == nil {
+		ids = []uuid.UUID{}
+	}
+	return r.getFull(ctx, ids)
+}
+
+// getFull loads teachers with their subjects and workload; nil ids loads all of them
+func (r *teacherRepository) getFull(ctx context.Context, ids []uuid.UUID) ([]models.Teacher, error) {
 	const q = `
 		SELECT id, first_name, last_name, patronymic,
 		       workload_hours_per_week,
 		       classroom_id, classroom_name,
 		       homeroom_class_id, homeroom_class_name
 		FROM v_teachers_full
+		WHERE $1::uuid[] IS NULL OR id = ANY($1::uuid[])
 		ORDER BY last_name, first_name
 	`
 
-	rows, err := r.db.QueryContext(ctx, q)
+	rows, err := r.db.QueryContext(ctx, q, uuidArray(ids))
 	if err != nil {
 		return nil, err
 	}
@@ -168,7 +186,7 @@ func (r *teacherRepository) loadTeacherClassHours(ctx context.Context, teacherID
 		var subjectID uuid.UUID
 		var subjectName string
 		var groupID sql.NullString
-		var hours int
+		var hours float64
 
 		if err := rows.Scan(&classID, &className, &subjectID, &subjectName, &groupID, &hours); err != nil {
 			return nil, err
@@ -291,10 +309,10 @@ func (r *teacherRepository) Delete(ctx context.Context, id uuid.UUID) error {
 
 // BulkUpdate updates many teachers; performs updates to teachers, teacher_subjects and teacher_workload
 // Returns number of teachers updated.
-func (r *teacherRepository) BulkUpdate(ctx context.Context, items []models.Teacher) (int, error) {
+func (r *teacherRepository) BulkUpdate(ctx context.Context, items []models.Teacher, etag string) (int, string, error) {
 	tx, err := r.db.BeginTx(ctx, nil)
 	if err != nil {
-		return 0, err
+		return 0, "", err
 	}
 	updated := 0
 
@@ -304,6 +322,10 @@ func (r *teacherRepository) BulkUpdate(ctx context.Context, items []models.Teach
 		}
 	}()
 
+	if err = checkTableETag(ctx, tx, "teachers", etag); err != nil {
+		return 0, "", err
+	}
+
 	for _, t := range items {
 		// update base teacher info
 		var patron sql.NullString
@@ -327,17 +349,17 @@ func (r *teacherRepository) BulkUpdate(ctx context.Context, items []models.Teach
 
 		res, err := tx.ExecContext(ctx, `
 			UPDATE teachers SET first_name = $1, last_name = $2, patronymic = $3,
-				classroom_id = $4, homeroom_class_id = $5
+				classroom_id = $4, homeroom_class_id = $5, version = version + 1
 			WHERE id = $6
 		`, t.FirstName, t.LastName, patron, classroomID, homeroomID, t.ID)
 		if err != nil {
 			tx.Rollback()
-			return updated, err
+			return updated, "", err
 		}
 		n, err := res.RowsAffected()
 		if err != nil {
 			tx.Rollback()
-			return updated, err
+			return updated, "", err
 		}
 
 		if n > 0 {
@@ -347,7 +369,7 @@ func (r *teacherRepository) BulkUpdate(ctx context.Context, items []models.Teach
 		// Replace teacher_subjects: delete & insert
 		if _, err := tx.ExecContext(ctx, `DELETE FROM teacher_subjects WHERE teacher_id = $1`, t.ID); err != nil {
 			tx.Rollback()
-			return updated, err
+			return updated, "", err
 		}
 		for _, tsa := range t.Subjects {
 			// tsa.Subject.ID must be valid
@@ -363,14 +385,14 @@ func (r *teacherRepository) BulkUpdate(ctx context.Context, items []models.Teach
 				ON CONFLICT (teacher_id, subject_id) DO UPDATE SET preferred_hours_per_week = EXCLUDED.preferred_hours_per_week
 			`, t.ID, tsa.Subject.ID, pref); err != nil {
 				tx.Rollback()
-				return updated, err
+				return updated, "", err
 			}
 		}
 
 		// Replace teacher_workload (classHours): delete & insert
 		if _, err := tx.ExecContext(ctx, `DELETE FROM teacher_workload WHERE teacher_id = $1`, t.ID); err != nil {
 			tx.Rollback()
-			return updated, err
+			return updated, "", err
 		}
 		for _, ch := range t.ClassHours {
 			var groupID interface{}
@@ -389,13 +411,23 @@ func (r *teacherRepository) BulkUpdate(ctx context.Context, items []models.Teach
 				VALUES ($1, $2, $3, $4, $5)
 			`, t.ID, ch.Class.ID, ch.Subject.ID, groupID, ch.Hours); err != nil {
 				tx.Rollback()
-				return updated, err
+				return updated, "", err
 			}
 		}
 	}
 
+	newTag, err := tableETag(ctx, tx, "teachers")
+	if err != nil {
+		tx.Rollback()
+		return updated, "", err
+	}
 	if err := tx.Commit(); err != nil {
-		return updated, err
+		return updated, "", err
 	}
-	return updated, nil
+	return updated, newTag, nil
+}
+
+// ETag returns the version tag of all teachers
+func (r *teacherRepository) ETag(ctx context.Context) (string, error) {
+	return tableETag(ctx, r.db, "teachers")
 }
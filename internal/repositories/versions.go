@@ -0,0 +1,49 @@
+package repositories
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+)
+
+// ErrVersionMismatch is returned when a write is based on an outdated version of the data
+var ErrVersionMismatch = errors.New("version mismatch")
+
+// ErrScheduleLocked is returned when a schedule that is not a draft is about to be edited
+var ErrScheduleLocked = errors.New("only a draft schedule can be edited")
+
+// AnyVersion as the expected tag skips the version check (If-Match: *)
+const AnyVersion = "*"
+
+type rowQuerier interface {
+	QueryRowContext(ctx context.Context, query string, args ...interface{}) *sql.Row
+}
+
+// tableETag returns the version tag of a whole table. It changes whenever a row is updated
+// (its version is incremented), added or deleted. table must be a constant.
+func tableETag(ctx context.Context, q rowQuerier, table string) (string, error) {
+	var tag string
+	err := q.QueryRowContext(ctx, `
+		SELECT md5(COALESCE(string_agg(id::text || ':' || version, ',' ORDER BY id), ''))
+		FROM `+table).Scan(&tag)
+	return tag, err
+}
+
+// checkTableETag locks the table against concurrent writes for the rest of the transaction
+// and compares its tag with the expected one
+func checkTableETag(ctx context.Context, tx *sql.Tx, table, expected string) error {
+	if _, err := tx.ExecContext(ctx, `LOCK TABLE `+table+` IN SHARE ROW EXCLUSIVE MODE`); err != nil {
+		return err
+	}
+	if expected == AnyVersion {
+		return nil
+	}
+	tag, err := tableETag(ctx, tx, table)
+	if err != nil {
+		return err
+	}
+	if tag != expected {
+		return ErrVersionMismatch
+	}
+	return nil
+}
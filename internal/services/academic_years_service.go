@@ -0,0 +1,212 @@
+package services
+
+import (
+	"context"
+	"errors"
+	"fmt"
+
+	"github.com/google/uuid"
+	"github.com/nikomkinds/SchoolSchedule/internal/models"
+	"github.com/nikomkinds/SchoolSchedule/internal/repositories"
+)
+
+var (
+	// ErrInvalidDateRange is returned when a period ends before it starts or has no dates
+	ErrInvalidDateRange = errors.New("invalid date range")
+	// ErrOutsideAcademicYear is returned when a term or holiday does not fit into its academic year
+	ErrOutsideAcademicYear = errors.New("period is outside of the academic year")
+	// ErrInvalidTermType is returned for unknown term types
+	ErrInvalidTermType = errors.New("invalid term type")
+	// ErrTermsOverlap is returned when a term intersects another term of the same year
+	ErrTermsOverlap = errors.New("term overlaps another term")
+)
+
+var termTypes = map[string]bool{
+	"quarter":   true,
+	"trimester": true,
+	"semester":  true,
+}
+
+type AcademicYearService interface {
+	GetAll(ctx context.Context) ([]models.AcademicYear, error)
+	Create(ctx context.Context, req models.AcademicYearRequest) (*models.AcademicYear, error)
+	Update(ctx context.Context, id uuid.UUID, req models.AcademicYearRequest) (*models.AcademicYear, error)
+	Delete(ctx context.Context, id uuid.UUID) error
+
+	// GetTermByDate returns the term containing the given date
+	GetTermByDate(ctx context.Context, date models.Date) (*models.AcademicTerm, error)
+	CreateTerm(ctx context.Context, yearID uuid.UUID, req models.AcademicTermRequest) (*models.AcademicTerm, error)
+	UpdateTerm(ctx context.Context, id uuid.UUID, req models.AcademicTermRequest) (*models.AcademicTerm, error)
+	DeleteTerm(ctx context.Context, id uuid.UUID) error
+
+	CreateHoliday(ctx context.Context, yearID uuid.UUID, req models.HolidayRequest) (*models.Holiday, error)
+	DeleteHoliday(ctx context.Context, id uuid.UUID) error
+}
+
+type academicYearService struct {
+	repo repositories.AcademicYearRepository
+}
+
+func NewAcademicYearService(repo repositories.AcademicYearRepository) AcademicYearService {
+	return &academicYearService{repo: repo}
+}
+
+func (s *academicYearService) GetAll(ctx context.Context) ([]models.AcademicYear, error) {
+	return s.repo.GetAll(ctx)
+}
+
+func (s *academicYearService) Create(ctx context.Context, req models.AcademicYearRequest) (*models.AcademicYear, error) {
+	if err := validateDateRange(req.StartDate, req.EndDate); err != nil {
+		return nil, err
+	}
+
+	return s.repo.Create(ctx, models.AcademicYear{
+		Name:      req.Name,
+		StartDate: req.StartDate,
+		EndDate:   req.EndDate,
+	})
+}
+
+func (s *academicYearService) Update(ctx context.Context, id uuid.UUID, req models.AcademicYearRequest) (*models.AcademicYear, error) {
+	if err := validateDateRange(req.StartDate, req.EndDate); err != nil {
+		return nil, err
+	}
+
+	existing, err := s.repo.GetByID(ctx, id)
+	if err != nil {
+		return nil, err
+	}
+
+	// Shrinking the year must not leave terms or holidays outside of it
+	for _, t := range existing.Terms {
+		if !t.StartDate.Between(req.StartDate, req.EndDate) || !t.EndDate.Between(req.StartDate, req.EndDate) {
+			return nil, fmt.Errorf("%w: term %q", ErrOutsideAcademicYear, t.Name)
+		}
+	}
+	for _, h := range existing.Holidays {
+		if !h.StartDate.Between(req.StartDate, req.EndDate) || !h.EndDate.Between(req.StartDate, req.EndDate) {
+			return nil, fmt.Errorf("%w: holiday %q", ErrOutsideAcademicYear, h.Name)
+		}
+	}
+
+	existing.Name = req.Name
+	existing.StartDate = req.StartDate
+	existing.EndDate = req.EndDate
+	if err := s.repo.Update(ctx, *existing); err != nil {
+		return nil, err
+	}
+	return s.repo.GetByID(ctx, id)
+}
+
+func (s *academicYearService) Delete(ctx context.Context, id uuid.UUID) error {
+	return s.repo.Delete(ctx, id)
+}
+
+func (s *academicYearService) GetTermByDate(ctx context.Context, date models.Date) (*models.AcademicTerm, error) {
+	return s.repo.GetTermByDate(ctx, date)
+}
+
+func (s *academicYearService) CreateTerm(ctx context.Context, yearID uuid.UUID, req models.AcademicTermRequest) (*models.AcademicTerm, error) {
+	year, err := s.repo.GetByID(ctx, yearID)
+	if err != nil {
+		return nil, err
+	}
+
+	term := models.AcademicTerm{
+		AcademicYearID: yearID,
+		Name:           req.Name,
+		Type:           req.Type,
+		Number:         req.Number,
+		StartDate:      req.StartDate,
+		EndDate:        req.EndDate,
+	}
+	if err := validateTerm(year, term); err != nil {
+		return nil, err
+	}
+
+	return s.repo.CreateTerm(ctx, term)
+}
+
+func (s *academicYearService) UpdateTerm(ctx context.Context, id uuid.UUID, req models.AcademicTermRequest) (*models.AcademicTerm, error) {
+	term, err := s.repo.GetTermByID(ctx, id)
+	if err != nil {
+		return nil, err
+	}
+	year, err := s.repo.GetByID(ctx, term.AcademicYearID)
+	if err != nil {
+		return nil, err
+	}
+
+	term.Name = req.Name
+	term.Type = req.Type
+	term.Number = req.Number
+	term.StartDate = req.StartDate
+	term.EndDate = req.EndDate
+	if err := validateTerm(year, *term); err != nil {
+		return nil, err
+	}
+
+	if err := s.repo.UpdateTerm(ctx, *term); err != nil {
+		return nil, err
+	}
+	return s.repo.GetTermByID(ctx, id)
+}
+
+func (s *academicYearService) DeleteTerm(ctx context.Context, id uuid.UUID) error {
+	return s.repo.DeleteTerm(ctx, id)
+}
+
+func (s *academicYearService) CreateHoliday(ctx context.Context, yearID uuid.UUID, req models.HolidayRequest) (*models.Holiday, error) {
+	if err := validateDateRange(req.StartDate, req.EndDate); err != nil {
+		return nil, err
+	}
+
+	year, err := s.repo.GetByID(ctx, yearID)
+	if err != nil {
+		return nil, err
+	}
+	if !req.StartDate.Between(year.StartDate, year.EndDate) || !req.EndDate.Between(year.StartDate, year.EndDate) {
+		return nil, ErrOutsideAcademicYear
+	}
+
+	return s.repo.CreateHoliday(ctx, models.Holiday{
+		AcademicYearID: yearID,
+		Name:           req.Name,
+		StartDate:      req.StartDate,
+		EndDate:        req.EndDate,
+	})
+}
+
+func (s *academicYearService) DeleteHoliday(ctx context.Context, id uuid.UUID) error {
+	return s.repo.DeleteHoliday(ctx, id)
+}
+
+func validateDateRange(start, end models.Date) error {
+	if start.IsZero() || end.IsZero() || end.Before(start.Time) {
+		return ErrInvalidDateRange
+	}
+	return nil
+}
+
+// validateTerm checks a term against its academic year and the sibling terms
+func validateTerm(year *models.AcademicYear, term models.AcademicTerm) error {
+	if !termTypes[term.Type] {
+		return ErrInvalidTermType
+	}
+	if err := validateDateRange(term.StartDate, term.EndDate); err != nil {
+		return err
+	}
+	if !term.StartDate.Between(year.StartDate, year.EndDate) || !term.EndDate.Between(year.StartDate, year.EndDate) {
+		return ErrOutsideAcademicYear
+	}
+
+	for _, other := range year.Terms {
+		if other.ID == term.ID {
+			continue
+		}
+		if !term.EndDate.Before(other.StartDate.Time) && !other.EndDate.Before(term.StartDate.Time) {
+			return fmt.Errorf("%w: %q", ErrTermsOverlap, other.Name)
+		}
+	}
+	return nil
+}
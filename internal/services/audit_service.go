@@ -0,0 +1,78 @@
+package services
+
+import (
+	"context"
+	"encoding/json"
+	"log/slog"
+
+	"github.com/google/uuid"
+	"github.com/nikomkinds/SchoolSchedule/internal/models"
+	"github.com/nikomkinds/SchoolSchedule/internal/repositories"
+	"github.com/nikomkinds/SchoolSchedule/internal/utils"
+)
+
+// Limits of the number of audit entries returned at once
+const (
+	defaultAuditLimit = 100
+	maxAuditLimit     = 1000
+)
+
+type AuditService interface {
+	// Record stores a completed write with the entity before and after it (nil if it did not exist);
+	// the actor and the request ID come from the request context. A failure is logged, not returned,
+	// since the write itself has already been made.
+	Record(ctx context.Context, action, entity string, entityID *uuid.UUID, before, after interface{})
+	// List loads the audit entries matching the filter, newest first
+	List(ctx context.Context, filter models.AuditFilter) ([]models.AuditEntry, error)
+}
+
+type auditService struct {
+	repo repositories.AuditRepository
+}
+
+func NewAuditService(repo repositories.AuditRepository) AuditService {
+	return &auditService{repo: repo}
+}
+
+func (s *auditService) Record(ctx context.Context, action, entity string, entityID *uuid.UUID, before, after interface{}) {
+	entry := models.AuditEntry{Action: action, Entity: entity, EntityID: entityID}
+	if userID, ok := utils.UserIDFromContext(ctx); ok {
+		entry.ActorID = &userID
+	}
+	if requestID := utils.RequestIDFromContext(ctx); requestID != "" {
+		entry.RequestID = &requestID
+	}
+
+	var err error
+	if entry.Before, err = auditSnapshot(before); err == nil {
+		entry.After, err = auditSnapshot(after)
+	}
+	if err == nil {
+		err = s.repo.Insert(ctx, entry)
+	}
+	if err != nil {
+		slog.Error("Failed to record audit entry", "action", action, "entity", entity, "entityID", entityID, "error", err)
+	}
+}
+
+func (s *auditService) List(ctx context.Context, filter models.AuditFilter) ([]models.AuditEntry, error) {
+	if filter.Limit <= 0 {
+		filter.Limit = defaultAuditLimit
+	}
+	if filter.Limit > maxAuditLimit {
+		filter.Limit = maxAuditLimit
+	}
+	return s.repo.List(ctx, filter)
+}
+
+// auditSnapshot encodes the state of an entity; nil (also a nil pointer) stays empty
+func auditSnapshot(v interface{}) (json.RawMessage, error) {
+	if v == nil {
+		return nil, nil
+	}
+	raw, err := json.Marshal(v)
+	if err != nil || string(raw) == "null" {
+		return nil, err
+	}
+	return raw, nil
+}
@@ -0,0 +1,257 @@
+package services
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"sort"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/nikomkinds/SchoolSchedule/internal/models"
+	"github.com/nikomkinds/SchoolSchedule/internal/repositories"
+)
+
+// ErrInvalidBellSchedule is returned when a bell schedule definition is inconsistent
+var ErrInvalidBellSchedule = errors.New("invalid bell schedule")
+
+// timeLayout is the wire format of lesson times ("08:30")
+const timeLayout = "15:04"
+
+type BellScheduleService interface {
+	GetAll(ctx context.Context) ([]models.BellSchedule, error)
+	Create(ctx context.Context, req models.BellScheduleRequest) (*models.BellSchedule, error)
+	Update(ctx context.Context, id uuid.UUID, req models.BellScheduleRequest) (*models.BellSchedule, error)
+	Delete(ctx context.Context, id uuid.UUID) error
+}
+
+type bellScheduleService struct {
+	repo repositories.BellScheduleRepository
+}
+
+func NewBellScheduleService(repo repositories.BellScheduleRepository) BellScheduleService {
+	return &bellScheduleService{repo: repo}
+}
+
+func (s *bellScheduleService) GetAll(ctx context.Context) ([]models.BellSchedule, error) {
+	return s.repo.GetAll(ctx)
+}
+
+func (s *bellScheduleService) Create(ctx context.Context, req models.BellScheduleRequest) (*models.BellSchedule, error) {
+	bell, err := bellScheduleFromRequest(req)
+	if err != nil {
+		return nil, err
+	}
+	return s.repo.Create(ctx, *bell)
+}
+
+func (s *bellScheduleService) Update(ctx context.Context, id uuid.UUID, req models.BellScheduleRequest) (*models.BellSchedule, error) {
+	bell, err := bellScheduleFromRequest(req)
+	if err != nil {
+		return nil, err
+	}
+	bell.ID = id
+
+	if err := s.repo.Update(ctx, *bell); err != nil {
+		return nil, err
+	}
+	return s.repo.GetByID(ctx, id)
+}
+
+func (s *bellScheduleService) Delete(ctx context.Context, id uuid.UUID) error {
+	return s.repo.Delete(ctx, id)
+}
+
+// bellScheduleFromRequest validates the request and normalizes lesson times to "HH:MM"
+func bellScheduleFromRequest(req models.BellScheduleRequest) (*models.BellSchedule, error) {
+	if req.DayOfWeek != nil && models.DayOfWeekNumber(*req.DayOfWeek) == 0 {
+		return nil, fmt.Errorf("%w: invalid day of week %q", ErrInvalidBellSchedule, *req.DayOfWeek)
+	}
+	for _, g := range []*int{req.GradeFrom, req.GradeTo} {
+		if g != nil && (*g < 1 || *g > 11) {
+			return nil, fmt.Errorf("%w: grade must be between 1 and 11", ErrInvalidBellSchedule)
+		}
+	}
+	if req.GradeFrom != nil && req.GradeTo != nil && *req.GradeFrom > *req.GradeTo {
+		return nil, fmt.Errorf("%w: gradeFrom is greater than gradeTo", ErrInvalidBellSchedule)
+	}
+	if len(req.Lessons) == 0 {
+		return nil, fmt.Errorf("%w: at least one lesson time is required", ErrInvalidBellSchedule)
+	}
+
+	lessons := make([]models.BellLesson, len(req.Lessons))
+	copy(lessons, req.Lessons)
+	sort.Slice(lessons, func(i, j int) bool { return lessons[i].LessonNumber < lessons[j].LessonNumber })
+
+	var prevEnd time.Time
+	for i := range lessons {
+		l := &lessons[i]
+		if l.LessonNumber < 1 {
+			return nil, fmt.Errorf("%w: lesson number must be positive", ErrInvalidBellSchedule)
+		}
+		if i > 0 && lessons[i-1].LessonNumber == l.LessonNumber {
+			return nil, fmt.Errorf("%w: duplicate lesson number %d", ErrInvalidBellSchedule, l.LessonNumber)
+		}
+
+		start, err := time.Parse(timeLayout, l.StartTime)
+		if err != nil {
+			return nil, fmt.Errorf("%w: invalid start time %q of lesson %d", ErrInvalidBellSchedule, l.StartTime, l.LessonNumber)
+		}
+		end, err := time.Parse(timeLayout, l.EndTime)
+		if err != nil {
+			return nil, fmt.Errorf("%w: invalid end time %q of lesson %d", ErrInvalidBellSchedule, l.EndTime, l.LessonNumber)
+		}
+		if !end.After(start) {
+			return nil, fmt.Errorf("%w: lesson %d ends before it starts", ErrInvalidBellSchedule, l.LessonNumber)
+		}
+		if i > 0 && start.Before(prevEnd) {
+			return nil, fmt.Errorf("%w: lesson %d overlaps the previous lesson", ErrInvalidBellSchedule, l.LessonNumber)
+		}
+		prevEnd = end
+
+		l.StartTime = start.Format(timeLayout)
+		l.EndTime = end.Format(timeLayout)
+	}
+
+	return &models.BellSchedule{
+		Name:      req.Name,
+		DayOfWeek: req.DayOfWeek,
+		ShiftID:   req.ShiftID,
+		GradeFrom: req.GradeFrom,
+		GradeTo:   req.GradeTo,
+		Date:      req.Date,
+		Lessons:   lessons,
+	}, nil
+}
+
+// bellTimes resolves lesson times from the set of defined bell schedules
+type bellTimes struct {
+	bells []models.BellSchedule
+}
+
+func newBellTimes(bells []models.BellSchedule) *bellTimes {
+	return &bellTimes{bells: bells}
+}
+
+// lookup returns the times of a lesson from the most specific matching bell schedule.
+// A date-bound schedule beats a weekday-bound one, which beats a shift-bound one, then a grade-band one,
+// then a general one. grade 0 and a nil shift mean "unknown": only schedules without that restriction match.
+// date may be nil for the weekly template.
+func (b *bellTimes) lookup(day int, date *models.Date, shiftID *uuid.UUID, grade, lessonNumber int) *models.BellLesson {
+	var best *models.BellLesson
+	bestScore := -1
+
+	for i := range b.bells {
+		bell := &b.bells[i]
+		score := 0
+
+		if bell.Date != nil {
+			if date == nil || !bell.Date.Equal(date.Time) {
+				continue
+			}
+			score += 8
+		}
+		if bell.DayOfWeek != nil {
+			if models.DayOfWeekNumber(*bell.DayOfWeek) != day {
+				continue
+			}
+			score += 4
+		}
+		if bell.ShiftID != nil {
+			if shiftID == nil || *bell.ShiftID != *shiftID {
+				continue
+			}
+			score += 2
+		}
+		if bell.GradeFrom != nil || bell.GradeTo != nil {
+			if grade == 0 {
+				continue
+			}
+			if (bell.GradeFrom != nil && grade < *bell.GradeFrom) || (bell.GradeTo != nil && grade > *bell.GradeTo) {
+				continue
+			}
+			score++
+		}
+		if score <= bestScore {
+			continue
+		}
+
+		for j := range bell.Lessons {
+			if bell.Lessons[j].LessonNumber == lessonNumber {
+				best = &bell.Lessons[j]
+				bestScore = score
+				break
+			}
+		}
+	}
+
+	return best
+}
+
+// apply fills start/end times of every slot and lesson.
+// weekStart is the Monday of the displayed week and enables date-bound (shortened day) schedules.
+func (b *bellTimes) apply(days []models.ScheduleDay, weekStart *models.Date) {
+	for i := range days {
+		slot := &days[i]
+		day := models.DayOfWeekNumber(slot.DayOfWeek)
+
+		var date *models.Date
+		if weekStart != nil {
+			d := weekStart.AddDays(day - 1)
+			date = &d
+		}
+
+		slotTimes := b.lookup(day, date, nil, 0, slot.LessonNumber)
+
+		// Lessons get times for their own shift and grade band, e.g. juniors with shorter breaks
+		var common *models.BellLesson
+		uniform := true
+		for j := range slot.Lessons {
+			lesson := &slot.Lessons[j]
+			lt := b.lookup(day, date, lessonShift(*lesson), lessonGrade(*lesson), slot.LessonNumber)
+			if lt == nil {
+				uniform = false
+				continue
+			}
+			start, end := lt.StartTime, lt.EndTime
+			lesson.StartTime = &start
+			lesson.EndTime = &end
+
+			if common == nil {
+				common = lt
+			} else if common.StartTime != lt.StartTime || common.EndTime != lt.EndTime {
+				uniform = false
+			}
+		}
+
+		// Without a general bell schedule the slot takes the times its lessons agree on
+		if slotTimes == nil && uniform {
+			slotTimes = common
+		}
+		if slotTimes != nil {
+			start, end := slotTimes.StartTime, slotTimes.EndTime
+			slot.StartTime = &start
+			slot.EndTime = &end
+		}
+	}
+}
+
+// lessonGrade returns the grade level of the lesson participants (0 if unknown)
+func lessonGrade(lesson models.ScheduleLesson) int {
+	for _, p := range lesson.Participants {
+		if p.Class != nil && p.Class.GradeLevel > 0 {
+			return p.Class.GradeLevel
+		}
+	}
+	return 0
+}
+
+// lessonShift returns the shift of the lesson participants (nil if unknown)
+func lessonShift(lesson models.ScheduleLesson) *uuid.UUID {
+	for _, p := range lesson.Participants {
+		if p.Class != nil && p.Class.ShiftID != nil {
+			return p.Class.ShiftID
+		}
+	}
+	return nil
+}
@@ -0,0 +1,90 @@
+package services
+
+import (
+	"bytes"
+	"fmt"
+	"strings"
+	"time"
+)
+
+// Formats of iCalendar (RFC 5545) times. Lesson times are floating: the calendar app shows them
+// in its own time zone, which is the school's one for the people subscribing.
+const (
+	icsLocalLayout = "20060102T150405"
+	icsUTCLayout   = "20060102T150405Z"
+)
+
+// icsEvent is a VEVENT: a lesson repeated weekly, or a single occurrence of it
+type icsEvent struct {
+	UID          string
+	Start, End   time.Time
+	Interval     int         // Weeks between occurrences; 0 for a single event
+	Until        time.Time   // Last moment of the recurrence
+	ExDates      []time.Time // Starts of the occurrences that do not take place
+	RecurrenceID *time.Time  // Original start of the occurrence this event replaces
+	Summary      string
+	Location     string
+	Description  string
+}
+
+// writeICS renders a calendar with the given name and events
+func writeICS(name string, events []icsEvent, now time.Time) []byte {
+	var b bytes.Buffer
+	line := func(s string) { writeICSLine(&b, s) }
+
+	line("BEGIN:VCALENDAR")
+	line("VERSION:2.0")
+	line("PRODID:-//SchoolSchedule//Timetable//RU")
+	line("CALSCALE:GREGORIAN")
+	line("METHOD:PUBLISH")
+	line("X-WR-CALNAME:" + icsText(name))
+	for _, e := range events {
+		line("BEGIN:VEVENT")
+		line("UID:" + e.UID)
+		line("DTSTAMP:" + now.UTC().Format(icsUTCLayout))
+		if e.RecurrenceID != nil {
+			line("RECURRENCE-ID:" + e.RecurrenceID.Format(icsLocalLayout))
+		}
+		line("DTSTART:" + e.Start.Format(icsLocalLayout))
+		line("DTEND:" + e.End.Format(icsLocalLayout))
+		if e.Interval > 0 {
+			line(fmt.Sprintf("RRULE:FREQ=WEEKLY;INTERVAL=%d;UNTIL=%s", e.Interval, e.Until.Format(icsLocalLayout)))
+		}
+		for _, d := range e.ExDates {
+			line("EXDATE:" + d.Format(icsLocalLayout))
+		}
+		line("SUMMARY:" + icsText(e.Summary))
+		if e.Location != "" {
+			line("LOCATION:" + icsText(e.Location))
+		}
+		if e.Description != "" {
+			line("DESCRIPTION:" + icsText(e.Description))
+		}
+		line("END:VEVENT")
+	}
+	line("END:VCALENDAR")
+	return b.Bytes()
+}
+
+// writeICSLine writes a content line folded at 75 octets without splitting UTF-8 characters
+func writeICSLine(b *bytes.Buffer, s string) {
+	const limit = 75
+	width := 0
+	for _, r := range s {
+		n := len(string(r))
+		if width+n > limit {
+			b.WriteString("\r\n ")
+			width = 1
+		}
+		b.WriteRune(r)
+		width += n
+	}
+	b.WriteString("\r\n")
+}
+
+var icsEscaper = strings.NewReplacer(`\`, `\\`, `;`, `\;`, `,`, `\,`, "\r\n", `\n`, "\n", `\n`)
+
+// icsText escapes a TEXT value
+func icsText(s string) string {
+	return icsEscaper.Replace(s)
+}
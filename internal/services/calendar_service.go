@@ -0,0 +1,466 @@
+package services
+
+import (
+	"context"
+	"crypto/rand"
+	"crypto/sha256"
+	"database/sql"
+	"encoding/base64"
+	"encoding/hex"
+	"errors"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/nikomkinds/SchoolSchedule/internal/models"
+	"github.com/nikomkinds/SchoolSchedule/internal/repositories"
+)
+
+// ErrInvalidCalendarTarget is returned when a calendar names not exactly one of a teacher, a class and a room
+// and the user is not a teacher whose own timetable could be used instead
+var ErrInvalidCalendarTarget = errors.New("set exactly one of teacherId, classId and roomId")
+
+type CalendarService interface {
+	// ExportICS renders the timetable of a teacher, class or room for a term as an iCalendar file.
+	// Without a target a teacher gets the own timetable.
+	ExportICS(ctx context.Context, userID uuid.UUID, target models.CalendarTarget) ([]byte, error)
+	// ListFeeds lists the calendar subscriptions of a user
+	ListFeeds(ctx context.Context, userID uuid.UUID) ([]models.CalendarFeed, error)
+	// CreateFeed creates a subscription; its token is only returned here
+	CreateFeed(ctx context.Context, userID uuid.UUID, target models.CalendarTarget) (*models.CalendarFeed, error)
+	// DeleteFeed revokes a subscription of a user
+	DeleteFeed(ctx context.Context, userID, id uuid.UUID) error
+	// FeedICS renders the calendar of a subscription by its token; sql.ErrNoRows for an unknown token
+	FeedICS(ctx context.Context, token string) ([]byte, error)
+}
+
+type calendarService struct {
+	repo             repositories.CalendarFeedRepository
+	substitutionRepo repositories.SubstitutionRepository
+	academicYearRepo repositories.AcademicYearRepository
+	schedules        ScheduleService
+}
+
+func NewCalendarService(
+	repo repositories.CalendarFeedRepository,
+	substitutionRepo repositories.SubstitutionRepository,
+	academicYearRepo repositories.AcademicYearRepository,
+	schedules ScheduleService,
+) CalendarService {
+	return &calendarService{
+		repo:             repo,
+		substitutionRepo: substitutionRepo,
+		academicYearRepo: academicYearRepo,
+		schedules:        schedules,
+	}
+}
+
+func (s *calendarService) ExportICS(ctx context.Context, userID uuid.UUID, target models.CalendarTarget) ([]byte, error) {
+	target, err := s.resolveTarget(ctx, userID, target)
+	if err != nil {
+		return nil, err
+	}
+	return s.render(ctx, userID, target)
+}
+
+func (s *calendarService) ListFeeds(ctx context.Context, userID uuid.UUID) ([]models.CalendarFeed, error) {
+	return s.repo.List(ctx, userID)
+}
+
+func (s *calendarService) CreateFeed(ctx context.Context, userID uuid.UUID, target models.CalendarTarget) (*models.CalendarFeed, error) {
+	target, err := s.resolveTarget(ctx, userID, target)
+	if err != nil {
+		return nil, err
+	}
+
+	raw := make([]byte, 24)
+	if _, err := rand.Read(raw); err != nil {
+		return nil, err
+	}
+	token := base64.RawURLEncoding.EncodeToString(raw)
+
+	feed, err := s.repo.Create(ctx, models.CalendarFeed{UserID: userID, CalendarTarget: target}, feedTokenHash(token))
+	if err != nil {
+		return nil, err
+	}
+	feed.Token = token
+	return feed, nil
+}
+
+func (s *calendarService) DeleteFeed(ctx context.Context, userID, id uuid.UUID) error {
+	return s.repo.Delete(ctx, userID, id)
+}
+
+func (s *calendarService) FeedICS(ctx context.Context, token string) ([]byte, error) {
+	feed, err := s.repo.UseByTokenHash(ctx, feedTokenHash(token))
+	if err != nil {
+		return nil, err
+	}
+	// The timetable is the one the owner of the feed sees
+	return s.render(ctx, feed.UserID, feed.CalendarTarget)
+}
+
+// feedTokenHash is what is stored of a feed token, so that a leaked database does not leak the URLs
+func feedTokenHash(token string) string {
+	sum := sha256.Sum256([]byte(token))
+	return hex.EncodeToString(sum[:])
+}
+
+// resolveTarget checks that the target names exactly one teacher, class or room; an empty one
+// becomes the user's own teacher record
+func (s *calendarService) resolveTarget(ctx context.Context, userID uuid.UUID, target models.CalendarTarget) (models.CalendarTarget, error) {
+	set := 0
+	for _, id := range []*uuid.UUID{target.TeacherID, target.ClassID, target.ClassroomID} {
+		if id != nil {
+			set++
+		}
+	}
+	switch {
+	case set > 1:
+		return target, ErrInvalidCalendarTarget
+	case set == 0:
+		teacherID, err := s.repo.TeacherIDByUser(ctx, userID)
+		if errors.Is(err, sql.ErrNoRows) {
+			return target, ErrInvalidCalendarTarget
+		}
+		if err != nil {
+			return target, err
+		}
+		target.TeacherID = &teacherID
+	}
+	return target, nil
+}
+
+// lessonSeries is a lesson of the weekly timetable repeated over a term
+type lessonSeries struct {
+	lesson       models.ScheduleLesson
+	lessonNumber int
+	first        models.Date // Date of the first occurrence in the term
+	interval     int         // Weeks between occurrences
+	start, end   string      // Bell times, "08:30"
+	occurs       map[string]bool
+	matched      bool // The lesson belongs to the calendar's teacher, class or room
+	event        icsEvent
+}
+
+// render builds the calendar of the target for a term: a recurring event per lesson of the weekly
+// timetable with the bell times, holidays excluded and the substitutions, cancellations and moves
+// of single dates as changed or extra occurrences
+func (s *calendarService) render(ctx context.Context, userID uuid.UUID, target models.CalendarTarget) ([]byte, error) {
+	term, err := s.term(ctx, target.TermID)
+	if err != nil {
+		return nil, err
+	}
+	holidays, err := s.academicYearRepo.GetHolidaysBetween(ctx, term.StartDate, term.EndDate)
+	if err != nil {
+		return nil, err
+	}
+	overrides, err := s.substitutionRepo.GetOverrides(ctx, term.StartDate, term.EndDate)
+	if err != nil {
+		return nil, err
+	}
+
+	// Two consecutive weeks hold every lesson: weekly ones and those of odd and even weeks
+	var weeks [2]*models.ScheduleWeek
+	for i := range weeks {
+		date := term.StartDate
+		if i > 0 {
+			date = term.StartDate.WeekStart().AddDays(7 * i)
+		}
+		if weeks[i], err = s.schedules.GetScheduleWeek(ctx, userID, date); err != nil {
+			return nil, err
+		}
+	}
+
+	inHoliday := func(date models.Date) bool {
+		for _, h := range holidays {
+			if date.Between(h.StartDate, h.EndDate) {
+				return true
+			}
+		}
+		return false
+	}
+
+	times := newLessonTimes()
+	byLesson := make(map[uuid.UUID]*lessonSeries)
+	var series []*lessonSeries
+	for _, week := range weeks {
+		for _, slot := range week.Days {
+			for _, l := range slot.Lessons {
+				start, end := slot.StartTime, slot.EndTime
+				if l.StartTime != nil && l.EndTime != nil {
+					start, end = l.StartTime, l.EndTime
+				}
+				// A lesson without bell times cannot be put into a calendar
+				if start == nil || end == nil {
+					continue
+				}
+				times.add(l, slot.LessonNumber, *start, *end)
+				if _, ok := byLesson[l.ID]; ok {
+					continue
+				}
+
+				ls := &lessonSeries{
+					lesson:       l,
+					lessonNumber: slot.LessonNumber,
+					first:        week.WeekStart.AddDays(models.DayOfWeekNumber(slot.DayOfWeek) - 1),
+					interval:     1,
+					start:        *start,
+					end:          *end,
+					occurs:       make(map[string]bool),
+					matched:      calendarMatches(target, l),
+				}
+				if l.WeekPattern == models.WeekOdd || l.WeekPattern == models.WeekEven {
+					ls.interval = 2
+				}
+				for ls.first.Before(term.StartDate.Time) {
+					ls.first = ls.first.AddDays(7 * ls.interval)
+				}
+				if ls.first.After(term.EndDate.Time) {
+					continue
+				}
+
+				ls.event = icsEvent{
+					UID:         l.ID.String() + "@schoolschedule",
+					Start:       icsTime(ls.first, ls.start),
+					End:         icsTime(ls.first, ls.end),
+					Interval:    ls.interval,
+					Until:       icsTime(term.EndDate, "23:59:59"),
+					Summary:     lessonSummary(l, nil),
+					Location:    lessonLocation(l),
+					Description: lessonDescription(l, nil),
+				}
+				for date := ls.first; !date.After(term.EndDate.Time); date = date.AddDays(7 * ls.interval) {
+					if inHoliday(date) {
+						ls.event.ExDates = append(ls.event.ExDates, icsTime(date, ls.start))
+						continue
+					}
+					ls.occurs[date.String()] = true
+				}
+				byLesson[l.ID] = ls
+				series = append(series, ls)
+			}
+		}
+	}
+
+	var events []icsEvent
+	for i := range overrides {
+		o := &overrides[i]
+		ls, ok := byLesson[o.LessonID]
+		if !ok || !ls.occurs[o.Date.String()] {
+			continue
+		}
+		changed := applyOverride(ls.lesson, o)
+		shown := o.Action != models.OverrideCancel && calendarMatches(target, changed)
+
+		date, lessonNumber := o.Date, ls.lessonNumber
+		start, end := ls.start, ls.end
+		if o.Action == models.OverrideMove {
+			if o.NewDate != nil {
+				date = *o.NewDate
+			}
+			if o.NewLessonNumber != nil {
+				lessonNumber = *o.NewLessonNumber
+			}
+			if !date.Between(term.StartDate, term.EndDate) || inHoliday(date) {
+				shown = false
+			}
+			if lessonNumber != ls.lessonNumber {
+				start, end = times.get(changed, lessonNumber, start, end)
+			}
+		}
+
+		original := icsTime(o.Date, ls.start)
+		switch {
+		case ls.matched && !shown:
+			ls.event.ExDates = append(ls.event.ExDates, original)
+		case shown:
+			e := icsEvent{
+				UID:         ls.event.UID,
+				Start:       icsTime(date, start),
+				End:         icsTime(date, end),
+				Summary:     lessonSummary(changed, o),
+				Location:    lessonLocation(changed),
+				Description: lessonDescription(changed, o),
+			}
+			if ls.matched {
+				e.RecurrenceID = &original
+			} else {
+				// Not a lesson of the target otherwise, e.g. one the teacher substitutes in
+				e.UID = o.LessonID.String() + "-" + o.Date.Format("20060102") + "@schoolschedule"
+			}
+			events = append(events, e)
+		}
+	}
+
+	name := "Расписание"
+	for _, ls := range series {
+		if n := calendarTargetName(target, ls.lesson); n != "" {
+			name += ": " + n
+			break
+		}
+	}
+	for _, ls := range series {
+		if !ls.matched {
+			continue
+		}
+		sort.Slice(ls.event.ExDates, func(i, j int) bool { return ls.event.ExDates[i].Before(ls.event.ExDates[j]) })
+		events = append(events, ls.event)
+	}
+	sort.SliceStable(events, func(i, j int) bool {
+		if !events[i].Start.Equal(events[j].Start) {
+			return events[i].Start.Before(events[j].Start)
+		}
+		return events[i].UID < events[j].UID
+	})
+
+	return writeICS(name+" — "+term.Name, events, time.Now()), nil
+}
+
+// term returns the given term or the one containing today
+func (s *calendarService) term(ctx context.Context, termID *uuid.UUID) (*models.AcademicTerm, error) {
+	if termID != nil {
+		return s.academicYearRepo.GetTermByID(ctx, *termID)
+	}
+	return s.academicYearRepo.GetTermByDate(ctx, models.Today())
+}
+
+// calendarMatches reports whether a lesson belongs to the teacher, class or room of the calendar
+func calendarMatches(target models.CalendarTarget, l models.ScheduleLesson) bool {
+	switch {
+	case target.TeacherID != nil:
+		return lessonHasTeacher(l, *target.TeacherID)
+	case target.ClassID != nil:
+		for _, p := range l.Participants {
+			if p.ClassID == *target.ClassID || (p.Class != nil && p.Class.ID == *target.ClassID) {
+				return true
+			}
+		}
+	case target.ClassroomID != nil:
+		for _, r := range l.Rooms {
+			if r.ID == *target.ClassroomID {
+				return true
+			}
+		}
+	}
+	return false
+}
+
+// calendarTargetName returns the name of the calendar's teacher, class or room as found in a lesson
+func calendarTargetName(target models.CalendarTarget, l models.ScheduleLesson) string {
+	switch {
+	case target.TeacherID != nil:
+		for _, t := range l.Teachers {
+			if t.ID == *target.TeacherID {
+				return teacherShortName(t.LastName, t.FirstName, t.Patronymic)
+			}
+		}
+	case target.ClassID != nil:
+		for _, p := range l.Participants {
+			if p.Class != nil && p.Class.ID == *target.ClassID {
+				return p.Class.Name
+			}
+		}
+	case target.ClassroomID != nil:
+		for _, r := range l.Rooms {
+			if r.ID == *target.ClassroomID {
+				return r.Name
+			}
+		}
+	}
+	return ""
+}
+
+func lessonSummary(l models.ScheduleLesson, o *models.LessonOverride) string {
+	summary := "Урок"
+	if l.Subject != nil {
+		summary = l.Subject.Name
+	}
+	if o != nil {
+		switch o.Action {
+		case models.OverrideSubstitute:
+			summary += " (замена)"
+		case models.OverrideMove:
+			summary += " (перенос)"
+		}
+	}
+	return summary
+}
+
+func lessonLocation(l models.ScheduleLesson) string {
+	rooms := make([]string, 0, len(l.Rooms))
+	for _, r := range l.Rooms {
+		rooms = append(rooms, r.Name)
+	}
+	return strings.Join(rooms, ", ")
+}
+
+func lessonDescription(l models.ScheduleLesson, o *models.LessonOverride) string {
+	var lines []string
+	if len(l.Teachers) > 0 {
+		names := make([]string, 0, len(l.Teachers))
+		for _, t := range l.Teachers {
+			names = append(names, teacherShortName(t.LastName, t.FirstName, t.Patronymic))
+		}
+		lines = append(lines, "Учитель: "+strings.Join(names, ", "))
+	}
+	var classes []string
+	for _, p := range l.Participants {
+		if p.Class != nil {
+			classes = append(classes, p.Class.Name)
+		}
+	}
+	if len(classes) > 0 {
+		lines = append(lines, "Класс: "+strings.Join(classes, ", "))
+	}
+	if o != nil && o.Comment != nil && *o.Comment != "" {
+		lines = append(lines, *o.Comment)
+	}
+	return strings.Join(lines, "\n")
+}
+
+// icsTime combines a date with a bell time ("08:30" or "08:30:00") into a floating time
+func icsTime(date models.Date, clock string) time.Time {
+	t, err := time.Parse("15:04:05", clock)
+	if err != nil {
+		t, _ = time.Parse("15:04", clock)
+	}
+	return time.Date(date.Year(), date.Month(), date.Day(), t.Hour(), t.Minute(), t.Second(), 0, time.UTC)
+}
+
+// lessonTimes collects the bell times of lesson numbers per class, to time a lesson moved to another number
+type lessonTimes struct {
+	byClass map[uuid.UUID]map[int][2]string
+	any     map[int][2]string
+}
+
+func newLessonTimes() *lessonTimes {
+	return &lessonTimes{byClass: make(map[uuid.UUID]map[int][2]string), any: make(map[int][2]string)}
+}
+
+func (t *lessonTimes) add(l models.ScheduleLesson, lessonNumber int, start, end string) {
+	for _, p := range l.Participants {
+		if t.byClass[p.ClassID] == nil {
+			t.byClass[p.ClassID] = make(map[int][2]string)
+		}
+		t.byClass[p.ClassID][lessonNumber] = [2]string{start, end}
+	}
+	if _, ok := t.any[lessonNumber]; !ok {
+		t.any[lessonNumber] = [2]string{start, end}
+	}
+}
+
+// get returns the times of the lesson number for the classes of the lesson, falling back to
+// any class and then to the given times
+func (t *lessonTimes) get(l models.ScheduleLesson, lessonNumber int, start, end string) (string, string) {
+	for _, p := range l.Participants {
+		if v, ok := t.byClass[p.ClassID][lessonNumber]; ok {
+			return v[0], v[1]
+		}
+	}
+	if v, ok := t.any[lessonNumber]; ok {
+		return v[0], v[1]
+	}
+	return start, end
+}
@@ -0,0 +1,213 @@
+package services
+
+import (
+	"bytes"
+	"context"
+	"flag"
+	"os"
+	"path/filepath"
+	"regexp"
+	"strings"
+	"testing"
+	"unicode/utf8"
+
+	"github.com/google/uuid"
+	"github.com/nikomkinds/SchoolSchedule/internal/models"
+	"github.com/nikomkinds/SchoolSchedule/internal/repositories"
+)
+
+var update = flag.Bool("update", false, "rewrite the golden files in testdata")
+
+func TestWriteICSLineFolding(t *testing.T) {
+	tests := []struct {
+		name string
+		line string
+	}{
+		{name: "short line", line: "SUMMARY:Математика"},
+		{name: "ascii", line: "DESCRIPTION:" + strings.Repeat("abcdefghij", 20)},
+		{name: "cyrillic", line: "DESCRIPTION:" + strings.Repeat("Замена в актовом зале. ", 8)},
+		{name: "exactly 75 octets", line: strings.Repeat("x", 75)},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var b bytes.Buffer
+			writeICSLine(&b, tt.line)
+			out := b.String()
+			if !strings.HasSuffix(out, "\r\n") {
+				t.Fatalf("%q does not end with CRLF", out)
+			}
+
+			physical := strings.Split(strings.TrimSuffix(out, "\r\n"), "\r\n")
+			for i, l := range physical {
+				if len(l) > 75 {
+					t.Errorf("line %d is %d octets: %q", i, len(l), l)
+				}
+				if !utf8.ValidString(l) {
+					t.Errorf("line %d splits a character: %q", i, l)
+				}
+				if i > 0 && !strings.HasPrefix(l, " ") {
+					t.Errorf("continuation line %d does not start with a space: %q", i, l)
+				}
+			}
+			if len(tt.line) <= 75 && len(physical) != 1 {
+				t.Errorf("a line of %d octets is folded into %d", len(tt.line), len(physical))
+			}
+			if unfolded := strings.ReplaceAll(strings.TrimSuffix(out, "\r\n"), "\r\n ", ""); unfolded != tt.line {
+				t.Errorf("unfolded %q, want %q", unfolded, tt.line)
+			}
+		})
+	}
+}
+
+func TestICSText(t *testing.T) {
+	tests := []struct {
+		in, want string
+	}{
+		{in: "Каб. 101", want: "Каб. 101"},
+		{in: "Лаб. 2; корпус Б, 3 этаж", want: `Лаб. 2\; корпус Б\, 3 этаж`},
+		{in: `C:\школа`, want: `C:\\школа`},
+		{in: "Учитель: Иванова А.П.\nКласс: 5А", want: `Учитель: Иванова А.П.\nКласс: 5А`},
+		{in: "первая\r\nвторая", want: `первая\nвторая`},
+	}
+
+	for _, tt := range tests {
+		if got := icsText(tt.in); got != tt.want {
+			t.Errorf("icsText(%q) = %q, want %q", tt.in, got, tt.want)
+		}
+	}
+}
+
+type fakeCalendarTerms struct {
+	repositories.AcademicYearRepository
+	term     models.AcademicTerm
+	holidays []models.Holiday
+}
+
+func (f *fakeCalendarTerms) GetTermByID(ctx context.Context, id uuid.UUID) (*models.AcademicTerm, error) {
+	return &f.term, nil
+}
+
+func (f *fakeCalendarTerms) GetHolidaysBetween(ctx context.Context, from, to models.Date) ([]models.Holiday, error) {
+	return f.holidays, nil
+}
+
+type fakeCalendarOverrides struct {
+	repositories.SubstitutionRepository
+	overrides []models.LessonOverride
+}
+
+func (f *fakeCalendarOverrides) GetOverrides(ctx context.Context, from, to models.Date) ([]models.LessonOverride, error) {
+	return f.overrides, nil
+}
+
+// fakeCalendarSchedules returns the weeks by their Monday, already filtered by parity like GetScheduleWeek
+type fakeCalendarSchedules struct {
+	ScheduleService
+	weeks map[string]models.ScheduleWeek
+}
+
+func (f *fakeCalendarSchedules) GetScheduleWeek(ctx context.Context, userID uuid.UUID, date models.Date) (*models.ScheduleWeek, error) {
+	week, ok := f.weeks[date.WeekStart().String()]
+	if !ok {
+		week = models.ScheduleWeek{WeekStart: date.WeekStart()}
+	}
+	return &week, nil
+}
+
+func TestCalendarRender(t *testing.T) {
+	date := func(s string) models.Date {
+		d, err := models.ParseDate(s)
+		if err != nil {
+			t.Fatal(err)
+		}
+		return d
+	}
+	str := func(s string) *string { return &s }
+
+	ivanova := models.Teacher{ID: uuid.MustParse("00000000-0000-0000-0000-00000000000a"), LastName: "Иванова", FirstName: "Анна", Patronymic: str("Петровна")}
+	petrov := models.Teacher{ID: uuid.MustParse("00000000-0000-0000-0000-00000000000b"), LastName: "Петров", FirstName: "Олег"}
+	class := &models.Class{ID: uuid.MustParse("00000000-0000-0000-0000-0000000000c1"), Name: "5А", GradeLevel: 5}
+	participants := []models.LessonParticipant{{ClassID: class.ID, Class: class}}
+
+	math := models.ScheduleLesson{
+		ID:           uuid.MustParse("00000000-0000-0000-0000-000000000001"),
+		Subject:      &models.Subject{Name: "Математика"},
+		WeekPattern:  models.WeekEvery,
+		Teachers:     []models.Teacher{ivanova},
+		Rooms:        []models.Classroom{{Name: "101"}},
+		Participants: participants,
+	}
+	physics := models.ScheduleLesson{
+		ID:           uuid.MustParse("00000000-0000-0000-0000-000000000002"),
+		Subject:      &models.Subject{Name: "Физика"},
+		WeekPattern:  models.WeekOdd,
+		Teachers:     []models.Teacher{ivanova},
+		Rooms:        []models.Classroom{{Name: "Лаб. 2; корпус Б, 3 этаж"}},
+		Participants: participants,
+	}
+	art := models.ScheduleLesson{
+		ID:           uuid.MustParse("00000000-0000-0000-0000-000000000003"),
+		Subject:      &models.Subject{Name: "ИЗО"},
+		WeekPattern:  models.WeekEven,
+		Teachers:     []models.Teacher{petrov},
+		Rooms:        []models.Classroom{{Name: "205"}},
+		Participants: participants,
+	}
+	slot := func(day string, n int, start, end string, lessons ...models.ScheduleLesson) models.ScheduleDay {
+		return models.ScheduleDay{DayOfWeek: day, LessonNumber: n, StartTime: str(start), EndTime: str(end), Lessons: lessons}
+	}
+
+	termID := uuid.MustParse("00000000-0000-0000-0000-0000000000f1")
+	comment := "Урок проводится в актовом зале: в кабинете 101 меняют окна, просьба взять с собой учебники и тетради"
+	s := &calendarService{
+		academicYearRepo: &fakeCalendarTerms{
+			term:     models.AcademicTerm{ID: termID, Name: "2 четверть", StartDate: date("2024-11-04"), EndDate: date("2024-11-24")},
+			holidays: []models.Holiday{{Name: "День народного единства (перенос)", StartDate: date("2024-11-18"), EndDate: date("2024-11-18")}},
+		},
+		substitutionRepo: &fakeCalendarOverrides{overrides: []models.LessonOverride{
+			// A lesson of the teacher in another room: the occurrence is replaced
+			{LessonID: math.ID, Date: date("2024-11-11"), Action: models.OverrideSubstitute, Room: &models.Classroom{Name: "Актовый зал"}, Comment: &comment},
+			// Cancelled: the occurrence is excluded
+			{LessonID: physics.ID, Date: date("2024-11-19"), Action: models.OverrideCancel},
+			// The teacher substitutes for a colleague: a single event of its own
+			{LessonID: art.ID, Date: date("2024-11-13"), Action: models.OverrideSubstitute, SubstituteTeacher: &models.LightTeacher{
+				ID: ivanova.ID, LastName: ivanova.LastName, FirstName: ivanova.FirstName, Patronymic: ivanova.Patronymic,
+			}},
+		}},
+		schedules: &fakeCalendarSchedules{weeks: map[string]models.ScheduleWeek{
+			"2024-11-04": {WeekStart: date("2024-11-04"), Parity: "odd", Days: []models.ScheduleDay{
+				slot("MONDAY", 1, "08:30", "09:15", math),
+				slot("TUESDAY", 2, "09:25", "10:10", physics),
+			}},
+			"2024-11-11": {WeekStart: date("2024-11-11"), Parity: "even", Days: []models.ScheduleDay{
+				slot("MONDAY", 1, "08:30", "09:15", math),
+				slot("WEDNESDAY", 3, "10:30", "11:15", art),
+			}},
+		}},
+	}
+
+	got, err := s.render(context.Background(), uuid.New(), models.CalendarTarget{TermID: &termID, TeacherID: &ivanova.ID})
+	if err != nil {
+		t.Fatal(err)
+	}
+	// DTSTAMP is the time of rendering
+	got = regexp.MustCompile(`DTSTAMP:\d{8}T\d{6}Z`).ReplaceAll(got, []byte("DTSTAMP:20000101T000000Z"))
+
+	golden := filepath.Join("testdata", "calendar_teacher.ics")
+	if *update {
+		if err := os.MkdirAll("testdata", 0o755); err != nil {
+			t.Fatal(err)
+		}
+		if err := os.WriteFile(golden, got, 0o644); err != nil {
+			t.Fatal(err)
+		}
+	}
+	want, err := os.ReadFile(golden)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !bytes.Equal(got, want) {
+		t.Errorf("calendar differs from %s (run with -update to rewrite it):\n%s", golden, got)
+	}
+}
@@ -3,6 +3,8 @@ package services
 import (
 	"context"
 	"errors"
+	"fmt"
+	"math"
 	"strconv"
 	"strings"
 	"unicode"
@@ -12,23 +14,31 @@ import (
 	"github.com/nikomkinds/SchoolSchedule/internal/repositories"
 )
 
+// ErrInvalidHours is returned for weekly hours of a study plan or workload that are not a positive
+// multiple of 0.5: half an hour is a lesson every other week, nothing finer can be scheduled
+var ErrInvalidHours = errors.New("hours per week must be a positive multiple of 0.5")
+
 type ClassService interface {
-	GetAll(ctx context.Context) ([]models.Class, error)
+	GetAll(ctx context.Context, termID *uuid.UUID) ([]models.Class, error)
 	Create(ctx context.Context, name string) (*models.Class, error)
 	Delete(ctx context.Context, id uuid.UUID) error
-	BulkUpdate(ctx context.Context, items []models.Class) (int, error)
+	// BulkUpdate updates classes if etag is still current and returns the new tag; otherwise ErrVersionMismatch
+	BulkUpdate(ctx context.Context, items []models.Class, etag string) (int, string, error)
+	// ETag returns the version tag of all classes
+	ETag(ctx context.Context) (string, error)
 }
 
 type classService struct {
-	repo repositories.ClassRepository
+	repo  repositories.ClassRepository
+	audit AuditService
 }
 
-func NewClassService(repo repositories.ClassRepository) ClassService {
-	return &classService{repo: repo}
+func NewClassService(repo repositories.ClassRepository, audit AuditService) ClassService {
+	return &classService{repo: repo, audit: audit}
 }
 
-func (s *classService) GetAll(ctx context.Context) ([]models.Class, error) {
-	return s.repo.GetAll(ctx)
+func (s *classService) GetAll(ctx context.Context, termID *uuid.UUID) ([]models.Class, error) {
+	return s.repo.GetAll(ctx, termID)
 }
 
 func (s *classService) Create(ctx context.Context, name string) (*models.Class, error) {
@@ -41,7 +51,12 @@ func (s *classService) Create(ctx context.Context, name string) (*models.Class,
 		return nil, errors.New("invalid grade")
 	}
 
-	return s.repo.Create(ctx, name, grade)
+	class, err := s.repo.Create(ctx, name, grade)
+	if err != nil {
+		return nil, err
+	}
+	s.audit.Record(ctx, models.AuditActionCreate, models.AuditEntityClass, &class.ID, nil, class)
+	return class, nil
 }
 
 func extractGrade(name string) (int, error) {
@@ -59,10 +74,77 @@ func extractGrade(name string) (int, error) {
 	return strconv.Atoi(numericPart.String())
 }
 
+// validHours reports whether weekly hours are positive and whole or half
+func validHours(hours float64) bool {
+	return hours > 0 && math.Mod(hours*2, 1) == 0
+}
+
+// formatHours formats weekly hours the Russian way: "2", "0,5"
+func formatHours(hours float64) string {
+	return strings.Replace(strconv.FormatFloat(hours, 'f', -1, 64), ".", ",", 1)
+}
+
 func (s *classService) Delete(ctx context.Context, id uuid.UUID) error {
-	return s.repo.Delete(ctx, id)
+	before, err := s.classesByID(ctx, []uuid.UUID{id})
+	if err != nil {
+		return err
+	}
+	if err := s.repo.Delete(ctx, id); err != nil {
+		return err
+	}
+	s.audit.Record(ctx, models.AuditActionDelete, models.AuditEntityClass, &id, before[id], nil)
+	return nil
+}
+
+func (s *classService) BulkUpdate(ctx context.Context, items []models.Class, etag string) (int, string, error) {
+	for _, item := range items {
+		for _, subj := range item.Subjects {
+			if !validHours(subj.HoursPerWeek) {
+				return 0, "", fmt.Errorf("%w: %s, %s: %v", ErrInvalidHours, item.Name, subj.Subject.Name, subj.HoursPerWeek)
+			}
+		}
+	}
+
+	ids := make([]uuid.UUID, len(items))
+	for i, item := range items {
+		ids[i] = item.ID
+	}
+	before, err := s.classesByID(ctx, ids)
+	if err != nil {
+		return 0, "", err
+	}
+	updated, tag, err := s.repo.BulkUpdate(ctx, items, etag)
+	if err != nil {
+		return updated, "", err
+	}
+	after, err := s.classesByID(ctx, ids)
+	if err != nil {
+		return 0, "", err
+	}
+
+	// One entry per class so that the history of a class can be looked up by its ID
+	for _, item := range items {
+		id := item.ID
+		if after[id] != nil {
+			s.audit.Record(ctx, models.AuditActionUpdate, models.AuditEntityClass, &id, before[id], after[id])
+		}
+	}
+	return updated, tag, nil
+}
+
+// classesByID loads the given classes for audit snapshots
+func (s *classService) classesByID(ctx context.Context, ids []uuid.UUID) (map[uuid.UUID]*models.Class, error) {
+	classes, err := s.repo.GetByIDs(ctx, ids)
+	if err != nil {
+		return nil, err
+	}
+	byID := make(map[uuid.UUID]*models.Class, len(classes))
+	for i := range classes {
+		byID[classes[i].ID] = &classes[i]
+	}
+	return byID, nil
 }
 
-func (s *classService) BulkUpdate(ctx context.Context, items []models.Class) (int, error) {
-	return s.repo.BulkUpdate(ctx, items)
+func (s *classService) ETag(ctx context.Context) (string, error) {
+	return s.repo.ETag(ctx)
 }
@@ -2,36 +2,247 @@ package services
 
 import (
 	"context"
+	"errors"
+	"fmt"
+	"sort"
 
 	"github.com/google/uuid"
 	"github.com/nikomkinds/SchoolSchedule/internal/models"
 	"github.com/nikomkinds/SchoolSchedule/internal/repositories"
 )
 
+// ErrInvalidClassroom is returned when a classroom has no name, a non-positive capacity or an unknown equipment tag
+var ErrInvalidClassroom = errors.New("invalid classroom")
+
+// ErrInvalidRoomFilter is returned when a free-room search has a bad day, lesson range or equipment tag
+var ErrInvalidRoomFilter = errors.New("invalid free room filter")
+
 type ClassroomService struct {
-	repo repositories.ClassroomRepository
+	repo          repositories.ClassroomRepository
+	schedules     ScheduleService
+	substitutions SubstitutionService
+	audit         AuditService
 }
 
-func NewClassroomService(repo repositories.ClassroomRepository) *ClassroomService {
-	return &ClassroomService{repo: repo}
+func NewClassroomService(repo repositories.ClassroomRepository, schedules ScheduleService, substitutions SubstitutionService, audit AuditService) *ClassroomService {
+	return &ClassroomService{repo: repo, schedules: schedules, substitutions: substitutions, audit: audit}
 }
 
 func (s *ClassroomService) GetAll(ctx context.Context) ([]*models.Classroom, error) {
 	return s.repo.GetAll(ctx)
 }
 
-func (s *ClassroomService) Create(ctx context.Context, name string) (*models.CreateClassroomResponse, error) {
-	classroom, err := s.repo.Create(ctx, name)
+func (s *ClassroomService) Create(ctx context.Context, req models.CreateClassroomRequest) (*models.CreateClassroomResponse, error) {
+	if err := validateClassroom(req); err != nil {
+		return nil, err
+	}
+
+	classroom, err := s.repo.Create(ctx, models.Classroom{
+		Name:      req.Name,
+		Capacity:  req.Capacity,
+		Equipment: req.Equipment,
+		Building:  req.Building,
+		Floor:     req.Floor,
+	})
 	if err != nil {
 		return nil, err
 	}
+	s.audit.Record(ctx, models.AuditActionCreate, models.AuditEntityClassroom, &classroom.ID, nil, classroom)
 
 	return &models.CreateClassroomResponse{
-		ID:   classroom.ID,
-		Name: classroom.Name,
+		ID:        classroom.ID,
+		Name:      classroom.Name,
+		Capacity:  classroom.Capacity,
+		Equipment: classroom.Equipment,
+		Building:  classroom.Building,
+		Floor:     classroom.Floor,
 	}, nil
 }
 
+func (s *ClassroomService) Update(ctx context.Context, id uuid.UUID, req models.CreateClassroomRequest) (*models.Classroom, error) {
+	if err := validateClassroom(req); err != nil {
+		return nil, err
+	}
+
+	before, err := s.repo.GetByID(ctx, id)
+	if err != nil {
+		return nil, err
+	}
+	err = s.repo.Update(ctx, models.Classroom{
+		ID:        id,
+		Name:      req.Name,
+		Capacity:  req.Capacity,
+		Equipment: req.Equipment,
+		Building:  req.Building,
+		Floor:     req.Floor,
+	})
+	if err != nil {
+		return nil, err
+	}
+	classroom, err := s.repo.GetByID(ctx, id)
+	if err != nil {
+		return nil, err
+	}
+	s.audit.Record(ctx, models.AuditActionUpdate, models.AuditEntityClassroom, &id, before, classroom)
+	return classroom, nil
+}
+
 func (s *ClassroomService) Delete(ctx context.Context, id uuid.UUID) error {
-	return s.repo.Delete(ctx, id)
+	before, err := s.repo.GetByID(ctx, id)
+	if err != nil {
+		return err
+	}
+	if err := s.repo.Delete(ctx, id); err != nil {
+		return err
+	}
+	s.audit.Record(ctx, models.AuditActionDelete, models.AuditEntityClassroom, &id, before, nil)
+	return nil
+}
+
+// FreeRooms returns classrooms not used by the user's active schedule at the time of the lesson range
+// that hold the students and have the equipment, nearest to the teacher's own room first
+func (s *ClassroomService) FreeRooms(ctx context.Context, userID uuid.UUID, filter models.FreeRoomsFilter) ([]*models.Classroom, error) {
+	if filter.DayOfWeek < 1 || filter.DayOfWeek > 7 || filter.LessonFrom < 1 || filter.LessonTo < filter.LessonFrom {
+		return nil, fmt.Errorf("%w: dayOfWeek and lesson range are required", ErrInvalidRoomFilter)
+	}
+	if err := validateEquipment(filter.Equipment, ErrInvalidRoomFilter); err != nil {
+		return nil, err
+	}
+
+	busy, err := s.busyRooms(ctx, userID, filter)
+	if err != nil {
+		return nil, err
+	}
+
+	rooms, err := s.repo.GetAll(ctx)
+	if err != nil {
+		return nil, err
+	}
+	free := make([]*models.Classroom, 0)
+	for _, r := range rooms {
+		if busy[r.ID] {
+			continue
+		}
+		if filter.MinCapacity > 0 && (r.Capacity == nil || *r.Capacity < filter.MinCapacity) {
+			continue
+		}
+		if !hasEquipment(r.Equipment, filter.Equipment) {
+			continue
+		}
+		free = append(free, r)
+	}
+
+	if filter.TeacherID != nil {
+		home, err := s.repo.GetTeacherRoom(ctx, *filter.TeacherID)
+		if err != nil {
+			return nil, err
+		}
+		if home != nil {
+			sort.SliceStable(free, func(i, j int) bool {
+				return roomDistance(home, free[i]) < roomDistance(home, free[j])
+			})
+		}
+	}
+	return free, nil
+}
+
+// busyRooms collects the rooms of lessons given on the filter's day of the date's week at a time overlapping
+// the lesson range. The range is timed by the bells of the class's shift (the common bells without a class),
+// the lessons by those of their own classes, so lessons of another shift clash by their real times.
+// Overrides of the date apply: cancelled lessons free their rooms, substitutions and moves take theirs.
+func (s *ClassroomService) busyRooms(ctx context.Context, userID uuid.UUID, filter models.FreeRoomsFilter) (map[uuid.UUID]bool, error) {
+	date := filter.Date.WeekStart().AddDays(filter.DayOfWeek - 1)
+	days, err := s.substitutions.GetCalendar(ctx, userID, date, date)
+	if err != nil {
+		return nil, err
+	}
+	data, err := s.schedules.GetPlanningData(ctx, nil)
+	if err != nil {
+		return nil, err
+	}
+	checker := newConflictChecker(data)
+
+	var classIDs []uuid.UUID
+	if filter.ClassID != nil {
+		classIDs = []uuid.UUID{*filter.ClassID}
+	}
+	var wanted []lessonWindow
+	for n := filter.LessonFrom; n <= filter.LessonTo; n++ {
+		w := checker.window(filter.DayOfWeek, n, classIDs)
+		// The calendar has already picked the lessons of the date's week
+		w.weekPattern = models.WeekEvery
+		wanted = append(wanted, w)
+	}
+
+	busy := make(map[uuid.UUID]bool)
+	for _, slot := range days[0].Slots {
+		for _, l := range slot.Lessons {
+			if l.Override != nil && l.Override.Action == models.OverrideCancel {
+				continue
+			}
+			p := checker.fromLesson(filter.DayOfWeek, slot.LessonNumber, l)
+			p.window.weekPattern = models.WeekEvery
+			for _, w := range wanted {
+				if w.overlaps(p.window) {
+					for _, id := range p.rooms {
+						busy[id] = true
+					}
+					break
+				}
+			}
+		}
+	}
+	return busy, nil
+}
+
+// roomDistance estimates how far a room is from the home room: the room itself is nearest,
+// then rooms on the same floor, then by floor difference; another building or an unknown location is farther than any floor
+func roomDistance(home, room *models.Classroom) int {
+	const (
+		unknownLocation = 500
+		otherBuilding   = 1000
+	)
+	if room.ID == home.ID {
+		return 0
+	}
+	if home.Building != nil && room.Building != nil && *home.Building != *room.Building {
+		return otherBuilding
+	}
+	if home.Floor == nil || room.Floor == nil {
+		return unknownLocation
+	}
+	d := *home.Floor - *room.Floor
+	if d < 0 {
+		d = -d
+	}
+	return 1 + d*10
+}
+
+func hasEquipment(have, want []string) bool {
+	for _, tag := range want {
+		if !containsString(have, tag) {
+			return false
+		}
+	}
+	return true
+}
+
+func validateClassroom(req models.CreateClassroomRequest) error {
+	if req.Name == "" {
+		return fmt.Errorf("%w: name is required", ErrInvalidClassroom)
+	}
+	if req.Capacity != nil && *req.Capacity < 1 {
+		return fmt.Errorf("%w: capacity must be positive", ErrInvalidClassroom)
+	}
+	return validateEquipment(req.Equipment, ErrInvalidClassroom)
+}
+
+// validateEquipment checks every tag is known, wrapping kind into the error
+func validateEquipment(tags []string, kind error) error {
+	for _, tag := range tags {
+		if !models.ValidEquipment(tag) {
+			return fmt.Errorf("%w: unknown equipment %q", kind, tag)
+		}
+	}
+	return nil
 }
@@ -0,0 +1,147 @@
+package services
+
+import (
+	"archive/zip"
+	"bytes"
+	"context"
+	"database/sql"
+	"errors"
+	"fmt"
+	"strings"
+
+	"github.com/google/uuid"
+	"github.com/nikomkinds/SchoolSchedule/internal/models"
+)
+
+// ErrInvalidExport is returned for an unknown export target or page size
+var ErrInvalidExport = errors.New("invalid export request")
+
+const xlsxContentType = "application/vnd.openxmlformats-officedocument.spreadsheetml.sheet"
+
+type ExportService interface {
+	// ExportPDF prints the timetables of a schedule: of one class, teacher or room, or of all of them as one
+	// multi-page PDF or a ZIP of PDFs. sql.ErrNoRows is returned if the requested one has no lessons in it.
+	ExportPDF(ctx context.Context, scheduleID uuid.UUID, req models.TimetableExportRequest) (*models.ExportFile, error)
+	// ExportXLSX renders a schedule as a workbook: the grid of all classes, the weekly hours of teachers
+	// and classes, and a sheet per teacher
+	ExportXLSX(ctx context.Context, scheduleID uuid.UUID) (*models.ExportFile, error)
+}
+
+type exportService struct {
+	schedules ScheduleService
+}
+
+func NewExportService(schedules ScheduleService) ExportService {
+	return &exportService{schedules: schedules}
+}
+
+func (s *exportService) ExportPDF(ctx context.Context, scheduleID uuid.UUID, req models.TimetableExportRequest) (*models.ExportFile, error) {
+	if req.PageSize == "" {
+		req.PageSize = models.PageSizeA4
+	}
+	if _, ok := pdfScale[req.PageSize]; !ok {
+		return nil, fmt.Errorf("%w: unknown page size %q", ErrInvalidExport, req.PageSize)
+	}
+	schedule, grid, views, err := s.timetables(ctx, scheduleID, req.Target, req.ID)
+	if err != nil {
+		return nil, err
+	}
+
+	if !req.Zip || req.ID != nil {
+		data, err := renderTimetablePDF(req.PageSize, schedule.Name, req.Target, grid, views)
+		if err != nil {
+			return nil, err
+		}
+		name := schedule.Name
+		if req.ID != nil {
+			name = views[0].Name
+		}
+		return &models.ExportFile{Name: exportFileName(name, "pdf"), ContentType: "application/pdf", Data: data}, nil
+	}
+
+	var buf bytes.Buffer
+	archive := zip.NewWriter(&buf)
+	used := make(map[string]bool)
+	for _, v := range views {
+		data, err := renderTimetablePDF(req.PageSize, schedule.Name, req.Target, grid, []*timetableView{v})
+		if err != nil {
+			return nil, err
+		}
+		name := exportFileName(v.Name, "pdf")
+		for i := 2; used[name]; i++ {
+			name = exportFileName(fmt.Sprintf("%s (%d)", v.Name, i), "pdf")
+		}
+		used[name] = true
+		w, err := archive.Create(name)
+		if err != nil {
+			return nil, err
+		}
+		if _, err := w.Write(data); err != nil {
+			return nil, err
+		}
+	}
+	if err := archive.Close(); err != nil {
+		return nil, err
+	}
+	return &models.ExportFile{Name: exportFileName(schedule.Name, "zip"), ContentType: "application/zip", Data: buf.Bytes()}, nil
+}
+
+func (s *exportService) ExportXLSX(ctx context.Context, scheduleID uuid.UUID) (*models.ExportFile, error) {
+	schedule, err := s.schedules.GetScheduleContent(ctx, scheduleID, models.Today())
+	if err != nil {
+		return nil, err
+	}
+	data, err := renderTimetableXLSX(
+		schedule.Name,
+		newTimetableGrid(schedule.ScheduleSlots),
+		timetableViews(schedule.ScheduleSlots, models.ExportTargetClass),
+		timetableViews(schedule.ScheduleSlots, models.ExportTargetTeacher),
+	)
+	if err != nil {
+		return nil, err
+	}
+	return &models.ExportFile{Name: exportFileName(schedule.Name, "xlsx"), ContentType: xlsxContentType, Data: data}, nil
+}
+
+// timetables loads the schedule and splits it into the timetables of the target, only the one of id if set
+func (s *exportService) timetables(ctx context.Context, scheduleID uuid.UUID, target string, id *uuid.UUID) (*models.ScheduleContent, timetableGrid, []*timetableView, error) {
+	switch target {
+	case models.ExportTargetClass, models.ExportTargetTeacher, models.ExportTargetRoom:
+	default:
+		return nil, timetableGrid{}, nil, fmt.Errorf("%w: target must be class, teacher or room", ErrInvalidExport)
+	}
+
+	schedule, err := s.schedules.GetScheduleContent(ctx, scheduleID, models.Today())
+	if err != nil {
+		return nil, timetableGrid{}, nil, err
+	}
+	grid := newTimetableGrid(schedule.ScheduleSlots)
+	views := timetableViews(schedule.ScheduleSlots, target)
+	if id != nil {
+		var found []*timetableView
+		for _, v := range views {
+			if v.ID == *id {
+				found = append(found, v)
+			}
+		}
+		views = found
+	}
+	if len(views) == 0 {
+		return nil, timetableGrid{}, nil, sql.ErrNoRows
+	}
+	return schedule, grid, views, nil
+}
+
+// exportFileName makes a file name of a timetable name, replacing characters not allowed in file names
+func exportFileName(name, ext string) string {
+	name = strings.Map(func(r rune) rune {
+		if strings.ContainsRune(`/\:*?"<>|`, r) || r < ' ' {
+			return '_'
+		}
+		return r
+	}, strings.TrimSpace(name))
+	if name == "" {
+		name = "timetable"
+	}
+	return name + "." + ext
+}
@@ -0,0 +1,115 @@
+package services
+
+import (
+	"bytes"
+	"encoding/csv"
+	"fmt"
+	"path/filepath"
+	"strings"
+
+	"github.com/xuri/excelize/v2"
+)
+
+// maxImportRows limits the data rows of an imported file
+const maxImportRows = 5000
+
+// importColumn is a column of an import file
+type importColumn struct {
+	name     string
+	required bool
+}
+
+// importTable is the content of an import file: rows of cells under known columns
+type importTable struct {
+	columns map[string]int // Column name → index of its cells
+	rows    [][]string     // Rows after the header, the first one being row 2 of the file
+}
+
+// readImportTable reads the first sheet of an XLSX file, or a CSV file separated by commas, semicolons
+// or tabs, and matches its header with the columns: case, spaces, "_" and "-" are ignored
+func readImportTable(fileName string, data []byte, columns []importColumn) (*importTable, error) {
+	var (
+		rows [][]string
+		err  error
+	)
+	switch ext := strings.ToLower(filepath.Ext(fileName)); {
+	case ext == ".xlsx" || (ext != ".csv" && bytes.HasPrefix(data, []byte("PK\x03\x04"))):
+		rows, err = readXLSXRows(data)
+	default:
+		rows, err = readCSVRows(data)
+	}
+	if err != nil {
+		return nil, fmt.Errorf("%w: %v", ErrInvalidImport, err)
+	}
+	if len(rows) == 0 {
+		return nil, fmt.Errorf("%w: the file is empty", ErrInvalidImport)
+	}
+	if len(rows)-1 > maxImportRows {
+		return nil, fmt.Errorf("%w: more than %d rows", ErrInvalidImport, maxImportRows)
+	}
+
+	known := make(map[string]string, len(columns))
+	for _, col := range columns {
+		known[columnKey(col.name)] = col.name
+	}
+	table := &importTable{columns: make(map[string]int), rows: rows[1:]}
+	for i, title := range rows[0] {
+		if strings.TrimSpace(title) == "" {
+			continue
+		}
+		name, ok := known[columnKey(title)]
+		if !ok {
+			return nil, fmt.Errorf("%w: unknown column %q", ErrInvalidImport, title)
+		}
+		if _, ok := table.columns[name]; ok {
+			return nil, fmt.Errorf("%w: column %q appears twice", ErrInvalidImport, title)
+		}
+		table.columns[name] = i
+	}
+	for _, col := range columns {
+		if _, ok := table.columns[col.name]; col.required && !ok {
+			return nil, fmt.Errorf("%w: column %q is required", ErrInvalidImport, col.name)
+		}
+	}
+	return table, nil
+}
+
+func columnKey(title string) string {
+	return strings.ToLower(strings.Map(func(r rune) rune {
+		if r == ' ' || r == '_' || r == '-' || r == '\uFEFF' {
+			return -1
+		}
+		return r
+	}, title))
+}
+
+func readXLSXRows(data []byte) ([][]string, error) {
+	f, err := excelize.OpenReader(bytes.NewReader(data))
+	if err != nil {
+		return nil, err
+	}
+	defer f.Close()
+	return f.GetRows(f.GetSheetName(0))
+}
+
+func readCSVRows(data []byte) ([][]string, error) {
+	data = bytes.TrimPrefix(data, []byte("\uFEFF"))
+	r := csv.NewReader(bytes.NewReader(data))
+	r.Comma = csvSeparator(data)
+	r.FieldsPerRecord = -1
+	r.LazyQuotes = true
+	return r.ReadAll()
+}
+
+// csvSeparator guesses the separator from the header: spreadsheets in a Russian locale save CSV
+// with semicolons
+func csvSeparator(data []byte) rune {
+	header, _, _ := bytes.Cut(data, []byte("\n"))
+	best, count := ',', bytes.Count(header, []byte(","))
+	for _, sep := range []rune{';', '\t'} {
+		if n := bytes.Count(header, []byte(string(sep))); n > count {
+			best, count = sep, n
+		}
+	}
+	return best
+}
@@ -0,0 +1,136 @@
+package services
+
+import (
+	"context"
+	"errors"
+	"reflect"
+	"testing"
+
+	"github.com/google/uuid"
+	"github.com/nikomkinds/SchoolSchedule/internal/models"
+	"github.com/nikomkinds/SchoolSchedule/internal/repositories"
+)
+
+func TestCSVSeparator(t *testing.T) {
+	tests := []struct {
+		name string
+		data string
+		want rune
+	}{
+		{name: "comma", data: "name,capacity,floor\n101,30,1\n", want: ','},
+		{name: "semicolon of a Russian locale", data: "name;capacity;floor\n101;30;1\n", want: ';'},
+		{name: "tab", data: "name\tcapacity\n101\t30\n", want: '\t'},
+		{name: "only the header counts", data: "name;capacity\n\"Лаб. 2, корпус Б, 3 этаж\";30\n", want: ';'},
+		{name: "a tie is a comma", data: "name,a;b\n", want: ','},
+		{name: "single column", data: "name\n101\n", want: ','},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := csvSeparator([]byte(tt.data)); got != tt.want {
+				t.Errorf("got %q, want %q", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestReadImportTable(t *testing.T) {
+	columns := importColumns[models.ImportClassrooms]
+
+	tests := []struct {
+		name    string
+		data    string
+		columns map[string]int
+		rows    [][]string
+		wantErr bool
+	}{
+		{
+			name:    "quoted fields keep separators, quotes and line breaks",
+			data:    "name;equipment;building\n\"Лаб. 2; физика\";\"lab\nprojector\";\"Корпус \"\"Б\"\"\"\n",
+			columns: map[string]int{"name": 0, "equipment": 1, "building": 2},
+			rows:    [][]string{{"Лаб. 2; физика", "lab\nprojector", `Корпус "Б"`}},
+		},
+		{
+			name:    "BOM and header spelling are ignored, blank titles skipped",
+			data:    "\uFEFFName,,Building\r\n101,x,А\r\n",
+			columns: map[string]int{"name": 0, "building": 2},
+			rows:    [][]string{{"101", "x", "А"}},
+		},
+		{
+			name:    "rows may be short",
+			data:    "name,capacity,floor\n101\n102,25\n",
+			columns: map[string]int{"name": 0, "capacity": 1, "floor": 2},
+			rows:    [][]string{{"101"}, {"102", "25"}},
+		},
+		{name: "empty file", data: "", wantErr: true},
+		{name: "unknown column", data: "name,color\n", wantErr: true},
+		{name: "column twice", data: "name,Name\n", wantErr: true},
+		{name: "required column missing", data: "capacity,floor\n30,1\n", wantErr: true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			table, err := readImportTable("rooms.csv", []byte(tt.data), columns)
+			if tt.wantErr {
+				if !errors.Is(err, ErrInvalidImport) {
+					t.Fatalf("got %v, want ErrInvalidImport", err)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatal(err)
+			}
+			if !reflect.DeepEqual(table.columns, tt.columns) {
+				t.Errorf("columns %v, want %v", table.columns, tt.columns)
+			}
+			if !reflect.DeepEqual(table.rows, tt.rows) {
+				t.Errorf("rows %q, want %q", table.rows, tt.rows)
+			}
+		})
+	}
+}
+
+type fakeImportClassrooms struct {
+	repositories.ClassroomRepository
+	rooms []*models.Classroom
+}
+
+func (f *fakeImportClassrooms) GetAll(ctx context.Context) ([]*models.Classroom, error) {
+	return f.rooms, nil
+}
+
+func TestImportRowErrors(t *testing.T) {
+	s := &importService{classroomRepo: &fakeImportClassrooms{rooms: []*models.Classroom{{ID: uuid.New(), Name: "Актовый зал"}}}}
+	data := "name;capacity;equipment;floor\n" +
+		"101;30;projector;1\n" + // row 2
+		";;;\n" + // row 3 is blank and skipped, but still counted
+		"102;тридцать;lab;1\n" + // row 4
+		"\"Лаб. 2; физика\";0;lab, telescope;-11\n" + // row 5
+		"  актовый   зал ;;;\n" + // row 6
+		"101;20;;2\n" + // row 7
+		";10;;\n" // row 8
+
+	report, err := s.Import(context.Background(), models.ImportClassrooms, "rooms.csv", []byte(data), true)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	want := []models.ImportError{
+		{Row: 4, Column: "capacity", Message: `"тридцать": expected a whole number of at least 1`},
+		{Row: 5, Column: "capacity", Message: `"0": expected a whole number of at least 1`},
+		{Row: 5, Column: "equipment", Message: `unknown equipment "telescope"`},
+		{Row: 5, Column: "floor", Message: `"-11": expected a whole number of at least -10`},
+		{Row: 6, Column: "name", Message: `"актовый зал" already exists`},
+		{Row: 7, Column: "name", Message: `"101" duplicates row 2`},
+		{Row: 8, Column: "name", Message: "name is required"},
+	}
+	if report.Rows != 6 {
+		t.Errorf("read %d rows, want 6", report.Rows)
+	}
+	if !reflect.DeepEqual(report.Errors, want) {
+		t.Errorf("errors:\n%+v\nwant:\n%+v", report.Errors, want)
+	}
+	if report.Created != 0 {
+		t.Errorf("created %d on a dry run", report.Created)
+	}
+}
@@ -0,0 +1,566 @@
+package services
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"strconv"
+	"strings"
+
+	"github.com/google/uuid"
+	"github.com/nikomkinds/SchoolSchedule/internal/models"
+	"github.com/nikomkinds/SchoolSchedule/internal/repositories"
+)
+
+var (
+	// ErrInvalidImport is returned for an unknown entity type or a file that cannot be read as a table
+	// of the entity's columns
+	ErrInvalidImport = errors.New("invalid import file")
+	// ErrImportRejected is returned when rows of a file to apply have errors; nothing is imported then
+	ErrImportRejected = errors.New("the file has errors, nothing was imported")
+)
+
+// importColumns are the columns of the import file of each entity type. Lists in a cell are separated
+// by ";" or line breaks, the parts of a list item by ":".
+var importColumns = map[string][]importColumn{
+	models.ImportClassrooms: {
+		{name: "name", required: true}, {name: "capacity"}, {name: "equipment"}, {name: "building"}, {name: "floor"},
+	},
+	models.ImportSubjects: {
+		{name: "name", required: true}, {name: "shortName"}, {name: "equipment"}, {name: "classrooms"},
+	},
+	models.ImportClasses: {
+		{name: "name", required: true}, {name: "grade"}, {name: "students"}, {name: "subjects"}, // "Математика:5; Английский язык:3:2"
+	},
+	models.ImportTeachers: {
+		{name: "lastName", required: true}, {name: "firstName", required: true}, {name: "patronymic"}, {name: "workload"},
+		{name: "subjects"},   // "Математика; Физика:6" with the preferred hours
+		{name: "classHours"}, // "5А:Математика:5; 6Б:Физика:2"
+	},
+}
+
+type ImportService interface {
+	// Import reads a CSV or XLSX file of teachers, classes, subjects or classrooms and validates every row:
+	// values, references to existing entities by name and duplicates within the file or of existing
+	// entities. Unless it is a dry run, the entities are then created in one transaction, or none of
+	// them if any row has errors (ErrImportRejected, with the report).
+	Import(ctx context.Context, entity, fileName string, data []byte, dryRun bool) (*models.ImportReport, error)
+}
+
+type importService struct {
+	repo          repositories.ImportRepository
+	teacherRepo   repositories.TeacherRepository
+	classRepo     repositories.ClassRepository
+	subjectRepo   repositories.SubjectRepository
+	classroomRepo repositories.ClassroomRepository
+	audit         AuditService
+}
+
+func NewImportService(
+	repo repositories.ImportRepository,
+	teacherRepo repositories.TeacherRepository,
+	classRepo repositories.ClassRepository,
+	subjectRepo repositories.SubjectRepository,
+	classroomRepo repositories.ClassroomRepository,
+	audit AuditService,
+) ImportService {
+	return &importService{
+		repo:          repo,
+		teacherRepo:   teacherRepo,
+		classRepo:     classRepo,
+		subjectRepo:   subjectRepo,
+		classroomRepo: classroomRepo,
+		audit:         audit,
+	}
+}
+
+func (s *importService) Import(ctx context.Context, entity, fileName string, data []byte, dryRun bool) (*models.ImportReport, error) {
+	columns, ok := importColumns[entity]
+	if !ok {
+		return nil, fmt.Errorf("%w: unknown entity %q", ErrInvalidImport, entity)
+	}
+	table, err := readImportTable(fileName, data, columns)
+	if err != nil {
+		return nil, err
+	}
+
+	report := &models.ImportReport{Entity: entity, DryRun: dryRun, Errors: []models.ImportError{}}
+	switch entity {
+	case models.ImportClassrooms:
+		rooms, err := s.readClassrooms(ctx, table, report)
+		if err != nil {
+			return nil, err
+		}
+		if apply, err := readyToApply(report); !apply {
+			return report, err
+		}
+		if err := s.repo.CreateClassrooms(ctx, rooms); err != nil {
+			return nil, err
+		}
+		for i := range rooms {
+			s.audit.Record(ctx, models.AuditActionCreate, models.AuditEntityClassroom, &rooms[i].ID, nil, rooms[i])
+		}
+		report.Created = len(rooms)
+	case models.ImportSubjects:
+		subjects, err := s.readSubjects(ctx, table, report)
+		if err != nil {
+			return nil, err
+		}
+		if apply, err := readyToApply(report); !apply {
+			return report, err
+		}
+		if err := s.repo.CreateSubjects(ctx, subjects); err != nil {
+			return nil, err
+		}
+		for i := range subjects {
+			s.audit.Record(ctx, models.AuditActionCreate, models.AuditEntitySubject, &subjects[i].ID, nil, subjects[i])
+		}
+		report.Created = len(subjects)
+	case models.ImportClasses:
+		classes, err := s.readClasses(ctx, table, report)
+		if err != nil {
+			return nil, err
+		}
+		if apply, err := readyToApply(report); !apply {
+			return report, err
+		}
+		if err := s.repo.CreateClasses(ctx, classes); err != nil {
+			return nil, err
+		}
+		for i := range classes {
+			s.audit.Record(ctx, models.AuditActionCreate, models.AuditEntityClass, &classes[i].ID, nil, classes[i])
+		}
+		report.Created = len(classes)
+	case models.ImportTeachers:
+		teachers, err := s.readTeachers(ctx, table, report)
+		if err != nil {
+			return nil, err
+		}
+		if apply, err := readyToApply(report); !apply {
+			return report, err
+		}
+		if err := s.repo.CreateTeachers(ctx, teachers); err != nil {
+			return nil, err
+		}
+		for i := range teachers {
+			s.audit.Record(ctx, models.AuditActionCreate, models.AuditEntityTeacher, &teachers[i].ID, nil, teachers[i])
+		}
+		report.Created = len(teachers)
+	}
+	return report, nil
+}
+
+// readyToApply tells whether the validated rows are to be created: not on a dry run, and only if
+// none of them has errors
+func readyToApply(report *models.ImportReport) (bool, error) {
+	switch {
+	case report.DryRun:
+		return false, nil
+	case len(report.Errors) > 0:
+		return false, ErrImportRejected
+	}
+	return true, nil
+}
+
+func (s *importService) readClassrooms(ctx context.Context, table *importTable, report *models.ImportReport) ([]models.Classroom, error) {
+	existing, err := s.classroomNames(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	var rooms []models.Classroom
+	seen := make(map[string]int)
+	for _, row := range table.importRows(report) {
+		room := models.Classroom{
+			Name:      row.required("name"),
+			Capacity:  row.intValue("capacity", 1),
+			Equipment: row.equipment("equipment"),
+			Floor:     row.intValue("floor", -10),
+		}
+		if building := row.get("building"); building != "" {
+			room.Building = &building
+		}
+		row.unique("name", room.Name, existing, seen)
+		if !row.failed {
+			rooms = append(rooms, room)
+		}
+	}
+	return rooms, nil
+}
+
+func (s *importService) readSubjects(ctx context.Context, table *importTable, report *models.ImportReport) ([]models.Subject, error) {
+	existing, err := s.subjectNames()
+	if err != nil {
+		return nil, err
+	}
+	rooms, err := s.classroomNames(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	var out []models.Subject
+	seen := make(map[string]int)
+	for _, row := range table.importRows(report) {
+		subj := models.Subject{
+			Name:              row.required("name"),
+			RequiredEquipment: row.equipment("equipment"),
+		}
+		if short := row.get("shortName"); short != "" {
+			subj.ShortName = &short
+		}
+		for _, name := range splitList(row.get("classrooms")) {
+			if id, ok := row.ref("classrooms", "classroom", rooms, name); ok && !containsID(subj.ClassroomIDs, id) {
+				subj.ClassroomIDs = append(subj.ClassroomIDs, id)
+			}
+		}
+		row.unique("name", subj.Name, existing, seen)
+		if !row.failed {
+			out = append(out, subj)
+		}
+	}
+	return out, nil
+}
+
+func (s *importService) readClasses(ctx context.Context, table *importTable, report *models.ImportReport) ([]models.Class, error) {
+	existing, err := s.classNames(ctx)
+	if err != nil {
+		return nil, err
+	}
+	subjects, err := s.subjectNames()
+	if err != nil {
+		return nil, err
+	}
+
+	var out []models.Class
+	seen := make(map[string]int)
+	for _, row := range table.importRows(report) {
+		class := models.Class{Name: row.required("name"), TotalStudents: row.intValue("students", 1)}
+		switch grade := row.intValue("grade", 1); {
+		case grade != nil:
+			class.GradeLevel = *grade
+		case !row.has("grade"):
+			// Like a class created by name: "10Б" is of grade 10
+			class.GradeLevel, _ = extractGrade(class.Name)
+		}
+		if class.Name != "" && (class.GradeLevel < 1 || class.GradeLevel > 11) {
+			row.fail("grade", "grade must be between 1 and 11, set it if the name has none")
+		}
+
+		for _, item := range splitList(row.get("subjects")) {
+			parts := splitItem(item)
+			if len(parts) < 2 || len(parts) > 3 {
+				row.fail("subjects", "%q: expected subject:hours or subject:hours:groups", item)
+				continue
+			}
+			id, ok := row.ref("subjects", "subject", subjects, parts[0])
+			hours, err := parseHours(parts[1])
+			if err != nil || !validHours(hours) {
+				row.fail("subjects", "%q: hours must be a positive multiple of 0.5", item)
+				ok = false
+			}
+			assignment := models.ClassSubjectAssignment{Subject: models.Subject{ID: id, Name: parts[0]}, HoursPerWeek: hours}
+			if len(parts) == 3 {
+				groups, err := strconv.Atoi(parts[2])
+				if err != nil || groups < 2 {
+					row.fail("subjects", "%q: groups must be a whole number of at least 2", item)
+					ok = false
+				}
+				assignment.Split = &models.ClassSubjectSplit{GroupsCount: groups}
+			}
+			if ok && hasSubject(class.Subjects, id) {
+				row.fail("subjects", "%q: the subject is listed twice", item)
+				ok = false
+			}
+			if ok {
+				class.Subjects = append(class.Subjects, assignment)
+			}
+		}
+		row.unique("name", class.Name, existing, seen)
+		if !row.failed {
+			out = append(out, class)
+		}
+	}
+	return out, nil
+}
+
+func (s *importService) readTeachers(ctx context.Context, table *importTable, report *models.ImportReport) ([]models.Teacher, error) {
+	teachers, err := s.teacherRepo.GetAllLight(ctx)
+	if err != nil {
+		return nil, err
+	}
+	existing := make(nameIndex)
+	for _, t := range teachers {
+		existing.add(teacherFullName(t.LastName, t.FirstName, t.Patronymic), t.ID)
+	}
+	subjects, err := s.subjectNames()
+	if err != nil {
+		return nil, err
+	}
+	classes, err := s.classNames(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	var out []models.Teacher
+	seen := make(map[string]int)
+	for _, row := range table.importRows(report) {
+		t := models.Teacher{LastName: row.required("lastName"), FirstName: row.required("firstName")}
+		if patronymic := row.get("patronymic"); patronymic != "" {
+			t.Patronymic = &patronymic
+		}
+		if workload := row.intValue("workload", 0); workload != nil {
+			t.WorkloadHoursPerWeek = *workload
+		}
+
+		for _, item := range splitList(row.get("subjects")) {
+			parts := splitItem(item)
+			if len(parts) > 2 {
+				row.fail("subjects", "%q: expected subject or subject:hours", item)
+				continue
+			}
+			id, ok := row.ref("subjects", "subject", subjects, parts[0])
+			assignment := models.TeacherSubjectAssignment{Subject: models.Subject{ID: id, Name: parts[0]}}
+			if len(parts) == 2 {
+				hours, err := strconv.Atoi(parts[1])
+				if err != nil || hours < 1 {
+					row.fail("subjects", "%q: hours must be a positive whole number", item)
+					ok = false
+				}
+				assignment.HoursPerWeek = &hours
+			}
+			for _, other := range t.Subjects {
+				if ok && other.Subject.ID == id {
+					row.fail("subjects", "%q: the subject is listed twice", item)
+					ok = false
+				}
+			}
+			if ok {
+				t.Subjects = append(t.Subjects, assignment)
+			}
+		}
+
+		for _, item := range splitList(row.get("classHours")) {
+			parts := splitItem(item)
+			if len(parts) != 3 {
+				row.fail("classHours", "%q: expected class:subject:hours", item)
+				continue
+			}
+			classID, classOK := row.ref("classHours", "class", classes, parts[0])
+			subjectID, subjectOK := row.ref("classHours", "subject", subjects, parts[1])
+			hours, err := parseHours(parts[2])
+			if err != nil || !validHours(hours) {
+				row.fail("classHours", "%q: hours must be a positive multiple of 0.5", item)
+				continue
+			}
+			if classOK && subjectOK {
+				t.ClassHours = append(t.ClassHours, models.TeacherClassHour{
+					Class:   models.Class{ID: classID, Name: parts[0]},
+					Subject: models.Subject{ID: subjectID, Name: parts[1]},
+					Hours:   hours,
+				})
+			}
+		}
+
+		if t.LastName != "" && t.FirstName != "" {
+			row.unique("", teacherFullName(t.LastName, t.FirstName, t.Patronymic), existing, seen)
+		}
+		if !row.failed {
+			out = append(out, t)
+		}
+	}
+	return out, nil
+}
+
+func (s *importService) classroomNames(ctx context.Context) (nameIndex, error) {
+	rooms, err := s.classroomRepo.GetAll(ctx)
+	if err != nil {
+		return nil, err
+	}
+	names := make(nameIndex)
+	for _, r := range rooms {
+		names.add(r.Name, r.ID)
+	}
+	return names, nil
+}
+
+func (s *importService) classNames(ctx context.Context) (nameIndex, error) {
+	classes, err := s.classRepo.GetAll(ctx, nil)
+	if err != nil {
+		return nil, err
+	}
+	names := make(nameIndex)
+	for _, c := range classes {
+		names.add(c.Name, c.ID)
+	}
+	return names, nil
+}
+
+func (s *importService) subjectNames() (nameIndex, error) {
+	subjects, err := s.subjectRepo.GetAll()
+	if err != nil {
+		return nil, err
+	}
+	names := make(nameIndex)
+	for _, subj := range subjects {
+		names.add(subj.Name, subj.ID)
+	}
+	return names, nil
+}
+
+func hasSubject(assignments []models.ClassSubjectAssignment, id uuid.UUID) bool {
+	for _, a := range assignments {
+		if a.Subject.ID == id {
+			return true
+		}
+	}
+	return false
+}
+
+func teacherFullName(last, first string, patronymic *string) string {
+	name := last + " " + first
+	if patronymic != nil {
+		name += " " + *patronymic
+	}
+	return name
+}
+
+// nameIndex finds entities by name ignoring case and repeated spaces; a name may be taken by several
+type nameIndex map[string][]uuid.UUID
+
+func nameKey(name string) string {
+	return strings.ToLower(strings.Join(strings.Fields(name), " "))
+}
+
+func (ix nameIndex) add(name string, id uuid.UUID) {
+	key := nameKey(name)
+	ix[key] = append(ix[key], id)
+}
+
+// importRow reads the cells of a data row, recording the problems in the report
+type importRow struct {
+	table  *importTable
+	report *models.ImportReport
+	number int
+	cells  []string
+	failed bool
+}
+
+// importRows returns the rows that are not blank
+func (t *importTable) importRows(report *models.ImportReport) []*importRow {
+	var rows []*importRow
+	for i, cells := range t.rows {
+		if strings.TrimSpace(strings.Join(cells, "")) == "" {
+			continue
+		}
+		rows = append(rows, &importRow{table: t, report: report, number: i + 2, cells: cells})
+	}
+	report.Rows = len(rows)
+	return rows
+}
+
+func (r *importRow) fail(column, format string, args ...interface{}) {
+	r.failed = true
+	r.report.Errors = append(r.report.Errors, models.ImportError{Row: r.number, Column: column, Message: fmt.Sprintf(format, args...)})
+}
+
+func (r *importRow) has(column string) bool {
+	return r.get(column) != ""
+}
+
+func (r *importRow) get(column string) string {
+	i, ok := r.table.columns[column]
+	if !ok || i >= len(r.cells) {
+		return ""
+	}
+	return strings.TrimSpace(r.cells[i])
+}
+
+func (r *importRow) required(column string) string {
+	value := strings.Join(strings.Fields(r.get(column)), " ")
+	if value == "" {
+		r.fail(column, "%s is required", column)
+	}
+	return value
+}
+
+// intValue reads an optional whole number not less than min
+func (r *importRow) intValue(column string, min int) *int {
+	raw := r.get(column)
+	if raw == "" {
+		return nil
+	}
+	n, err := strconv.Atoi(raw)
+	if err != nil || n < min {
+		r.fail(column, "%q: expected a whole number of at least %d", raw, min)
+		return nil
+	}
+	return &n
+}
+
+// equipment reads equipment tags separated by commas, semicolons or spaces
+func (r *importRow) equipment(column string) []string {
+	tags := strings.FieldsFunc(r.get(column), func(c rune) bool {
+		return c == ',' || c == ';' || c == ' ' || c == '\n'
+	})
+	for _, tag := range tags {
+		if !models.ValidEquipment(tag) {
+			r.fail(column, "unknown equipment %q", tag)
+		}
+	}
+	return tags
+}
+
+// ref resolves the name of an existing entity of a kind
+func (r *importRow) ref(column, kind string, names nameIndex, name string) (uuid.UUID, bool) {
+	switch ids := names[nameKey(name)]; len(ids) {
+	case 0:
+		r.fail(column, "unknown %s %q", kind, name)
+	case 1:
+		return ids[0], true
+	default:
+		r.fail(column, "%d entries named %q, %s is ambiguous", len(ids), name, kind)
+	}
+	return uuid.Nil, false
+}
+
+// unique reports a name taken by an existing entity or by an earlier row of the file
+func (r *importRow) unique(column, name string, existing nameIndex, seen map[string]int) {
+	key := nameKey(name)
+	if key == "" {
+		return
+	}
+	if _, ok := existing[key]; ok {
+		r.fail(column, "%q already exists", name)
+		return
+	}
+	if row, ok := seen[key]; ok {
+		r.fail(column, "%q duplicates row %d", name, row)
+		return
+	}
+	seen[key] = r.number
+}
+
+// splitList splits a list cell into its items
+func splitList(cell string) []string {
+	var items []string
+	for _, item := range strings.FieldsFunc(cell, func(c rune) bool { return c == ';' || c == '\n' }) {
+		if item = strings.TrimSpace(item); item != "" {
+			items = append(items, item)
+		}
+	}
+	return items
+}
+
+// splitItem splits a list item into its parts separated by ":"
+func splitItem(item string) []string {
+	parts := strings.Split(item, ":")
+	for i := range parts {
+		parts[i] = strings.TrimSpace(parts[i])
+	}
+	return parts
+}
+
+// parseHours reads hours per week, possibly fractional with a decimal comma ("0,5")
+func parseHours(raw string) (float64, error) {
+	return strconv.ParseFloat(strings.Replace(raw, ",", ".", 1), 64)
+}
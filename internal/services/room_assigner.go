@@ -0,0 +1,102 @@
+package services
+
+import (
+	"github.com/google/uuid"
+	"github.com/nikomkinds/SchoolSchedule/internal/models"
+)
+
+// Room preference weights: the teacher's own room beats a subject room, which beats keeping
+// the class where it already is that day. Unneeded special equipment is a small penalty,
+// so a plain lesson does not take the chemistry lab while an ordinary room is free.
+const (
+	roomScoreTeacherHome   = 1000
+	roomScoreSubjectRoom   = 100
+	roomScoreClassAdjacent = 20
+	roomScoreClassSameDay  = 10
+	roomScoreUnneededTag   = -1
+)
+
+// roomAssigner picks rooms for lessons that have none
+type roomAssigner struct {
+	checker *conflictChecker
+	rooms   []models.Classroom
+}
+
+func newRoomAssigner(checker *conflictChecker, data *models.PlanningData) *roomAssigner {
+	return &roomAssigner{checker: checker, rooms: data.Classrooms}
+}
+
+// pick returns the best room that fits the lesson and is free of the already planned lessons of the day
+func (a *roomAssigner) pick(l plannedLesson, planned []plannedLesson) (uuid.UUID, bool) {
+	best, bestScore, found := uuid.Nil, 0, false
+	for _, r := range a.rooms {
+		if !a.checker.roomFits(l, r.ID) || roomBusy(r.ID, l, planned) {
+			continue
+		}
+		if score := a.score(l, r, planned); !found || score > bestScore {
+			best, bestScore, found = r.ID, score, true
+		}
+	}
+	return best, found
+}
+
+func (a *roomAssigner) score(l plannedLesson, room models.Classroom, planned []plannedLesson) int {
+	score := 0
+	for _, t := range l.teachers {
+		if teacher, ok := a.checker.teachers[t]; ok && teacher.ClassroomID != nil && *teacher.ClassroomID == room.ID {
+			score += roomScoreTeacherHome
+			break
+		}
+	}
+
+	var required []string
+	if subject, ok := a.checker.subjects[l.subjectID]; ok {
+		if containsID(subject.ClassroomIDs, room.ID) {
+			score += roomScoreSubjectRoom
+		}
+		required = subject.RequiredEquipment
+	}
+	for _, tag := range room.Equipment {
+		if !containsString(required, tag) {
+			score += roomScoreUnneededTag
+		}
+	}
+
+	// Keep the class in the room it uses that day, best of all in the neighbouring lesson's room
+	classBonus := 0
+	for _, other := range planned {
+		if !containsID(other.rooms, room.ID) || !sharesClass(l, other) {
+			continue
+		}
+		bonus := roomScoreClassSameDay
+		if d := other.window.lessonNumber - l.window.lessonNumber; d == 1 || d == -1 {
+			bonus = roomScoreClassAdjacent
+		}
+		if bonus > classBonus {
+			classBonus = bonus
+		}
+	}
+	return score + classBonus
+}
+
+// roomBusy reports whether another lesson taking place at the same time already uses the room
+func roomBusy(room uuid.UUID, l plannedLesson, planned []plannedLesson) bool {
+	for _, other := range planned {
+		if containsID(other.rooms, room) && l.window.overlaps(other.window) {
+			return true
+		}
+	}
+	return false
+}
+
+// sharesClass reports whether two lessons have a participant class in common
+func sharesClass(a, b plannedLesson) bool {
+	for _, p := range a.participants {
+		for _, o := range b.participants {
+			if p.classID == o.classID {
+				return true
+			}
+		}
+	}
+	return false
+}
@@ -0,0 +1,100 @@
+package services
+
+import (
+	"context"
+	"errors"
+	"fmt"
+
+	"github.com/google/uuid"
+	"github.com/nikomkinds/SchoolSchedule/internal/models"
+	"github.com/nikomkinds/SchoolSchedule/internal/repositories"
+)
+
+// ErrScheduleForbidden is returned when a user lacks the access an operation on a schedule needs
+var ErrScheduleForbidden = errors.New("no access to the schedule")
+
+// ErrScheduleLocked is returned when a schedule that is not a draft is about to be edited
+var ErrScheduleLocked = repositories.ErrScheduleLocked
+
+// ErrInvalidShare is returned when a share names neither or both of a user and a role, or an unknown permission
+var ErrInvalidShare = errors.New("invalid share")
+
+var accessRank = map[string]int{
+	models.ScheduleAccessView:  1,
+	models.ScheduleAccessEdit:  2,
+	models.ScheduleAccessOwner: 3,
+}
+
+func (s *scheduleService) CheckScheduleAccess(ctx context.Context, scheduleID uuid.UUID, actor models.Actor, need string) error {
+	access, status, err := s.scheduleAccess(ctx, scheduleID, actor)
+	if err != nil {
+		return err
+	}
+	if accessRank[access] < accessRank[need] {
+		return ErrScheduleForbidden
+	}
+	// Only drafts are edited; the other statuses are under review or already signed off
+	if need == models.ScheduleAccessEdit && status != models.ScheduleStatusDraft {
+		return fmt.Errorf("%w: the schedule is %s", ErrScheduleLocked, status)
+	}
+	return nil
+}
+
+// CheckOverrideAccess needs edit access whatever the status: overrides leave the weekly timetable as it
+// is, and substitutions are made in the timetable in use. Schedulers also manage the substitutions of
+// published schedules.
+func (s *scheduleService) CheckOverrideAccess(ctx context.Context, scheduleID uuid.UUID, actor models.Actor) error {
+	access, status, err := s.scheduleAccess(ctx, scheduleID, actor)
+	if err != nil {
+		return err
+	}
+	if accessRank[access] >= accessRank[models.ScheduleAccessEdit] ||
+		(actor.Role == models.RoleScheduler && status == models.ScheduleStatusPublished) {
+		return nil
+	}
+	return ErrScheduleForbidden
+}
+
+// scheduleAccess returns the access of the actor to a schedule and the status of the schedule
+func (s *scheduleService) scheduleAccess(ctx context.Context, scheduleID uuid.UUID, actor models.Actor) (string, string, error) {
+	access, status, err := s.repo.ScheduleAccess(ctx, scheduleID, actor.UserID, actor.Role)
+	if err != nil {
+		return "", "", err
+	}
+	// Admins manage every schedule of the school
+	if actor.Role == models.RoleAdmin {
+		access = models.ScheduleAccessOwner
+	}
+	return access, status, nil
+}
+
+func (s *scheduleService) ListShares(ctx context.Context, scheduleID uuid.UUID) ([]models.ScheduleShare, error) {
+	return s.repo.ListShares(ctx, scheduleID)
+}
+
+func (s *scheduleService) ShareSchedule(ctx context.Context, scheduleID uuid.UUID, req models.ShareScheduleRequest) (*models.ScheduleShare, error) {
+	if (req.UserID == nil) == (req.Role == nil) {
+		return nil, fmt.Errorf("%w: set either userId or role", ErrInvalidShare)
+	}
+	if req.Role != nil {
+		switch *req.Role {
+		case models.RoleAdmin, models.RoleScheduler, models.RoleTeacher:
+		default:
+			return nil, fmt.Errorf("%w: unknown role %q", ErrInvalidShare, *req.Role)
+		}
+	}
+	if req.Permission != models.ScheduleAccessView && req.Permission != models.ScheduleAccessEdit {
+		return nil, fmt.Errorf("%w: permission must be view or edit", ErrInvalidShare)
+	}
+
+	return s.repo.SaveShare(ctx, models.ScheduleShare{
+		ScheduleID: scheduleID,
+		UserID:     req.UserID,
+		Role:       req.Role,
+		Permission: req.Permission,
+	})
+}
+
+func (s *scheduleService) DeleteShare(ctx context.Context, scheduleID, shareID uuid.UUID) error {
+	return s.repo.DeleteShare(ctx, scheduleID, shareID)
+}
@@ -0,0 +1,229 @@
+package services
+
+import (
+	"context"
+
+	"github.com/google/uuid"
+	"github.com/nikomkinds/SchoolSchedule/internal/models"
+	"github.com/nikomkinds/SchoolSchedule/internal/repositories"
+)
+
+// Audit actions on schedules besides create, update and delete
+const (
+	auditActionClone            = "clone"
+	auditActionRename           = "rename"
+	auditActionActivate         = "activate"
+	auditActionRestore          = "restore"
+	auditActionFillRooms        = "fill_rooms"
+	auditActionAddLesson        = "add_lesson"
+	auditActionUpdateLesson     = "update_lesson"
+	auditActionMoveLesson       = "move_lesson"
+	auditActionSwapLessons      = "swap_lessons"
+	auditActionRearrangeLessons = "rearrange_lessons"
+	auditActionDeleteLesson     = "delete_lesson"
+	auditActionTransition       = "transition"
+	auditActionShare            = "share"
+	auditActionUnshare          = "unshare"
+)
+
+// auditedScheduleService records the writes of the schedule service in the audit log. A schedule is
+// snapshotted by its header: the version in it points to the revision holding the timetable itself.
+// Writes made by the service internally, e.g. the save of a restore, are not recorded twice.
+type auditedScheduleService struct {
+	ScheduleService
+	repo  repositories.ScheduleRepository
+	audit AuditService
+}
+
+func (s *auditedScheduleService) CreateSchedule(ctx context.Context, userID uuid.UUID, schedule models.Schedule, slots []models.ScheduleSlotInput) (*models.Schedule, error) {
+	created, err := s.ScheduleService.CreateSchedule(ctx, userID, schedule, slots)
+	if err != nil {
+		return nil, err
+	}
+	s.audit.Record(ctx, models.AuditActionCreate, models.AuditEntitySchedule, &created.ID, nil, created)
+	return created, nil
+}
+
+func (s *auditedScheduleService) UpdateSchedule(ctx context.Context, scheduleID uuid.UUID, name *string, slots []models.ScheduleSlotInput, version *int, note models.RevisionNote) (int, error) {
+	before := s.snapshot(ctx, scheduleID)
+	newVersion, err := s.ScheduleService.UpdateSchedule(ctx, scheduleID, name, slots, version, note)
+	if err != nil {
+		return 0, err
+	}
+	s.record(ctx, models.AuditActionUpdate, scheduleID, before)
+	return newVersion, nil
+}
+
+func (s *auditedScheduleService) DeleteSchedule(ctx context.Context, scheduleID uuid.UUID) error {
+	before := s.snapshot(ctx, scheduleID)
+	if err := s.ScheduleService.DeleteSchedule(ctx, scheduleID); err != nil {
+		return err
+	}
+	s.audit.Record(ctx, models.AuditActionDelete, models.AuditEntitySchedule, &scheduleID, before, nil)
+	return nil
+}
+
+func (s *auditedScheduleService) CloneSchedule(ctx context.Context, userID, sourceID uuid.UUID, req models.CloneScheduleRequest) (*models.Schedule, error) {
+	clone, err := s.ScheduleService.CloneSchedule(ctx, userID, sourceID, req)
+	if err != nil {
+		return nil, err
+	}
+	s.audit.Record(ctx, auditActionClone, models.AuditEntitySchedule, &clone.ID, nil, clone)
+	return clone, nil
+}
+
+func (s *auditedScheduleService) RenameSchedule(ctx context.Context, scheduleID uuid.UUID, name string) (*models.Schedule, error) {
+	before := s.snapshot(ctx, scheduleID)
+	schedule, err := s.ScheduleService.RenameSchedule(ctx, scheduleID, name)
+	if err != nil {
+		return nil, err
+	}
+	s.audit.Record(ctx, auditActionRename, models.AuditEntitySchedule, &scheduleID, before, schedule)
+	return schedule, nil
+}
+
+func (s *auditedScheduleService) ActivateSchedule(ctx context.Context, scheduleID uuid.UUID) (*models.Schedule, error) {
+	before := s.snapshot(ctx, scheduleID)
+	schedule, err := s.ScheduleService.ActivateSchedule(ctx, scheduleID)
+	if err != nil {
+		return nil, err
+	}
+	s.audit.Record(ctx, auditActionActivate, models.AuditEntitySchedule, &scheduleID, before, schedule)
+	return schedule, nil
+}
+
+func (s *auditedScheduleService) RestoreRevision(ctx context.Context, scheduleID uuid.UUID, version int, expected *int, note models.RevisionNote) (int, error) {
+	before := s.snapshot(ctx, scheduleID)
+	newVersion, err := s.ScheduleService.RestoreRevision(ctx, scheduleID, version, expected, note)
+	if err != nil {
+		return 0, err
+	}
+	s.record(ctx, auditActionRestore, scheduleID, before)
+	return newVersion, nil
+}
+
+func (s *auditedScheduleService) FillRooms(ctx context.Context, scheduleID uuid.UUID, version *int, note models.RevisionNote) (*models.FillRoomsResult, int, error) {
+	before := s.snapshot(ctx, scheduleID)
+	result, newVersion, err := s.ScheduleService.FillRooms(ctx, scheduleID, version, note)
+	if err != nil {
+		return nil, 0, err
+	}
+	s.record(ctx, auditActionFillRooms, scheduleID, before)
+	return result, newVersion, nil
+}
+
+func (s *auditedScheduleService) AddLesson(ctx context.Context, scheduleID uuid.UUID, req models.ScheduleLessonRequest, version *int, note models.RevisionNote) (*models.ScheduleDay, int, error) {
+	before := s.snapshot(ctx, scheduleID)
+	day, newVersion, err := s.ScheduleService.AddLesson(ctx, scheduleID, req, version, note)
+	if err != nil {
+		return nil, 0, err
+	}
+	s.record(ctx, auditActionAddLesson, scheduleID, before)
+	return day, newVersion, nil
+}
+
+func (s *auditedScheduleService) UpdateLesson(ctx context.Context, scheduleID, lessonID uuid.UUID, req models.ScheduleLessonRequest, version *int, note models.RevisionNote) (*models.ScheduleDay, int, error) {
+	before := s.snapshot(ctx, scheduleID)
+	day, newVersion, err := s.ScheduleService.UpdateLesson(ctx, scheduleID, lessonID, req, version, note)
+	if err != nil {
+		return nil, 0, err
+	}
+	s.record(ctx, auditActionUpdateLesson, scheduleID, before)
+	return day, newVersion, nil
+}
+
+func (s *auditedScheduleService) MoveLesson(ctx context.Context, scheduleID, lessonID uuid.UUID, req models.MoveLessonRequest, version *int, note models.RevisionNote) (*models.ScheduleDay, int, error) {
+	before := s.snapshot(ctx, scheduleID)
+	day, newVersion, err := s.ScheduleService.MoveLesson(ctx, scheduleID, lessonID, req, version, note)
+	if err != nil {
+		return nil, 0, err
+	}
+	s.record(ctx, auditActionMoveLesson, scheduleID, before)
+	return day, newVersion, nil
+}
+
+func (s *auditedScheduleService) SwapLessons(ctx context.Context, scheduleID, lessonID uuid.UUID, req models.SwapLessonsRequest, version *int, note models.RevisionNote) ([]models.ScheduleDay, int, error) {
+	before := s.snapshot(ctx, scheduleID)
+	days, newVersion, err := s.ScheduleService.SwapLessons(ctx, scheduleID, lessonID, req, version, note)
+	if err != nil {
+		return nil, 0, err
+	}
+	s.record(ctx, auditActionSwapLessons, scheduleID, before)
+	return days, newVersion, nil
+}
+
+func (s *auditedScheduleService) RearrangeLessons(ctx context.Context, scheduleID uuid.UUID, req models.RearrangeLessonsRequest, version *int, note models.RevisionNote) ([]models.ScheduleDay, int, error) {
+	before := s.snapshot(ctx, scheduleID)
+	days, newVersion, err := s.ScheduleService.RearrangeLessons(ctx, scheduleID, req, version, note)
+	if err != nil {
+		return nil, 0, err
+	}
+	s.record(ctx, auditActionRearrangeLessons, scheduleID, before)
+	return days, newVersion, nil
+}
+
+func (s *auditedScheduleService) DeleteLesson(ctx context.Context, scheduleID, lessonID uuid.UUID, version *int, note models.RevisionNote) (int, error) {
+	before := s.snapshot(ctx, scheduleID)
+	newVersion, err := s.ScheduleService.DeleteLesson(ctx, scheduleID, lessonID, version, note)
+	if err != nil {
+		return 0, err
+	}
+	s.record(ctx, auditActionDeleteLesson, scheduleID, before)
+	return newVersion, nil
+}
+
+func (s *auditedScheduleService) TransitionSchedule(ctx context.Context, scheduleID uuid.UUID, actor models.Actor, req models.ScheduleTransitionRequest) (*models.Schedule, error) {
+	before := s.snapshot(ctx, scheduleID)
+	schedule, err := s.ScheduleService.TransitionSchedule(ctx, scheduleID, actor, req)
+	if err != nil {
+		return nil, err
+	}
+	s.audit.Record(ctx, auditActionTransition, models.AuditEntitySchedule, &scheduleID, before, schedule)
+	return schedule, nil
+}
+
+func (s *auditedScheduleService) ShareSchedule(ctx context.Context, scheduleID uuid.UUID, req models.ShareScheduleRequest) (*models.ScheduleShare, error) {
+	share, err := s.ScheduleService.ShareSchedule(ctx, scheduleID, req)
+	if err != nil {
+		return nil, err
+	}
+	s.audit.Record(ctx, auditActionShare, models.AuditEntitySchedule, &scheduleID, nil, share)
+	return share, nil
+}
+
+func (s *auditedScheduleService) DeleteShare(ctx context.Context, scheduleID, shareID uuid.UUID) error {
+	before := s.share(ctx, scheduleID, shareID)
+	if err := s.ScheduleService.DeleteShare(ctx, scheduleID, shareID); err != nil {
+		return err
+	}
+	s.audit.Record(ctx, auditActionUnshare, models.AuditEntitySchedule, &scheduleID, before, nil)
+	return nil
+}
+
+// snapshot loads the header of a schedule before a write; a failure is left to the write to report
+func (s *auditedScheduleService) snapshot(ctx context.Context, scheduleID uuid.UUID) *models.Schedule {
+	schedule, err := s.repo.GetScheduleByID(ctx, scheduleID)
+	if err != nil {
+		return nil
+	}
+	return schedule
+}
+
+// record stores a write to a schedule with its header before and after it
+func (s *auditedScheduleService) record(ctx context.Context, action string, scheduleID uuid.UUID, before *models.Schedule) {
+	s.audit.Record(ctx, action, models.AuditEntitySchedule, &scheduleID, before, s.snapshot(ctx, scheduleID))
+}
+
+// share finds a share of a schedule before it is revoked
+func (s *auditedScheduleService) share(ctx context.Context, scheduleID, shareID uuid.UUID) *models.ScheduleShare {
+	shares, err := s.repo.ListShares(ctx, scheduleID)
+	if err != nil {
+		return nil
+	}
+	for i := range shares {
+		if shares[i].ID == shareID {
+			return &shares[i]
+		}
+	}
+	return nil
+}
@@ -0,0 +1,142 @@
+package services
+
+import (
+	"fmt"
+	"sort"
+
+	"github.com/google/uuid"
+	"github.com/nikomkinds/SchoolSchedule/internal/models"
+)
+
+// Conflict types reported for teacher availability and load limits
+const (
+	ConflictUnavailable = "teacher_unavailable"
+	ConflictOverload    = "teacher_overload"
+)
+
+// teacherRules is the availability grid and load limits of one teacher.
+// Slot keys are {day, lessonNumber}; lessonNumber 0 stands for the whole day. Zero limits mean no limit.
+type teacherRules struct {
+	unavailable    map[[2]int]bool
+	preferred      map[[2]int]bool
+	maxPerDay      int
+	maxConsecutive int
+}
+
+func newTeacherRules(a models.TeacherAvailability) *teacherRules {
+	r := &teacherRules{
+		unavailable: make(map[[2]int]bool),
+		preferred:   make(map[[2]int]bool),
+	}
+	if a.MaxLessonsPerDay != nil {
+		r.maxPerDay = *a.MaxLessonsPerDay
+	}
+	if a.MaxConsecutiveLessons != nil {
+		r.maxConsecutive = *a.MaxConsecutiveLessons
+	}
+	for _, slot := range a.Slots {
+		key := [2]int{models.DayOfWeekNumber(slot.DayOfWeek), 0}
+		if slot.LessonNumber != nil {
+			key[1] = *slot.LessonNumber
+		}
+		switch slot.Status {
+		case models.AvailabilityUnavailable:
+			r.unavailable[key] = true
+		case models.AvailabilityPreferred:
+			r.preferred[key] = true
+		}
+	}
+	return r
+}
+
+func (r *teacherRules) unavailableAt(day, lessonNumber int) bool {
+	return r.unavailable[[2]int{day, 0}] || r.unavailable[[2]int{day, lessonNumber}]
+}
+
+func (r *teacherRules) preferredAt(day, lessonNumber int) bool {
+	return r.preferred[[2]int{day, 0}] || r.preferred[[2]int{day, lessonNumber}]
+}
+
+// overLimit checks the teacher's lesson numbers of one day against the limits.
+// It returns the first lesson number beyond a limit and the limit message, or 0 if the day is fine.
+func (r *teacherRules) overLimit(numbers []int) (int, string) {
+	numbers = distinctSorted(numbers)
+
+	if r.maxPerDay > 0 && len(numbers) > r.maxPerDay {
+		return numbers[r.maxPerDay], fmt.Sprintf("больше %d уроков в день", r.maxPerDay)
+	}
+	if r.maxConsecutive > 0 {
+		run := 0
+		for i, n := range numbers {
+			if i > 0 && n == numbers[i-1]+1 {
+				run++
+			} else {
+				run = 1
+			}
+			if run > r.maxConsecutive {
+				return n, fmt.Sprintf("больше %d уроков подряд", r.maxConsecutive)
+			}
+		}
+	}
+	return 0, ""
+}
+
+// availabilityConflicts reports teachers of the lesson who are unavailable at its time
+func (c *conflictChecker) availabilityConflicts(l plannedLesson) []models.ConflictDetail {
+	var details []models.ConflictDetail
+	for _, t := range l.teachers {
+		if rules, ok := c.rules[t]; ok && rules.unavailableAt(l.window.day, l.window.lessonNumber) {
+			details = append(details, c.detail(l, ConflictUnavailable,
+				fmt.Sprintf("Учитель %s недоступен в это время", c.teacherName(t))))
+		}
+	}
+	return details
+}
+
+// loadConflicts reports teachers whose day exceeds their lessons-per-day or consecutive-lessons limit
+func (c *conflictChecker) loadConflicts(lessons []plannedLesson) []models.ConflictDetail {
+	type teacherDay struct {
+		teacher uuid.UUID
+		day     int
+	}
+	numbers := make(map[teacherDay][]int)
+	var order []teacherDay
+	for _, l := range lessons {
+		for _, t := range l.teachers {
+			if _, ok := c.rules[t]; !ok {
+				continue
+			}
+			key := teacherDay{t, l.window.day}
+			if _, seen := numbers[key]; !seen {
+				order = append(order, key)
+			}
+			numbers[key] = append(numbers[key], l.window.lessonNumber)
+		}
+	}
+
+	var details []models.ConflictDetail
+	for _, key := range order {
+		n, reason := c.rules[key.teacher].overLimit(numbers[key])
+		if n == 0 {
+			continue
+		}
+		at := plannedLesson{window: lessonWindow{day: key.day, lessonNumber: n}}
+		details = append(details, c.detail(at, ConflictOverload,
+			fmt.Sprintf("У учителя %s %s", c.teacherName(key.teacher), reason)))
+	}
+	return details
+}
+
+// distinctSorted returns the numbers sorted without duplicates; odd- and even-week lessons share a number
+func distinctSorted(numbers []int) []int {
+	out := make([]int, 0, len(numbers))
+	seen := make(map[int]bool)
+	for _, n := range numbers {
+		if !seen[n] {
+			seen[n] = true
+			out = append(out, n)
+		}
+	}
+	sort.Ints(out)
+	return out
+}
@@ -0,0 +1,385 @@
+package services
+
+import (
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/nikomkinds/SchoolSchedule/internal/models"
+)
+
+// Conflict types reported by the conflict checker
+const (
+	ConflictTeacher   = "teacher_conflict"
+	ConflictClassroom = "classroom_conflict"
+	ConflictClass     = "class_conflict"
+	ConflictShift     = "shift_conflict"
+)
+
+// ConflictError is returned when a schedule has clashes; Details are sent to the client as is.
+// A rejected lesson move also carries Suggestions that would make it legal.
+type ConflictError struct {
+	Details     []models.ConflictDetail
+	Suggestions []models.MoveSuggestion
+}
+
+func (e *ConflictError) Error() string {
+	return fmt.Sprintf("schedule has %d conflict(s)", len(e.Details))
+}
+
+// lessonWindow is the time a lesson occupies. start/end are minutes since midnight, -1 if no bell times are known.
+type lessonWindow struct {
+	day          int
+	lessonNumber int
+	weekPattern  string
+	shiftID      *uuid.UUID
+	start, end   int
+}
+
+func (w lessonWindow) timed() bool {
+	return w.start >= 0 && w.end >= 0
+}
+
+// overlaps reports whether two lessons happen at the same time.
+// Odd-week and even-week lessons never meet. With bell times the real intervals are compared,
+// so shift 1 lesson 6 may collide with shift 2 lesson 1. Without them only equal lesson numbers
+// of the same shift collide.
+func (w lessonWindow) overlaps(o lessonWindow) bool {
+	if w.day != o.day || !weeksIntersect(w.weekPattern, o.weekPattern) {
+		return false
+	}
+	if w.timed() && o.timed() {
+		return w.start < o.end && o.start < w.end
+	}
+	if w.lessonNumber != o.lessonNumber {
+		return false
+	}
+	return w.shiftID == nil || o.shiftID == nil || *w.shiftID == *o.shiftID
+}
+
+// plannedParticipant is a class or some of its groups attending a lesson
+type plannedParticipant struct {
+	classID  uuid.UUID
+	groupIDs []uuid.UUID // empty means the whole class
+}
+
+// plannedLesson is a lesson being validated or placed by the generator; id is set for stored lessons
+type plannedLesson struct {
+	id           uuid.UUID
+	window       lessonWindow
+	subjectID    uuid.UUID
+	teachers     []uuid.UUID
+	rooms        []uuid.UUID
+	participants []plannedParticipant
+}
+
+// conflictChecker detects teacher, classroom, class and shift clashes, teacher availability violations
+// and rooms that do not fit the lesson
+type conflictChecker struct {
+	bells    *bellTimes
+	classes  map[uuid.UUID]*models.Class
+	teachers map[uuid.UUID]*models.Teacher
+	rooms    map[uuid.UUID]*models.Classroom
+	shifts   map[uuid.UUID]*models.Shift
+	subjects map[uuid.UUID]*models.Subject
+	rules    map[uuid.UUID]*teacherRules
+}
+
+func newConflictChecker(data *models.PlanningData) *conflictChecker {
+	c := &conflictChecker{
+		bells:    newBellTimes(data.Bells),
+		classes:  make(map[uuid.UUID]*models.Class),
+		teachers: make(map[uuid.UUID]*models.Teacher),
+		rooms:    make(map[uuid.UUID]*models.Classroom),
+		shifts:   make(map[uuid.UUID]*models.Shift),
+		subjects: make(map[uuid.UUID]*models.Subject),
+		rules:    make(map[uuid.UUID]*teacherRules),
+	}
+	for i := range data.Classes {
+		c.classes[data.Classes[i].ID] = &data.Classes[i]
+	}
+	for i := range data.Teachers {
+		c.teachers[data.Teachers[i].ID] = &data.Teachers[i]
+	}
+	for i := range data.Classrooms {
+		c.rooms[data.Classrooms[i].ID] = &data.Classrooms[i]
+	}
+	for i := range data.Shifts {
+		c.shifts[data.Shifts[i].ID] = &data.Shifts[i]
+	}
+	for i := range data.Subjects {
+		c.subjects[data.Subjects[i].ID] = &data.Subjects[i]
+	}
+	for _, a := range data.Availability {
+		c.rules[a.TeacherID] = newTeacherRules(a)
+	}
+	return c
+}
+
+// window resolves the time a lesson of the given classes occupies from their shift and grade
+func (c *conflictChecker) window(day, lessonNumber int, classIDs []uuid.UUID) lessonWindow {
+	w := lessonWindow{day: day, lessonNumber: lessonNumber, start: -1, end: -1}
+
+	grade := 0
+	for _, id := range classIDs {
+		if class, ok := c.classes[id]; ok {
+			if w.shiftID == nil {
+				w.shiftID = class.ShiftID
+			}
+			if grade == 0 {
+				grade = class.GradeLevel
+			}
+		}
+	}
+
+	if lt := c.bells.lookup(day, nil, w.shiftID, grade, lessonNumber); lt != nil {
+		w.start = minutesOf(lt.StartTime)
+		w.end = minutesOf(lt.EndTime)
+	}
+	return w
+}
+
+// minutesOf converts "HH:MM" to minutes since midnight (-1 if malformed)
+func minutesOf(hhmm string) int {
+	t, err := time.Parse(timeLayout, hhmm)
+	if err != nil {
+		return -1
+	}
+	return t.Hour()*60 + t.Minute()
+}
+
+// fromSlots converts the request payload into planned lessons; malformed IDs are skipped
+func (c *conflictChecker) fromSlots(slots []models.ScheduleSlotInput) []plannedLesson {
+	var out []plannedLesson
+	for _, slot := range slots {
+		day := models.DayOfWeekNumber(slot.DayOfWeek)
+		for _, in := range slot.Lessons {
+			var l plannedLesson
+			l.subjectID, _ = uuid.Parse(in.Subject.ID)
+			for _, t := range in.Teachers {
+				if id, err := uuid.Parse(t.ID); err == nil {
+					l.teachers = append(l.teachers, id)
+				}
+			}
+			for _, r := range in.Rooms {
+				if id, err := uuid.Parse(r.ID); err == nil {
+					l.rooms = append(l.rooms, id)
+				}
+			}
+			var classIDs []uuid.UUID
+			for _, p := range in.Participants {
+				id, err := uuid.Parse(p.Class.ID)
+				if err != nil {
+					continue
+				}
+				pp := plannedParticipant{classID: id}
+				for _, g := range p.GroupIDs {
+					if gid, err := uuid.Parse(g); err == nil {
+						pp.groupIDs = append(pp.groupIDs, gid)
+					}
+				}
+				l.participants = append(l.participants, pp)
+				classIDs = append(classIDs, id)
+			}
+			l.window = c.window(day, slot.LessonNumber, classIDs)
+			l.window.weekPattern = in.WeekPattern
+			out = append(out, l)
+		}
+	}
+	return out
+}
+
+// fromLesson converts a stored lesson of the weekly timetable into a planned lesson
+func (c *conflictChecker) fromLesson(day, lessonNumber int, lesson models.ScheduleLesson) plannedLesson {
+	l := plannedLesson{id: lesson.ID}
+	if lesson.Subject != nil {
+		l.subjectID = lesson.Subject.ID
+	}
+	for _, t := range lesson.Teachers {
+		l.teachers = append(l.teachers, t.ID)
+	}
+	for _, r := range lesson.Rooms {
+		l.rooms = append(l.rooms, r.ID)
+	}
+	var classIDs []uuid.UUID
+	for _, p := range lesson.Participants {
+		l.participants = append(l.participants, plannedParticipant{classID: p.ClassID, groupIDs: p.GroupIDs})
+		classIDs = append(classIDs, p.ClassID)
+	}
+	l.window = c.window(day, lessonNumber, classIDs)
+	l.window.weekPattern = lesson.WeekPattern
+	return l
+}
+
+// check returns all conflicts between the lessons, each clashing pair reported once
+func (c *conflictChecker) check(lessons []plannedLesson) []models.ConflictDetail {
+	var details []models.ConflictDetail
+
+	for i := range lessons {
+		details = append(details, c.shiftConflicts(lessons[i])...)
+		details = append(details, c.availabilityConflicts(lessons[i])...)
+		details = append(details, c.roomConflicts(lessons[i])...)
+		for j := i + 1; j < len(lessons); j++ {
+			details = append(details, c.clashes(lessons[j], lessons[i])...)
+		}
+	}
+	return append(details, c.loadConflicts(lessons)...)
+}
+
+// checkLesson returns the conflicts of one lesson with the rest of the timetable; clashes between
+// other lessons are left out. Load limits are checked for the lesson's teachers on its day only.
+func (c *conflictChecker) checkLesson(l plannedLesson, lessons []plannedLesson) []models.ConflictDetail {
+	details := c.shiftConflicts(l)
+	details = append(details, c.availabilityConflicts(l)...)
+	details = append(details, c.roomConflicts(l)...)
+
+	load := []plannedLesson{l}
+	for _, other := range lessons {
+		if other.id == l.id {
+			continue
+		}
+		details = append(details, c.clashes(l, other)...)
+		if other.window.day != l.window.day {
+			continue
+		}
+		var shared []uuid.UUID
+		for _, t := range other.teachers {
+			if containsID(l.teachers, t) {
+				shared = append(shared, t)
+			}
+		}
+		if len(shared) > 0 {
+			other.teachers = shared
+			load = append(load, other)
+		}
+	}
+	return append(details, c.loadConflicts(load)...)
+}
+
+// clashes returns conflicts of a lesson with an already planned one
+func (c *conflictChecker) clashes(l, other plannedLesson) []models.ConflictDetail {
+	if !l.window.overlaps(other.window) {
+		return nil
+	}
+
+	var details []models.ConflictDetail
+	for _, t := range l.teachers {
+		if containsID(other.teachers, t) {
+			details = append(details, c.detail(l, ConflictTeacher,
+				fmt.Sprintf("Учитель %s уже занят в это время", c.teacherName(t))))
+		}
+	}
+	for _, r := range l.rooms {
+		if containsID(other.rooms, r) {
+			details = append(details, c.detail(l, ConflictClassroom,
+				fmt.Sprintf("Кабинет %s уже занят в это время", c.roomName(r))))
+		}
+	}
+	for _, p := range l.participants {
+		for _, o := range other.participants {
+			if p.classID == o.classID && groupsIntersect(p.groupIDs, o.groupIDs) {
+				details = append(details, c.detail(l, ConflictClass,
+					fmt.Sprintf("У класса %s уже есть урок в это время", c.className(p.classID))))
+			}
+		}
+	}
+	return details
+}
+
+// shiftConflicts reports participants whose shift does not include the lesson number
+func (c *conflictChecker) shiftConflicts(l plannedLesson) []models.ConflictDetail {
+	var details []models.ConflictDetail
+	for _, p := range l.participants {
+		if !c.inShift(p.classID, l.window.lessonNumber) {
+			details = append(details, c.detail(l, ConflictShift,
+				fmt.Sprintf("Урок %d не входит в смену класса %s", l.window.lessonNumber, c.className(p.classID))))
+		}
+	}
+	return details
+}
+
+// inShift reports whether the class may have the lesson number; classes without a shift may have any
+func (c *conflictChecker) inShift(classID uuid.UUID, lessonNumber int) bool {
+	class, ok := c.classes[classID]
+	if !ok || class.ShiftID == nil {
+		return true
+	}
+	shift, ok := c.shifts[*class.ShiftID]
+	if !ok {
+		return true
+	}
+	return lessonNumber >= shift.FirstLesson && lessonNumber <= shift.LastLesson
+}
+
+func (c *conflictChecker) detail(l plannedLesson, kind, message string) models.ConflictDetail {
+	return models.ConflictDetail{
+		Type:         kind,
+		Message:      message,
+		DayOfWeek:    strings.ToLower(time.Weekday(l.window.day % 7).String()),
+		LessonNumber: l.window.lessonNumber,
+	}
+}
+
+func (c *conflictChecker) teacherName(id uuid.UUID) string {
+	t, ok := c.teachers[id]
+	if !ok {
+		return id.String()
+	}
+	return teacherShortName(t.LastName, t.FirstName, t.Patronymic)
+}
+
+func (c *conflictChecker) roomName(id uuid.UUID) string {
+	if r, ok := c.rooms[id]; ok {
+		return r.Name
+	}
+	return id.String()
+}
+
+func (c *conflictChecker) className(id uuid.UUID) string {
+	if class, ok := c.classes[id]; ok {
+		return class.Name
+	}
+	return id.String()
+}
+
+// teacherShortName formats a teacher as "Иванова А.П."
+func teacherShortName(last, first string, patronymic *string) string {
+	name := last
+	if r := []rune(first); len(r) > 0 {
+		name += " " + string(r[0]) + "."
+		if patronymic != nil {
+			if p := []rune(*patronymic); len(p) > 0 {
+				name += string(p[0]) + "."
+			}
+		}
+	}
+	return name
+}
+
+// weeksIntersect reports whether lessons with the two week patterns may take place in the same week
+func weeksIntersect(a, b string) bool {
+	return models.WeekMatches(a, b) || models.WeekMatches(b, a)
+}
+
+// groupsIntersect reports whether two group sets share students; an empty set is the whole class
+func groupsIntersect(a, b []uuid.UUID) bool {
+	if len(a) == 0 || len(b) == 0 {
+		return true
+	}
+	for _, g := range a {
+		if containsID(b, g) {
+			return true
+		}
+	}
+	return false
+}
+
+func containsID(ids []uuid.UUID, id uuid.UUID) bool {
+	for _, v := range ids {
+		if v == id {
+			return true
+		}
+	}
+	return false
+}
@@ -0,0 +1,264 @@
+package services
+
+import (
+	"testing"
+
+	"github.com/google/uuid"
+	"github.com/nikomkinds/SchoolSchedule/internal/models"
+)
+
+func TestLessonWindowOverlapsWeekPatterns(t *testing.T) {
+	patterns := []string{"", models.WeekEvery, models.WeekOdd, models.WeekEven}
+	want := map[[2]string]bool{
+		{models.WeekOdd, models.WeekEven}: false,
+		{models.WeekEven, models.WeekOdd}: false,
+	}
+
+	for _, a := range patterns {
+		for _, b := range patterns {
+			expected, ok := want[[2]string{a, b}]
+			if !ok {
+				expected = true
+			}
+			for _, timed := range []bool{false, true} {
+				w1 := lessonWindow{day: 1, lessonNumber: 2, weekPattern: a, start: -1, end: -1}
+				w2 := lessonWindow{day: 1, lessonNumber: 2, weekPattern: b, start: -1, end: -1}
+				if timed {
+					w1.start, w1.end = 540, 585
+					w2.start, w2.end = 540, 585
+				}
+				if got := w1.overlaps(w2); got != expected {
+					t.Errorf("%q vs %q (timed %v): overlaps = %v, want %v", a, b, timed, got, expected)
+				}
+			}
+		}
+	}
+}
+
+func TestLessonWindowOverlaps(t *testing.T) {
+	shift1, shift2 := uuid.New(), uuid.New()
+
+	tests := []struct {
+		name string
+		a, b lessonWindow
+		want bool
+	}{
+		{
+			name: "same untimed lesson",
+			a:    lessonWindow{day: 1, lessonNumber: 3, start: -1, end: -1},
+			b:    lessonWindow{day: 1, lessonNumber: 3, start: -1, end: -1},
+			want: true,
+		},
+		{
+			name: "other day",
+			a:    lessonWindow{day: 1, lessonNumber: 3, start: -1, end: -1},
+			b:    lessonWindow{day: 2, lessonNumber: 3, start: -1, end: -1},
+		},
+		{
+			name: "other untimed lesson number",
+			a:    lessonWindow{day: 1, lessonNumber: 3, start: -1, end: -1},
+			b:    lessonWindow{day: 1, lessonNumber: 4, start: -1, end: -1},
+		},
+		{
+			name: "untimed lessons of different shifts",
+			a:    lessonWindow{day: 1, lessonNumber: 1, shiftID: &shift1, start: -1, end: -1},
+			b:    lessonWindow{day: 1, lessonNumber: 1, shiftID: &shift2, start: -1, end: -1},
+		},
+		{
+			name: "untimed lessons of the same shift",
+			a:    lessonWindow{day: 1, lessonNumber: 1, shiftID: &shift1, start: -1, end: -1},
+			b:    lessonWindow{day: 1, lessonNumber: 1, shiftID: &shift1, start: -1, end: -1},
+			want: true,
+		},
+		{
+			name: "untimed lesson without a shift",
+			a:    lessonWindow{day: 1, lessonNumber: 1, shiftID: &shift1, start: -1, end: -1},
+			b:    lessonWindow{day: 1, lessonNumber: 1, start: -1, end: -1},
+			want: true,
+		},
+		{
+			name: "timed and untimed fall back to lesson numbers",
+			a:    lessonWindow{day: 1, lessonNumber: 2, start: 540, end: 585},
+			b:    lessonWindow{day: 1, lessonNumber: 2, start: -1, end: -1},
+			want: true,
+		},
+		{
+			name: "different numbers at the same time",
+			a:    lessonWindow{day: 1, lessonNumber: 6, start: 780, end: 825},
+			b:    lessonWindow{day: 1, lessonNumber: 1, start: 800, end: 845},
+			want: true,
+		},
+		{
+			name: "same number at different times",
+			a:    lessonWindow{day: 1, lessonNumber: 2, start: 530, end: 565},
+			b:    lessonWindow{day: 1, lessonNumber: 2, start: 565, end: 610},
+		},
+		{
+			name: "overlapping times in other weeks",
+			a:    lessonWindow{day: 1, lessonNumber: 6, weekPattern: models.WeekOdd, start: 780, end: 825},
+			b:    lessonWindow{day: 1, lessonNumber: 1, weekPattern: models.WeekEven, start: 800, end: 845},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := tt.a.overlaps(tt.b); got != tt.want {
+				t.Errorf("a.overlaps(b) = %v, want %v", got, tt.want)
+			}
+			if got := tt.b.overlaps(tt.a); got != tt.want {
+				t.Errorf("b.overlaps(a) = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestConflictCheckerWindowBells(t *testing.T) {
+	first := models.Shift{ID: uuid.New(), Number: 1, FirstLesson: 1, LastLesson: 6}
+	second := models.Shift{ID: uuid.New(), Number: 2, FirstLesson: 1, LastLesson: 6}
+	primary := models.Class{ID: uuid.New(), Name: "2А", GradeLevel: 2, ShiftID: &first.ID}
+	senior := models.Class{ID: uuid.New(), Name: "9А", GradeLevel: 9, ShiftID: &first.ID}
+	afternoon := models.Class{ID: uuid.New(), Name: "6Б", GradeLevel: 6, ShiftID: &second.ID}
+	unshifted := models.Class{ID: uuid.New(), Name: "11А", GradeLevel: 11}
+
+	gradeTo, gradeFrom := 4, 5
+	data := &models.PlanningData{
+		Classes: []models.Class{primary, senior, afternoon, unshifted},
+		Shifts:  []models.Shift{first, second},
+		Bells: []models.BellSchedule{
+			{Name: "Основное", Lessons: []models.BellLesson{
+				{LessonNumber: 1, StartTime: "08:00", EndTime: "08:45"},
+				{LessonNumber: 2, StartTime: "08:55", EndTime: "09:40"},
+				{LessonNumber: 6, StartTime: "13:00", EndTime: "13:45"},
+			}},
+			{Name: "Начальная школа", GradeTo: &gradeTo, Lessons: []models.BellLesson{
+				{LessonNumber: 1, StartTime: "08:00", EndTime: "08:35"},
+				{LessonNumber: 2, StartTime: "08:45", EndTime: "09:20"},
+				{LessonNumber: 3, StartTime: "09:30", EndTime: "10:05"},
+			}},
+			{Name: "Старшие", GradeFrom: &gradeFrom, ShiftID: &first.ID, Lessons: []models.BellLesson{
+				{LessonNumber: 1, StartTime: "08:00", EndTime: "08:45"},
+				{LessonNumber: 2, StartTime: "08:55", EndTime: "09:40"},
+				{LessonNumber: 6, StartTime: "13:00", EndTime: "13:45"},
+			}},
+			{Name: "Вторая смена", ShiftID: &second.ID, Lessons: []models.BellLesson{
+				{LessonNumber: 1, StartTime: "13:30", EndTime: "14:15"},
+				{LessonNumber: 2, StartTime: "14:25", EndTime: "15:10"},
+			}},
+		},
+	}
+	c := newConflictChecker(data)
+
+	tests := []struct {
+		name       string
+		a, b       lessonWindow
+		want       bool
+		wantStartA int
+	}{
+		{
+			name:       "grade bands: primary lesson 2 meets senior lesson 2",
+			a:          c.window(1, 2, []uuid.UUID{primary.ID}),
+			b:          c.window(1, 2, []uuid.UUID{senior.ID}),
+			want:       true,
+			wantStartA: 8*60 + 45,
+		},
+		{
+			name:       "grade bands: primary lesson 3 meets senior lesson 2",
+			a:          c.window(1, 3, []uuid.UUID{primary.ID}),
+			b:          c.window(1, 2, []uuid.UUID{senior.ID}),
+			want:       true,
+			wantStartA: 9*60 + 30,
+		},
+		{
+			name:       "grade bands: primary lesson 1 ends before senior lesson 2",
+			a:          c.window(1, 1, []uuid.UUID{primary.ID}),
+			b:          c.window(1, 2, []uuid.UUID{senior.ID}),
+			wantStartA: 8 * 60,
+		},
+		{
+			name:       "cross-shift: lesson 6 of the first shift meets lesson 1 of the second",
+			a:          c.window(1, 6, []uuid.UUID{senior.ID}),
+			b:          c.window(1, 1, []uuid.UUID{afternoon.ID}),
+			want:       true,
+			wantStartA: 13 * 60,
+		},
+		{
+			name:       "cross-shift: lesson 2 of the first shift is before lesson 1 of the second",
+			a:          c.window(1, 2, []uuid.UUID{senior.ID}),
+			b:          c.window(1, 1, []uuid.UUID{afternoon.ID}),
+			wantStartA: 8*60 + 55,
+		},
+		{
+			name:       "cross-shift: same number, different times",
+			a:          c.window(1, 1, []uuid.UUID{afternoon.ID}),
+			b:          c.window(1, 1, []uuid.UUID{senior.ID}),
+			wantStartA: 13*60 + 30,
+		},
+		{
+			name:       "class without a shift uses the general bells",
+			a:          c.window(1, 6, []uuid.UUID{unshifted.ID}),
+			b:          c.window(1, 1, []uuid.UUID{afternoon.ID}),
+			want:       true,
+			wantStartA: 13 * 60,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if tt.a.start != tt.wantStartA {
+				t.Errorf("start = %d, want %d", tt.a.start, tt.wantStartA)
+			}
+			if got := tt.a.overlaps(tt.b); got != tt.want {
+				t.Errorf("overlaps = %v, want %v", got, tt.want)
+			}
+			if got := tt.b.overlaps(tt.a); got != tt.want {
+				t.Errorf("reverse overlaps = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestConflictCheckerClashesByWeek(t *testing.T) {
+	teacher := models.Teacher{ID: uuid.New(), LastName: "Иванова", FirstName: "Анна"}
+	room := models.Classroom{ID: uuid.New(), Name: "101"}
+	class := models.Class{ID: uuid.New(), Name: "5А", GradeLevel: 5}
+	c := newConflictChecker(&models.PlanningData{
+		Classes:    []models.Class{class},
+		Teachers:   []models.Teacher{teacher},
+		Classrooms: []models.Classroom{room},
+	})
+
+	lesson := func(pattern string) plannedLesson {
+		l := plannedLesson{
+			window:       c.window(1, 1, []uuid.UUID{class.ID}),
+			teachers:     []uuid.UUID{teacher.ID},
+			rooms:        []uuid.UUID{room.ID},
+			participants: []plannedParticipant{{classID: class.ID}},
+		}
+		l.window.weekPattern = pattern
+		return l
+	}
+
+	tests := []struct {
+		a, b string
+		want int
+	}{
+		{models.WeekEvery, models.WeekEvery, 3},
+		{models.WeekEvery, models.WeekOdd, 3},
+		{models.WeekEven, models.WeekEvery, 3},
+		{models.WeekOdd, models.WeekOdd, 3},
+		{models.WeekOdd, models.WeekEven, 0},
+	}
+	for _, tt := range tests {
+		got := c.clashes(lesson(tt.a), lesson(tt.b))
+		if len(got) != tt.want {
+			t.Errorf("%s vs %s: %d conflicts %v, want %d", tt.a, tt.b, len(got), got, tt.want)
+		}
+		kinds := make(map[string]bool)
+		for _, d := range got {
+			kinds[d.Type] = true
+		}
+		if tt.want > 0 && !(kinds[ConflictTeacher] && kinds[ConflictClassroom] && kinds[ConflictClass]) {
+			t.Errorf("%s vs %s: conflict types %v, want teacher, classroom and class", tt.a, tt.b, kinds)
+		}
+	}
+}
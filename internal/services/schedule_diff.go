@@ -0,0 +1,263 @@
+package services
+
+import (
+	"context"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/nikomkinds/SchoolSchedule/internal/models"
+)
+
+func (s *scheduleService) CompareSchedules(ctx context.Context, from, to models.ScheduleRef) (*models.ScheduleDiff, error) {
+	before, fromVersion, err := s.scheduleAt(ctx, from)
+	if err != nil {
+		return nil, err
+	}
+	after, toVersion, err := s.scheduleAt(ctx, to)
+	if err != nil {
+		return nil, err
+	}
+
+	diff := diffSchedules(before, after)
+	diff.FromVersion, diff.ToVersion = fromVersion, toVersion
+	if from.ScheduleID != to.ScheduleID {
+		diff.FromScheduleID, diff.ToScheduleID = &from.ScheduleID, &to.ScheduleID
+	}
+	return &diff, nil
+}
+
+// scheduleAt loads the timetable a reference points at and its version
+func (s *scheduleService) scheduleAt(ctx context.Context, ref models.ScheduleRef) ([]models.ScheduleDay, int, error) {
+	if ref.Version != nil {
+		days, err := s.repo.GetRevision(ctx, ref.ScheduleID, *ref.Version)
+		return days, *ref.Version, err
+	}
+	schedule, err := s.repo.GetScheduleByID(ctx, ref.ScheduleID)
+	if err != nil {
+		return nil, 0, err
+	}
+	days, err := s.repo.GetScheduleDays(ctx, ref.ScheduleID)
+	return days, schedule.Version, err
+}
+
+// diffSchedules compares two timetables slot by slot. Lessons are matched by content, not by ID,
+// as the lessons of a clone or of another schedule have other IDs. A lesson that keeps its subject and classes in the slot
+// but gets other teachers, rooms or weeks is reported as changed; one that keeps them in another
+// slot is reported as moved.
+func diffSchedules(before, after []models.ScheduleDay) models.ScheduleDiff {
+	diff := models.ScheduleDiff{
+		Added:   []models.LessonDiff{},
+		Removed: []models.LessonDiff{},
+		Changed: []models.LessonDiff{},
+		Moved:   []models.LessonDiff{},
+	}
+
+	type slotKey struct {
+		day, number int
+	}
+	slots := make(map[slotKey][2][]models.ScheduleLesson)
+	for side, days := range [][]models.ScheduleDay{before, after} {
+		for _, d := range days {
+			key := slotKey{models.DayOfWeekNumber(d.DayOfWeek), d.LessonNumber}
+			lessons := slots[key]
+			lessons[side] = append(lessons[side], d.Lessons...)
+			slots[key] = lessons
+		}
+	}
+	keys := make([]slotKey, 0, len(slots))
+	for k := range slots {
+		keys = append(keys, k)
+	}
+	sort.Slice(keys, func(i, j int) bool {
+		if keys[i].day != keys[j].day {
+			return keys[i].day < keys[j].day
+		}
+		return keys[i].number < keys[j].number
+	})
+
+	// Lessons gone from or new in their slot; those found on the other side in another slot were moved
+	var removed, added []models.LessonDiff
+	for _, key := range keys {
+		old, cur := unmatched(slots[key][0], slots[key][1])
+		entry := func(l models.ScheduleLesson) models.LessonDiff {
+			return models.LessonDiff{DayOfWeek: strings.ToUpper(time.Weekday(key.day % 7).String()), LessonNumber: key.number, Lesson: l}
+		}
+
+		// Lessons left on both sides with the same subject and classes were edited in place
+		for len(old) > 0 {
+			l := old[0]
+			old = old[1:]
+			i := indexOfLesson(cur, lessonIdentity(l), lessonIdentity)
+			if i < 0 {
+				removed = append(removed, entry(l))
+				continue
+			}
+			diff.Changed = append(diff.Changed, withBefore(entry(cur[i]), l))
+			cur = append(cur[:i], cur[i+1:]...)
+		}
+		for _, l := range cur {
+			added = append(added, entry(l))
+		}
+	}
+
+	// Moves as is are matched first, so that a moved lesson is not paired with an edited one
+	for _, keyOf := range []func(models.ScheduleLesson) string{lessonSignature, lessonIdentity} {
+		rest := added[:0:0]
+		for _, a := range added {
+			i := -1
+			for j, r := range removed {
+				if keyOf(r.Lesson) == keyOf(a.Lesson) {
+					i = j
+					break
+				}
+			}
+			if i < 0 {
+				rest = append(rest, a)
+				continue
+			}
+			moved := withBefore(a, removed[i].Lesson)
+			moved.FromDayOfWeek, moved.FromLessonNumber = removed[i].DayOfWeek, removed[i].LessonNumber
+			diff.Moved = append(diff.Moved, moved)
+			removed = append(removed[:i], removed[i+1:]...)
+		}
+		added = rest
+	}
+	diff.Added = append(diff.Added, added...)
+	diff.Removed = append(diff.Removed, removed...)
+
+	diff.AffectedTeachers, diff.AffectedClasses = affected(diff)
+	return diff
+}
+
+// withBefore records the earlier state of a lesson and what differs from it
+func withBefore(entry models.LessonDiff, before models.ScheduleLesson) models.LessonDiff {
+	entry.Before = &before
+	if teacherIDs(before) != teacherIDs(entry.Lesson) {
+		entry.Changes = append(entry.Changes, models.LessonChangeTeachers)
+	}
+	if roomIDs(before) != roomIDs(entry.Lesson) {
+		entry.Changes = append(entry.Changes, models.LessonChangeRooms)
+	}
+	if weekPatternOrEvery(before.WeekPattern) != weekPatternOrEvery(entry.Lesson.WeekPattern) {
+		entry.Changes = append(entry.Changes, models.LessonChangeWeekPattern)
+	}
+	return entry
+}
+
+// affected collects the teachers and classes of the differing lessons, before and after the change
+func affected(diff models.ScheduleDiff) ([]uuid.UUID, []uuid.UUID) {
+	teachers := make(map[uuid.UUID]bool)
+	classes := make(map[uuid.UUID]bool)
+	add := func(l models.ScheduleLesson) {
+		for _, t := range l.Teachers {
+			teachers[t.ID] = true
+		}
+		for _, p := range l.Participants {
+			if p.Class != nil {
+				classes[p.Class.ID] = true
+			} else {
+				classes[p.ClassID] = true
+			}
+		}
+	}
+	for _, entries := range [][]models.LessonDiff{diff.Added, diff.Removed, diff.Changed, diff.Moved} {
+		for _, e := range entries {
+			add(e.Lesson)
+			if e.Before != nil {
+				add(*e.Before)
+			}
+		}
+	}
+	return sortedSet(teachers), sortedSet(classes)
+}
+
+func sortedSet(set map[uuid.UUID]bool) []uuid.UUID {
+	ids := make([]uuid.UUID, 0, len(set))
+	for id := range set {
+		ids = append(ids, id)
+	}
+	sort.Slice(ids, func(i, j int) bool {
+		return ids[i].String() < ids[j].String()
+	})
+	return ids
+}
+
+// unmatched drops the lessons present on both sides unchanged
+func unmatched(before, after []models.ScheduleLesson) ([]models.ScheduleLesson, []models.ScheduleLesson) {
+	rest := append([]models.ScheduleLesson{}, after...)
+	var gone []models.ScheduleLesson
+	for _, l := range before {
+		if i := indexOfLesson(rest, lessonSignature(l), lessonSignature); i >= 0 {
+			rest = append(rest[:i], rest[i+1:]...)
+			continue
+		}
+		gone = append(gone, l)
+	}
+	return gone, rest
+}
+
+func indexOfLesson(lessons []models.ScheduleLesson, key string, keyOf func(models.ScheduleLesson) string) int {
+	for i, l := range lessons {
+		if keyOf(l) == key {
+			return i
+		}
+	}
+	return -1
+}
+
+// lessonIdentity is the subject and the participants of a lesson
+func lessonIdentity(l models.ScheduleLesson) string {
+	var subject string
+	if l.Subject != nil {
+		subject = l.Subject.ID.String()
+	}
+	participants := make([]string, 0, len(l.Participants))
+	for _, p := range l.Participants {
+		classID := p.ClassID
+		if p.Class != nil {
+			classID = p.Class.ID
+		}
+		participants = append(participants, classID.String()+"/"+sortedIDs(p.GroupIDs))
+	}
+	sort.Strings(participants)
+	return subject + "|" + strings.Join(participants, ",")
+}
+
+// lessonSignature is the whole content of a lesson
+func lessonSignature(l models.ScheduleLesson) string {
+	return lessonIdentity(l) + "|" + teacherIDs(l) + "|" + roomIDs(l) + "|" + weekPatternOrEvery(l.WeekPattern)
+}
+
+func teacherIDs(l models.ScheduleLesson) string {
+	ids := make([]uuid.UUID, 0, len(l.Teachers))
+	for _, t := range l.Teachers {
+		ids = append(ids, t.ID)
+	}
+	return sortedIDs(ids)
+}
+
+func roomIDs(l models.ScheduleLesson) string {
+	ids := make([]uuid.UUID, 0, len(l.Rooms))
+	for _, r := range l.Rooms {
+		ids = append(ids, r.ID)
+	}
+	return sortedIDs(ids)
+}
+
+func sortedIDs(ids []uuid.UUID) string {
+	out := make([]string, 0, len(ids))
+	for _, id := range ids {
+		out = append(out, id.String())
+	}
+	sort.Strings(out)
+	return strings.Join(out, ",")
+}
+
+func weekPatternOrEvery(p string) string {
+	if p == "" {
+		return models.WeekEvery
+	}
+	return p
+}
@@ -0,0 +1,221 @@
+package services
+
+import (
+	"strconv"
+	"testing"
+
+	"github.com/google/uuid"
+	"github.com/nikomkinds/SchoolSchedule/internal/models"
+)
+
+func TestDiffSchedules(t *testing.T) {
+	math, art := uuid.New(), uuid.New()
+	ivanova, petrov, sidorova := uuid.New(), uuid.New(), uuid.New()
+	room101, room202 := uuid.New(), uuid.New()
+	class5a, class6b := uuid.New(), uuid.New()
+
+	lesson := func(subject, teacher, room, class uuid.UUID, pattern string) models.ScheduleLesson {
+		return models.ScheduleLesson{
+			ID:           uuid.New(),
+			Subject:      &models.Subject{ID: subject},
+			Teachers:     []models.Teacher{{ID: teacher}},
+			Rooms:        []models.Classroom{{ID: room}},
+			Participants: []models.LessonParticipant{{ClassID: class}},
+			WeekPattern:  pattern,
+		}
+	}
+	slot := func(day string, number int, lessons ...models.ScheduleLesson) models.ScheduleDay {
+		return models.ScheduleDay{DayOfWeek: day, LessonNumber: number, Lessons: lessons}
+	}
+	mathA := lesson(math, ivanova, room101, class5a, models.WeekEvery)
+	artB := lesson(art, petrov, room202, class6b, models.WeekEvery)
+
+	// A copy of a lesson with a new ID, as in another schedule
+	copyOf := func(l models.ScheduleLesson) models.ScheduleLesson {
+		l.ID = uuid.New()
+		return l
+	}
+	with := func(l models.ScheduleLesson, edit func(*models.ScheduleLesson)) models.ScheduleLesson {
+		edit(&l)
+		return l
+	}
+
+	type entry struct {
+		day     string
+		number  int
+		subject uuid.UUID
+		from    string // "DAY/number" of a moved lesson
+		changes []string
+	}
+	tests := []struct {
+		name                           string
+		before, after                  []models.ScheduleDay
+		added, removed, changed, moved []entry
+		teachers, classes              []uuid.UUID
+	}{
+		{
+			name:     "same content with other IDs is no change",
+			before:   []models.ScheduleDay{slot("MONDAY", 1, mathA, artB)},
+			after:    []models.ScheduleDay{slot("monday", 1, copyOf(artB), copyOf(mathA))},
+			teachers: []uuid.UUID{},
+			classes:  []uuid.UUID{},
+		},
+		{
+			name:     "added lesson",
+			before:   []models.ScheduleDay{slot("MONDAY", 1, mathA)},
+			after:    []models.ScheduleDay{slot("MONDAY", 1, mathA), slot("TUESDAY", 3, artB)},
+			added:    []entry{{day: "TUESDAY", number: 3, subject: art}},
+			teachers: []uuid.UUID{petrov},
+			classes:  []uuid.UUID{class6b},
+		},
+		{
+			name:     "removed lesson",
+			before:   []models.ScheduleDay{slot("MONDAY", 1, mathA, artB)},
+			after:    []models.ScheduleDay{slot("MONDAY", 1, mathA)},
+			removed:  []entry{{day: "MONDAY", number: 1, subject: art}},
+			teachers: []uuid.UUID{petrov},
+			classes:  []uuid.UUID{class6b},
+		},
+		{
+			name:     "moved lesson",
+			before:   []models.ScheduleDay{slot("MONDAY", 1, mathA)},
+			after:    []models.ScheduleDay{slot("WEDNESDAY", 4, mathA)},
+			moved:    []entry{{day: "WEDNESDAY", number: 4, subject: math, from: "MONDAY/1"}},
+			teachers: []uuid.UUID{ivanova},
+			classes:  []uuid.UUID{class5a},
+		},
+		{
+			name:   "teacher change in place",
+			before: []models.ScheduleDay{slot("MONDAY", 1, mathA)},
+			after: []models.ScheduleDay{slot("MONDAY", 1, with(mathA, func(l *models.ScheduleLesson) {
+				l.Teachers = []models.Teacher{{ID: sidorova}}
+			}))},
+			changed:  []entry{{day: "MONDAY", number: 1, subject: math, changes: []string{models.LessonChangeTeachers}}},
+			teachers: []uuid.UUID{ivanova, sidorova},
+			classes:  []uuid.UUID{class5a},
+		},
+		{
+			name:   "room and week change in place",
+			before: []models.ScheduleDay{slot("MONDAY", 1, mathA)},
+			after: []models.ScheduleDay{slot("MONDAY", 1, with(mathA, func(l *models.ScheduleLesson) {
+				l.Rooms = []models.Classroom{{ID: room202}}
+				l.WeekPattern = models.WeekOdd
+			}))},
+			changed: []entry{{day: "MONDAY", number: 1, subject: math,
+				changes: []string{models.LessonChangeRooms, models.LessonChangeWeekPattern}}},
+			teachers: []uuid.UUID{ivanova},
+			classes:  []uuid.UUID{class5a},
+		},
+		{
+			name:   "an empty week pattern is every week",
+			before: []models.ScheduleDay{slot("MONDAY", 1, mathA)},
+			after: []models.ScheduleDay{slot("MONDAY", 1, with(mathA, func(l *models.ScheduleLesson) {
+				l.WeekPattern = ""
+			}))},
+			teachers: []uuid.UUID{},
+			classes:  []uuid.UUID{},
+		},
+		{
+			name:   "moved with a room change",
+			before: []models.ScheduleDay{slot("MONDAY", 1, mathA)},
+			after: []models.ScheduleDay{slot("FRIDAY", 2, with(mathA, func(l *models.ScheduleLesson) {
+				l.Rooms = []models.Classroom{{ID: room202}}
+			}))},
+			moved:    []entry{{day: "FRIDAY", number: 2, subject: math, from: "MONDAY/1", changes: []string{models.LessonChangeRooms}}},
+			teachers: []uuid.UUID{ivanova},
+			classes:  []uuid.UUID{class5a},
+		},
+		{
+			name: "unchanged move is preferred over an edited copy",
+			before: []models.ScheduleDay{
+				slot("MONDAY", 1, mathA),
+				slot("MONDAY", 2, with(mathA, func(l *models.ScheduleLesson) { l.Teachers = []models.Teacher{{ID: sidorova}} })),
+			},
+			after: []models.ScheduleDay{
+				slot("TUESDAY", 1, with(mathA, func(l *models.ScheduleLesson) { l.Teachers = []models.Teacher{{ID: sidorova}} })),
+				slot("TUESDAY", 2, mathA),
+			},
+			moved: []entry{
+				{day: "TUESDAY", number: 1, subject: math, from: "MONDAY/2"},
+				{day: "TUESDAY", number: 2, subject: math, from: "MONDAY/1"},
+			},
+			teachers: []uuid.UUID{ivanova, sidorova},
+			classes:  []uuid.UUID{class5a},
+		},
+		{
+			name:     "other subject in the slot is removed and added",
+			before:   []models.ScheduleDay{slot("MONDAY", 1, mathA)},
+			after:    []models.ScheduleDay{slot("MONDAY", 1, lesson(art, ivanova, room101, class5a, models.WeekEvery))},
+			added:    []entry{{day: "MONDAY", number: 1, subject: art}},
+			removed:  []entry{{day: "MONDAY", number: 1, subject: math}},
+			teachers: []uuid.UUID{ivanova},
+			classes:  []uuid.UUID{class5a},
+		},
+	}
+
+	check := func(t *testing.T, kind string, got []models.LessonDiff, want []entry) {
+		t.Helper()
+		if len(got) != len(want) {
+			t.Fatalf("%s: got %d lessons %+v, want %d", kind, len(got), got, len(want))
+		}
+		for i, w := range want {
+			g := got[i]
+			if g.DayOfWeek != w.day || g.LessonNumber != w.number || g.Lesson.Subject.ID != w.subject {
+				t.Errorf("%s[%d]: got %s/%d subject %s, want %s/%d subject %s",
+					kind, i, g.DayOfWeek, g.LessonNumber, g.Lesson.Subject.ID, w.day, w.number, w.subject)
+			}
+			if w.from != "" {
+				if from := g.FromDayOfWeek + "/" + strconv.Itoa(g.FromLessonNumber); from != w.from {
+					t.Errorf("%s[%d]: moved from %s, want %s", kind, i, from, w.from)
+				}
+			}
+			if (kind == "changed" || kind == "moved") && g.Before == nil {
+				t.Errorf("%s[%d]: before is not set", kind, i)
+			}
+			if !equalStrings(g.Changes, w.changes) {
+				t.Errorf("%s[%d]: changes %v, want %v", kind, i, g.Changes, w.changes)
+			}
+		}
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			diff := diffSchedules(tt.before, tt.after)
+			check(t, "added", diff.Added, tt.added)
+			check(t, "removed", diff.Removed, tt.removed)
+			check(t, "changed", diff.Changed, tt.changed)
+			check(t, "moved", diff.Moved, tt.moved)
+			if !sameIDs(diff.AffectedTeachers, tt.teachers) {
+				t.Errorf("affected teachers %v, want %v", diff.AffectedTeachers, tt.teachers)
+			}
+			if !sameIDs(diff.AffectedClasses, tt.classes) {
+				t.Errorf("affected classes %v, want %v", diff.AffectedClasses, tt.classes)
+			}
+		})
+	}
+}
+
+func equalStrings(a, b []string) bool {
+	if len(a) != len(b) {
+		return false
+	}
+	for i := range a {
+		if a[i] != b[i] {
+			return false
+		}
+	}
+	return true
+}
+
+// sameIDs compares ID sets; affected sets are sorted, the expected ones need not be
+func sameIDs(got, want []uuid.UUID) bool {
+	if got == nil || len(got) != len(want) {
+		return false
+	}
+	for _, id := range want {
+		if !containsID(got, id) {
+			return false
+		}
+	}
+	return true
+}
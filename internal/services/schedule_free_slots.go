@@ -0,0 +1,198 @@
+package services
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"fmt"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/nikomkinds/SchoolSchedule/internal/models"
+)
+
+// ErrInvalidFreeSlotsRequest is returned when no participants are given or a teacher, class, group or day is unknown
+var ErrInvalidFreeSlotsRequest = errors.New("invalid free slots request")
+
+// slotParticipant is a teacher, class or group whose lessons block slots
+type slotParticipant struct {
+	busy map[int][]int // day → lesson numbers
+}
+
+func (s *scheduleService) FindFreeSlots(ctx context.Context, userID uuid.UUID, req models.FreeSlotsRequest) ([]models.FreeSlot, error) {
+	if len(req.TeacherIDs)+len(req.ClassIDs)+len(req.GroupIDs) == 0 {
+		return nil, fmt.Errorf("%w: at least one teacher, class or group is required", ErrInvalidFreeSlotsRequest)
+	}
+	for _, d := range req.Days {
+		if d < 1 || d > 7 {
+			return nil, fmt.Errorf("%w: unknown day", ErrInvalidFreeSlotsRequest)
+		}
+	}
+
+	var days []models.ScheduleDay
+	scheduleID, err := s.repo.GetActiveScheduleID(ctx, userID, req.Date)
+	switch {
+	case err == nil:
+		if days, err = s.repo.GetScheduleDays(ctx, scheduleID); err != nil {
+			return nil, err
+		}
+	case !errors.Is(err, sql.ErrNoRows):
+		return nil, err
+	}
+
+	data, err := s.loadPlanningData(ctx, nil)
+	if err != nil {
+		return nil, err
+	}
+	checker := newConflictChecker(data)
+
+	groupClass := make(map[uuid.UUID]uuid.UUID)
+	for _, c := range data.Classes {
+		for _, g := range c.Groups {
+			groupClass[g.ID] = c.ID
+		}
+	}
+	for _, t := range req.TeacherIDs {
+		if _, ok := checker.teachers[t]; !ok {
+			return nil, fmt.Errorf("%w: unknown teacher %s", ErrInvalidFreeSlotsRequest, t)
+		}
+	}
+	for _, c := range req.ClassIDs {
+		if _, ok := checker.classes[c]; !ok {
+			return nil, fmt.Errorf("%w: unknown class %s", ErrInvalidFreeSlotsRequest, c)
+		}
+	}
+	for _, g := range req.GroupIDs {
+		if _, ok := groupClass[g]; !ok {
+			return nil, fmt.Errorf("%w: unknown group %s", ErrInvalidFreeSlotsRequest, g)
+		}
+	}
+
+	teachers := make(map[uuid.UUID]*slotParticipant)
+	classes := make(map[uuid.UUID]*slotParticipant)
+	groups := make(map[uuid.UUID]*slotParticipant)
+	var participants []*slotParticipant
+	for _, list := range []struct {
+		ids []uuid.UUID
+		m   map[uuid.UUID]*slotParticipant
+	}{{req.TeacherIDs, teachers}, {req.ClassIDs, classes}, {req.GroupIDs, groups}} {
+		for _, id := range list.ids {
+			if _, dup := list.m[id]; !dup {
+				p := &slotParticipant{busy: make(map[int][]int)}
+				list.m[id] = p
+				participants = append(participants, p)
+			}
+		}
+	}
+
+	// Any lesson blocks its slot, whatever its week pattern
+	maxLesson, saturday := 0, false
+	for _, d := range days {
+		day := models.DayOfWeekNumber(d.DayOfWeek)
+		if d.LessonNumber > maxLesson {
+			maxLesson = d.LessonNumber
+		}
+		if day == 6 && len(d.Lessons) > 0 {
+			saturday = true
+		}
+		for _, lesson := range d.Lessons {
+			for _, p := range lessonSlotParticipants(lesson, teachers, classes, groups, groupClass) {
+				p.busy[day] = append(p.busy[day], d.LessonNumber)
+			}
+		}
+	}
+
+	weekDays := req.Days
+	if len(weekDays) == 0 {
+		weekDays = []int{1, 2, 3, 4, 5}
+		if saturday {
+			weekDays = append(weekDays, 6)
+		}
+	}
+	first, last := freeSlotRange(checker, req, groupClass, maxLesson)
+
+	slots := make([]models.FreeSlot, 0)
+	for _, day := range weekDays {
+		for n := first; n <= last; n++ {
+			added, free := 0, true
+			for _, p := range participants {
+				if containsInt(p.busy[day], n) {
+					free = false
+					break
+				}
+				added += windows(append(p.busy[day][:len(p.busy[day]):len(p.busy[day])], n)) - windows(p.busy[day])
+			}
+			if free {
+				slots = append(slots, models.FreeSlot{
+					DayOfWeek:    strings.ToUpper(time.Weekday(day % 7).String()),
+					LessonNumber: n,
+					WindowsAdded: added,
+				})
+			}
+		}
+	}
+
+	sort.SliceStable(slots, func(i, j int) bool {
+		return slots[i].WindowsAdded < slots[j].WindowsAdded
+	})
+	return slots, nil
+}
+
+// lessonSlotParticipants returns the requested participants a lesson occupies. A class is busy with any
+// of its lessons; a group is busy with lessons of its whole class or of that group.
+func lessonSlotParticipants(lesson models.ScheduleLesson, teachers, classes, groups map[uuid.UUID]*slotParticipant,
+	groupClass map[uuid.UUID]uuid.UUID) []*slotParticipant {
+	var out []*slotParticipant
+	for _, t := range lesson.Teachers {
+		if p, ok := teachers[t.ID]; ok {
+			out = append(out, p)
+		}
+	}
+	for _, lp := range lesson.Participants {
+		if p, ok := classes[lp.ClassID]; ok {
+			out = append(out, p)
+		}
+		for g, p := range groups {
+			if groupClass[g] == lp.ClassID && (len(lp.GroupIDs) == 0 || containsID(lp.GroupIDs, g)) {
+				out = append(out, p)
+			}
+		}
+	}
+	return out
+}
+
+// freeSlotRange returns the lesson numbers to search: the common part of the requested classes' shifts,
+// otherwise up to the last lesson of the timetable
+func freeSlotRange(checker *conflictChecker, req models.FreeSlotsRequest,
+	groupClass map[uuid.UUID]uuid.UUID, maxLesson int) (int, int) {
+	first, last := 1, maxLesson
+	if last == 0 {
+		last = defaultMaxLessonsPerDay
+	}
+
+	classIDs := append([]uuid.UUID{}, req.ClassIDs...)
+	for _, g := range req.GroupIDs {
+		classIDs = append(classIDs, groupClass[g])
+	}
+	shiftLast := 0
+	for _, id := range classIDs {
+		class, ok := checker.classes[id]
+		if !ok || class.ShiftID == nil {
+			continue
+		}
+		if shift, ok := checker.shifts[*class.ShiftID]; ok {
+			if shift.FirstLesson > first {
+				first = shift.FirstLesson
+			}
+			if shiftLast == 0 || shift.LastLesson < shiftLast {
+				shiftLast = shift.LastLesson
+			}
+		}
+	}
+	if shiftLast > 0 {
+		last = shiftLast
+	}
+	return first, last
+}
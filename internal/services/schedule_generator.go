@@ -0,0 +1,383 @@
+package services
+
+import (
+	"math"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/nikomkinds/SchoolSchedule/internal/models"
+)
+
+const (
+	defaultMaxLessonsPerDay = 7
+	defaultDaysPerWeek      = 5
+)
+
+// Reasons reported for lessons the generator could not place
+const (
+	unplacedNoTeacher = "Не назначен учитель"
+	unplacedNoSlot    = "Нет свободного времени без конфликтов"
+	unplacedNoRoom    = "Нет кабинета нужной вместимости и оборудования"
+)
+
+// generationUnit is one weekly hour of a workload row that has to be placed, or half an hour: a lesson
+// every other week
+type generationUnit struct {
+	teacherID uuid.UUID
+	classID   uuid.UUID
+	subjectID uuid.UUID
+	groupID   *uuid.UUID
+	half      bool
+}
+
+func (u generationUnit) participant() plannedParticipant {
+	p := plannedParticipant{classID: u.classID}
+	if u.groupID != nil {
+		p.groupIDs = []uuid.UUID{*u.groupID}
+	}
+	return p
+}
+
+// scheduleGenerator places workload hours greedily into a weekly timetable.
+// It is shift-aware: a class only gets lesson numbers of its shift, and clashes are checked
+// by real bell times, so a teacher may work in both shifts while rooms are not double-booked.
+// Teacher availability is respected: unavailable lessons and load limits are never violated,
+// preferred lessons are tried first.
+type scheduleGenerator struct {
+	data        *models.PlanningData
+	checker     *conflictChecker
+	rooms       *roomAssigner
+	maxPerDay   int
+	daysPerWeek int
+
+	placed   map[int][]plannedLesson // by day
+	perDay   map[uuid.UUID]map[int]int
+	subjects map[uuid.UUID]*models.Subject
+}
+
+func newScheduleGenerator(data *models.PlanningData, req models.GenerateScheduleRequest) *scheduleGenerator {
+	checker := newConflictChecker(data)
+	g := &scheduleGenerator{
+		data:        data,
+		checker:     checker,
+		rooms:       newRoomAssigner(checker, data),
+		maxPerDay:   defaultMaxLessonsPerDay,
+		daysPerWeek: defaultDaysPerWeek,
+		placed:      make(map[int][]plannedLesson),
+		perDay:      make(map[uuid.UUID]map[int]int),
+		subjects:    make(map[uuid.UUID]*models.Subject),
+	}
+	if req.MaxLessonsPerDay != nil && *req.MaxLessonsPerDay > 0 {
+		g.maxPerDay = *req.MaxLessonsPerDay
+	}
+	if req.DaysPerWeek != nil && *req.DaysPerWeek >= 1 && *req.DaysPerWeek <= 6 {
+		g.daysPerWeek = *req.DaysPerWeek
+	}
+	for i := range data.Subjects {
+		g.subjects[data.Subjects[i].ID] = &data.Subjects[i]
+	}
+	return g
+}
+
+// generate builds the timetable; lessons that do not fit are returned in Unplaced
+func (g *scheduleGenerator) generate() *models.GenerateScheduleResult {
+	result := &models.GenerateScheduleResult{Unplaced: []models.UnplacedLesson{}}
+
+	// Study plan rows nobody teaches cannot be placed at all
+	taught := make(map[[2]uuid.UUID]bool)
+	for _, w := range g.data.Workloads {
+		taught[[2]uuid.UUID{w.ClassID, w.SubjectID}] = true
+	}
+	for _, p := range g.data.StudyPlans {
+		if p.HoursPerWeek > 0 && !taught[[2]uuid.UUID{p.ClassID, p.SubjectID}] {
+			result.Unplaced = append(result.Unplaced, g.unplaced(generationUnit{classID: p.ClassID, subjectID: p.SubjectID}, unplacedNoTeacher))
+		}
+	}
+
+	for _, u := range g.units() {
+		if g.place(u) {
+			continue
+		}
+		reason := unplacedNoSlot
+		if !g.anyRoomFits(u) {
+			reason = unplacedNoRoom
+		}
+		result.Unplaced = append(result.Unplaced, g.unplaced(u, reason))
+	}
+
+	result.Data = g.days()
+	return result
+}
+
+// units expands workload into single hours and a half hour for a fractional row, classes with the
+// narrowest shift first
+func (g *scheduleGenerator) units() []generationUnit {
+	workloads := make([]models.TeacherWorkload, len(g.data.Workloads))
+	copy(workloads, g.data.Workloads)
+	sort.SliceStable(workloads, func(i, j int) bool {
+		return len(g.lessonNumbers(workloads[i].ClassID)) < len(g.lessonNumbers(workloads[j].ClassID))
+	})
+
+	var out []generationUnit
+	for _, w := range workloads {
+		halves := int(math.Round(w.HoursPerWeek * 2))
+		for h := 0; h < halves; h += 2 {
+			out = append(out, generationUnit{
+				teacherID: w.TeacherID,
+				classID:   w.ClassID,
+				subjectID: w.SubjectID,
+				groupID:   w.GroupID,
+				half:      h+1 == halves,
+			})
+		}
+	}
+	return out
+}
+
+// lessonNumbers returns the lesson numbers a class may use: its shift range capped by the daily maximum
+func (g *scheduleGenerator) lessonNumbers(classID uuid.UUID) []int {
+	first, last := 1, g.maxPerDay
+	if class, ok := g.checker.classes[classID]; ok && class.ShiftID != nil {
+		if shift, ok := g.checker.shifts[*class.ShiftID]; ok {
+			first, last = shift.FirstLesson, shift.LastLesson
+			if last-first+1 > g.maxPerDay {
+				last = first + g.maxPerDay - 1
+			}
+		}
+	}
+
+	out := make([]int, 0, last-first+1)
+	for n := first; n <= last; n++ {
+		out = append(out, n)
+	}
+	return out
+}
+
+// place puts a unit into the first free slot, preferring the least loaded day of the class.
+// A half unit goes into the even weeks of a slot where the class has an odd-week lesson, pairing two
+// biweekly subjects; if there is none, it takes the odd weeks of a free slot.
+func (g *scheduleGenerator) place(u generationUnit) bool {
+	days := make([]int, g.daysPerWeek)
+	for i := range days {
+		days[i] = i + 1
+	}
+	sort.SliceStable(days, func(i, j int) bool {
+		return g.perDay[u.classID][days[i]] < g.perDay[u.classID][days[j]]
+	})
+
+	pattern := models.WeekEvery
+	if u.half {
+		pattern = models.WeekOdd
+		for _, day := range days {
+			for _, n := range g.candidateNumbers(u, day) {
+				if g.oddOnly(u.classID, day, n) && g.tryPlace(u, day, n, models.WeekEven) {
+					return true
+				}
+			}
+		}
+	}
+
+	for _, day := range days {
+		if g.perDay[u.classID][day] >= g.maxPerDay {
+			continue
+		}
+		for _, n := range g.candidateNumbers(u, day) {
+			if g.tryPlace(u, day, n, pattern) {
+				if g.perDay[u.classID] == nil {
+					g.perDay[u.classID] = make(map[int]int)
+				}
+				g.perDay[u.classID][day]++
+				return true
+			}
+		}
+	}
+	return false
+}
+
+// tryPlace places the unit at the lesson number in the weeks of the pattern unless it clashes or no room is free
+func (g *scheduleGenerator) tryPlace(u generationUnit, day, n int, pattern string) bool {
+	l := plannedLesson{
+		window:       g.checker.window(day, n, []uuid.UUID{u.classID}),
+		subjectID:    u.subjectID,
+		teachers:     []uuid.UUID{u.teacherID},
+		participants: []plannedParticipant{u.participant()},
+	}
+	l.window.weekPattern = pattern
+	if g.clashes(l) {
+		return false
+	}
+
+	room, ok := g.freeRoom(l)
+	if !ok {
+		return false
+	}
+	if room != uuid.Nil {
+		l.rooms = []uuid.UUID{room}
+	}
+	g.placed[day] = append(g.placed[day], l)
+	return true
+}
+
+// oddOnly reports whether the class has an odd-week lesson at the lesson number, leaving the even weeks to pair
+func (g *scheduleGenerator) oddOnly(classID uuid.UUID, day, n int) bool {
+	for _, l := range g.placed[day] {
+		if l.window.lessonNumber != n || l.window.weekPattern != models.WeekOdd {
+			continue
+		}
+		for _, p := range l.participants {
+			if p.classID == classID {
+				return true
+			}
+		}
+	}
+	return false
+}
+
+// candidateNumbers returns the class's lesson numbers the teacher may take on the day, preferred ones first
+func (g *scheduleGenerator) candidateNumbers(u generationUnit, day int) []int {
+	rules, ok := g.checker.rules[u.teacherID]
+	if !ok {
+		return g.lessonNumbers(u.classID)
+	}
+
+	var taken []int
+	for _, l := range g.placed[day] {
+		if containsID(l.teachers, u.teacherID) {
+			taken = append(taken, l.window.lessonNumber)
+		}
+	}
+
+	var out []int
+	for _, n := range g.lessonNumbers(u.classID) {
+		if rules.unavailableAt(day, n) {
+			continue
+		}
+		if over, _ := rules.overLimit(append(taken[:len(taken):len(taken)], n)); over != 0 {
+			continue
+		}
+		out = append(out, n)
+	}
+	sort.SliceStable(out, func(i, j int) bool {
+		return rules.preferredAt(day, out[i]) && !rules.preferredAt(day, out[j])
+	})
+	return out
+}
+
+func (g *scheduleGenerator) clashes(l plannedLesson) bool {
+	for _, other := range g.placed[l.window.day] {
+		if len(g.checker.clashes(l, other)) > 0 {
+			return true
+		}
+	}
+	return false
+}
+
+// freeRoom picks a free fitting room through the room assigner.
+// uuid.Nil with ok=true means the school has no classrooms defined.
+func (g *scheduleGenerator) freeRoom(l plannedLesson) (uuid.UUID, bool) {
+	if len(g.data.Classrooms) == 0 {
+		return uuid.Nil, true
+	}
+	return g.rooms.pick(l, g.placed[l.window.day])
+}
+
+// anyRoomFits reports whether some classroom fits the unit regardless of time
+func (g *scheduleGenerator) anyRoomFits(u generationUnit) bool {
+	if len(g.data.Classrooms) == 0 {
+		return true
+	}
+	l := plannedLesson{subjectID: u.subjectID, participants: []plannedParticipant{u.participant()}}
+	for _, r := range g.data.Classrooms {
+		if g.checker.roomFits(l, r.ID) {
+			return true
+		}
+	}
+	return false
+}
+
+// days converts placed lessons into the schedule response format
+func (g *scheduleGenerator) days() []models.ScheduleDay {
+	type slotKey struct{ day, lesson int }
+	index := make(map[slotKey]int)
+	out := make([]models.ScheduleDay, 0)
+
+	for day := 1; day <= g.daysPerWeek; day++ {
+		for _, l := range g.placed[day] {
+			key := slotKey{day, l.window.lessonNumber}
+			i, ok := index[key]
+			if !ok {
+				i = len(out)
+				index[key] = i
+				out = append(out, models.ScheduleDay{
+					DayOfWeek:    strings.ToUpper(time.Weekday(day % 7).String()),
+					LessonNumber: l.window.lessonNumber,
+					Lessons:      []models.ScheduleLesson{},
+				})
+			}
+			out[i].Lessons = append(out[i].Lessons, g.scheduleLesson(l))
+		}
+	}
+
+	sort.SliceStable(out, func(i, j int) bool {
+		di, dj := models.DayOfWeekNumber(out[i].DayOfWeek), models.DayOfWeekNumber(out[j].DayOfWeek)
+		if di != dj {
+			return di < dj
+		}
+		return out[i].LessonNumber < out[j].LessonNumber
+	})
+	return out
+}
+
+func (g *scheduleGenerator) scheduleLesson(l plannedLesson) models.ScheduleLesson {
+	lesson := models.ScheduleLesson{
+		Subject:      &models.Subject{ID: l.subjectID},
+		WeekPattern:  l.window.weekPattern,
+		Teachers:     []models.Teacher{},
+		Rooms:        []models.Classroom{},
+		Participants: []models.LessonParticipant{},
+	}
+	if s, ok := g.subjects[l.subjectID]; ok {
+		lesson.Subject.Name = s.Name
+	}
+	for _, id := range l.teachers {
+		if t, ok := g.checker.teachers[id]; ok {
+			lesson.Teachers = append(lesson.Teachers, models.Teacher{
+				ID: t.ID, FirstName: t.FirstName, LastName: t.LastName, Patronymic: t.Patronymic,
+			})
+		}
+	}
+	for _, id := range l.rooms {
+		if r, ok := g.checker.rooms[id]; ok {
+			lesson.Rooms = append(lesson.Rooms, models.Classroom{ID: r.ID, Name: r.Name})
+		}
+	}
+	for _, p := range l.participants {
+		lp := models.LessonParticipant{ClassID: p.classID, GroupIDs: p.groupIDs}
+		if c, ok := g.checker.classes[p.classID]; ok {
+			lp.Class = &models.Class{ID: c.ID, Name: c.Name, GradeLevel: c.GradeLevel, ShiftID: c.ShiftID}
+		}
+		lesson.Participants = append(lesson.Participants, lp)
+	}
+	return lesson
+}
+
+func (g *scheduleGenerator) unplaced(u generationUnit, reason string) models.UnplacedLesson {
+	out := models.UnplacedLesson{
+		Class:   models.ClassInput{ID: u.classID.String(), Name: g.checker.className(u.classID)},
+		Subject: models.SubjectInput{ID: u.subjectID.String()},
+		Reason:  reason,
+	}
+	if s, ok := g.subjects[u.subjectID]; ok {
+		out.Subject.Name = s.Name
+	}
+	if t, ok := g.checker.teachers[u.teacherID]; ok {
+		out.Teacher = &models.LightTeacher{ID: t.ID, FirstName: t.FirstName, LastName: t.LastName, Patronymic: t.Patronymic}
+	}
+	if u.groupID != nil {
+		gid := u.groupID.String()
+		out.GroupID = &gid
+	}
+	return out
+}
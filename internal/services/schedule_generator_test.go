@@ -0,0 +1,71 @@
+package services
+
+import (
+	"testing"
+
+	"github.com/google/uuid"
+	"github.com/nikomkinds/SchoolSchedule/internal/models"
+)
+
+func TestGeneratorHalfHours(t *testing.T) {
+	class := models.Class{ID: uuid.New(), Name: "7А", GradeLevel: 7}
+	music := models.Subject{ID: uuid.New(), Name: "Музыка"}
+	art := models.Subject{ID: uuid.New(), Name: "ИЗО"}
+	history := models.Subject{ID: uuid.New(), Name: "История"}
+	t1 := models.Teacher{ID: uuid.New(), LastName: "Петрова", FirstName: "Ольга"}
+	t2 := models.Teacher{ID: uuid.New(), LastName: "Сидоров", FirstName: "Иван"}
+
+	data := &models.PlanningData{
+		Classes:  []models.Class{class},
+		Teachers: []models.Teacher{t1, t2},
+		Subjects: []models.Subject{music, art, history},
+		Workloads: []models.TeacherWorkload{
+			{TeacherID: t1.ID, ClassID: class.ID, SubjectID: music.ID, HoursPerWeek: 0.5},
+			{TeacherID: t2.ID, ClassID: class.ID, SubjectID: art.ID, HoursPerWeek: 0.5},
+			{TeacherID: t2.ID, ClassID: class.ID, SubjectID: history.ID, HoursPerWeek: 1.5},
+		},
+	}
+	result := newScheduleGenerator(data, models.GenerateScheduleRequest{}).generate()
+	if len(result.Unplaced) != 0 {
+		t.Fatalf("unplaced lessons: %+v", result.Unplaced)
+	}
+
+	patterns := make(map[uuid.UUID][]string)
+	slots := make(map[uuid.UUID]string)
+	for _, day := range result.Data {
+		for _, l := range day.Lessons {
+			patterns[l.Subject.ID] = append(patterns[l.Subject.ID], l.WeekPattern)
+			slots[l.Subject.ID] = day.DayOfWeek + string(rune('0'+day.LessonNumber))
+		}
+	}
+
+	if got := patterns[history.ID]; len(got) != 2 || !containsPattern(got, models.WeekEvery) || !containsPattern(got, models.WeekOdd) {
+		t.Errorf("history: week patterns %v, want one weekly and one biweekly lesson", got)
+	}
+	if len(patterns[music.ID]) != 1 || len(patterns[art.ID]) != 1 {
+		t.Fatalf("music %v, art %v: want one biweekly lesson each", patterns[music.ID], patterns[art.ID])
+	}
+	if patterns[music.ID][0] == patterns[art.ID][0] || patterns[music.ID][0] == models.WeekEvery {
+		t.Errorf("music %v, art %v: want odd and even weeks", patterns[music.ID], patterns[art.ID])
+	}
+	if slots[music.ID] != slots[art.ID] {
+		t.Errorf("music at %s, art at %s: want the two half hours to share a slot", slots[music.ID], slots[art.ID])
+	}
+}
+
+func TestValidHours(t *testing.T) {
+	for hours, want := range map[float64]bool{0.5: true, 1: true, 2.5: true, 0: false, -1: false, 0.25: false, 1.3: false} {
+		if got := validHours(hours); got != want {
+			t.Errorf("validHours(%v) = %v, want %v", hours, got, want)
+		}
+	}
+}
+
+func containsPattern(patterns []string, pattern string) bool {
+	for _, p := range patterns {
+		if p == pattern {
+			return true
+		}
+	}
+	return false
+}
@@ -0,0 +1,169 @@
+package services
+
+import (
+	"context"
+	"errors"
+	"fmt"
+
+	"github.com/google/uuid"
+	"github.com/nikomkinds/SchoolSchedule/internal/models"
+	"github.com/nikomkinds/SchoolSchedule/internal/repositories"
+)
+
+// ErrInvalidLesson is returned when a lesson edit has an unknown day, lesson number or malformed IDs
+var ErrInvalidLesson = errors.New("invalid lesson")
+
+func (s *scheduleService) AddLesson(ctx context.Context, scheduleID uuid.UUID, req models.ScheduleLessonRequest, version *int, note models.RevisionNote) (*models.ScheduleDay, int, error) {
+	day, err := validateLesson(req.DayOfWeek, req.LessonNumber, &req.LessonInput)
+	if err != nil {
+		return nil, 0, err
+	}
+	checker, err := s.lessonChecker(ctx, scheduleID)
+	if err != nil {
+		return nil, 0, err
+	}
+
+	var slots []models.ScheduleDay
+	note = withComment(note, "Добавление урока")
+	_, newVersion, err := s.repo.AddLesson(ctx, scheduleID, day, req.LessonNumber, req.LessonInput, version, note, checker.lessonCheck(&slots))
+	if err != nil {
+		return nil, 0, s.lessonEditError(ctx, scheduleID, version, err)
+	}
+	return slotWithBells(checker, slots), newVersion, nil
+}
+
+func (s *scheduleService) UpdateLesson(ctx context.Context, scheduleID, lessonID uuid.UUID, req models.ScheduleLessonRequest, version *int, note models.RevisionNote) (*models.ScheduleDay, int, error) {
+	day, err := validateLesson(req.DayOfWeek, req.LessonNumber, &req.LessonInput)
+	if err != nil {
+		return nil, 0, err
+	}
+	checker, err := s.lessonChecker(ctx, scheduleID)
+	if err != nil {
+		return nil, 0, err
+	}
+
+	var slots []models.ScheduleDay
+	note = withComment(note, "Изменение урока")
+	newVersion, err := s.repo.UpdateLesson(ctx, scheduleID, lessonID, day, req.LessonNumber, req.LessonInput, version, note, checker.lessonCheck(&slots))
+	if err != nil {
+		return nil, 0, s.lessonEditError(ctx, scheduleID, version, err)
+	}
+	return slotWithBells(checker, slots), newVersion, nil
+}
+
+func (s *scheduleService) DeleteLesson(ctx context.Context, scheduleID, lessonID uuid.UUID, version *int, note models.RevisionNote) (int, error) {
+	newVersion, err := s.repo.DeleteLesson(ctx, scheduleID, lessonID, version, withComment(note, "Удаление урока"))
+	if err != nil {
+		return 0, s.lessonEditError(ctx, scheduleID, version, err)
+	}
+	return newVersion, nil
+}
+
+// lessonEditError turns a version mismatch of an edit into a *ScheduleVersionError with the changes
+// since the client's version; other errors are returned as is
+func (s *scheduleService) lessonEditError(ctx context.Context, scheduleID uuid.UUID, version *int, err error) error {
+	if version != nil && errors.Is(err, repositories.ErrVersionMismatch) {
+		return s.versionError(ctx, scheduleID, *version)
+	}
+	return err
+}
+
+// lessonChecker loads the planning data for the term of the schedule
+func (s *scheduleService) lessonChecker(ctx context.Context, scheduleID uuid.UUID) (*conflictChecker, error) {
+	schedule, err := s.repo.GetScheduleByID(ctx, scheduleID)
+	if err != nil {
+		return nil, err
+	}
+	data, err := s.loadPlanningData(ctx, schedule.TermID)
+	if err != nil {
+		return nil, err
+	}
+	return newConflictChecker(data), nil
+}
+
+// lessonCheck checks the edited lessons against the rest of the timetable and stores their slots into slots.
+// Conflicts elsewhere in the schedule do not block the edit.
+func (c *conflictChecker) lessonCheck(slots *[]models.ScheduleDay) repositories.LessonCheck {
+	return func(days []models.ScheduleDay, lessonIDs []uuid.UUID) error {
+		var lessons []plannedLesson
+		*slots = (*slots)[:0]
+		for _, d := range days {
+			day := models.DayOfWeekNumber(d.DayOfWeek)
+			edited := false
+			for _, lesson := range d.Lessons {
+				lessons = append(lessons, c.fromLesson(day, d.LessonNumber, lesson))
+				edited = edited || containsID(lessonIDs, lesson.ID)
+			}
+			if edited {
+				*slots = append(*slots, d)
+			}
+		}
+
+		// Swapped lessons may clash with each other; such a clash is reported once
+		var details []models.ConflictDetail
+		seen := make(map[models.ConflictDetail]bool)
+		for _, l := range lessons {
+			if !containsID(lessonIDs, l.id) {
+				continue
+			}
+			for _, d := range c.checkLesson(l, lessons) {
+				if !seen[d] {
+					seen[d] = true
+					details = append(details, d)
+				}
+			}
+		}
+		if len(details) > 0 {
+			return &ConflictError{Details: details}
+		}
+		return nil
+	}
+}
+
+// slotWithBells returns the first of the edited slots with its start and end times
+func slotWithBells(checker *conflictChecker, slots []models.ScheduleDay) *models.ScheduleDay {
+	checker.bells.apply(slots, nil)
+	return &slots[0]
+}
+
+// validateLesson checks the target slot and, if given, the lesson payload; it returns the day number
+func validateLesson(dayOfWeek string, lessonNumber int, lesson *models.LessonInput) (int, error) {
+	day := models.DayOfWeekNumber(dayOfWeek)
+	if day < 1 || day > 6 {
+		return 0, fmt.Errorf("%w: unknown day of week %q", ErrInvalidLesson, dayOfWeek)
+	}
+	if lessonNumber < 1 {
+		return 0, fmt.Errorf("%w: lessonNumber must be positive", ErrInvalidLesson)
+	}
+	if lesson == nil {
+		return day, nil
+	}
+
+	if !models.ValidWeekPattern(lesson.WeekPattern) {
+		return 0, fmt.Errorf("%w: %q", ErrInvalidWeekPattern, lesson.WeekPattern)
+	}
+	if _, err := uuid.Parse(lesson.Subject.ID); err != nil {
+		return 0, fmt.Errorf("%w: invalid subject id", ErrInvalidLesson)
+	}
+	// The timetable loader skips lessons without teachers or participants
+	if len(lesson.Teachers) == 0 || len(lesson.Participants) == 0 {
+		return 0, fmt.Errorf("%w: at least one teacher and one class are required", ErrInvalidLesson)
+	}
+	ids := make([]string, 0)
+	for _, t := range lesson.Teachers {
+		ids = append(ids, t.ID)
+	}
+	for _, r := range lesson.Rooms {
+		ids = append(ids, r.ID)
+	}
+	for _, p := range lesson.Participants {
+		ids = append(ids, p.Class.ID)
+		ids = append(ids, p.GroupIDs...)
+	}
+	for _, id := range ids {
+		if _, err := uuid.Parse(id); err != nil {
+			return 0, fmt.Errorf("%w: invalid id %q", ErrInvalidLesson, id)
+		}
+	}
+	return day, nil
+}
@@ -0,0 +1,297 @@
+package services
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"fmt"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/nikomkinds/SchoolSchedule/internal/models"
+)
+
+// Bounds of the search for move suggestions. A chain holds the requested move and up to
+// maxChainMoves moves of lessons in the way; the search stops after maxSearchChecks lesson checks.
+const (
+	maxSlotSuggestions  = 3
+	maxChainSuggestions = 3
+	maxChainMoves       = 3
+	maxSearchChecks     = 5000
+)
+
+func (s *scheduleService) MoveLesson(ctx context.Context, scheduleID, lessonID uuid.UUID, req models.MoveLessonRequest, version *int, note models.RevisionNote) (*models.ScheduleDay, int, error) {
+	move := models.LessonMove{LessonID: lessonID, DayOfWeek: req.DayOfWeek, LessonNumber: req.LessonNumber}
+	checker, slots, newVersion, err := s.moveLessons(ctx, scheduleID, []models.LessonMove{move}, version, withComment(note, "Перенос урока"))
+
+	var conflict *ConflictError
+	if errors.As(err, &conflict) {
+		// The move was rolled back, so the stored timetable is the one to search
+		days, err := s.repo.GetScheduleDays(ctx, scheduleID)
+		if err != nil {
+			return nil, 0, err
+		}
+		conflict.Suggestions = checker.suggestMoves(days, move)
+		return nil, 0, conflict
+	}
+	if err != nil {
+		return nil, 0, err
+	}
+	return slotWithBells(checker, slots), newVersion, nil
+}
+
+func (s *scheduleService) SwapLessons(ctx context.Context, scheduleID, lessonID uuid.UUID, req models.SwapLessonsRequest, version *int, note models.RevisionNote) ([]models.ScheduleDay, int, error) {
+	if req.LessonID == lessonID {
+		return nil, 0, fmt.Errorf("%w: a lesson cannot be swapped with itself", ErrInvalidLesson)
+	}
+	days, err := s.repo.GetScheduleDays(ctx, scheduleID)
+	if err != nil {
+		return nil, 0, err
+	}
+	a, okA := findLessonSlot(days, lessonID)
+	b, okB := findLessonSlot(days, req.LessonID)
+	if !okA || !okB {
+		return nil, 0, sql.ErrNoRows
+	}
+
+	_, slots, newVersion, err := s.moveLessons(ctx, scheduleID, []models.LessonMove{
+		{LessonID: lessonID, DayOfWeek: b.DayOfWeek, LessonNumber: b.LessonNumber},
+		{LessonID: req.LessonID, DayOfWeek: a.DayOfWeek, LessonNumber: a.LessonNumber},
+	}, version, withComment(note, "Обмен уроков"))
+	return slots, newVersion, err
+}
+
+func (s *scheduleService) RearrangeLessons(ctx context.Context, scheduleID uuid.UUID, req models.RearrangeLessonsRequest, version *int, note models.RevisionNote) ([]models.ScheduleDay, int, error) {
+	_, slots, newVersion, err := s.moveLessons(ctx, scheduleID, req.Moves, version, withComment(note, "Перестановка уроков"))
+	return slots, newVersion, err
+}
+
+// moveLessons applies the moves in one transaction and returns the slots the lessons ended up in
+func (s *scheduleService) moveLessons(ctx context.Context, scheduleID uuid.UUID, moves []models.LessonMove, version *int, note models.RevisionNote) (*conflictChecker, []models.ScheduleDay, int, error) {
+	if len(moves) == 0 {
+		return nil, nil, 0, fmt.Errorf("%w: no moves", ErrInvalidLesson)
+	}
+	seen := make(map[uuid.UUID]bool)
+	for _, m := range moves {
+		if _, err := validateLesson(m.DayOfWeek, m.LessonNumber, nil); err != nil {
+			return nil, nil, 0, err
+		}
+		if seen[m.LessonID] {
+			return nil, nil, 0, fmt.Errorf("%w: lesson %s is moved twice", ErrInvalidLesson, m.LessonID)
+		}
+		seen[m.LessonID] = true
+	}
+
+	checker, err := s.lessonChecker(ctx, scheduleID)
+	if err != nil {
+		return nil, nil, 0, err
+	}
+	var slots []models.ScheduleDay
+	newVersion, err := s.repo.MoveLessons(ctx, scheduleID, moves, version, note, checker.lessonCheck(&slots))
+	if err != nil {
+		return checker, nil, 0, s.lessonEditError(ctx, scheduleID, version, err)
+	}
+	checker.bells.apply(slots, nil)
+	return checker, slots, newVersion, nil
+}
+
+func findLessonSlot(days []models.ScheduleDay, lessonID uuid.UUID) (models.ScheduleDay, bool) {
+	for _, d := range days {
+		for _, l := range d.Lessons {
+			if l.ID == lessonID {
+				return d, true
+			}
+		}
+	}
+	return models.ScheduleDay{}, false
+}
+
+// moveSearch looks for ways to make a rejected move legal over the current timetable
+type moveSearch struct {
+	c      *conflictChecker
+	slots  [][2]int // {day, lessonNumber} a lesson may be moved to
+	budget int
+	chains [][]models.LessonMove
+}
+
+// suggestMoves returns other slots the lesson fits into as is, and chains of moves that clear
+// the requested slot by moving the lessons in the way. Chains come first as they keep the requested slot.
+func (c *conflictChecker) suggestMoves(days []models.ScheduleDay, move models.LessonMove) []models.MoveSuggestion {
+	var lessons []plannedLesson
+	idx := -1
+	for _, d := range days {
+		day := models.DayOfWeekNumber(d.DayOfWeek)
+		for _, lesson := range d.Lessons {
+			if lesson.ID == move.LessonID {
+				idx = len(lessons)
+			}
+			lessons = append(lessons, c.fromLesson(day, d.LessonNumber, lesson))
+		}
+	}
+	if idx < 0 {
+		return nil
+	}
+
+	search := &moveSearch{c: c, slots: timetableSlots(days), budget: maxSearchChecks}
+	origin := lessons[idx].window
+	target := [2]int{models.DayOfWeekNumber(move.DayOfWeek), move.LessonNumber}
+
+	suggestions := make([]models.MoveSuggestion, 0)
+	next := append([]plannedLesson{}, lessons...)
+	next[idx] = c.placed(lessons[idx], target[0], target[1])
+	search.chain(next, []int{idx}, []models.LessonMove{move}, [][2]int{{origin.day, origin.lessonNumber}})
+	for _, chain := range search.chains {
+		suggestions = append(suggestions, models.MoveSuggestion{Kind: models.SuggestionChain, Moves: chain})
+	}
+
+	found := 0
+	for _, slot := range search.byDistance(target) {
+		if found == maxSlotSuggestions {
+			break
+		}
+		if slot == target || (slot[0] == origin.day && slot[1] == origin.lessonNumber) {
+			continue
+		}
+		if len(c.checkLesson(c.placed(lessons[idx], slot[0], slot[1]), lessons)) == 0 {
+			suggestions = append(suggestions, models.MoveSuggestion{
+				Kind:  models.SuggestionSlot,
+				Moves: []models.LessonMove{lessonMove(move.LessonID, slot)},
+			})
+			found++
+		}
+	}
+	return suggestions
+}
+
+// chain makes the moved lessons legal by moving one lesson in the way at a time, depth first.
+// moved are indexes of lessons already moved; they stay put. freed are slots the moved lessons left,
+// tried first so that a swap is found before longer chains.
+func (s *moveSearch) chain(lessons []plannedLesson, moved []int, moves []models.LessonMove, freed [][2]int) {
+	for _, i := range moved {
+		if s.budget <= 0 || len(s.chains) == maxChainSuggestions {
+			return
+		}
+		s.budget--
+		details := s.c.checkLesson(lessons[i], lessons)
+		if len(details) == 0 {
+			continue
+		}
+		// Only clashes can be solved by moving other lessons away
+		for _, d := range details {
+			if d.Type != ConflictTeacher && d.Type != ConflictClassroom && d.Type != ConflictClass {
+				return
+			}
+		}
+		if len(moves) > maxChainMoves {
+			return
+		}
+
+		blocker := -1
+		for j := range lessons {
+			if j != i && len(s.c.clashes(lessons[i], lessons[j])) > 0 {
+				if containsInt(moved, j) {
+					return
+				}
+				blocker = j
+				break
+			}
+		}
+		if blocker < 0 {
+			return
+		}
+
+		from := [2]int{lessons[blocker].window.day, lessons[blocker].window.lessonNumber}
+		candidates := append(append([][2]int{}, freed...), s.byDistance(from)...)
+		tried := make(map[[2]int]bool)
+		for _, slot := range candidates {
+			if tried[slot] || slot == from {
+				continue
+			}
+			tried[slot] = true
+			placed := s.c.placed(lessons[blocker], slot[0], slot[1])
+			if !s.c.placeable(placed) {
+				continue
+			}
+			next := append([]plannedLesson{}, lessons...)
+			next[blocker] = placed
+			s.chain(next, append(moved[:len(moved):len(moved)], blocker),
+				append(moves[:len(moves):len(moves)], lessonMove(placed.id, slot)),
+				append(freed[:len(freed):len(freed)], from))
+			if s.budget <= 0 || len(s.chains) == maxChainSuggestions {
+				return
+			}
+		}
+		return
+	}
+	s.chains = append(s.chains, moves)
+}
+
+// byDistance returns the slots sorted by closeness to the given one: the same day first, then nearer lessons
+func (s *moveSearch) byDistance(from [2]int) [][2]int {
+	slots := append([][2]int{}, s.slots...)
+	distance := func(slot [2]int) int {
+		return abs(slot[0]-from[0])*100 + abs(slot[1]-from[1])
+	}
+	sort.SliceStable(slots, func(i, j int) bool {
+		return distance(slots[i]) < distance(slots[j])
+	})
+	return slots
+}
+
+// placed returns the lesson moved to another slot
+func (c *conflictChecker) placed(l plannedLesson, day, lessonNumber int) plannedLesson {
+	classIDs := make([]uuid.UUID, 0, len(l.participants))
+	for _, p := range l.participants {
+		classIDs = append(classIDs, p.classID)
+	}
+	weekPattern := l.window.weekPattern
+	l.window = c.window(day, lessonNumber, classIDs)
+	l.window.weekPattern = weekPattern
+	return l
+}
+
+// placeable reports whether the lesson may take place at its time regardless of other lessons
+func (c *conflictChecker) placeable(l plannedLesson) bool {
+	return len(c.shiftConflicts(l))+len(c.availabilityConflicts(l))+len(c.roomConflicts(l)) == 0
+}
+
+// timetableSlots returns the weekdays (Saturday if it has lessons) times the lesson numbers up to the last one used
+func timetableSlots(days []models.ScheduleDay) [][2]int {
+	maxLesson, lastDay := 0, 5
+	for _, d := range days {
+		if d.LessonNumber > maxLesson {
+			maxLesson = d.LessonNumber
+		}
+		if day := models.DayOfWeekNumber(d.DayOfWeek); day == 6 && len(d.Lessons) > 0 {
+			lastDay = 6
+		}
+	}
+	if maxLesson == 0 {
+		maxLesson = defaultMaxLessonsPerDay
+	}
+
+	var slots [][2]int
+	for day := 1; day <= lastDay; day++ {
+		for n := 1; n <= maxLesson; n++ {
+			slots = append(slots, [2]int{day, n})
+		}
+	}
+	return slots
+}
+
+func lessonMove(lessonID uuid.UUID, slot [2]int) models.LessonMove {
+	return models.LessonMove{
+		LessonID:     lessonID,
+		DayOfWeek:    strings.ToUpper(time.Weekday(slot[0] % 7).String()),
+		LessonNumber: slot[1],
+	}
+}
+
+func abs(n int) int {
+	if n < 0 {
+		return -n
+	}
+	return n
+}
@@ -0,0 +1,169 @@
+package services
+
+import (
+	"fmt"
+	"strings"
+	"testing"
+
+	"github.com/google/uuid"
+	"github.com/nikomkinds/SchoolSchedule/internal/models"
+)
+
+func TestSuggestMovesChains(t *testing.T) {
+	classA, classB := uuid.New(), uuid.New()
+	weekdays := []string{"TUESDAY", "WEDNESDAY", "THURSDAY", "FRIDAY"}
+
+	type lesson struct {
+		name   string
+		number int // on Monday
+		class  uuid.UUID
+		// Monday lesson numbers the teacher can give; nil means any time of the week
+		available []int
+	}
+	type scenario struct {
+		lessons   []lesson
+		maxLesson int
+		move      string // name of the lesson moved to Monday, lesson number moveTo
+		moveTo    int
+	}
+
+	// staircase makes n lessons of a class on Monday 1..n whose teachers can only give lesson i or i+1,
+	// so moving the first one forward shifts every lesson by one into the free lesson n+1
+	staircase := func(n int) scenario {
+		s := scenario{maxLesson: n + 1, move: "L1", moveTo: 2}
+		for i := 1; i <= n; i++ {
+			s.lessons = append(s.lessons, lesson{name: fmt.Sprintf("L%d", i), number: i, class: classA, available: []int{i, i + 1}})
+		}
+		return s
+	}
+
+	tests := []struct {
+		name       string
+		scenario   scenario
+		wantChains int
+		wantFirst  []string // moves of the first chain as "lesson→DAY/number"
+		noSlots    bool
+	}{
+		{
+			name: "a swap is found before longer chains",
+			// Y is in the way; the slot X leaves is tried before the nearer Monday 1 and 3, which are taken
+			scenario: scenario{
+				lessons: []lesson{
+					{name: "W", number: 1, class: classA},
+					{name: "Y", number: 2, class: classA},
+					{name: "Z", number: 3, class: classA},
+					{name: "X", number: 4, class: classA},
+				},
+				maxLesson: 4,
+				move:      "X",
+				moveTo:    2,
+			},
+			wantChains: maxChainSuggestions,
+			wantFirst:  []string{"X→MONDAY/2", "Y→MONDAY/4"},
+		},
+		{
+			name:       "a chain of maxChainMoves moves clears the slot",
+			scenario:   staircase(maxChainMoves + 1),
+			wantChains: 1,
+			wantFirst:  []string{"L1→MONDAY/2", "L2→MONDAY/3", "L3→MONDAY/4", "L4→MONDAY/5"},
+			noSlots:    true,
+		},
+		{
+			name:     "the search stops at maxChainMoves",
+			scenario: staircase(maxChainMoves + 2),
+			noSlots:  true,
+		},
+		{
+			name: "no suggestion when the only conflict is availability",
+			// O has a lesson in the target slot, but with another class and teacher, so nothing is in the way
+			scenario: scenario{
+				lessons: []lesson{
+					{name: "X", number: 1, class: classA, available: []int{1}},
+					{name: "O", number: 2, class: classB},
+				},
+				maxLesson: 2,
+				move:      "X",
+				moveTo:    2,
+			},
+			noSlots: true,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			data := &models.PlanningData{
+				Classes: []models.Class{{ID: classA, Name: "5А", GradeLevel: 5}, {ID: classB, Name: "5Б", GradeLevel: 5}},
+			}
+			names := make(map[uuid.UUID]string)
+			var moved uuid.UUID
+			days := make([]models.ScheduleDay, tt.scenario.maxLesson)
+			for n := range days {
+				days[n] = models.ScheduleDay{DayOfWeek: "MONDAY", LessonNumber: n + 1}
+			}
+			for _, l := range tt.scenario.lessons {
+				teacher := models.Teacher{ID: uuid.New(), LastName: l.name}
+				data.Teachers = append(data.Teachers, teacher)
+				if l.available != nil {
+					a := models.TeacherAvailability{TeacherID: teacher.ID}
+					for _, day := range weekdays {
+						a.Slots = append(a.Slots, models.AvailabilitySlot{DayOfWeek: day, Status: models.AvailabilityUnavailable})
+					}
+					for n := 1; n <= tt.scenario.maxLesson; n++ {
+						if !containsInt(l.available, n) {
+							a.Slots = append(a.Slots, models.AvailabilitySlot{DayOfWeek: "MONDAY", LessonNumber: &n, Status: models.AvailabilityUnavailable})
+						}
+					}
+					data.Availability = append(data.Availability, a)
+				}
+
+				id := uuid.New()
+				names[id] = l.name
+				if l.name == tt.scenario.move {
+					moved = id
+				}
+				days[l.number-1].Lessons = append(days[l.number-1].Lessons, models.ScheduleLesson{
+					ID:           id,
+					Subject:      &models.Subject{ID: uuid.New()},
+					Teachers:     []models.Teacher{teacher},
+					Participants: []models.LessonParticipant{{ClassID: l.class}},
+					WeekPattern:  models.WeekEvery,
+				})
+			}
+
+			c := newConflictChecker(data)
+			suggestions := c.suggestMoves(days, models.LessonMove{LessonID: moved, DayOfWeek: "MONDAY", LessonNumber: tt.scenario.moveTo})
+
+			format := func(moves []models.LessonMove) []string {
+				out := make([]string, len(moves))
+				for i, m := range moves {
+					out[i] = fmt.Sprintf("%s→%s/%d", names[m.LessonID], m.DayOfWeek, m.LessonNumber)
+				}
+				return out
+			}
+			var chains, slots [][]string
+			for _, s := range suggestions {
+				switch s.Kind {
+				case models.SuggestionChain:
+					chains = append(chains, format(s.Moves))
+				case models.SuggestionSlot:
+					slots = append(slots, format(s.Moves))
+				}
+			}
+
+			if len(chains) != tt.wantChains {
+				t.Fatalf("got %d chains %v, want %d", len(chains), chains, tt.wantChains)
+			}
+			for _, chain := range chains {
+				if len(chain) > maxChainMoves+1 {
+					t.Errorf("chain %v is longer than the requested move and %d more", chain, maxChainMoves)
+				}
+			}
+			if tt.wantFirst != nil && strings.Join(chains[0], ", ") != strings.Join(tt.wantFirst, ", ") {
+				t.Errorf("first chain %v, want %v", chains[0], tt.wantFirst)
+			}
+			if tt.noSlots && len(slots) > 0 {
+				t.Errorf("got slot suggestions %v, want none", slots)
+			}
+		})
+	}
+}
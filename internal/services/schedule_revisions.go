@@ -0,0 +1,91 @@
+package services
+
+import (
+	"context"
+	"fmt"
+
+	"github.com/google/uuid"
+	"github.com/nikomkinds/SchoolSchedule/internal/models"
+)
+
+func (s *scheduleService) ListRevisions(ctx context.Context, scheduleID uuid.UUID) ([]models.ScheduleRevision, error) {
+	return s.repo.ListRevisions(ctx, scheduleID)
+}
+
+func (s *scheduleService) GetRevision(ctx context.Context, scheduleID uuid.UUID, version int) ([]models.ScheduleDay, error) {
+	days, err := s.repo.GetRevision(ctx, scheduleID, version)
+	if err != nil {
+		return nil, err
+	}
+
+	// Times are not part of a revision; they come from the current bell schedules
+	bells, err := s.bellRepo.GetAll(ctx)
+	if err != nil {
+		return nil, err
+	}
+	newBellTimes(bells).apply(days, nil)
+	return days, nil
+}
+
+func (s *scheduleService) RestoreRevision(ctx context.Context, scheduleID uuid.UUID, version int, expected *int, note models.RevisionNote) (int, error) {
+	days, err := s.repo.GetRevision(ctx, scheduleID, version)
+	if err != nil {
+		return 0, err
+	}
+	note = withComment(note, fmt.Sprintf("Восстановление версии %d", version))
+	return s.UpdateSchedule(ctx, scheduleID, nil, slotsFromDays(days), expected, note)
+}
+
+// slotsFromDays turns a loaded timetable back into the input of a full save
+func slotsFromDays(days []models.ScheduleDay) []models.ScheduleSlotInput {
+	slots := make([]models.ScheduleSlotInput, 0, len(days))
+	for _, d := range days {
+		slot := models.ScheduleSlotInput{
+			DayOfWeek:    d.DayOfWeek,
+			DayOfWeekInt: models.DayOfWeekNumber(d.DayOfWeek),
+			LessonNumber: d.LessonNumber,
+			Lessons:      make([]models.LessonInput, 0, len(d.Lessons)),
+		}
+		for _, l := range d.Lessons {
+			subjectID := l.SubjectID
+			if l.Subject != nil {
+				subjectID = l.Subject.ID
+			}
+			lesson := models.LessonInput{
+				Subject:     models.SubjectInput{ID: subjectID.String()},
+				WeekPattern: l.WeekPattern,
+			}
+			if l.ID != uuid.Nil {
+				lesson.ID = l.ID.String()
+			}
+			for _, t := range l.Teachers {
+				lesson.Teachers = append(lesson.Teachers, models.TeacherInput{ID: t.ID.String()})
+			}
+			for _, r := range l.Rooms {
+				lesson.Rooms = append(lesson.Rooms, models.ClassroomInput{ID: r.ID.String()})
+			}
+			for _, p := range l.Participants {
+				classID := p.ClassID
+				if p.Class != nil {
+					classID = p.Class.ID
+				}
+				participant := models.ParticipantInput{Class: models.ClassInput{ID: classID.String()}}
+				for _, g := range p.GroupIDs {
+					participant.GroupIDs = append(participant.GroupIDs, g.String())
+				}
+				lesson.Participants = append(lesson.Participants, participant)
+			}
+			slot.Lessons = append(slot.Lessons, lesson)
+		}
+		slots = append(slots, slot)
+	}
+	return slots
+}
+
+// withComment sets the comment of a revision note the client left empty
+func withComment(note models.RevisionNote, comment string) models.RevisionNote {
+	if note.Comment == "" {
+		note.Comment = comment
+	}
+	return note
+}
@@ -0,0 +1,39 @@
+package services
+
+import (
+	"testing"
+
+	"github.com/google/uuid"
+	"github.com/nikomkinds/SchoolSchedule/internal/models"
+)
+
+// A restore saves the revision's timetable as a whole; the lessons must keep their IDs for their
+// overrides on single dates to survive it
+func TestSlotsFromDaysKeepsLessonIDs(t *testing.T) {
+	lessonID, subjectID, classID := uuid.New(), uuid.New(), uuid.New()
+	days := []models.ScheduleDay{{
+		DayOfWeek:    "MONDAY",
+		LessonNumber: 2,
+		Lessons: []models.ScheduleLesson{{
+			ID:           lessonID,
+			Subject:      &models.Subject{ID: subjectID},
+			WeekPattern:  models.WeekOdd,
+			Participants: []models.LessonParticipant{{ClassID: classID}},
+		}},
+	}}
+
+	slots := slotsFromDays(days)
+	if len(slots) != 1 || len(slots[0].Lessons) != 1 {
+		t.Fatalf("got %+v, want one slot with one lesson", slots)
+	}
+	l := slots[0].Lessons[0]
+	if l.ID != lessonID.String() {
+		t.Errorf("lesson ID = %q, want %q", l.ID, lessonID)
+	}
+	if l.Subject.ID != subjectID.String() || l.WeekPattern != models.WeekOdd {
+		t.Errorf("lesson = %+v, want subject %s in odd weeks", l, subjectID)
+	}
+	if len(l.Participants) != 1 || l.Participants[0].Class.ID != classID.String() {
+		t.Errorf("participants = %+v, want class %s", l.Participants, classID)
+	}
+}
@@ -0,0 +1,129 @@
+package services
+
+import (
+	"fmt"
+	"strings"
+
+	"github.com/google/uuid"
+	"github.com/nikomkinds/SchoolSchedule/internal/models"
+)
+
+// Conflict types reported for rooms that do not fit the lesson
+const (
+	ConflictRoomCapacity  = "classroom_capacity"
+	ConflictRoomEquipment = "classroom_equipment"
+)
+
+// headcount returns the number of students attending the lesson: the class size for whole-class
+// participants and the sum of group sizes otherwise. ok is false if any size is unknown.
+func (c *conflictChecker) headcount(l plannedLesson) (int, bool) {
+	total := 0
+	for _, p := range l.participants {
+		class, ok := c.classes[p.classID]
+		if !ok {
+			return 0, false
+		}
+		if len(p.groupIDs) == 0 {
+			if class.TotalStudents == nil {
+				return 0, false
+			}
+			total += *class.TotalStudents
+			continue
+		}
+		for _, gid := range p.groupIDs {
+			size, ok := groupSize(class, gid)
+			if !ok {
+				return 0, false
+			}
+			total += size
+		}
+	}
+	return total, len(l.participants) > 0
+}
+
+func groupSize(class *models.Class, groupID uuid.UUID) (int, bool) {
+	for _, g := range class.Groups {
+		if g.ID == groupID && g.Size != nil {
+			return *g.Size, true
+		}
+	}
+	return 0, false
+}
+
+// missingEquipment returns the subject's required equipment tags the room lacks
+func (c *conflictChecker) missingEquipment(subjectID, roomID uuid.UUID) []string {
+	subject, ok := c.subjects[subjectID]
+	if !ok {
+		return nil
+	}
+	room, ok := c.rooms[roomID]
+	if !ok {
+		return nil
+	}
+
+	var missing []string
+	for _, tag := range subject.RequiredEquipment {
+		if !containsString(room.Equipment, tag) {
+			missing = append(missing, tag)
+		}
+	}
+	return missing
+}
+
+// roomFits reports whether a single room holds all students of the lesson and has the required equipment
+func (c *conflictChecker) roomFits(l plannedLesson, roomID uuid.UUID) bool {
+	if len(c.missingEquipment(l.subjectID, roomID)) > 0 {
+		return false
+	}
+	room, ok := c.rooms[roomID]
+	if !ok || room.Capacity == nil {
+		return true
+	}
+	n, ok := c.headcount(l)
+	return !ok || n <= *room.Capacity
+}
+
+// roomConflicts reports rooms lacking required equipment and rooms too small for the students.
+// A lesson held in several rooms (split groups) is checked against their total capacity.
+func (c *conflictChecker) roomConflicts(l plannedLesson) []models.ConflictDetail {
+	if len(l.rooms) == 0 {
+		return nil
+	}
+
+	var details []models.ConflictDetail
+	for _, r := range l.rooms {
+		if missing := c.missingEquipment(l.subjectID, r); len(missing) > 0 {
+			details = append(details, c.detail(l, ConflictRoomEquipment,
+				fmt.Sprintf("В кабинете %s нет оборудования: %s", c.roomName(r), strings.Join(missing, ", "))))
+		}
+	}
+
+	n, ok := c.headcount(l)
+	if !ok {
+		return details
+	}
+	capacity := 0
+	names := make([]string, 0, len(l.rooms))
+	for _, r := range l.rooms {
+		room, ok := c.rooms[r]
+		if !ok || room.Capacity == nil {
+			return details
+		}
+		capacity += *room.Capacity
+		names = append(names, room.Name)
+	}
+	if n > capacity {
+		details = append(details, c.detail(l, ConflictRoomCapacity,
+			fmt.Sprintf("Кабинет %s вмещает %d учеников, а на уроке %d", strings.Join(names, ", "), capacity, n)))
+	}
+	return details
+}
+
+func containsString(list []string, s string) bool {
+	for _, v := range list {
+		if v == s {
+			return true
+		}
+	}
+	return false
+}
@@ -2,61 +2,482 @@ package services
 
 import (
 	"context"
+	"database/sql"
+	"errors"
+	"fmt"
+	"strings"
 
 	"github.com/google/uuid"
 	"github.com/nikomkinds/SchoolSchedule/internal/models"
 	"github.com/nikomkinds/SchoolSchedule/internal/repositories"
 )
 
+// ErrInvalidWeekPattern is returned when a lesson has an unknown week pattern
+var ErrInvalidWeekPattern = errors.New("invalid week pattern, allowed: every, odd, even")
+
+// ErrEmptyScheduleName is returned when a schedule is renamed to a blank name
+var ErrEmptyScheduleName = errors.New("schedule name is required")
+
+// ErrScheduleInUse is returned when a schedule in review, approved or published is about to be deleted
+var ErrScheduleInUse = repositories.ErrScheduleInUse
+
+// ErrActivationNoEffect is returned when an activated schedule would not be resolved for its owner from today on:
+// GetActiveScheduleID never resolves an archived schedule, and one bound to a term only on the dates of the term
+var ErrActivationNoEffect = errors.New("activation would have no effect")
+
 type ScheduleService interface {
-	// GetSchedule loads the active schedule for a specific user
-	GetSchedule(ctx context.Context, userID uuid.UUID) ([]models.ScheduleDay, error)
+	// GetSchedule loads the schedule of a specific user that is active on the given date
+	GetSchedule(ctx context.Context, userID uuid.UUID, date models.Date) ([]models.ScheduleDay, error)
+	// GetScheduleWeek loads the calendar week containing the date, keeping only lessons of the week's parity
+	GetScheduleWeek(ctx context.Context, userID uuid.UUID, date models.Date) (*models.ScheduleWeek, error)
+	// GetActiveScheduleID resolves the schedule of a user that is active on the given date
+	GetActiveScheduleID(ctx context.Context, userID uuid.UUID, date models.Date) (uuid.UUID, error)
+	// GetPublishedScheduleID resolves the published school timetable valid on the given date, sql.ErrNoRows if none
+	GetPublishedScheduleID(ctx context.Context, date models.Date) (uuid.UUID, error)
+	// GetPlanningData loads the school data conflicts are checked against, with the study plans of the term
+	GetPlanningData(ctx context.Context, termID *uuid.UUID) (*models.PlanningData, error)
 	// GetScheduleByID loads a specific named schedule by its ID
 	GetScheduleByID(ctx context.Context, scheduleID uuid.UUID) (*models.Schedule, error)
-	// GetAllSchedules loads all schedules for a specific user
-	GetAllSchedules(ctx context.Context, userID uuid.UUID) ([]models.Schedule, error)
+	// GetScheduleContent loads a named schedule with its lessons, times resolved for the week of the date
+	GetScheduleContent(ctx context.Context, scheduleID uuid.UUID, date models.Date) (*models.ScheduleContent, error)
+	// GetScheduleContentWeek loads a named schedule with the calendar week containing the date, like GetScheduleWeek
+	GetScheduleContentWeek(ctx context.Context, scheduleID uuid.UUID, date models.Date) (*models.ScheduleContent, error)
+	// GetAllSchedules loads the schedules the user sees: own ones, shared ones and published ones
+	GetAllSchedules(ctx context.Context, actor models.Actor) ([]models.Schedule, error)
 	// CreateSchedule creates a new named schedule
 	CreateSchedule(ctx context.Context, userID uuid.UUID, schedule models.Schedule, slots []models.ScheduleSlotInput) (*models.Schedule, error)
-	// UpdateSchedule updates an existing schedule
-	UpdateSchedule(ctx context.Context, scheduleID uuid.UUID, name *string, slots []models.ScheduleSlotInput) error
-	// DeleteSchedule deletes a schedule
+	// UpdateSchedule updates an existing schedule and returns its new version. With version set, a schedule
+	// changed in the meantime is not updated and a *ScheduleVersionError is returned.
+	UpdateSchedule(ctx context.Context, scheduleID uuid.UUID, name *string, slots []models.ScheduleSlotInput, version *int, note models.RevisionNote) (int, error)
+	// DeleteSchedule deletes a draft or archived schedule; others return ErrScheduleInUse. A schedule in review
+	// or approved goes back to draft on withdraw, reject or reopen; a published one is archived when its replacement is published.
 	DeleteSchedule(ctx context.Context, scheduleID uuid.UUID) error
-	// GenerateSchedule generates a schedule based on study plans and workload
-	GenerateSchedule(ctx context.Context, req models.GenerateScheduleRequest) ([]models.ScheduleDay, error)
+	// CloneSchedule copies a schedule with all its lessons into a new inactive schedule of the user
+	CloneSchedule(ctx context.Context, userID, sourceID uuid.UUID, req models.CloneScheduleRequest) (*models.Schedule, error)
+	// RenameSchedule changes the name of a schedule and returns the schedule
+	RenameSchedule(ctx context.Context, scheduleID uuid.UUID, name string) (*models.Schedule, error)
+	// ActivateSchedule makes a schedule the active one of its owner, deactivating the others. GetActiveScheduleID
+	// resolves the active schedule for the owner ahead of the published one, on the dates of its term or on any date
+	// without a term. An archived schedule or one whose term ended before today is not activated (ErrActivationNoEffect).
+	ActivateSchedule(ctx context.Context, scheduleID uuid.UUID) (*models.Schedule, error)
+	// GenerateSchedule generates a schedule based on study plans and workload; it is not saved
+	GenerateSchedule(ctx context.Context, req models.GenerateScheduleRequest) (*models.GenerateScheduleResult, error)
+	// FindFreeSlots returns slots of the active schedule where all given teachers, classes and groups are free,
+	// those adding the fewest windows first
+	FindFreeSlots(ctx context.Context, userID uuid.UUID, req models.FreeSlotsRequest) ([]models.FreeSlot, error)
+	// FillRooms assigns rooms to the lessons of a schedule that have none and saves them.
+	// Like the lesson edits below it checks the version as UpdateSchedule does and returns the new one.
+	FillRooms(ctx context.Context, scheduleID uuid.UUID, version *int, note models.RevisionNote) (*models.FillRoomsResult, int, error)
+	// AddLesson adds a lesson to a named schedule and returns its slot; only the new lesson is checked for conflicts
+	AddLesson(ctx context.Context, scheduleID uuid.UUID, req models.ScheduleLessonRequest, version *int, note models.RevisionNote) (*models.ScheduleDay, int, error)
+	// UpdateLesson replaces a lesson of a named schedule and returns its slot; only that lesson is checked for conflicts
+	UpdateLesson(ctx context.Context, scheduleID, lessonID uuid.UUID, req models.ScheduleLessonRequest, version *int, note models.RevisionNote) (*models.ScheduleDay, int, error)
+	// MoveLesson moves a lesson to another slot and returns that slot; only the moved lesson is checked for conflicts.
+	// A rejected move's *ConflictError carries suggestions: other slots or chains of moves clearing the requested one.
+	MoveLesson(ctx context.Context, scheduleID, lessonID uuid.UUID, req models.MoveLessonRequest, version *int, note models.RevisionNote) (*models.ScheduleDay, int, error)
+	// SwapLessons swaps the slots of two lessons and returns both slots
+	SwapLessons(ctx context.Context, scheduleID, lessonID uuid.UUID, req models.SwapLessonsRequest, version *int, note models.RevisionNote) ([]models.ScheduleDay, int, error)
+	// RearrangeLessons applies several moves at once, e.g. a suggested chain, and returns the slots of the moved lessons
+	RearrangeLessons(ctx context.Context, scheduleID uuid.UUID, req models.RearrangeLessonsRequest, version *int, note models.RevisionNote) ([]models.ScheduleDay, int, error)
+	// DeleteLesson deletes a lesson of a named schedule
+	DeleteLesson(ctx context.Context, scheduleID, lessonID uuid.UUID, version *int, note models.RevisionNote) (int, error)
+	// CheckScheduleAccess returns ErrScheduleForbidden unless the actor has at least the given access to the schedule
+	CheckScheduleAccess(ctx context.Context, scheduleID uuid.UUID, actor models.Actor, need string) error
+	// CheckOverrideAccess returns ErrScheduleForbidden unless the actor may change lessons of the schedule
+	// on single dates (cancel, substitute, move)
+	CheckOverrideAccess(ctx context.Context, scheduleID uuid.UUID, actor models.Actor) error
+	// TransitionSchedule applies a workflow action (submit, withdraw, approve, reject, reopen, publish)
+	// to a schedule and returns it with the new status
+	TransitionSchedule(ctx context.Context, scheduleID uuid.UUID, actor models.Actor, req models.ScheduleTransitionRequest) (*models.Schedule, error)
+	// ListTransitions loads the status history of a schedule
+	ListTransitions(ctx context.Context, scheduleID uuid.UUID) ([]models.ScheduleTransition, error)
+	// ListShares lists who a schedule is shared with
+	ListShares(ctx context.Context, scheduleID uuid.UUID) ([]models.ScheduleShare, error)
+	// ShareSchedule shares a schedule with a user or a role, or changes the permission of the existing share
+	ShareSchedule(ctx context.Context, scheduleID uuid.UUID, req models.ShareScheduleRequest) (*models.ScheduleShare, error)
+	// DeleteShare revokes a share of a schedule
+	DeleteShare(ctx context.Context, scheduleID, shareID uuid.UUID) error
+	// CompareSchedules lists the differences between two schedules or two versions of one schedule
+	CompareSchedules(ctx context.Context, from, to models.ScheduleRef) (*models.ScheduleDiff, error)
+	// ListRevisions lists the saved versions of a schedule, newest first
+	ListRevisions(ctx context.Context, scheduleID uuid.UUID) ([]models.ScheduleRevision, error)
+	// GetRevision loads the timetable of a schedule as of the given version
+	GetRevision(ctx context.Context, scheduleID uuid.UUID, version int) ([]models.ScheduleDay, error)
+	// RestoreRevision saves the timetable of an earlier version as a new one and returns the new version;
+	// expected is checked like the version of UpdateSchedule
+	RestoreRevision(ctx context.Context, scheduleID uuid.UUID, version int, expected *int, note models.RevisionNote) (int, error)
 }
 
 type scheduleService struct {
-	repo repositories.ScheduleRepository
+	repo             repositories.ScheduleRepository
+	bellRepo         repositories.BellScheduleRepository
+	planningRepo     repositories.PlanningRepository
+	shiftRepo        repositories.ShiftRepository
+	academicYearRepo repositories.AcademicYearRepository
+}
+
+func NewScheduleService(
+	repo repositories.ScheduleRepository,
+	bellRepo repositories.BellScheduleRepository,
+	planningRepo repositories.PlanningRepository,
+	shiftRepo repositories.ShiftRepository,
+	academicYearRepo repositories.AcademicYearRepository,
+	audit AuditService,
+) ScheduleService {
+	service := &scheduleService{
+		repo:             repo,
+		bellRepo:         bellRepo,
+		planningRepo:     planningRepo,
+		shiftRepo:        shiftRepo,
+		academicYearRepo: academicYearRepo,
+	}
+	return &auditedScheduleService{ScheduleService: service, repo: repo, audit: audit}
+}
+
+func (s *scheduleService) GetSchedule(ctx context.Context, userID uuid.UUID, date models.Date) ([]models.ScheduleDay, error) {
+	scheduleID, err := s.repo.GetActiveScheduleID(ctx, userID, date)
+	if err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			// Without an active schedule the timetable is empty
+			return []models.ScheduleDay{}, nil
+		}
+		return nil, fmt.Errorf("failed to find active schedule: %w", err)
+	}
+	return s.scheduleDays(ctx, scheduleID, date)
+}
+
+func (s *scheduleService) GetScheduleWeek(ctx context.Context, userID uuid.UUID, date models.Date) (*models.ScheduleWeek, error) {
+	days, err := s.GetSchedule(ctx, userID, date)
+	if err != nil {
+		return nil, err
+	}
+	return s.weekOf(ctx, days, date)
+}
+
+func (s *scheduleService) GetScheduleContent(ctx context.Context, scheduleID uuid.UUID, date models.Date) (*models.ScheduleContent, error) {
+	schedule, err := s.repo.GetScheduleByID(ctx, scheduleID)
+	if err != nil {
+		return nil, err
+	}
+	days, err := s.scheduleDays(ctx, scheduleID, date)
+	if err != nil {
+		return nil, err
+	}
+	return &models.ScheduleContent{Schedule: *schedule, ScheduleSlots: days}, nil
+}
+
+func (s *scheduleService) GetScheduleContentWeek(ctx context.Context, scheduleID uuid.UUID, date models.Date) (*models.ScheduleContent, error) {
+	content, err := s.GetScheduleContent(ctx, scheduleID, date)
+	if err != nil {
+		return nil, err
+	}
+	week, err := s.weekOf(ctx, content.ScheduleSlots, date)
+	if err != nil {
+		return nil, err
+	}
+	content.ScheduleSlots, content.WeekStart, content.Parity = week.Days, &week.WeekStart, week.Parity
+	return content, nil
+}
+
+// scheduleDays loads the timetable of a named schedule with times from the bell schedules valid in the week of the date
+func (s *scheduleService) scheduleDays(ctx context.Context, scheduleID uuid.UUID, date models.Date) ([]models.ScheduleDay, error) {
+	days, err := s.repo.GetScheduleDays(ctx, scheduleID)
+	if err != nil {
+		return nil, err
+	}
+
+	bells, err := s.bellRepo.GetAll(ctx)
+	if err != nil {
+		return nil, err
+	}
+	weekStart := date.WeekStart()
+	newBellTimes(bells).apply(days, &weekStart)
+
+	return days, nil
+}
+
+// weekOf keeps the lessons of the timetable that take place in the calendar week containing the date
+func (s *scheduleService) weekOf(ctx context.Context, days []models.ScheduleDay, date models.Date) (*models.ScheduleWeek, error) {
+	parity, err := s.weekParity(ctx, date)
+	if err != nil {
+		return nil, err
+	}
+
+	week := &models.ScheduleWeek{
+		WeekStart: date.WeekStart(),
+		Parity:    parity,
+		Days:      make([]models.ScheduleDay, 0, len(days)),
+	}
+	for _, day := range days {
+		lessons := make([]models.ScheduleLesson, 0, len(day.Lessons))
+		for _, l := range day.Lessons {
+			if models.WeekMatches(l.WeekPattern, parity) {
+				lessons = append(lessons, l)
+			}
+		}
+		if len(lessons) == 0 {
+			continue
+		}
+		day.Lessons = lessons
+		week.Days = append(week.Days, day)
+	}
+
+	return week, nil
+}
+
+// weekParity counts weeks from the start of the academic year containing the date.
+// Outside of academic years the ISO week number is used.
+func (s *scheduleService) weekParity(ctx context.Context, date models.Date) (string, error) {
+	year, err := s.academicYearRepo.GetYearByDate(ctx, date)
+	if err != nil {
+		if !errors.Is(err, sql.ErrNoRows) {
+			return "", err
+		}
+		if _, n := date.ISOWeek(); n%2 == 0 {
+			return models.WeekEven, nil
+		}
+		return models.WeekOdd, nil
+	}
+	return date.WeekParity(year.StartDate), nil
+}
+
+func (s *scheduleService) GetActiveScheduleID(ctx context.Context, userID uuid.UUID, date models.Date) (uuid.UUID, error) {
+	return s.repo.GetActiveScheduleID(ctx, userID, date)
 }
 
-func NewScheduleService(repo repositories.ScheduleRepository) ScheduleService {
-	return &scheduleService{repo: repo}
+func (s *scheduleService) GetPublishedScheduleID(ctx context.Context, date models.Date) (uuid.UUID, error) {
+	return s.repo.GetPublishedScheduleID(ctx, date)
 }
 
-func (s *scheduleService) GetSchedule(ctx context.Context, userID uuid.UUID) ([]models.ScheduleDay, error) {
-	return s.repo.GetSchedule(ctx, userID)
+func (s *scheduleService) GetPlanningData(ctx context.Context, termID *uuid.UUID) (*models.PlanningData, error) {
+	return s.loadPlanningData(ctx, termID)
 }
 
 func (s *scheduleService) GetScheduleByID(ctx context.Context, scheduleID uuid.UUID) (*models.Schedule, error) {
 	return s.repo.GetScheduleByID(ctx, scheduleID)
 }
 
-func (s *scheduleService) GetAllSchedules(ctx context.Context, userID uuid.UUID) ([]models.Schedule, error) {
-	return s.repo.GetAllSchedules(ctx, userID)
+func (s *scheduleService) GetAllSchedules(ctx context.Context, actor models.Actor) ([]models.Schedule, error) {
+	return s.repo.GetAllSchedules(ctx, actor.UserID, actor.Role)
 }
 
 func (s *scheduleService) CreateSchedule(ctx context.Context, userID uuid.UUID, schedule models.Schedule, slots []models.ScheduleSlotInput) (*models.Schedule, error) {
+	if err := s.checkConflicts(ctx, schedule.TermID, slots); err != nil {
+		return nil, err
+	}
 	return s.repo.CreateSchedule(ctx, userID, schedule, slots)
 }
 
-func (s *scheduleService) UpdateSchedule(ctx context.Context, scheduleID uuid.UUID, name *string, slots []models.ScheduleSlotInput) error {
-	return s.repo.UpdateSchedule(ctx, scheduleID, name, slots)
+func (s *scheduleService) UpdateSchedule(ctx context.Context, scheduleID uuid.UUID, name *string, slots []models.ScheduleSlotInput, version *int, note models.RevisionNote) (int, error) {
+	// Conflicts are checked against the study plans and workload of the schedule's term
+	schedule, err := s.repo.GetScheduleByID(ctx, scheduleID)
+	if err != nil {
+		return 0, err
+	}
+	if err := s.checkConflicts(ctx, schedule.TermID, slots); err != nil {
+		return 0, err
+	}
+	newVersion, err := s.repo.UpdateSchedule(ctx, scheduleID, name, slots, version, note)
+	if errors.Is(err, repositories.ErrVersionMismatch) {
+		return 0, s.versionError(ctx, scheduleID, *version)
+	}
+	return newVersion, err
 }
 
 func (s *scheduleService) DeleteSchedule(ctx context.Context, scheduleID uuid.UUID) error {
 	return s.repo.DeleteSchedule(ctx, scheduleID)
 }
 
-func (s *scheduleService) GenerateSchedule(ctx context.Context, req models.GenerateScheduleRequest) ([]models.ScheduleDay, error) {
-	return s.repo.GenerateSchedule(ctx, req)
+func (s *scheduleService) CloneSchedule(ctx context.Context, userID, sourceID uuid.UUID, req models.CloneScheduleRequest) (*models.Schedule, error) {
+	source, err := s.repo.GetScheduleByID(ctx, sourceID)
+	if err != nil {
+		return nil, err
+	}
+	days, err := s.repo.GetScheduleDays(ctx, sourceID)
+	if err != nil {
+		return nil, err
+	}
+
+	// A clone is always a draft; cloning is how a published schedule gets changed
+	clone := models.Schedule{Name: strings.TrimSpace(req.Name), TermID: source.TermID, DerivedFrom: &source.ID}
+	if clone.Name == "" {
+		clone.Name = source.Name + " (копия)"
+	}
+	if req.TermID != nil {
+		clone.TermID = req.TermID
+	}
+	// The lessons were checked when saved into the source, so they are copied as is
+	return s.repo.CreateSchedule(ctx, userID, clone, slotsFromDays(days))
+}
+
+func (s *scheduleService) RenameSchedule(ctx context.Context, scheduleID uuid.UUID, name string) (*models.Schedule, error) {
+	name = strings.TrimSpace(name)
+	if name == "" {
+		return nil, ErrEmptyScheduleName
+	}
+	if err := s.repo.RenameSchedule(ctx, scheduleID, name); err != nil {
+		return nil, err
+	}
+	return s.repo.GetScheduleByID(ctx, scheduleID)
+}
+
+func (s *scheduleService) ActivateSchedule(ctx context.Context, scheduleID uuid.UUID) (*models.Schedule, error) {
+	schedule, err := s.repo.GetScheduleByID(ctx, scheduleID)
+	if err != nil {
+		return nil, err
+	}
+	// The same conditions as in GetActiveScheduleID: archived schedules are never resolved, and
+	// a schedule bound to a term only on its dates, so an ended term is not resolved from today on
+	if schedule.Status == models.ScheduleStatusArchived {
+		return nil, fmt.Errorf("%w: the schedule is archived", ErrActivationNoEffect)
+	}
+	if schedule.TermID != nil {
+		term, err := s.academicYearRepo.GetTermByID(ctx, *schedule.TermID)
+		if err != nil {
+			return nil, err
+		}
+		if term.EndDate.Before(models.Today().Time) {
+			return nil, fmt.Errorf("%w: its term %s ended on %s", ErrActivationNoEffect, term.Name, term.EndDate)
+		}
+	}
+
+	if err := s.repo.ActivateSchedule(ctx, scheduleID); err != nil {
+		return nil, err
+	}
+	return s.repo.GetScheduleByID(ctx, scheduleID)
+}
+
+func (s *scheduleService) GenerateSchedule(ctx context.Context, req models.GenerateScheduleRequest) (*models.GenerateScheduleResult, error) {
+	termID := req.TermID
+	if termID == nil {
+		term, err := s.academicYearRepo.GetTermByDate(ctx, models.Today())
+		if err != nil && !errors.Is(err, sql.ErrNoRows) {
+			return nil, err
+		}
+		if term != nil {
+			termID = &term.ID
+		}
+	}
+
+	data, err := s.loadPlanningData(ctx, termID)
+	if err != nil {
+		return nil, err
+	}
+
+	result := newScheduleGenerator(data, req).generate()
+	newBellTimes(data.Bells).apply(result.Data, nil)
+	return result, nil
+}
+
+func (s *scheduleService) FillRooms(ctx context.Context, scheduleID uuid.UUID, version *int, note models.RevisionNote) (*models.FillRoomsResult, int, error) {
+	schedule, err := s.repo.GetScheduleByID(ctx, scheduleID)
+	if err != nil {
+		return nil, 0, err
+	}
+	// Rooms are picked from the stored timetable, so an outdated client is rejected before the search
+	if version != nil && *version != schedule.Version {
+		return nil, 0, s.versionError(ctx, scheduleID, *version)
+	}
+	days, err := s.repo.GetScheduleDays(ctx, scheduleID)
+	if err != nil {
+		return nil, 0, err
+	}
+	data, err := s.loadPlanningData(ctx, schedule.TermID)
+	if err != nil {
+		return nil, 0, err
+	}
+
+	checker := newConflictChecker(data)
+	assigner := newRoomAssigner(checker, data)
+
+	// Lessons that already have rooms keep them and block those rooms for the rest
+	planned := make(map[int][]plannedLesson)
+	for _, d := range days {
+		day := models.DayOfWeekNumber(d.DayOfWeek)
+		for _, lesson := range d.Lessons {
+			if len(lesson.Rooms) > 0 {
+				planned[day] = append(planned[day], checker.fromLesson(day, d.LessonNumber, lesson))
+			}
+		}
+	}
+
+	result := &models.FillRoomsResult{Assigned: []models.RoomAssignment{}, Unassigned: []models.RoomAssignment{}}
+	rooms := make(map[uuid.UUID]uuid.UUID)
+	for _, d := range days {
+		day := models.DayOfWeekNumber(d.DayOfWeek)
+		for _, lesson := range d.Lessons {
+			if len(lesson.Rooms) > 0 {
+				continue
+			}
+			entry := models.RoomAssignment{
+				LessonID:     lesson.ID,
+				DayOfWeek:    d.DayOfWeek,
+				LessonNumber: d.LessonNumber,
+				Subject:      lesson.Subject,
+			}
+
+			l := checker.fromLesson(day, d.LessonNumber, lesson)
+			room, ok := assigner.pick(l, planned[day])
+			if !ok {
+				result.Unassigned = append(result.Unassigned, entry)
+				continue
+			}
+
+			l.rooms = []uuid.UUID{room}
+			planned[day] = append(planned[day], l)
+			rooms[lesson.ID] = room
+			r := checker.rooms[room]
+			entry.Room = &models.Classroom{ID: r.ID, Name: r.Name, Capacity: r.Capacity, Equipment: r.Equipment}
+			result.Assigned = append(result.Assigned, entry)
+		}
+	}
+
+	if len(rooms) == 0 {
+		return result, schedule.Version, nil
+	}
+	newVersion, err := s.repo.AddLessonRooms(ctx, scheduleID, rooms, version, withComment(note, "Расстановка кабинетов"))
+	if err != nil {
+		return nil, 0, s.lessonEditError(ctx, scheduleID, version, err)
+	}
+	return result, newVersion, nil
+}
+
+// checkConflicts validates the slots against each other and returns a *ConflictError on clashes
+func (s *scheduleService) checkConflicts(ctx context.Context, termID *uuid.UUID, slots []models.ScheduleSlotInput) error {
+	if len(slots) == 0 {
+		return nil
+	}
+	for _, slot := range slots {
+		for _, l := range slot.Lessons {
+			if !models.ValidWeekPattern(l.WeekPattern) {
+				return fmt.Errorf("%w: %q", ErrInvalidWeekPattern, l.WeekPattern)
+			}
+		}
+	}
+
+	data, err := s.loadPlanningData(ctx, termID)
+	if err != nil {
+		return err
+	}
+
+	checker := newConflictChecker(data)
+	if details := checker.check(checker.fromSlots(slots)); len(details) > 0 {
+		return &ConflictError{Details: details}
+	}
+	return nil
+}
+
+// loadPlanningData loads school data together with shifts and bell schedules
+func (s *scheduleService) loadPlanningData(ctx context.Context, termID *uuid.UUID) (*models.PlanningData, error) {
+	data, err := s.planningRepo.GetPlanningData(ctx, termID)
+	if err != nil {
+		return nil, err
+	}
+	if data.Shifts, err = s.shiftRepo.GetAll(ctx); err != nil {
+		return nil, err
+	}
+	if data.Bells, err = s.bellRepo.GetAll(ctx); err != nil {
+		return nil, err
+	}
+	return data, nil
 }
@@ -0,0 +1,90 @@
+package services
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"strings"
+
+	"github.com/google/uuid"
+	"github.com/nikomkinds/SchoolSchedule/internal/models"
+	"github.com/nikomkinds/SchoolSchedule/internal/repositories"
+)
+
+// ErrInvalidTransition is returned for an unknown workflow action or one missing a required comment
+var ErrInvalidTransition = errors.New("invalid transition")
+
+// ErrTransitionNotAllowed is returned when the schedule is not in the status the action starts from
+var ErrTransitionNotAllowed = errors.New("transition not allowed in the current status")
+
+type scheduleTransition struct {
+	from, to string
+	roles    []string // Roles allowed to take the action; nil allows any role
+	access   string   // Access to the schedule the action needs, besides the role
+	comment  bool     // The action must be explained
+}
+
+// scheduleTransitions is the workflow: a draft is submitted for review, approved or rejected by an
+// admin, and the approved schedule is published by its owner
+var scheduleTransitions = map[string]scheduleTransition{
+	"submit": {
+		from: models.ScheduleStatusDraft, to: models.ScheduleStatusReview,
+		access: models.ScheduleAccessEdit,
+	},
+	"withdraw": {
+		from: models.ScheduleStatusReview, to: models.ScheduleStatusDraft,
+		access: models.ScheduleAccessEdit,
+	},
+	"approve": {
+		from: models.ScheduleStatusReview, to: models.ScheduleStatusApproved,
+		roles: []string{models.RoleAdmin}, access: models.ScheduleAccessView,
+	},
+	"reject": {
+		from: models.ScheduleStatusReview, to: models.ScheduleStatusDraft,
+		roles: []string{models.RoleAdmin}, access: models.ScheduleAccessView, comment: true,
+	},
+	"reopen": {
+		from: models.ScheduleStatusApproved, to: models.ScheduleStatusDraft,
+		access: models.ScheduleAccessOwner,
+	},
+	"publish": {
+		from: models.ScheduleStatusApproved, to: models.ScheduleStatusPublished,
+		roles: []string{models.RoleAdmin, models.RoleScheduler}, access: models.ScheduleAccessOwner,
+	},
+}
+
+func (s *scheduleService) TransitionSchedule(ctx context.Context, scheduleID uuid.UUID, actor models.Actor, req models.ScheduleTransitionRequest) (*models.Schedule, error) {
+	t, ok := scheduleTransitions[req.Action]
+	if !ok {
+		return nil, fmt.Errorf("%w: unknown action %q", ErrInvalidTransition, req.Action)
+	}
+	comment := strings.TrimSpace(req.Comment)
+	if t.comment && comment == "" {
+		return nil, fmt.Errorf("%w: %s needs a comment", ErrInvalidTransition, req.Action)
+	}
+
+	access, status, err := s.scheduleAccess(ctx, scheduleID, actor)
+	if err != nil {
+		return nil, err
+	}
+	if accessRank[access] < accessRank[t.access] || (t.roles != nil && !containsString(t.roles, actor.Role)) {
+		return nil, ErrScheduleForbidden
+	}
+	if status != t.from {
+		return nil, fmt.Errorf("%w: cannot %s a schedule in status %s", ErrTransitionNotAllowed, req.Action, status)
+	}
+
+	err = s.repo.TransitionSchedule(ctx, scheduleID, t.from, t.to, &actor.UserID, comment)
+	if errors.Is(err, repositories.ErrStatusMismatch) {
+		// Changed by someone else since it was read above
+		return nil, fmt.Errorf("%w: the schedule status has changed", ErrTransitionNotAllowed)
+	}
+	if err != nil {
+		return nil, err
+	}
+	return s.repo.GetScheduleByID(ctx, scheduleID)
+}
+
+func (s *scheduleService) ListTransitions(ctx context.Context, scheduleID uuid.UUID) ([]models.ScheduleTransition, error) {
+	return s.repo.ListTransitions(ctx, scheduleID)
+}
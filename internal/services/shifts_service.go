@@ -0,0 +1,79 @@
+package services
+
+import (
+	"context"
+	"errors"
+
+	"github.com/google/uuid"
+	"github.com/nikomkinds/SchoolSchedule/internal/models"
+	"github.com/nikomkinds/SchoolSchedule/internal/repositories"
+)
+
+// ErrInvalidShift is returned when a shift has an empty or inverted lesson range
+var ErrInvalidShift = errors.New("invalid shift: lesson range must be positive and firstLesson <= lastLesson")
+
+type ShiftService interface {
+	GetAll(ctx context.Context) ([]models.Shift, error)
+	Create(ctx context.Context, req models.ShiftRequest) (*models.Shift, error)
+	Update(ctx context.Context, id uuid.UUID, req models.ShiftRequest) (*models.Shift, error)
+	Delete(ctx context.Context, id uuid.UUID) error
+	AssignClasses(ctx context.Context, id uuid.UUID, classIDs []uuid.UUID) error
+}
+
+type shiftService struct {
+	repo repositories.ShiftRepository
+}
+
+func NewShiftService(repo repositories.ShiftRepository) ShiftService {
+	return &shiftService{repo: repo}
+}
+
+func (s *shiftService) GetAll(ctx context.Context) ([]models.Shift, error) {
+	return s.repo.GetAll(ctx)
+}
+
+func (s *shiftService) Create(ctx context.Context, req models.ShiftRequest) (*models.Shift, error) {
+	shift, err := shiftFromRequest(req)
+	if err != nil {
+		return nil, err
+	}
+	return s.repo.Create(ctx, *shift)
+}
+
+func (s *shiftService) Update(ctx context.Context, id uuid.UUID, req models.ShiftRequest) (*models.Shift, error) {
+	shift, err := shiftFromRequest(req)
+	if err != nil {
+		return nil, err
+	}
+	shift.ID = id
+
+	if err := s.repo.Update(ctx, *shift); err != nil {
+		return nil, err
+	}
+	return s.repo.GetByID(ctx, id)
+}
+
+func (s *shiftService) Delete(ctx context.Context, id uuid.UUID) error {
+	return s.repo.Delete(ctx, id)
+}
+
+func (s *shiftService) AssignClasses(ctx context.Context, id uuid.UUID, classIDs []uuid.UUID) error {
+	return s.repo.AssignClasses(ctx, id, classIDs)
+}
+
+func shiftFromRequest(req models.ShiftRequest) (*models.Shift, error) {
+	first := req.FirstLesson
+	if first == 0 {
+		first = 1
+	}
+	if req.Number < 1 || first < 1 || req.LastLesson < first {
+		return nil, ErrInvalidShift
+	}
+
+	return &models.Shift{
+		Name:        req.Name,
+		Number:      req.Number,
+		FirstLesson: first,
+		LastLesson:  req.LastLesson,
+	}, nil
+}
@@ -1,33 +1,98 @@
 package services
 
 import (
+	"context"
+	"errors"
+
 	"github.com/google/uuid"
 	"github.com/nikomkinds/SchoolSchedule/internal/models"
 	"github.com/nikomkinds/SchoolSchedule/internal/repositories"
 )
 
+// ErrInvalidSubject is returned when a subject requires an unknown equipment tag
+var ErrInvalidSubject = errors.New("invalid subject")
+
 type SubjectService interface {
-	GetAll() ([]models.Subject, error)
-	Create(name string) (models.Subject, error)
-	Delete(id uuid.UUID) error
+	GetAll(ctx context.Context) ([]models.Subject, error)
+	Create(ctx context.Context, req models.CreateSubjectRequest) (models.Subject, error)
+	Update(ctx context.Context, id uuid.UUID, req models.CreateSubjectRequest) (models.Subject, error)
+	Delete(ctx context.Context, id uuid.UUID) error
 }
 
 type subjectService struct {
-	repo repositories.SubjectRepository
+	repo  repositories.SubjectRepository
+	audit AuditService
 }
 
-func NewSubjectService(repo repositories.SubjectRepository) SubjectService {
-	return &subjectService{repo: repo}
+func NewSubjectService(repo repositories.SubjectRepository, audit AuditService) SubjectService {
+	return &subjectService{repo: repo, audit: audit}
 }
 
-func (s *subjectService) GetAll() ([]models.Subject, error) {
+func (s *subjectService) GetAll(ctx context.Context) ([]models.Subject, error) {
 	return s.repo.GetAll()
 }
 
-func (s *subjectService) Create(name string) (models.Subject, error) {
-	return s.repo.Create(name)
+func (s *subjectService) Create(ctx context.Context, req models.CreateSubjectRequest) (models.Subject, error) {
+	if err := validateEquipment(req.RequiredEquipment, ErrInvalidSubject); err != nil {
+		return models.Subject{}, err
+	}
+	subject, err := s.repo.Create(models.Subject{
+		Name:              req.Name,
+		ShortName:         req.ShortName,
+		RequiredEquipment: req.RequiredEquipment,
+		ClassroomIDs:      req.ClassroomIDs,
+	})
+	if err != nil {
+		return models.Subject{}, err
+	}
+	s.audit.Record(ctx, models.AuditActionCreate, models.AuditEntitySubject, &subject.ID, nil, subject)
+	return subject, nil
+}
+
+func (s *subjectService) Update(ctx context.Context, id uuid.UUID, req models.CreateSubjectRequest) (models.Subject, error) {
+	if err := validateEquipment(req.RequiredEquipment, ErrInvalidSubject); err != nil {
+		return models.Subject{}, err
+	}
+	before, err := s.findSubject(id)
+	if err != nil {
+		return models.Subject{}, err
+	}
+	subject, err := s.repo.Update(models.Subject{
+		ID:                id,
+		Name:              req.Name,
+		ShortName:         req.ShortName,
+		RequiredEquipment: req.RequiredEquipment,
+		ClassroomIDs:      req.ClassroomIDs,
+	})
+	if err != nil {
+		return models.Subject{}, err
+	}
+	s.audit.Record(ctx, models.AuditActionUpdate, models.AuditEntitySubject, &id, before, subject)
+	return subject, nil
+}
+
+func (s *subjectService) Delete(ctx context.Context, id uuid.UUID) error {
+	before, err := s.findSubject(id)
+	if err != nil {
+		return err
+	}
+	if err := s.repo.Delete(id); err != nil {
+		return err
+	}
+	s.audit.Record(ctx, models.AuditActionDelete, models.AuditEntitySubject, &id, before, nil)
+	return nil
 }
 
-func (s *subjectService) Delete(id uuid.UUID) error {
-	return s.repo.Delete(id)
+// findSubject loads a subject for audit snapshots, nil if there is none
+func (s *subjectService) findSubject(id uuid.UUID) (*models.Subject, error) {
+	subjects, err := s.repo.GetAll()
+	if err != nil {
+		return nil, err
+	}
+	for i := range subjects {
+		if subjects[i].ID == id {
+			return &subjects[i], nil
+		}
+	}
+	return nil, nil
 }
@@ -0,0 +1,698 @@
+package services
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"fmt"
+	"sort"
+	"strings"
+
+	"github.com/google/uuid"
+	"github.com/nikomkinds/SchoolSchedule/internal/models"
+	"github.com/nikomkinds/SchoolSchedule/internal/repositories"
+)
+
+// maxCalendarDays limits the range of the calendar view
+const maxCalendarDays = 62
+
+var (
+	// ErrCalendarRangeTooLong is returned when a calendar range exceeds maxCalendarDays
+	ErrCalendarRangeTooLong = fmt.Errorf("date range must not exceed %d days", maxCalendarDays)
+	// ErrInvalidOverride is returned when an override misses the fields its action needs
+	ErrInvalidOverride = errors.New("invalid lesson override")
+	// ErrLessonNotOnDate is returned when an override date is not a day the lesson takes place on
+	ErrLessonNotOnDate = errors.New("lesson does not take place on this date")
+	// ErrAbsenceForbidden is returned when a teacher records or deletes an absence
+	ErrAbsenceForbidden = errors.New("only admins and schedulers manage absences")
+)
+
+type SubstitutionService interface {
+	// GetAbsences loads the absences of the range: all of them for admins and schedulers, a teacher's own otherwise
+	GetAbsences(ctx context.Context, actor models.Actor, from, to models.Date) ([]models.TeacherAbsence, error)
+	// CreateAbsence and DeleteAbsence are left to admins and schedulers (ErrAbsenceForbidden)
+	CreateAbsence(ctx context.Context, actor models.Actor, req models.TeacherAbsenceRequest) (*models.TeacherAbsence, error)
+	DeleteAbsence(ctx context.Context, actor models.Actor, id uuid.UUID) error
+	// GetAbsenceLessons returns the lessons of the absent teacher on every date of the absence
+	GetAbsenceLessons(ctx context.Context, userID, absenceID uuid.UUID) ([]models.CalendarDay, error)
+
+	// GetOverrides loads the overrides of the range in the schedules the actor sees; admins see all of them
+	GetOverrides(ctx context.Context, actor models.Actor, from, to models.Date) ([]models.LessonOverride, error)
+	// SaveOverride cancels, reassigns or moves a lesson on one date; an existing override of the date is replaced.
+	// The actor needs the override access to the lesson's schedule (ErrScheduleForbidden).
+	SaveOverride(ctx context.Context, actor models.Actor, req models.LessonOverrideRequest) (*models.LessonOverride, error)
+	// DeleteOverride deletes an override, with the same access as SaveOverride
+	DeleteOverride(ctx context.Context, actor models.Actor, id uuid.UUID) error
+	// SuggestSubstitutes ranks teachers who could give the lesson on the date instead of its teacher;
+	// the actor needs view access to the lesson's schedule
+	SuggestSubstitutes(ctx context.Context, actor models.Actor, lessonID uuid.UUID, date models.Date) ([]models.SubstituteCandidate, error)
+
+	// GetCalendar merges the weekly timetable with holidays and overrides for every date of the range
+	GetCalendar(ctx context.Context, userID uuid.UUID, from, to models.Date) ([]models.CalendarDay, error)
+}
+
+type substitutionService struct {
+	repo             repositories.SubstitutionRepository
+	academicYearRepo repositories.AcademicYearRepository
+	schedules        ScheduleService
+}
+
+func NewSubstitutionService(
+	repo repositories.SubstitutionRepository,
+	academicYearRepo repositories.AcademicYearRepository,
+	schedules ScheduleService,
+) SubstitutionService {
+	return &substitutionService{repo: repo, academicYearRepo: academicYearRepo, schedules: schedules}
+}
+
+func (s *substitutionService) GetAbsences(ctx context.Context, actor models.Actor, from, to models.Date) ([]models.TeacherAbsence, error) {
+	if err := validateDateRange(from, to); err != nil {
+		return nil, err
+	}
+	if managesAbsences(actor) {
+		return s.repo.GetAbsences(ctx, from, to, nil)
+	}
+	return s.repo.GetAbsences(ctx, from, to, &actor.UserID)
+}
+
+func (s *substitutionService) CreateAbsence(ctx context.Context, actor models.Actor, req models.TeacherAbsenceRequest) (*models.TeacherAbsence, error) {
+	if !managesAbsences(actor) {
+		return nil, ErrAbsenceForbidden
+	}
+	if err := validateDateRange(req.StartDate, req.EndDate); err != nil {
+		return nil, err
+	}
+	return s.repo.CreateAbsence(ctx, models.TeacherAbsence{
+		TeacherID: req.TeacherID,
+		StartDate: req.StartDate,
+		EndDate:   req.EndDate,
+		Reason:    req.Reason,
+	})
+}
+
+func (s *substitutionService) DeleteAbsence(ctx context.Context, actor models.Actor, id uuid.UUID) error {
+	if !managesAbsences(actor) {
+		return ErrAbsenceForbidden
+	}
+	return s.repo.DeleteAbsence(ctx, id)
+}
+
+// managesAbsences reports whether the actor records absences of any teacher
+func managesAbsences(actor models.Actor) bool {
+	return actor.Role == models.RoleAdmin || actor.Role == models.RoleScheduler
+}
+
+func (s *substitutionService) GetAbsenceLessons(ctx context.Context, userID, absenceID uuid.UUID) ([]models.CalendarDay, error) {
+	absence, err := s.repo.GetAbsenceByID(ctx, absenceID)
+	if err != nil {
+		return nil, err
+	}
+
+	return s.calendar(ctx, userID, absence.StartDate, absence.EndDate, func(l models.ScheduleLesson) bool {
+		return lessonHasTeacher(l, absence.TeacherID)
+	})
+}
+
+func (s *substitutionService) GetOverrides(ctx context.Context, actor models.Actor, from, to models.Date) ([]models.LessonOverride, error) {
+	if err := validateDateRange(from, to); err != nil {
+		return nil, err
+	}
+	if actor.Role == models.RoleAdmin {
+		return s.repo.GetOverrides(ctx, from, to)
+	}
+
+	visible, err := s.schedules.GetAllSchedules(ctx, actor)
+	if err != nil {
+		return nil, err
+	}
+	ids := make([]uuid.UUID, len(visible))
+	for i, schedule := range visible {
+		ids[i] = schedule.ID
+	}
+	return s.repo.GetScheduleOverrides(ctx, ids, from, to)
+}
+
+func (s *substitutionService) SaveOverride(ctx context.Context, actor models.Actor, req models.LessonOverrideRequest) (*models.LessonOverride, error) {
+	if req.Date.IsZero() {
+		return nil, fmt.Errorf("%w: date is required", ErrInvalidOverride)
+	}
+
+	scheduleID, day, _, err := s.repo.GetLessonSlot(ctx, req.LessonID)
+	if err != nil {
+		return nil, err
+	}
+	if err := s.schedules.CheckOverrideAccess(ctx, scheduleID, actor); err != nil {
+		return nil, err
+	}
+	if req.Date.DayOfWeek() != day {
+		return nil, ErrLessonNotOnDate
+	}
+	if err := s.checkScheduleInEffect(ctx, actor.UserID, scheduleID, req.Date); err != nil {
+		return nil, err
+	}
+
+	// The lesson as given on the date; odd- or even-week lessons are missing from the other weeks
+	content, err := s.schedules.GetScheduleContentWeek(ctx, scheduleID, req.Date)
+	if err != nil {
+		return nil, err
+	}
+	lesson, lessonNumber, ok := findLesson(content.ScheduleSlots, req.LessonID)
+	if !ok {
+		return nil, ErrLessonNotOnDate
+	}
+
+	o := models.LessonOverride{
+		LessonID:  req.LessonID,
+		Date:      req.Date,
+		Action:    req.Action,
+		AbsenceID: req.AbsenceID,
+		Comment:   req.Comment,
+	}
+
+	// When and where the lesson will be given
+	targetDate, targetNumber := req.Date, lessonNumber
+
+	switch req.Action {
+	case models.OverrideCancel:
+		return s.repo.SaveOverride(ctx, o)
+	case models.OverrideSubstitute:
+		if req.SubstituteTeacherID == nil && req.RoomID == nil {
+			return nil, fmt.Errorf("%w: substituteTeacherId or roomId is required", ErrInvalidOverride)
+		}
+		o.SubstituteTeacherID = req.SubstituteTeacherID
+		o.RoomID = req.RoomID
+	case models.OverrideMove:
+		if req.NewDate == nil && req.NewLessonNumber == nil {
+			return nil, fmt.Errorf("%w: newDate or newLessonNumber is required", ErrInvalidOverride)
+		}
+		o.NewDate = req.NewDate
+		o.NewLessonNumber = req.NewLessonNumber
+		o.RoomID = req.RoomID
+		if req.NewDate != nil {
+			targetDate = *req.NewDate
+		}
+		if req.NewLessonNumber != nil {
+			targetNumber = *req.NewLessonNumber
+		}
+	default:
+		return nil, fmt.Errorf("%w: unknown action %q", ErrInvalidOverride, req.Action)
+	}
+
+	data, err := s.schedules.GetPlanningData(ctx, content.TermID)
+	if err != nil {
+		return nil, err
+	}
+	checker := newConflictChecker(data)
+	others, err := s.dateLessons(ctx, checker, scheduleID, targetDate)
+	if err != nil {
+		return nil, err
+	}
+
+	l := checker.fromLesson(targetDate.DayOfWeek(), targetNumber, lesson)
+	l.window.weekPattern = models.WeekEvery
+	if req.RoomID != nil {
+		l.rooms = []uuid.UUID{*req.RoomID}
+	}
+	var details []models.ConflictDetail
+	if req.Action == models.OverrideMove {
+		details = checker.shiftConflicts(l)
+	} else {
+		// A substitution keeps the class in its slot; only the new teacher and room may clash
+		l.participants, l.teachers = nil, nil
+		if req.SubstituteTeacherID != nil {
+			l.teachers = []uuid.UUID{*req.SubstituteTeacherID}
+		}
+		if req.RoomID == nil {
+			l.rooms = nil
+		}
+	}
+	for _, other := range others {
+		if other.id != req.LessonID {
+			details = append(details, checker.clashes(l, other)...)
+		}
+	}
+	if len(details) > 0 {
+		return nil, &ConflictError{Details: details}
+	}
+
+	return s.repo.SaveOverride(ctx, o)
+}
+
+func (s *substitutionService) DeleteOverride(ctx context.Context, actor models.Actor, id uuid.UUID) error {
+	o, err := s.repo.GetOverrideByID(ctx, id)
+	if err != nil {
+		return err
+	}
+	scheduleID, _, _, err := s.repo.GetLessonSlot(ctx, o.LessonID)
+	if err != nil {
+		return err
+	}
+	if err := s.schedules.CheckOverrideAccess(ctx, scheduleID, actor); err != nil {
+		return err
+	}
+	return s.repo.DeleteOverride(ctx, id)
+}
+
+// substitutionFairnessDays is the period recent substitutions are counted over
+const substitutionFairnessDays = 30
+
+func (s *substitutionService) SuggestSubstitutes(ctx context.Context, actor models.Actor, lessonID uuid.UUID, date models.Date) ([]models.SubstituteCandidate, error) {
+	scheduleID, day, _, err := s.repo.GetLessonSlot(ctx, lessonID)
+	if err != nil {
+		return nil, err
+	}
+	if err := s.schedules.CheckScheduleAccess(ctx, scheduleID, actor, models.ScheduleAccessView); err != nil {
+		return nil, err
+	}
+	if date.DayOfWeek() != day {
+		return nil, ErrLessonNotOnDate
+	}
+	if err := s.checkScheduleInEffect(ctx, actor.UserID, scheduleID, date); err != nil {
+		return nil, err
+	}
+
+	content, err := s.schedules.GetScheduleContentWeek(ctx, scheduleID, date)
+	if err != nil {
+		return nil, err
+	}
+	lesson, lessonNumber, ok := findLesson(content.ScheduleSlots, lessonID)
+	if !ok {
+		// Odd- or even-week lesson in the other week
+		return nil, ErrLessonNotOnDate
+	}
+
+	var classID uuid.UUID
+	if len(lesson.Participants) > 0 {
+		classID = lesson.Participants[0].ClassID
+	}
+	candidates, err := s.repo.GetSubstituteCandidates(ctx, lesson.Subject.ID, classID, date, date.AddDays(-substitutionFairnessDays))
+	if err != nil {
+		return nil, err
+	}
+
+	// Lessons every teacher already gives on the date, overrides included, compared by bell times
+	data, err := s.schedules.GetPlanningData(ctx, content.TermID)
+	if err != nil {
+		return nil, err
+	}
+	checker := newConflictChecker(data)
+	others, err := s.dateLessons(ctx, checker, scheduleID, date)
+	if err != nil {
+		return nil, err
+	}
+	target := checker.fromLesson(day, lessonNumber, lesson)
+	target.window.weekPattern = models.WeekEvery
+	busy := make(map[uuid.UUID][]lessonWindow)
+	for _, l := range others {
+		if l.id == lessonID {
+			continue
+		}
+		for _, t := range l.teachers {
+			busy[t] = append(busy[t], l.window)
+		}
+	}
+
+	out := make([]models.SubstituteCandidate, 0, len(candidates))
+	for _, c := range candidates {
+		if lessonHasTeacher(lesson, c.Teacher.ID) {
+			continue
+		}
+		free := true
+		var lessons []int
+		for _, w := range busy[c.Teacher.ID] {
+			free = free && !w.overlaps(target.window)
+			if !containsInt(lessons, w.lessonNumber) {
+				lessons = append(lessons, w.lessonNumber)
+			}
+		}
+		if !free {
+			continue
+		}
+		c.LessonsThatDay = len(lessons)
+		c.WindowsAdded = windows(append(lessons, lessonNumber)) - windows(lessons)
+		rankSubstitute(&c)
+		out = append(out, c)
+	}
+
+	sort.SliceStable(out, func(i, j int) bool { return out[i].Score > out[j].Score })
+	return out, nil
+}
+
+// rankSubstitute scores a free candidate and explains the score.
+// Qualification matters most, then knowing the class, not adding windows, fairness and load.
+func rankSubstitute(c *models.SubstituteCandidate) {
+	score := 100
+	var why []string
+
+	if c.Qualified {
+		score += 40
+		why = append(why, "Ведёт этот предмет")
+	} else {
+		why = append(why, "Не ведёт этот предмет")
+	}
+	if c.TeachesClass {
+		score += 10
+		why = append(why, "Уже работает с этим классом")
+	}
+
+	switch {
+	case c.LessonsThatDay == 0:
+		score -= 10
+		why = append(why, "В этот день нет своих уроков, придётся прийти отдельно")
+	case c.WindowsAdded > 0:
+		score -= 15 * c.WindowsAdded
+		why = append(why, fmt.Sprintf("Добавит окон: %d", c.WindowsAdded))
+	case c.WindowsAdded < 0:
+		score += 5 * -c.WindowsAdded
+		why = append(why, fmt.Sprintf("Закроет окон: %d", -c.WindowsAdded))
+	default:
+		why = append(why, "Урок встаёт рядом со своими уроками")
+	}
+
+	if c.RecentSubstitutions > 0 {
+		score -= 5 * c.RecentSubstitutions
+		why = append(why, fmt.Sprintf("Замен за последние %d дней: %d", substitutionFairnessDays, c.RecentSubstitutions))
+	}
+	score -= int(c.WeeklyHours / 2)
+	why = append(why, fmt.Sprintf("Недельная нагрузка: %s ч", formatHours(c.WeeklyHours)))
+
+	c.Score = score
+	c.Explanation = why
+}
+
+// windows counts free periods between the first and the last lesson of a day
+func windows(lessonNumbers []int) int {
+	if len(lessonNumbers) == 0 {
+		return 0
+	}
+	seen := make(map[int]bool)
+	first, last := lessonNumbers[0], lessonNumbers[0]
+	for _, n := range lessonNumbers {
+		seen[n] = true
+		if n < first {
+			first = n
+		}
+		if n > last {
+			last = n
+		}
+	}
+	return last - first + 1 - len(seen)
+}
+
+func containsInt(list []int, v int) bool {
+	for _, x := range list {
+		if x == v {
+			return true
+		}
+	}
+	return false
+}
+
+func (s *substitutionService) GetCalendar(ctx context.Context, userID uuid.UUID, from, to models.Date) ([]models.CalendarDay, error) {
+	return s.calendar(ctx, userID, from, to, nil)
+}
+
+// checkScheduleInEffect returns ErrLessonNotOnDate unless the schedule is the user's active one on the date
+// or the published school timetable of the date
+func (s *substitutionService) checkScheduleInEffect(ctx context.Context, userID, scheduleID uuid.UUID, date models.Date) error {
+	for _, resolve := range []func() (uuid.UUID, error){
+		func() (uuid.UUID, error) { return s.schedules.GetActiveScheduleID(ctx, userID, date) },
+		func() (uuid.UUID, error) { return s.schedules.GetPublishedScheduleID(ctx, date) },
+	} {
+		id, err := resolve()
+		if err != nil && !errors.Is(err, sql.ErrNoRows) {
+			return err
+		}
+		if err == nil && id == scheduleID {
+			return nil
+		}
+	}
+	return ErrLessonNotOnDate
+}
+
+// dateLessons returns the lessons given on the date, overrides applied and cancelled ones left out, as planned
+// lessons of that date only. They come from the schedule and from the published timetable if it is another one;
+// lessons of the latter keep only their teachers and rooms, as its classes are planned by the schedule.
+func (s *substitutionService) dateLessons(ctx context.Context, checker *conflictChecker, scheduleID uuid.UUID, date models.Date) ([]plannedLesson, error) {
+	sources := []uuid.UUID{scheduleID}
+	published, err := s.schedules.GetPublishedScheduleID(ctx, date)
+	switch {
+	case err == nil && published != scheduleID:
+		sources = append(sources, published)
+	case err != nil && !errors.Is(err, sql.ErrNoRows):
+		return nil, err
+	}
+
+	var lessons []plannedLesson
+	for i, id := range sources {
+		days, err := s.calendarOf(ctx, newScheduleWeekCache(s.schedules, id), date, date, nil)
+		if err != nil {
+			return nil, err
+		}
+		for _, slot := range days[0].Slots {
+			for _, l := range slot.Lessons {
+				if l.Override != nil && l.Override.Action == models.OverrideCancel {
+					continue
+				}
+				p := checker.fromLesson(date.DayOfWeek(), slot.LessonNumber, l)
+				// The calendar has already picked the lessons of the date's week
+				p.window.weekPattern = models.WeekEvery
+				if i > 0 {
+					p.participants = nil
+				}
+				lessons = append(lessons, p)
+			}
+		}
+	}
+	return lessons, nil
+}
+
+// calendar builds the calendar view. keep, if set, selects lessons by their weekly-timetable state.
+func (s *substitutionService) calendar(ctx context.Context, userID uuid.UUID, from, to models.Date, keep func(models.ScheduleLesson) bool) ([]models.CalendarDay, error) {
+	return s.calendarOf(ctx, newWeekCache(s.schedules, userID), from, to, keep)
+}
+
+// calendarOf builds the calendar view of the timetables the weeks are loaded from
+func (s *substitutionService) calendarOf(ctx context.Context, weeks *weekCache, from, to models.Date, keep func(models.ScheduleLesson) bool) ([]models.CalendarDay, error) {
+	if err := validateDateRange(from, to); err != nil {
+		return nil, err
+	}
+	if to.AddDays(-maxCalendarDays).After(from.Time) {
+		return nil, ErrCalendarRangeTooLong
+	}
+
+	holidays, err := s.academicYearRepo.GetHolidaysBetween(ctx, from, to)
+	if err != nil {
+		return nil, err
+	}
+	overrides, err := s.repo.GetOverrides(ctx, from, to)
+	if err != nil {
+		return nil, err
+	}
+
+	byLesson := make(map[string]*models.LessonOverride)
+	for i := range overrides {
+		byLesson[overrideKey(overrides[i].LessonID, overrides[i].Date)] = &overrides[i]
+	}
+
+	days := make([]models.CalendarDay, 0)
+	for date := from; !date.After(to.Time); date = date.AddDays(1) {
+		week, err := weeks.get(ctx, date)
+		if err != nil {
+			return nil, err
+		}
+
+		day := models.CalendarDay{
+			Date:       date,
+			DayOfWeek:  strings.ToUpper(date.Weekday().String()),
+			WeekParity: week.Parity,
+			Slots:      []models.ScheduleDay{},
+		}
+		for _, h := range holidays {
+			if date.Between(h.StartDate, h.EndDate) {
+				name := h.Name
+				day.Holiday = &name
+				break
+			}
+		}
+		if day.Holiday == nil {
+			day.Slots = s.daySlots(week, date, byLesson, keep)
+		}
+		days = append(days, day)
+	}
+
+	// Lessons moved onto dates of the range from anywhere
+	for i := range overrides {
+		o := &overrides[i]
+		if o.Action != models.OverrideMove {
+			continue
+		}
+		target := o.Date
+		if o.NewDate != nil {
+			target = *o.NewDate
+		}
+		if target.Before(from.Time) || target.After(to.Time) {
+			continue
+		}
+		day := &days[int(target.Sub(from.Time).Hours()/24)]
+		if day.Holiday != nil {
+			continue
+		}
+
+		source, err := weeks.get(ctx, o.Date)
+		if err != nil {
+			return nil, err
+		}
+		lesson, lessonNumber, ok := findLesson(source.Days, o.LessonID)
+		if !ok || (keep != nil && !keep(lesson)) {
+			continue
+		}
+		placeMovedLesson(day, lesson, lessonNumber, o)
+	}
+
+	return days, nil
+}
+
+// daySlots returns the slots of the date from the weekly timetable with cancellations and substitutions applied.
+// Moved lessons are taken out; they are placed on their target date separately.
+func (s *substitutionService) daySlots(week *models.ScheduleWeek, date models.Date, overrides map[string]*models.LessonOverride, keep func(models.ScheduleLesson) bool) []models.ScheduleDay {
+	slots := make([]models.ScheduleDay, 0)
+	for _, slot := range week.Days {
+		if models.DayOfWeekNumber(slot.DayOfWeek) != date.DayOfWeek() {
+			continue
+		}
+
+		lessons := make([]models.ScheduleLesson, 0, len(slot.Lessons))
+		for _, l := range slot.Lessons {
+			if keep != nil && !keep(l) {
+				continue
+			}
+			o, ok := overrides[overrideKey(l.ID, date)]
+			if !ok {
+				lessons = append(lessons, l)
+				continue
+			}
+			if o.Action == models.OverrideMove {
+				continue
+			}
+			lessons = append(lessons, applyOverride(l, o))
+		}
+		if len(lessons) == 0 {
+			continue
+		}
+		slot.Lessons = lessons
+		slots = append(slots, slot)
+	}
+	return slots
+}
+
+// applyOverride returns the lesson as changed by the override
+func applyOverride(l models.ScheduleLesson, o *models.LessonOverride) models.ScheduleLesson {
+	if o.SubstituteTeacher != nil {
+		t := o.SubstituteTeacher
+		l.Teachers = []models.Teacher{{ID: t.ID, FirstName: t.FirstName, LastName: t.LastName, Patronymic: t.Patronymic}}
+	}
+	if o.Room != nil {
+		l.Rooms = []models.Classroom{*o.Room}
+	}
+	l.Override = o
+	return l
+}
+
+// placeMovedLesson puts a moved lesson into its target slot of the day; lessonNumber is the original one
+func placeMovedLesson(day *models.CalendarDay, l models.ScheduleLesson, lessonNumber int, o *models.LessonOverride) {
+	l = applyOverride(l, o)
+	if o.NewLessonNumber != nil && *o.NewLessonNumber != lessonNumber {
+		lessonNumber = *o.NewLessonNumber
+		// Times of the original slot do not apply to another lesson number
+		l.StartTime, l.EndTime = nil, nil
+	}
+
+	for i := range day.Slots {
+		if day.Slots[i].LessonNumber == lessonNumber {
+			day.Slots[i].Lessons = append(day.Slots[i].Lessons, l)
+			return
+		}
+	}
+	day.Slots = append(day.Slots, models.ScheduleDay{
+		DayOfWeek:    day.DayOfWeek,
+		LessonNumber: lessonNumber,
+		Lessons:      []models.ScheduleLesson{l},
+	})
+	sort.Slice(day.Slots, func(i, j int) bool { return day.Slots[i].LessonNumber < day.Slots[j].LessonNumber })
+}
+
+// findLesson returns a lesson of the timetable with its lesson number
+func findLesson(days []models.ScheduleDay, lessonID uuid.UUID) (models.ScheduleLesson, int, bool) {
+	for _, slot := range days {
+		for _, l := range slot.Lessons {
+			if l.ID == lessonID {
+				return l, slot.LessonNumber, true
+			}
+		}
+	}
+	return models.ScheduleLesson{}, 0, false
+}
+
+func lessonHasTeacher(l models.ScheduleLesson, teacherID uuid.UUID) bool {
+	for _, t := range l.Teachers {
+		if t.ID == teacherID {
+			return true
+		}
+	}
+	return false
+}
+
+func overrideKey(lessonID uuid.UUID, date models.Date) string {
+	return lessonID.String() + "/" + date.String()
+}
+
+// weekCache loads each calendar week of the active schedule, or of a given one, once
+type weekCache struct {
+	schedules  ScheduleService
+	userID     uuid.UUID
+	scheduleID *uuid.UUID
+	weeks      map[string]*models.ScheduleWeek
+}
+
+func newWeekCache(schedules ScheduleService, userID uuid.UUID) *weekCache {
+	return &weekCache{schedules: schedules, userID: userID, weeks: make(map[string]*models.ScheduleWeek)}
+}
+
+// newScheduleWeekCache loads the weeks of the given schedule whichever is active
+func newScheduleWeekCache(schedules ScheduleService, scheduleID uuid.UUID) *weekCache {
+	return &weekCache{schedules: schedules, scheduleID: &scheduleID, weeks: make(map[string]*models.ScheduleWeek)}
+}
+
+func (c *weekCache) get(ctx context.Context, date models.Date) (*models.ScheduleWeek, error) {
+	if c.scheduleID != nil {
+		key := c.scheduleID.String() + "/" + date.WeekStart().String()
+		if week, ok := c.weeks[key]; ok {
+			return week, nil
+		}
+		content, err := c.schedules.GetScheduleContentWeek(ctx, *c.scheduleID, date)
+		if err != nil {
+			return nil, err
+		}
+		week := &models.ScheduleWeek{WeekStart: *content.WeekStart, Parity: content.Parity, Days: content.ScheduleSlots}
+		c.weeks[key] = week
+		return week, nil
+	}
+
+	// Terms may change mid-week, so the key includes the schedule active on the date
+	scheduleID, err := c.schedules.GetActiveScheduleID(ctx, c.userID, date)
+	if err != nil && !errors.Is(err, sql.ErrNoRows) {
+		return nil, err
+	}
+	key := scheduleID.String() + "/" + date.WeekStart().String()
+
+	if week, ok := c.weeks[key]; ok {
+		return week, nil
+	}
+	week, err := c.schedules.GetScheduleWeek(ctx, c.userID, date)
+	if err != nil {
+		return nil, err
+	}
+	c.weeks[key] = week
+	return week, nil
+}
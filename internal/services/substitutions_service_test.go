@@ -0,0 +1,242 @@
+package services
+
+import (
+	"context"
+	"database/sql"
+	"sort"
+	"testing"
+
+	"github.com/google/uuid"
+	"github.com/nikomkinds/SchoolSchedule/internal/models"
+	"github.com/nikomkinds/SchoolSchedule/internal/repositories"
+)
+
+func TestRankSubstitute(t *testing.T) {
+	tests := []struct {
+		name      string
+		candidate models.SubstituteCandidate
+		want      int
+	}{
+		{
+			name:      "qualified, knows the class, next to own lessons",
+			candidate: models.SubstituteCandidate{Qualified: true, TeachesClass: true, LessonsThatDay: 4, WeeklyHours: 18},
+			want:      100 + 40 + 10 - 9,
+		},
+		{
+			name:      "no own lessons that day",
+			candidate: models.SubstituteCandidate{Qualified: true, WeeklyHours: 18},
+			want:      100 + 40 - 10 - 9,
+		},
+		{
+			name:      "two windows added",
+			candidate: models.SubstituteCandidate{Qualified: true, LessonsThatDay: 2, WindowsAdded: 2, WeeklyHours: 18},
+			want:      100 + 40 - 30 - 9,
+		},
+		{
+			name:      "a window closed",
+			candidate: models.SubstituteCandidate{Qualified: true, LessonsThatDay: 3, WindowsAdded: -1, WeeklyHours: 18},
+			want:      100 + 40 + 5 - 9,
+		},
+		{
+			name:      "recent substitutions and a half-hour load",
+			candidate: models.SubstituteCandidate{LessonsThatDay: 3, RecentSubstitutions: 3, WeeklyHours: 20.5},
+			want:      100 - 15 - 10,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			c := tt.candidate
+			rankSubstitute(&c)
+			if c.Score != tt.want {
+				t.Errorf("score %d, want %d (%v)", c.Score, tt.want, c.Explanation)
+			}
+			if len(c.Explanation) == 0 {
+				t.Error("no explanation")
+			}
+		})
+	}
+}
+
+func TestRankSubstituteOrder(t *testing.T) {
+	// From best to worst
+	candidates := []models.SubstituteCandidate{
+		// Qualification outweighs a window and a recent substitution
+		{Teacher: models.LightTeacher{LastName: "qualified with a window"}, Qualified: true, LessonsThatDay: 2, WindowsAdded: 1, RecentSubstitutions: 1, WeeklyHours: 24},
+		{Teacher: models.LightTeacher{LastName: "knows the class"}, TeachesClass: true, LessonsThatDay: 3, WeeklyHours: 18},
+		{Teacher: models.LightTeacher{LastName: "next to own lessons"}, LessonsThatDay: 3, WeeklyHours: 18},
+		// Coming for one lesson is better than waiting through a window
+		{Teacher: models.LightTeacher{LastName: "no own lessons"}, WeeklyHours: 18},
+		{Teacher: models.LightTeacher{LastName: "adds a window"}, LessonsThatDay: 3, WindowsAdded: 1, WeeklyHours: 18},
+		{Teacher: models.LightTeacher{LastName: "adds a window, substituted recently"}, LessonsThatDay: 3, WindowsAdded: 1, RecentSubstitutions: 1, WeeklyHours: 18},
+	}
+
+	ranked := make([]models.SubstituteCandidate, len(candidates))
+	for i := range candidates {
+		ranked[len(candidates)-1-i] = candidates[i]
+	}
+	for i := range ranked {
+		rankSubstitute(&ranked[i])
+	}
+	sort.SliceStable(ranked, func(i, j int) bool { return ranked[i].Score > ranked[j].Score })
+
+	for i := range candidates {
+		if ranked[i].Teacher.LastName != candidates[i].Teacher.LastName {
+			t.Errorf("place %d: %q (%d), want %q", i+1, ranked[i].Teacher.LastName, ranked[i].Score, candidates[i].Teacher.LastName)
+		}
+	}
+}
+
+type fakeSubstitutionRepo struct {
+	repositories.SubstitutionRepository
+	scheduleID uuid.UUID
+	candidates []models.SubstituteCandidate
+}
+
+func (f *fakeSubstitutionRepo) GetLessonSlot(ctx context.Context, lessonID uuid.UUID) (uuid.UUID, int, int, error) {
+	return f.scheduleID, 1, 1, nil
+}
+
+func (f *fakeSubstitutionRepo) GetSubstituteCandidates(ctx context.Context, subjectID, classID uuid.UUID, date, since models.Date) ([]models.SubstituteCandidate, error) {
+	return append([]models.SubstituteCandidate(nil), f.candidates...), nil
+}
+
+func (f *fakeSubstitutionRepo) GetOverrides(ctx context.Context, from, to models.Date) ([]models.LessonOverride, error) {
+	return nil, nil
+}
+
+type fakeSubstitutionHolidays struct {
+	repositories.AcademicYearRepository
+}
+
+func (f *fakeSubstitutionHolidays) GetHolidaysBetween(ctx context.Context, from, to models.Date) ([]models.Holiday, error) {
+	return nil, nil
+}
+
+// fakeSubstitutionSchedules serves one active, unpublished schedule with the same lessons every week
+type fakeSubstitutionSchedules struct {
+	ScheduleService
+	scheduleID uuid.UUID
+	slots      []models.ScheduleDay
+	data       *models.PlanningData
+}
+
+func (f *fakeSubstitutionSchedules) CheckScheduleAccess(ctx context.Context, scheduleID uuid.UUID, actor models.Actor, need string) error {
+	return nil
+}
+
+func (f *fakeSubstitutionSchedules) GetActiveScheduleID(ctx context.Context, userID uuid.UUID, date models.Date) (uuid.UUID, error) {
+	return f.scheduleID, nil
+}
+
+func (f *fakeSubstitutionSchedules) GetPublishedScheduleID(ctx context.Context, date models.Date) (uuid.UUID, error) {
+	return uuid.Nil, sql.ErrNoRows
+}
+
+func (f *fakeSubstitutionSchedules) GetScheduleContentWeek(ctx context.Context, scheduleID uuid.UUID, date models.Date) (*models.ScheduleContent, error) {
+	weekStart := date.WeekStart()
+	return &models.ScheduleContent{
+		Schedule:      models.Schedule{ID: scheduleID},
+		ScheduleSlots: f.slots,
+		WeekStart:     &weekStart,
+		Parity:        "odd",
+	}, nil
+}
+
+func (f *fakeSubstitutionSchedules) GetPlanningData(ctx context.Context, termID *uuid.UUID) (*models.PlanningData, error) {
+	return f.data, nil
+}
+
+func TestSuggestSubstitutesBusyAcrossBellSchedules(t *testing.T) {
+	first := models.Shift{ID: uuid.New(), Number: 1, FirstLesson: 1, LastLesson: 6}
+	second := models.Shift{ID: uuid.New(), Number: 2, FirstLesson: 1, LastLesson: 6}
+	senior := models.Class{ID: uuid.New(), Name: "9А", GradeLevel: 9, ShiftID: &first.ID}
+	afternoon := models.Class{ID: uuid.New(), Name: "6Б", GradeLevel: 6, ShiftID: &second.ID}
+	data := &models.PlanningData{
+		Classes: []models.Class{senior, afternoon},
+		Shifts:  []models.Shift{first, second},
+		Bells: []models.BellSchedule{
+			{Name: "Первая смена", ShiftID: &first.ID, Lessons: []models.BellLesson{
+				{LessonNumber: 1, StartTime: "08:00", EndTime: "08:45"},
+				{LessonNumber: 6, StartTime: "13:00", EndTime: "13:45"},
+			}},
+			{Name: "Вторая смена", ShiftID: &second.ID, Lessons: []models.BellLesson{
+				{LessonNumber: 1, StartTime: "13:30", EndTime: "14:15"},
+				{LessonNumber: 2, StartTime: "14:25", EndTime: "15:10"},
+			}},
+		},
+	}
+
+	absent := models.LightTeacher{ID: uuid.New(), LastName: "Отсутствующий"}
+	overlapping := models.LightTeacher{ID: uuid.New(), LastName: "Урок 6 первой смены"}
+	sameNumber := models.LightTeacher{ID: uuid.New(), LastName: "Урок 1 первой смены"}
+	after := models.LightTeacher{ID: uuid.New(), LastName: "Урок 2 второй смены"}
+	free := models.LightTeacher{ID: uuid.New(), LastName: "Без уроков"}
+
+	lesson := func(teacher models.LightTeacher, class models.Class) models.ScheduleLesson {
+		return models.ScheduleLesson{
+			ID:           uuid.New(),
+			Subject:      &models.Subject{ID: uuid.New()},
+			WeekPattern:  models.WeekEvery,
+			Teachers:     []models.Teacher{{ID: teacher.ID, LastName: teacher.LastName}},
+			Participants: []models.LessonParticipant{{ClassID: class.ID}},
+		}
+	}
+	// Lesson 1 of the second shift, 13:30–14:15
+	target := lesson(absent, afternoon)
+	slots := []models.ScheduleDay{
+		{DayOfWeek: "MONDAY", LessonNumber: 1, Lessons: []models.ScheduleLesson{target, lesson(sameNumber, senior)}},
+		{DayOfWeek: "MONDAY", LessonNumber: 2, Lessons: []models.ScheduleLesson{lesson(after, afternoon)}},
+		{DayOfWeek: "MONDAY", LessonNumber: 6, Lessons: []models.ScheduleLesson{lesson(overlapping, senior)}},
+	}
+
+	scheduleID := uuid.New()
+	s := &substitutionService{
+		repo: &fakeSubstitutionRepo{scheduleID: scheduleID, candidates: []models.SubstituteCandidate{
+			{Teacher: absent, Qualified: true},
+			{Teacher: overlapping, Qualified: true},
+			{Teacher: sameNumber},
+			{Teacher: after, TeachesClass: true},
+			{Teacher: free, Qualified: true},
+		}},
+		academicYearRepo: &fakeSubstitutionHolidays{},
+		schedules:        &fakeSubstitutionSchedules{scheduleID: scheduleID, slots: slots, data: data},
+	}
+
+	monday, err := models.ParseDate("2024-11-11")
+	if err != nil {
+		t.Fatal(err)
+	}
+	got, err := s.SuggestSubstitutes(context.Background(), models.Actor{UserID: uuid.New(), Role: models.RoleAdmin}, target.ID, monday)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	want := []struct {
+		teacher                      models.LightTeacher
+		lessonsThatDay, windowsAdded int
+	}{
+		{free, 0, 0},
+		{after, 1, 0},
+		// Lesson 1 of the first shift is in the morning, the same number does not make it busy
+		{sameNumber, 1, 0},
+	}
+	if len(got) != len(want) {
+		var names []string
+		for _, c := range got {
+			names = append(names, c.Teacher.LastName)
+		}
+		t.Fatalf("got %v, want %d candidates", names, len(want))
+	}
+	for i, w := range want {
+		c := got[i]
+		if c.Teacher.ID != w.teacher.ID {
+			t.Errorf("place %d: %q, want %q", i+1, c.Teacher.LastName, w.teacher.LastName)
+			continue
+		}
+		if c.LessonsThatDay != w.lessonsThatDay || c.WindowsAdded != w.windowsAdded {
+			t.Errorf("%q: %d lessons that day, %d windows added; want %d and %d",
+				c.Teacher.LastName, c.LessonsThatDay, c.WindowsAdded, w.lessonsThatDay, w.windowsAdded)
+		}
+	}
+}
@@ -0,0 +1,87 @@
+package services
+
+import (
+	"context"
+	"errors"
+	"fmt"
+
+	"github.com/google/uuid"
+	"github.com/nikomkinds/SchoolSchedule/internal/models"
+	"github.com/nikomkinds/SchoolSchedule/internal/repositories"
+)
+
+// ErrInvalidAvailability is returned when an availability grid has an unknown day or status, a bad lesson number or limit
+var ErrInvalidAvailability = errors.New("invalid availability")
+
+type TeacherAvailabilityService interface {
+	GetByTeacher(ctx context.Context, teacherID uuid.UUID) (*models.TeacherAvailability, error)
+	Save(ctx context.Context, teacherID uuid.UUID, req models.TeacherAvailabilityRequest) (*models.TeacherAvailability, error)
+	Delete(ctx context.Context, teacherID uuid.UUID) error
+}
+
+type teacherAvailabilityService struct {
+	repo repositories.TeacherAvailabilityRepository
+}
+
+func NewTeacherAvailabilityService(repo repositories.TeacherAvailabilityRepository) TeacherAvailabilityService {
+	return &teacherAvailabilityService{repo: repo}
+}
+
+func (s *teacherAvailabilityService) GetByTeacher(ctx context.Context, teacherID uuid.UUID) (*models.TeacherAvailability, error) {
+	return s.repo.GetByTeacher(ctx, teacherID)
+}
+
+func (s *teacherAvailabilityService) Save(ctx context.Context, teacherID uuid.UUID, req models.TeacherAvailabilityRequest) (*models.TeacherAvailability, error) {
+	if err := validateAvailability(req); err != nil {
+		return nil, err
+	}
+
+	err := s.repo.Save(ctx, models.TeacherAvailability{
+		TeacherID:             teacherID,
+		MaxLessonsPerDay:      req.MaxLessonsPerDay,
+		MaxConsecutiveLessons: req.MaxConsecutiveLessons,
+		Slots:                 req.Slots,
+	})
+	if err != nil {
+		return nil, err
+	}
+	return s.repo.GetByTeacher(ctx, teacherID)
+}
+
+func (s *teacherAvailabilityService) Delete(ctx context.Context, teacherID uuid.UUID) error {
+	return s.repo.Delete(ctx, teacherID)
+}
+
+func validateAvailability(req models.TeacherAvailabilityRequest) error {
+	if req.MaxLessonsPerDay != nil && *req.MaxLessonsPerDay < 1 {
+		return fmt.Errorf("%w: maxLessonsPerDay must be positive", ErrInvalidAvailability)
+	}
+	if req.MaxConsecutiveLessons != nil && *req.MaxConsecutiveLessons < 1 {
+		return fmt.Errorf("%w: maxConsecutiveLessons must be positive", ErrInvalidAvailability)
+	}
+
+	seen := make(map[[2]int]bool)
+	for _, slot := range req.Slots {
+		day := models.DayOfWeekNumber(slot.DayOfWeek)
+		if day == 0 {
+			return fmt.Errorf("%w: unknown day %q", ErrInvalidAvailability, slot.DayOfWeek)
+		}
+		lesson := 0
+		if slot.LessonNumber != nil {
+			if *slot.LessonNumber < 1 {
+				return fmt.Errorf("%w: lessonNumber must be positive", ErrInvalidAvailability)
+			}
+			lesson = *slot.LessonNumber
+		}
+		if slot.Status != models.AvailabilityUnavailable && slot.Status != models.AvailabilityPreferred {
+			return fmt.Errorf("%w: unknown status %q", ErrInvalidAvailability, slot.Status)
+		}
+
+		key := [2]int{day, lesson}
+		if seen[key] {
+			return fmt.Errorf("%w: duplicate slot %s %d", ErrInvalidAvailability, slot.DayOfWeek, lesson)
+		}
+		seen[key] = true
+	}
+	return nil
+}
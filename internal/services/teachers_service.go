@@ -2,6 +2,7 @@ package services
 
 import (
 	"context"
+	"fmt"
 
 	"github.com/google/uuid"
 	"github.com/nikomkinds/SchoolSchedule/internal/models"
@@ -13,15 +14,19 @@ type TeacherService interface {
 	GetAllLight(ctx context.Context) ([]models.LightTeacher, error)
 	Create(ctx context.Context, firstName, lastName string, patronymic *string) (*models.Teacher, error)
 	Delete(ctx context.Context, id uuid.UUID) error
-	BulkUpdate(ctx context.Context, items []models.Teacher) (int, error)
+	// BulkUpdate updates teachers if etag is still current and returns the new tag; otherwise ErrVersionMismatch
+	BulkUpdate(ctx context.Context, items []models.Teacher, etag string) (int, string, error)
+	// ETag returns the version tag of all teachers
+	ETag(ctx context.Context) (string, error)
 }
 
 type teacherService struct {
-	repo repositories.TeacherRepository
+	repo  repositories.TeacherRepository
+	audit AuditService
 }
 
-func NewTeacherService(repo repositories.TeacherRepository) TeacherService {
-	return &teacherService{repo: repo}
+func NewTeacherService(repo repositories.TeacherRepository, audit AuditService) TeacherService {
+	return &teacherService{repo: repo, audit: audit}
 }
 
 func (s *teacherService) GetAllFull(ctx context.Context) ([]models.Teacher, error) {
@@ -33,13 +38,75 @@ func (s *teacherService) GetAllLight(ctx context.Context) ([]models.LightTeacher
 }
 
 func (s *teacherService) Create(ctx context.Context, firstName, lastName string, patronymic *string) (*models.Teacher, error) {
-	return s.repo.Create(ctx, firstName, lastName, patronymic)
+	teacher, err := s.repo.Create(ctx, firstName, lastName, patronymic)
+	if err != nil {
+		return nil, err
+	}
+	s.audit.Record(ctx, models.AuditActionCreate, models.AuditEntityTeacher, &teacher.ID, nil, teacher)
+	return teacher, nil
 }
 
 func (s *teacherService) Delete(ctx context.Context, id uuid.UUID) error {
-	return s.repo.Delete(ctx, id)
+	before, err := s.teachersByID(ctx, []uuid.UUID{id})
+	if err != nil {
+		return err
+	}
+	if err := s.repo.Delete(ctx, id); err != nil {
+		return err
+	}
+	s.audit.Record(ctx, models.AuditActionDelete, models.AuditEntityTeacher, &id, before[id], nil)
+	return nil
 }
 
-func (s *teacherService) BulkUpdate(ctx context.Context, items []models.Teacher) (int, error) {
-	return s.repo.BulkUpdate(ctx, items)
+func (s *teacherService) BulkUpdate(ctx context.Context, items []models.Teacher, etag string) (int, string, error) {
+	for _, item := range items {
+		for _, ch := range item.ClassHours {
+			if !validHours(ch.Hours) {
+				return 0, "", fmt.Errorf("%w: %s %s, %s: %v", ErrInvalidHours, item.LastName, item.FirstName, ch.Class.Name, ch.Hours)
+			}
+		}
+	}
+
+	ids := make([]uuid.UUID, len(items))
+	for i, item := range items {
+		ids[i] = item.ID
+	}
+	before, err := s.teachersByID(ctx, ids)
+	if err != nil {
+		return 0, "", err
+	}
+	updated, tag, err := s.repo.BulkUpdate(ctx, items, etag)
+	if err != nil {
+		return updated, "", err
+	}
+	after, err := s.teachersByID(ctx, ids)
+	if err != nil {
+		return 0, "", err
+	}
+
+	// One entry per teacher so that the history of a teacher, e.g. of the workload, can be looked up by its ID
+	for _, item := range items {
+		id := item.ID
+		if after[id] != nil {
+			s.audit.Record(ctx, models.AuditActionUpdate, models.AuditEntityTeacher, &id, before[id], after[id])
+		}
+	}
+	return updated, tag, nil
+}
+
+// teachersByID loads the given teachers with their workload for audit snapshots
+func (s *teacherService) teachersByID(ctx context.Context, ids []uuid.UUID) (map[uuid.UUID]*models.Teacher, error) {
+	teachers, err := s.repo.GetByIDs(ctx, ids)
+	if err != nil {
+		return nil, err
+	}
+	byID := make(map[uuid.UUID]*models.Teacher, len(teachers))
+	for i := range teachers {
+		byID[teachers[i].ID] = &teachers[i]
+	}
+	return byID, nil
+}
+
+func (s *teacherService) ETag(ctx context.Context) (string, error) {
+	return s.repo.ETag(ctx)
 }
@@ -0,0 +1,201 @@
+package services
+
+import (
+	"bytes"
+	"embed"
+	"fmt"
+	"strings"
+
+	"github.com/go-pdf/fpdf"
+	"github.com/nikomkinds/SchoolSchedule/internal/models"
+)
+
+// DejaVu fonts cover Cyrillic, which the core PDF fonts do not
+//
+//go:embed fonts/*.ttf
+var pdfFonts embed.FS
+
+const pdfFontFamily = "DejaVu"
+
+// Layout of a printed timetable in millimetres and points for A4; A3 pages scale it up
+const (
+	pdfMargin      = 10.0
+	pdfTitleHeight = 12.0
+	pdfHeaderRow   = 8.0
+	pdfFirstColumn = 22.0
+	pdfTitleFont   = 14.0
+	pdfHeaderFont  = 9.0
+	pdfLessonFont  = 9.0
+	pdfMinFont     = 5.0
+	pdfLineSpacing = 1.15
+)
+
+// pdfScale is the ratio of the side of a page size to that of A4
+var pdfScale = map[string]float64{
+	models.PageSizeA4: 1,
+	models.PageSizeA3: 1.414,
+}
+
+// newTimetablePDF creates a landscape document with the fonts loaded
+func newTimetablePDF(pageSize string) (*fpdf.Fpdf, error) {
+	pdf := fpdf.NewCustom(&fpdf.InitType{OrientationStr: "L", UnitStr: "mm", SizeStr: pageSize})
+	for style, file := range map[string]string{"": "fonts/DejaVuSansCondensed.ttf", "B": "fonts/DejaVuSansCondensed-Bold.ttf"} {
+		font, err := pdfFonts.ReadFile(file)
+		if err != nil {
+			return nil, err
+		}
+		pdf.AddUTF8FontFromBytes(pdfFontFamily, style, font)
+	}
+	pdf.SetMargins(pdfMargin, pdfMargin, pdfMargin)
+	pdf.SetAutoPageBreak(false, pdfMargin)
+	pdf.SetCreator("SchoolSchedule", true)
+	return pdf, pdf.Error()
+}
+
+// renderTimetablePDF renders the views one per page
+func renderTimetablePDF(pageSize, scheduleName, target string, grid timetableGrid, views []*timetableView) ([]byte, error) {
+	pdf, err := newTimetablePDF(pageSize)
+	if err != nil {
+		return nil, err
+	}
+	if len(views) == 1 {
+		pdf.SetTitle(views[0].Name, true)
+	} else {
+		pdf.SetTitle(scheduleName, true)
+	}
+	for _, v := range views {
+		drawTimetablePage(pdf, pdfScale[pageSize], scheduleName, target, grid, v)
+	}
+
+	var buf bytes.Buffer
+	if err := pdf.Output(&buf); err != nil {
+		return nil, err
+	}
+	return buf.Bytes(), nil
+}
+
+// drawTimetablePage draws the title and the grid of days by lesson numbers filling the page
+func drawTimetablePage(pdf *fpdf.Fpdf, scale float64, scheduleName, target string, grid timetableGrid, v *timetableView) {
+	pdf.AddPage()
+	pageW, pageH := pdf.GetPageSize()
+	margin := pdfMargin * scale
+
+	pdf.SetFont(pdfFontFamily, "B", pdfTitleFont*scale)
+	pdf.SetXY(margin, margin)
+	pdf.CellFormat(pageW-2*margin, pdfTitleHeight*scale*0.6, v.Name, "", 1, "L", false, 0, "")
+	pdf.SetFont(pdfFontFamily, "", pdfHeaderFont*scale)
+	pdf.CellFormat(pageW-2*margin, pdfTitleHeight*scale*0.4, scheduleName, "", 1, "L", false, 0, "")
+
+	top := margin + pdfTitleHeight*scale
+	firstW := pdfFirstColumn * scale
+	dayW := (pageW - 2*margin - firstW) / float64(len(grid.days))
+	headerH := pdfHeaderRow * scale
+	rowH := (pageH - top - margin - headerH) / float64(len(grid.lessonNumbers))
+
+	pdf.SetLineWidth(0.2 * scale)
+	pdf.SetDrawColor(80, 80, 80)
+	pdf.SetFillColor(230, 230, 230)
+	pdf.SetFont(pdfFontFamily, "B", pdfHeaderFont*scale)
+	pdf.SetXY(margin, top)
+	pdf.CellFormat(firstW, headerH, "Урок", "1", 0, "C", true, 0, "")
+	for _, day := range grid.days {
+		pdf.CellFormat(dayW, headerH, dayNames[day], "1", 0, "C", true, 0, "")
+	}
+
+	for i, n := range grid.lessonNumbers {
+		y := top + headerH + float64(i)*rowH
+		pdf.Rect(margin, y, firstW, rowH, "DF")
+		label := []pdfLine{{text: fmt.Sprintf("%d", n), bold: true}}
+		if t, ok := grid.times[n]; ok {
+			label = append(label, pdfLine{text: t[0] + "–" + t[1]})
+		}
+		drawPDFLines(pdf, margin, y, firstW, rowH, label, pdfHeaderFont*scale, "C")
+
+		for j, day := range grid.days {
+			x := margin + firstW + float64(j)*dayW
+			pdf.Rect(x, y, dayW, rowH, "D")
+			lessons := v.cells[slotKey{day: day, lessonNumber: n}]
+			// Lessons of groups and alternating weeks share the cell, one below the other
+			partH := rowH / float64(max(len(lessons), 1))
+			for k, l := range lessons {
+				if k > 0 {
+					pdf.Line(x, y+float64(k)*partH, x+dayW, y+float64(k)*partH)
+				}
+				drawPDFLines(pdf, x, y+float64(k)*partH, dayW, partH, lessonPDFLines(l, target), pdfLessonFont*scale, "L")
+			}
+		}
+	}
+}
+
+// pdfLine is a paragraph of a cell
+type pdfLine struct {
+	text string
+	bold bool
+}
+
+func lessonPDFLines(l models.ScheduleLesson, target string) []pdfLine {
+	subject := subjectLabel(l)
+	if week := weekLabel(l); week != "" {
+		subject += " (" + week + ")"
+	}
+	lines := []pdfLine{{text: subject, bold: true}}
+	if details := lessonDetails(l, target); details != "" {
+		lines = append(lines, pdfLine{text: details})
+	}
+	return lines
+}
+
+// drawPDFLines writes the paragraphs wrapped into the box, vertically centred, shrinking the font
+// until they fit
+func drawPDFLines(pdf *fpdf.Fpdf, x, y, w, h float64, paragraphs []pdfLine, size float64, align string) {
+	if len(paragraphs) == 0 {
+		return
+	}
+	minSize := pdfMinFont * size / pdfLessonFont
+	var (
+		wrapped []pdfLine
+		lineH   float64
+	)
+	for ; ; size -= 0.5 {
+		wrapped = wrapped[:0]
+		for _, p := range paragraphs {
+			pdf.SetFont(pdfFontFamily, boldStyle(p.bold), size)
+			for _, s := range pdf.SplitText(sanitizePDFText(p.text), w) {
+				wrapped = append(wrapped, pdfLine{text: s, bold: p.bold})
+			}
+		}
+		lineH = pdf.PointConvert(size) * pdfLineSpacing
+		if float64(len(wrapped))*lineH <= h || size-0.5 < minSize {
+			break
+		}
+	}
+
+	// Lines that still do not fit at the smallest size are cut off
+	if fit := int(h / lineH); len(wrapped) > fit {
+		wrapped = wrapped[:max(fit, 1)]
+	}
+	top := y + (h-float64(len(wrapped))*lineH)/2
+	for i, line := range wrapped {
+		pdf.SetFont(pdfFontFamily, boldStyle(line.bold), size)
+		pdf.SetXY(x, top+float64(i)*lineH)
+		pdf.CellFormat(w, lineH, line.text, "", 0, align, false, 0, "")
+	}
+}
+
+func boldStyle(bold bool) string {
+	if bold {
+		return "B"
+	}
+	return ""
+}
+
+// sanitizePDFText drops characters outside the Basic Multilingual Plane, which the embedded fonts
+// do not have glyphs for
+func sanitizePDFText(s string) string {
+	return strings.Map(func(r rune) rune {
+		if r > 0xFFFF {
+			return -1
+		}
+		return r
+	}, s)
+}
@@ -0,0 +1,218 @@
+package services
+
+import (
+	"sort"
+	"strings"
+
+	"github.com/google/uuid"
+	"github.com/nikomkinds/SchoolSchedule/internal/models"
+)
+
+// Russian names of the days of week, index = day number (1=Monday)
+var dayNames = [...]string{"", "Понедельник", "Вторник", "Среда", "Четверг", "Пятница", "Суббота", "Воскресенье"}
+
+// slotKey identifies a slot of the week: day number and lesson number
+type slotKey struct {
+	day, lessonNumber int
+}
+
+// timetableGrid is the shape shared by all timetables of a schedule, so that printed pages line up
+type timetableGrid struct {
+	days          []int // Day numbers shown: Monday to Friday, and the weekend days having lessons
+	lessonNumbers []int // From the first to the last lesson number of the schedule
+	times         map[int][2]string
+}
+
+// timetableView is the timetable of one class, teacher or room
+type timetableView struct {
+	ID    uuid.UUID
+	Name  string
+	sort  string
+	cells map[slotKey][]models.ScheduleLesson
+}
+
+func newTimetableGrid(days []models.ScheduleDay) timetableGrid {
+	grid := timetableGrid{times: make(map[int][2]string)}
+	weekend := map[int]bool{}
+	first, last := 0, 0
+	for _, slot := range days {
+		if len(slot.Lessons) == 0 {
+			continue
+		}
+		if day := models.DayOfWeekNumber(slot.DayOfWeek); day > 5 {
+			weekend[day] = true
+		}
+		if first == 0 || slot.LessonNumber < first {
+			first = slot.LessonNumber
+		}
+		if slot.LessonNumber > last {
+			last = slot.LessonNumber
+		}
+		if _, ok := grid.times[slot.LessonNumber]; !ok {
+			if start, end := slotTimes(slot, slot.Lessons[0]); start != "" {
+				grid.times[slot.LessonNumber] = [2]string{start, end}
+			}
+		}
+	}
+
+	grid.days = []int{1, 2, 3, 4, 5}
+	for _, day := range []int{6, 7} {
+		if weekend[day] {
+			grid.days = append(grid.days, day)
+		}
+	}
+	if first == 0 {
+		first, last = 1, 1
+	}
+	for n := first; n <= last; n++ {
+		grid.lessonNumbers = append(grid.lessonNumbers, n)
+	}
+	return grid
+}
+
+// timetableViews splits a timetable into the timetables of every class, teacher or room in it, sorted
+// by grade and name, by name or by room name
+func timetableViews(days []models.ScheduleDay, target string) []*timetableView {
+	byID := make(map[uuid.UUID]*timetableView)
+	add := func(id uuid.UUID, name, sortKey string, key slotKey, l models.ScheduleLesson) {
+		v, ok := byID[id]
+		if !ok {
+			v = &timetableView{ID: id, Name: name, sort: sortKey, cells: make(map[slotKey][]models.ScheduleLesson)}
+			byID[id] = v
+		}
+		v.cells[key] = append(v.cells[key], l)
+	}
+
+	for _, slot := range days {
+		key := slotKey{day: models.DayOfWeekNumber(slot.DayOfWeek), lessonNumber: slot.LessonNumber}
+		for _, l := range slot.Lessons {
+			// Times of the slot travel with the lesson into each view
+			if l.StartTime == nil {
+				l.StartTime, l.EndTime = slot.StartTime, slot.EndTime
+			}
+			switch target {
+			case models.ExportTargetClass:
+				for _, p := range l.Participants {
+					name := ""
+					grade := 0
+					if p.Class != nil {
+						name, grade = p.Class.Name, p.Class.GradeLevel
+					}
+					add(p.ClassID, name, sortableGrade(grade)+name, key, l)
+				}
+			case models.ExportTargetTeacher:
+				for _, t := range l.Teachers {
+					name := teacherShortName(t.LastName, t.FirstName, t.Patronymic)
+					add(t.ID, name, name, key, l)
+				}
+			case models.ExportTargetRoom:
+				for _, r := range l.Rooms {
+					add(r.ID, r.Name, r.Name, key, l)
+				}
+			}
+		}
+	}
+
+	views := make([]*timetableView, 0, len(byID))
+	for _, v := range byID {
+		views = append(views, v)
+	}
+	sort.Slice(views, func(i, j int) bool {
+		if views[i].sort != views[j].sort {
+			return views[i].sort < views[j].sort
+		}
+		return views[i].ID.String() < views[j].ID.String()
+	})
+	return views
+}
+
+// sortableGrade makes "2А" sort before "10А"
+func sortableGrade(grade int) string {
+	return string(rune('A'+grade)) + " "
+}
+
+// slotTimes returns the bell times of a lesson, those of its slot if it has none
+func slotTimes(slot models.ScheduleDay, l models.ScheduleLesson) (string, string) {
+	switch {
+	case l.StartTime != nil && l.EndTime != nil:
+		return *l.StartTime, *l.EndTime
+	case slot.StartTime != nil && slot.EndTime != nil:
+		return *slot.StartTime, *slot.EndTime
+	}
+	return "", ""
+}
+
+// subjectLabel is the short name of the lesson's subject, if it has one
+func subjectLabel(l models.ScheduleLesson) string {
+	switch {
+	case l.Subject == nil:
+		return "Урок"
+	case l.Subject.ShortName != nil && *l.Subject.ShortName != "":
+		return *l.Subject.ShortName
+	}
+	return l.Subject.Name
+}
+
+// weekLabel marks lessons held every other week
+func weekLabel(l models.ScheduleLesson) string {
+	switch l.WeekPattern {
+	case models.WeekOdd:
+		return "нечёт. нед."
+	case models.WeekEven:
+		return "чёт. нед."
+	}
+	return ""
+}
+
+// lessonDetails describes a lesson in a timetable of the target: who else and where
+func lessonDetails(l models.ScheduleLesson, target string) string {
+	var parts []string
+	if target != models.ExportTargetTeacher {
+		for _, t := range l.Teachers {
+			parts = append(parts, teacherShortName(t.LastName, t.FirstName, t.Patronymic))
+		}
+	}
+	if target != models.ExportTargetClass {
+		for _, p := range l.Participants {
+			if p.Class != nil {
+				name := p.Class.Name
+				if len(p.GroupIDs) > 0 {
+					name += " (гр.)"
+				}
+				parts = append(parts, name)
+			}
+		}
+	}
+	if target != models.ExportTargetRoom {
+		for _, r := range l.Rooms {
+			parts = append(parts, "каб. "+r.Name)
+		}
+	}
+	return strings.Join(parts, ", ")
+}
+
+// weeklyHours counts the lessons of a timetable per week; a slot taken only every other week counts
+// as half an hour, group lessons in one slot as one
+func weeklyHours(cells map[slotKey][]models.ScheduleLesson) float64 {
+	var hours float64
+	for _, lessons := range cells {
+		var every, odd, even bool
+		for _, l := range lessons {
+			switch l.WeekPattern {
+			case models.WeekOdd:
+				odd = true
+			case models.WeekEven:
+				even = true
+			default:
+				every = true
+			}
+		}
+		switch {
+		case every || (odd && even):
+			hours++
+		case odd || even:
+			hours += 0.5
+		}
+	}
+	return hours
+}
@@ -0,0 +1,331 @@
+package services
+
+import (
+	"fmt"
+	"strings"
+	"unicode/utf8"
+
+	"github.com/nikomkinds/SchoolSchedule/internal/models"
+	"github.com/xuri/excelize/v2"
+)
+
+const (
+	xlsxMasterSheet  = "Расписание"
+	xlsxSummarySheet = "Нагрузка"
+	xlsxClassWidth   = 26.0 // Width of a class column, shared by its group sub-columns
+	xlsxDayWidth     = 26.0
+)
+
+// xlsxBook wraps a workbook keeping the first error of the calls, so that rendering reads as a sequence
+// of cells and the error is checked once
+type xlsxBook struct {
+	f      *excelize.File
+	err    error
+	styles map[string]int
+}
+
+func (b *xlsxBook) check(err error) {
+	if b.err == nil {
+		b.err = err
+	}
+}
+
+func (b *xlsxBook) cell(col, row int) string {
+	name, err := excelize.CoordinatesToCellName(col, row)
+	b.check(err)
+	return name
+}
+
+func (b *xlsxBook) set(sheet string, col, row int, value interface{}) {
+	b.check(b.f.SetCellValue(sheet, b.cell(col, row), value))
+}
+
+// area merges the cells if there are several and applies the style to them
+func (b *xlsxBook) area(sheet string, col1, row1, col2, row2 int, style string) {
+	tl, br := b.cell(col1, row1), b.cell(col2, row2)
+	if tl != br {
+		b.check(b.f.MergeCell(sheet, tl, br))
+	}
+	b.check(b.f.SetCellStyle(sheet, tl, br, b.styles[style]))
+}
+
+func (b *xlsxBook) width(sheet string, col1, col2 int, width float64) {
+	c1, err := excelize.ColumnNumberToName(col1)
+	b.check(err)
+	c2, err := excelize.ColumnNumberToName(col2)
+	b.check(err)
+	b.check(b.f.SetColWidth(sheet, c1, c2, width))
+}
+
+func (b *xlsxBook) freeze(sheet string, cols, rows int) {
+	b.check(b.f.SetPanes(sheet, &excelize.Panes{
+		Freeze: true, XSplit: cols, YSplit: rows, TopLeftCell: b.cell(cols+1, rows+1), ActivePane: "bottomRight",
+	}))
+}
+
+func newXLSXBook() *xlsxBook {
+	b := &xlsxBook{f: excelize.NewFile(), styles: make(map[string]int)}
+	border := []excelize.Border{
+		{Type: "left", Color: "808080", Style: 1},
+		{Type: "top", Color: "808080", Style: 1},
+		{Type: "right", Color: "808080", Style: 1},
+		{Type: "bottom", Color: "808080", Style: 1},
+	}
+	for name, style := range map[string]*excelize.Style{
+		"title": {Font: &excelize.Font{Bold: true, Size: 14}},
+		"header": {
+			Font:      &excelize.Font{Bold: true},
+			Fill:      excelize.Fill{Type: "pattern", Pattern: 1, Color: []string{"E6E6E6"}},
+			Alignment: &excelize.Alignment{Horizontal: "center", Vertical: "center", WrapText: true},
+			Border:    border,
+		},
+		"lesson": {
+			Alignment: &excelize.Alignment{Horizontal: "center", Vertical: "center", WrapText: true},
+			Border:    border,
+		},
+		"number": {NumFmt: 2, Border: border}, // 0.00
+		"text":   {Border: border},
+	} {
+		id, err := b.f.NewStyle(style)
+		b.check(err)
+		b.styles[name] = id
+	}
+	return b
+}
+
+// renderTimetableXLSX renders the master grid of classes by slots, the summary of weekly hours and
+// the timetable of every teacher on a sheet of its own
+func renderTimetableXLSX(scheduleName string, grid timetableGrid, classes, teachers []*timetableView) ([]byte, error) {
+	b := newXLSXBook()
+	defer b.f.Close()
+
+	b.check(b.f.SetSheetName("Sheet1", xlsxMasterSheet))
+	writeMasterSheet(b, scheduleName, grid, classes)
+	_, err := b.f.NewSheet(xlsxSummarySheet)
+	b.check(err)
+	writeSummarySheet(b, teachers, classes)
+
+	used := map[string]bool{strings.ToLower(xlsxMasterSheet): true, strings.ToLower(xlsxSummarySheet): true}
+	for _, v := range teachers {
+		sheet := xlsxSheetName(v.Name, used)
+		_, err := b.f.NewSheet(sheet)
+		b.check(err)
+		writeTeacherSheet(b, sheet, scheduleName, grid, v)
+	}
+	if b.err != nil {
+		return nil, b.err
+	}
+
+	buf, err := b.f.WriteToBuffer()
+	if err != nil {
+		return nil, err
+	}
+	return buf.Bytes(), nil
+}
+
+// writeMasterSheet lays the slots of the week out in rows and the classes in columns. A class takes as
+// many columns as it has group lessons at once; lessons of the whole class are merged across them.
+func writeMasterSheet(b *xlsxBook, scheduleName string, grid timetableGrid, classes []*timetableView) {
+	const sheet, firstCol, firstRow = xlsxMasterSheet, 4, 3
+
+	b.set(sheet, 1, 1, scheduleName)
+	b.area(sheet, 1, 1, 1, 1, "title")
+	for i, title := range []string{"День", "№", "Время"} {
+		b.set(sheet, i+1, 2, title)
+		b.area(sheet, i+1, 2, i+1, 2, "header")
+	}
+	b.width(sheet, 1, 1, 14)
+	b.width(sheet, 2, 2, 4)
+	b.width(sheet, 3, 3, 12)
+
+	// Rows of the slots, a day spanning its lessons
+	row := firstRow
+	for _, day := range grid.days {
+		b.set(sheet, 1, row, dayNames[day])
+		b.area(sheet, 1, row, 1, row+len(grid.lessonNumbers)-1, "header")
+		for i, n := range grid.lessonNumbers {
+			b.set(sheet, 2, row+i, n)
+			b.area(sheet, 2, row+i, 2, row+i, "header")
+			if t, ok := grid.times[n]; ok {
+				b.set(sheet, 3, row+i, t[0]+"–"+t[1])
+			}
+			b.area(sheet, 3, row+i, 3, row+i, "header")
+		}
+		row += len(grid.lessonNumbers)
+	}
+
+	col := firstCol
+	for _, v := range classes {
+		span := classColumns(v)
+		b.set(sheet, col, 2, v.Name)
+		b.area(sheet, col, 2, col+span-1, 2, "header")
+		b.width(sheet, col, col+span-1, max(xlsxClassWidth/float64(span), 12))
+
+		row := firstRow
+		for _, day := range grid.days {
+			for _, n := range grid.lessonNumbers {
+				lessons := v.cells[slotKey{day: day, lessonNumber: n}]
+				if !hasGroupLesson(lessons, v) {
+					if len(lessons) > 0 {
+						b.set(sheet, col, row, lessonsText(lessons, models.ExportTargetClass))
+					}
+					b.area(sheet, col, row, col+span-1, row, "lesson")
+				} else {
+					// One column per lesson, the last one taking the columns left over
+					for i, l := range lessons {
+						end := col + i
+						if i == len(lessons)-1 {
+							end = col + span - 1
+						}
+						b.set(sheet, col+i, row, lessonText(l, models.ExportTargetClass))
+						b.area(sheet, col+i, row, end, row, "lesson")
+					}
+				}
+				row++
+			}
+		}
+		col += span
+	}
+	b.freeze(sheet, firstCol-1, firstRow-1)
+}
+
+// writeTeacherSheet lays the timetable of a teacher out as days by lesson numbers, like the printed one
+func writeTeacherSheet(b *xlsxBook, sheet, scheduleName string, grid timetableGrid, v *timetableView) {
+	const firstRow = 3
+
+	b.set(sheet, 1, 1, v.Name+" — "+scheduleName)
+	b.area(sheet, 1, 1, 1, 1, "title")
+	for i, title := range []string{"№", "Время"} {
+		b.set(sheet, i+1, 2, title)
+		b.area(sheet, i+1, 2, i+1, 2, "header")
+	}
+	for i, day := range grid.days {
+		b.set(sheet, i+3, 2, dayNames[day])
+		b.area(sheet, i+3, 2, i+3, 2, "header")
+	}
+	b.width(sheet, 1, 1, 4)
+	b.width(sheet, 2, 2, 12)
+	b.width(sheet, 3, len(grid.days)+2, xlsxDayWidth)
+
+	for i, n := range grid.lessonNumbers {
+		row := firstRow + i
+		b.set(sheet, 1, row, n)
+		b.area(sheet, 1, row, 1, row, "header")
+		if t, ok := grid.times[n]; ok {
+			b.set(sheet, 2, row, t[0]+"–"+t[1])
+		}
+		b.area(sheet, 2, row, 2, row, "header")
+		for j, day := range grid.days {
+			if lessons := v.cells[slotKey{day: day, lessonNumber: n}]; len(lessons) > 0 {
+				b.set(sheet, j+3, row, lessonsText(lessons, models.ExportTargetTeacher))
+			}
+			b.area(sheet, j+3, row, j+3, row, "lesson")
+		}
+	}
+	b.freeze(sheet, 2, 2)
+}
+
+// writeSummarySheet lists the weekly hours of the teachers and, next to them, of the classes
+func writeSummarySheet(b *xlsxBook, teachers, classes []*timetableView) {
+	const sheet = xlsxSummarySheet
+
+	table := func(col int, title string, views []*timetableView) {
+		b.set(sheet, col, 1, title)
+		b.set(sheet, col+1, 1, "Часов в неделю")
+		b.area(sheet, col, 1, col, 1, "header")
+		b.area(sheet, col+1, 1, col+1, 1, "header")
+		var total float64
+		for i, v := range views {
+			hours := weeklyHours(v.cells)
+			total += hours
+			b.set(sheet, col, i+2, v.Name)
+			b.area(sheet, col, i+2, col, i+2, "text")
+			b.set(sheet, col+1, i+2, hours)
+			b.area(sheet, col+1, i+2, col+1, i+2, "number")
+		}
+		row := len(views) + 2
+		b.set(sheet, col, row, "Итого")
+		b.set(sheet, col+1, row, total)
+		b.area(sheet, col, row, col, row, "header")
+		b.area(sheet, col+1, row, col+1, row, "number")
+		b.width(sheet, col, col, 28)
+		b.width(sheet, col+1, col+1, 16)
+	}
+	table(1, "Учитель", teachers)
+	table(4, "Класс", classes)
+	b.width(sheet, 3, 3, 4)
+	b.freeze(sheet, 0, 1)
+}
+
+// classColumns is the number of columns a class needs: the most lessons it has in a slot with groups
+func classColumns(v *timetableView) int {
+	span := 1
+	for _, lessons := range v.cells {
+		if hasGroupLesson(lessons, v) {
+			span = max(span, len(lessons))
+		}
+	}
+	return span
+}
+
+// hasGroupLesson tells whether a group of the class has one of the lessons rather than the whole class
+func hasGroupLesson(lessons []models.ScheduleLesson, class *timetableView) bool {
+	for _, l := range lessons {
+		for _, p := range l.Participants {
+			if p.ClassID == class.ID && len(p.GroupIDs) > 0 {
+				return true
+			}
+		}
+	}
+	return false
+}
+
+// lessonText is the content of a timetable cell: subject and week on the first line, the rest below
+func lessonText(l models.ScheduleLesson, target string) string {
+	text := subjectLabel(l)
+	if week := weekLabel(l); week != "" {
+		text += " (" + week + ")"
+	}
+	if details := lessonDetails(l, target); details != "" {
+		text += "\n" + details
+	}
+	return text
+}
+
+func lessonsText(lessons []models.ScheduleLesson, target string) string {
+	texts := make([]string, 0, len(lessons))
+	for _, l := range lessons {
+		texts = append(texts, lessonText(l, target))
+	}
+	return strings.Join(texts, "\n")
+}
+
+// xlsxSheetName makes a sheet name of a timetable name: at most 31 characters, none of []:*?/\ and
+// unique ignoring case among the used ones
+func xlsxSheetName(name string, used map[string]bool) string {
+	name = strings.Trim(strings.Map(func(r rune) rune {
+		if strings.ContainsRune(`[]:*?/\`, r) {
+			return '_'
+		}
+		return r
+	}, name), "' ")
+	if name == "" {
+		name = "Лист"
+	}
+
+	const limit = 31
+	candidate := truncateRunes(name, limit)
+	for i := 2; used[strings.ToLower(candidate)]; i++ {
+		suffix := fmt.Sprintf(" (%d)", i)
+		candidate = truncateRunes(name, limit-utf8.RuneCountInString(suffix)) + suffix
+	}
+	used[strings.ToLower(candidate)] = true
+	return candidate
+}
+
+func truncateRunes(s string, n int) string {
+	if utf8.RuneCountInString(s) <= n {
+		return s
+	}
+	return string([]rune(s)[:n])
+}
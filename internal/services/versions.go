@@ -0,0 +1,61 @@
+package services
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"fmt"
+
+	"github.com/google/uuid"
+	"github.com/nikomkinds/SchoolSchedule/internal/models"
+	"github.com/nikomkinds/SchoolSchedule/internal/repositories"
+)
+
+// ErrVersionMismatch is returned when a write is based on an outdated version (If-Match does not match)
+var ErrVersionMismatch = repositories.ErrVersionMismatch
+
+// AnyVersion as the expected tag skips the version check (If-Match: *)
+const AnyVersion = repositories.AnyVersion
+
+// ScheduleVersionError is returned when a schedule write is based on an outdated version.
+// Diff lists the changes between the client's base version and the current one; it is nil
+// if the base version is unknown.
+type ScheduleVersionError struct {
+	Version int
+	Diff    *models.ScheduleDiff
+}
+
+func (e *ScheduleVersionError) Error() string {
+	return fmt.Sprintf("schedule was changed, current version is %d", e.Version)
+}
+
+func (e *ScheduleVersionError) Unwrap() error {
+	return ErrVersionMismatch
+}
+
+// versionError describes a rejected write based on the base version: the current version and,
+// if the base revision is still stored, what changed since then
+func (s *scheduleService) versionError(ctx context.Context, scheduleID uuid.UUID, base int) error {
+	schedule, err := s.repo.GetScheduleByID(ctx, scheduleID)
+	if err != nil {
+		return err
+	}
+	versionErr := &ScheduleVersionError{Version: schedule.Version}
+
+	before, err := s.repo.GetRevision(ctx, scheduleID, base)
+	switch {
+	case errors.Is(err, sql.ErrNoRows):
+		return versionErr
+	case err != nil:
+		return err
+	}
+	after, err := s.repo.GetScheduleDays(ctx, scheduleID)
+	if err != nil {
+		return err
+	}
+
+	diff := diffSchedules(before, after)
+	diff.FromVersion, diff.ToVersion = base, schedule.Version
+	versionErr.Diff = &diff
+	return versionErr
+}
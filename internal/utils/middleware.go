@@ -37,6 +37,7 @@ func AuthMiddleware(secret string) gin.HandlerFunc {
 		c.Set("userID", claims.UserID)
 		c.Set("email", claims.Email)
 		c.Set("role", claims.Role)
+		withUserID(c, claims.UserID)
 
 		c.Next()
 	}
@@ -66,6 +67,7 @@ func AuthMiddlewareWithTeacher(secret string, db *sql.DB) gin.HandlerFunc {
 		c.Set("userID", claims.UserID)
 		c.Set("email", claims.Email)
 		c.Set("role", claims.Role)
+		withUserID(c, claims.UserID)
 
 		// 3) Teacher restriction: user MUST be a teacher
 		var teacherID uuid.UUID
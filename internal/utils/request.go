@@ -0,0 +1,54 @@
+package utils
+
+import (
+	"context"
+
+	"github.com/gin-gonic/gin"
+	"github.com/google/uuid"
+)
+
+// RequestIDHeader carries the ID of a request, from the client or generated
+const RequestIDHeader = "X-Request-ID"
+
+type contextKey int
+
+const (
+	requestIDKey contextKey = iota
+	userIDKey
+)
+
+// RequestIDMiddleware assigns every request an ID, keeping the one the client sent, and returns it
+// in the response. The ID is also put into the request context for the services.
+func RequestIDMiddleware() gin.HandlerFunc {
+	return func(c *gin.Context) {
+		requestID := c.GetHeader(RequestIDHeader)
+		if requestID == "" || len(requestID) > 128 {
+			requestID = uuid.NewString()
+		}
+
+		c.Set("requestID", requestID)
+		c.Header(RequestIDHeader, requestID)
+		c.Request = c.Request.WithContext(context.WithValue(c.Request.Context(), requestIDKey, requestID))
+
+		c.Next()
+	}
+}
+
+// RequestIDFromContext returns the ID of the request the context belongs to, "" outside of a request
+func RequestIDFromContext(ctx context.Context) string {
+	requestID, _ := ctx.Value(requestIDKey).(string)
+	return requestID
+}
+
+// UserIDFromContext returns the authenticated user of the request the context belongs to
+func UserIDFromContext(ctx context.Context) (uuid.UUID, bool) {
+	userID, ok := ctx.Value(userIDKey).(uuid.UUID)
+	return userID, ok
+}
+
+// withUserID puts the authenticated user into the request context
+func withUserID(c *gin.Context, rawUserID string) {
+	if userID, err := uuid.Parse(rawUserID); err == nil {
+		c.Request = c.Request.WithContext(context.WithValue(c.Request.Context(), userIDKey, userID))
+	}
+}
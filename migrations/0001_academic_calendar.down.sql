@@ -0,0 +1,11 @@
+DROP INDEX IF EXISTS uq_class_subjects_class_subject_term;
+DELETE FROM class_subjects WHERE term_id IS NOT NULL;
+ALTER TABLE class_subjects DROP COLUMN term_id;
+ALTER TABLE class_subjects ADD CONSTRAINT class_subjects_class_id_subject_id_key UNIQUE (class_id, subject_id);
+
+COMMENT ON COLUMN schedules.academic_year IS NULL;
+ALTER TABLE schedules DROP COLUMN term_id;
+
+DROP TABLE holidays;
+DROP TABLE academic_terms;
+DROP TABLE academic_years;
@@ -0,0 +1,59 @@
+-- Academic years, terms and holidays as first-class entities.
+-- Schedules and study plans are bound to terms instead of a free-form academic_year string.
+
+CREATE TABLE academic_years (
+    id         UUID PRIMARY KEY DEFAULT gen_random_uuid(),
+    name       TEXT NOT NULL UNIQUE,
+    start_date DATE NOT NULL,
+    end_date   DATE NOT NULL,
+    created_at TIMESTAMPTZ NOT NULL DEFAULT now(),
+    updated_at TIMESTAMPTZ NOT NULL DEFAULT now(),
+    CHECK (end_date > start_date)
+);
+
+CREATE TABLE academic_terms (
+    id               UUID PRIMARY KEY DEFAULT gen_random_uuid(),
+    academic_year_id UUID NOT NULL REFERENCES academic_years (id) ON DELETE CASCADE,
+    name             TEXT NOT NULL,
+    term_type        TEXT NOT NULL CHECK (term_type IN ('quarter', 'trimester', 'semester')),
+    number           INT  NOT NULL DEFAULT 1,
+    start_date       DATE NOT NULL,
+    end_date         DATE NOT NULL,
+    created_at       TIMESTAMPTZ NOT NULL DEFAULT now(),
+    updated_at       TIMESTAMPTZ NOT NULL DEFAULT now(),
+    CHECK (end_date >= start_date)
+);
+
+CREATE INDEX idx_academic_terms_dates ON academic_terms (start_date, end_date);
+
+CREATE TABLE holidays (
+    id               UUID PRIMARY KEY DEFAULT gen_random_uuid(),
+    academic_year_id UUID NOT NULL REFERENCES academic_years (id) ON DELETE CASCADE,
+    name             TEXT NOT NULL,
+    start_date       DATE NOT NULL,
+    end_date         DATE NOT NULL,
+    created_at       TIMESTAMPTZ NOT NULL DEFAULT now(),
+    CHECK (end_date >= start_date)
+);
+
+ALTER TABLE schedules ADD COLUMN term_id UUID REFERENCES academic_terms (id) ON DELETE SET NULL;
+
+-- The free-form years of existing schedules ("2024/2025", "2024-2025") become academic years from
+-- September 1 to August 31, for their terms to be added. No terms are created for them, so schedules are
+-- not bound automatically; the column stays, unused by the code, as the record of the year a schedule had.
+INSERT INTO academic_years (name, start_date, end_date)
+SELECT DISTINCT
+       y[1] || '/' || y[2],
+       make_date(y[1]::INT, 9, 1),
+       make_date(y[2]::INT, 8, 31)
+FROM schedules,
+     LATERAL regexp_match(btrim(academic_year), '^(\d{4})\s*[/-]\s*(\d{4})$') AS y
+WHERE y IS NOT NULL AND y[2]::INT = y[1]::INT + 1
+ON CONFLICT (name) DO NOTHING;
+COMMENT ON COLUMN schedules.academic_year IS 'Deprecated: migrated to academic_years, use term_id';
+
+-- Study plans may differ by term: a row with term_id overrides the general (NULL) row for the same subject
+ALTER TABLE class_subjects ADD COLUMN term_id UUID REFERENCES academic_terms (id) ON DELETE CASCADE;
+ALTER TABLE class_subjects DROP CONSTRAINT IF EXISTS class_subjects_class_id_subject_id_key;
+CREATE UNIQUE INDEX uq_class_subjects_class_subject_term
+    ON class_subjects (class_id, subject_id, COALESCE(term_id, '00000000-0000-0000-0000-000000000000'));
@@ -0,0 +1,2 @@
+DROP TABLE bell_schedule_lessons;
+DROP TABLE bell_schedules;
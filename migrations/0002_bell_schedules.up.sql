@@ -0,0 +1,24 @@
+-- Bell schedules: real start/end times per lesson number.
+-- A bell schedule may be restricted to a weekday, a grade band and/or a single date (shortened days).
+
+CREATE TABLE bell_schedules (
+    id          UUID PRIMARY KEY DEFAULT gen_random_uuid(),
+    name        TEXT NOT NULL,
+    day_of_week INT  CHECK (day_of_week BETWEEN 1 AND 7),
+    grade_from  INT  CHECK (grade_from BETWEEN 1 AND 11),
+    grade_to    INT  CHECK (grade_to BETWEEN 1 AND 11),
+    date        DATE,
+    created_at  TIMESTAMPTZ NOT NULL DEFAULT now(),
+    updated_at  TIMESTAMPTZ NOT NULL DEFAULT now(),
+    CHECK (grade_from IS NULL OR grade_to IS NULL OR grade_from <= grade_to)
+);
+
+CREATE TABLE bell_schedule_lessons (
+    id               UUID PRIMARY KEY DEFAULT gen_random_uuid(),
+    bell_schedule_id UUID NOT NULL REFERENCES bell_schedules (id) ON DELETE CASCADE,
+    lesson_number    INT  NOT NULL CHECK (lesson_number > 0),
+    start_time       TIME NOT NULL,
+    end_time         TIME NOT NULL,
+    UNIQUE (bell_schedule_id, lesson_number),
+    CHECK (end_time > start_time)
+);
@@ -0,0 +1,3 @@
+ALTER TABLE bell_schedules DROP COLUMN shift_id;
+ALTER TABLE classes DROP COLUMN shift_id;
+DROP TABLE shifts;
@@ -0,0 +1,16 @@
+-- School shifts: each class studies in one shift, each shift has its own lesson-number range.
+-- Bell schedules may be bound to a shift, so lesson N of different shifts has different real times.
+
+CREATE TABLE shifts (
+    id           UUID PRIMARY KEY DEFAULT gen_random_uuid(),
+    name         TEXT NOT NULL,
+    number       INT  NOT NULL UNIQUE CHECK (number > 0),
+    first_lesson INT  NOT NULL DEFAULT 1 CHECK (first_lesson > 0),
+    last_lesson  INT  NOT NULL CHECK (last_lesson > 0),
+    created_at   TIMESTAMPTZ NOT NULL DEFAULT now(),
+    updated_at   TIMESTAMPTZ NOT NULL DEFAULT now(),
+    CHECK (first_lesson <= last_lesson)
+);
+
+ALTER TABLE classes ADD COLUMN shift_id UUID REFERENCES shifts (id) ON DELETE SET NULL;
+ALTER TABLE bell_schedules ADD COLUMN shift_id UUID REFERENCES shifts (id) ON DELETE CASCADE;
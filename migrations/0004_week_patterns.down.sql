@@ -0,0 +1,11 @@
+DO $$
+DECLARE
+    view_def TEXT;
+BEGIN
+    view_def := pg_get_viewdef('v_teacher_workload_detailed'::regclass);
+    DROP VIEW v_teacher_workload_detailed;
+    ALTER TABLE teacher_workload ALTER COLUMN hours_per_week TYPE INT USING ceil(hours_per_week)::int;
+    EXECUTE 'CREATE VIEW v_teacher_workload_detailed AS ' || view_def;
+END $$;
+ALTER TABLE class_subjects ALTER COLUMN hours_per_week TYPE INT USING ceil(hours_per_week)::int;
+ALTER TABLE schedule_lessons DROP COLUMN week_pattern;
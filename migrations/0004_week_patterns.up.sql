@@ -0,0 +1,18 @@
+-- Alternating A/B weeks: a lesson may run every week or only in odd/even weeks of the academic year.
+-- Study plans and teacher workload accept fractional weekly hours (0.5 = once every two weeks).
+
+ALTER TABLE schedule_lessons
+    ADD COLUMN week_pattern TEXT NOT NULL DEFAULT 'every' CHECK (week_pattern IN ('every', 'odd', 'even'));
+
+ALTER TABLE class_subjects ALTER COLUMN hours_per_week TYPE NUMERIC(4, 1);
+
+-- v_teacher_workload_detailed selects the workload hours, so it is recreated around the type change
+DO $$
+DECLARE
+    view_def TEXT;
+BEGIN
+    view_def := pg_get_viewdef('v_teacher_workload_detailed'::regclass);
+    DROP VIEW v_teacher_workload_detailed;
+    ALTER TABLE teacher_workload ALTER COLUMN hours_per_week TYPE NUMERIC(4, 1);
+    EXECUTE 'CREATE VIEW v_teacher_workload_detailed AS ' || view_def;
+END $$;
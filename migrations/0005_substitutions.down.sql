@@ -0,0 +1,2 @@
+DROP TABLE lesson_overrides;
+DROP TABLE teacher_absences;
@@ -0,0 +1,32 @@
+-- Substitutions: teacher absences and per-date overrides of lessons of the weekly timetable.
+-- An override cancels, reassigns (substitute teacher and/or room) or moves one lesson on one date only.
+
+CREATE TABLE teacher_absences (
+    id         UUID PRIMARY KEY DEFAULT gen_random_uuid(),
+    teacher_id UUID NOT NULL REFERENCES teachers (id) ON DELETE CASCADE,
+    start_date DATE NOT NULL,
+    end_date   DATE NOT NULL,
+    reason     TEXT,
+    created_at TIMESTAMPTZ NOT NULL DEFAULT now(),
+    CHECK (end_date >= start_date)
+);
+
+CREATE INDEX idx_teacher_absences_dates ON teacher_absences (start_date, end_date);
+
+CREATE TABLE lesson_overrides (
+    id                    UUID PRIMARY KEY DEFAULT gen_random_uuid(),
+    lesson_id             UUID NOT NULL REFERENCES schedule_lessons (id) ON DELETE CASCADE,
+    date                  DATE NOT NULL,
+    action                TEXT NOT NULL CHECK (action IN ('cancel', 'substitute', 'move')),
+    absence_id            UUID REFERENCES teacher_absences (id) ON DELETE CASCADE,
+    substitute_teacher_id UUID REFERENCES teachers (id) ON DELETE CASCADE,
+    room_id               UUID REFERENCES classrooms (id) ON DELETE SET NULL,
+    new_date              DATE,
+    new_lesson_number     INT CHECK (new_lesson_number > 0),
+    comment               TEXT,
+    created_at            TIMESTAMPTZ NOT NULL DEFAULT now(),
+    UNIQUE (lesson_id, date)
+);
+
+CREATE INDEX idx_lesson_overrides_date ON lesson_overrides (date);
+CREATE INDEX idx_lesson_overrides_new_date ON lesson_overrides (new_date);
@@ -0,0 +1,3 @@
+DROP TABLE teacher_availability;
+ALTER TABLE teachers DROP COLUMN max_consecutive_lessons;
+ALTER TABLE teachers DROP COLUMN max_lessons_per_day;
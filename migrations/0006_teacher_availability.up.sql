@@ -0,0 +1,15 @@
+-- Teacher availability: hard-unavailable and soft-preferred lessons of the week plus daily load limits.
+-- A row without lesson_number covers the whole day (e.g. a methodical day).
+
+ALTER TABLE teachers ADD COLUMN max_lessons_per_day INT CHECK (max_lessons_per_day > 0);
+ALTER TABLE teachers ADD COLUMN max_consecutive_lessons INT CHECK (max_consecutive_lessons > 0);
+
+CREATE TABLE teacher_availability (
+    id            UUID PRIMARY KEY DEFAULT gen_random_uuid(),
+    teacher_id    UUID NOT NULL REFERENCES teachers (id) ON DELETE CASCADE,
+    day_of_week   INT  NOT NULL CHECK (day_of_week BETWEEN 1 AND 7),
+    lesson_number INT  CHECK (lesson_number > 0),
+    status        TEXT NOT NULL CHECK (status IN ('unavailable', 'preferred'))
+);
+
+CREATE INDEX idx_teacher_availability_teacher ON teacher_availability (teacher_id);
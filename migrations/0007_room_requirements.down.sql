@@ -0,0 +1,2 @@
+ALTER TABLE subjects DROP COLUMN required_equipment;
+ALTER TABLE classrooms DROP COLUMN equipment_tags;
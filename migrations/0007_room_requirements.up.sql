@@ -0,0 +1,9 @@
+-- Classroom capabilities and subject room requirements.
+-- Equipment is a set of tags; a lesson room must have every tag its subject requires.
+
+ALTER TABLE classrooms ADD COLUMN IF NOT EXISTS capacity INT CHECK (capacity > 0);
+ALTER TABLE classrooms ADD COLUMN equipment_tags TEXT[] NOT NULL DEFAULT '{}'
+    CHECK (equipment_tags <@ ARRAY['lab', 'computers', 'gym', 'projector']);
+
+ALTER TABLE subjects ADD COLUMN required_equipment TEXT[] NOT NULL DEFAULT '{}'
+    CHECK (required_equipment <@ ARRAY['lab', 'computers', 'gym', 'projector']);
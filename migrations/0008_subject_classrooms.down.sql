@@ -0,0 +1 @@
+DROP TABLE subject_classrooms;
@@ -0,0 +1,7 @@
+-- Rooms suited to a subject (chemistry lab, gym); the room assigner prefers them after the teacher's own room.
+
+CREATE TABLE subject_classrooms (
+    subject_id   UUID NOT NULL REFERENCES subjects (id) ON DELETE CASCADE,
+    classroom_id UUID NOT NULL REFERENCES classrooms (id) ON DELETE CASCADE,
+    PRIMARY KEY (subject_id, classroom_id)
+);
@@ -0,0 +1,2 @@
+ALTER TABLE classrooms DROP COLUMN floor;
+ALTER TABLE classrooms DROP COLUMN building;
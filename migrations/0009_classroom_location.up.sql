@@ -0,0 +1,4 @@
+-- Classroom location, used to sort free rooms by closeness to a teacher's own room.
+
+ALTER TABLE classrooms ADD COLUMN building TEXT;
+ALTER TABLE classrooms ADD COLUMN floor INT;
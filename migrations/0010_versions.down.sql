@@ -0,0 +1,5 @@
+DROP TABLE IF EXISTS schedule_revisions;
+
+ALTER TABLE teachers DROP COLUMN version;
+ALTER TABLE classes DROP COLUMN version;
+ALTER TABLE schedules DROP COLUMN version;
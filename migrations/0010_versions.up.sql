@@ -0,0 +1,15 @@
+-- Optimistic concurrency: every write increments the row version, exposed to clients as an ETag.
+-- schedule_revisions keeps the timetable as of each schedule version, so a rejected write can be
+-- answered with a diff between the client's base version and the current one.
+
+ALTER TABLE schedules ADD COLUMN version INT NOT NULL DEFAULT 1;
+ALTER TABLE classes ADD COLUMN version INT NOT NULL DEFAULT 1;
+ALTER TABLE teachers ADD COLUMN version INT NOT NULL DEFAULT 1;
+
+CREATE TABLE schedule_revisions (
+    schedule_id UUID        NOT NULL REFERENCES schedules (id) ON DELETE CASCADE,
+    version     INT         NOT NULL,
+    content     JSONB       NOT NULL,
+    created_at  TIMESTAMPTZ NOT NULL DEFAULT now(),
+    PRIMARY KEY (schedule_id, version)
+);
@@ -0,0 +1,5 @@
+DROP TRIGGER IF EXISTS schedule_revisions_no_update ON schedule_revisions;
+DROP FUNCTION IF EXISTS schedule_revisions_immutable();
+
+ALTER TABLE schedule_revisions DROP COLUMN comment;
+ALTER TABLE schedule_revisions DROP COLUMN author_id;
@@ -0,0 +1,20 @@
+-- Who made each schedule revision and why. Revisions are history: they are never changed,
+-- only removed together with their schedule. The author is unset when the user is deleted.
+
+ALTER TABLE schedule_revisions ADD COLUMN author_id UUID REFERENCES users (id) ON DELETE SET NULL;
+ALTER TABLE schedule_revisions ADD COLUMN comment TEXT;
+
+CREATE FUNCTION schedule_revisions_immutable() RETURNS trigger AS $$
+BEGIN
+    IF (NEW.schedule_id, NEW.version, NEW.content, NEW.comment, NEW.created_at)
+           IS DISTINCT FROM (OLD.schedule_id, OLD.version, OLD.content, OLD.comment, OLD.created_at)
+       OR NEW.author_id IS NOT NULL AND NEW.author_id IS DISTINCT FROM OLD.author_id THEN
+        RAISE EXCEPTION 'schedule revisions are immutable';
+    END IF;
+    RETURN NEW;
+END;
+$$ LANGUAGE plpgsql;
+
+CREATE TRIGGER schedule_revisions_no_update
+    BEFORE UPDATE ON schedule_revisions
+    FOR EACH ROW EXECUTE FUNCTION schedule_revisions_immutable();
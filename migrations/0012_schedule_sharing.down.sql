@@ -0,0 +1,3 @@
+DROP TABLE IF EXISTS schedule_shares;
+
+ALTER TABLE schedules DROP COLUMN status;
@@ -0,0 +1,18 @@
+-- School-wide schedules. A published schedule is the official timetable everyone sees; drafts are
+-- visible to their owner and to the users and roles they are shared with.
+
+ALTER TABLE schedules ADD COLUMN status TEXT NOT NULL DEFAULT 'draft'
+    CHECK (status IN ('draft', 'published'));
+
+CREATE TABLE schedule_shares (
+    id          UUID PRIMARY KEY DEFAULT gen_random_uuid(),
+    schedule_id UUID        NOT NULL REFERENCES schedules (id) ON DELETE CASCADE,
+    user_id     UUID REFERENCES users (id) ON DELETE CASCADE,
+    role        TEXT,
+    permission  TEXT        NOT NULL CHECK (permission IN ('view', 'edit')),
+    created_at  TIMESTAMPTZ NOT NULL DEFAULT now(),
+    CHECK ((user_id IS NULL) <> (role IS NULL))
+);
+
+CREATE UNIQUE INDEX schedule_shares_user_idx ON schedule_shares (schedule_id, user_id) WHERE user_id IS NOT NULL;
+CREATE UNIQUE INDEX schedule_shares_role_idx ON schedule_shares (schedule_id, role) WHERE role IS NOT NULL;
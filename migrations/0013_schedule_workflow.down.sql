@@ -0,0 +1,6 @@
+DROP TABLE IF EXISTS schedule_transitions;
+
+ALTER TABLE schedules DROP COLUMN derived_from;
+UPDATE schedules SET status = 'draft' WHERE status NOT IN ('draft', 'published');
+ALTER TABLE schedules DROP CONSTRAINT schedules_status_check;
+ALTER TABLE schedules ADD CONSTRAINT schedules_status_check CHECK (status IN ('draft', 'published'));
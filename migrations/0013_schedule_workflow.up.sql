@@ -0,0 +1,20 @@
+-- Sign-off before a timetable goes live: draft -> review -> approved -> published.
+-- Only drafts are edited; a published schedule is replaced by publishing a draft derived from it,
+-- and the one it replaces is archived. Every status change is kept with its author and comment.
+
+ALTER TABLE schedules DROP CONSTRAINT schedules_status_check;
+ALTER TABLE schedules ADD CONSTRAINT schedules_status_check
+    CHECK (status IN ('draft', 'review', 'approved', 'published', 'archived'));
+ALTER TABLE schedules ADD COLUMN derived_from UUID REFERENCES schedules (id) ON DELETE SET NULL;
+
+CREATE TABLE schedule_transitions (
+    id          UUID PRIMARY KEY DEFAULT gen_random_uuid(),
+    schedule_id UUID        NOT NULL REFERENCES schedules (id) ON DELETE CASCADE,
+    from_status TEXT        NOT NULL,
+    to_status   TEXT        NOT NULL,
+    actor_id    UUID REFERENCES users (id) ON DELETE SET NULL,
+    comment     TEXT,
+    created_at  TIMESTAMPTZ NOT NULL DEFAULT now()
+);
+
+CREATE INDEX schedule_transitions_schedule_idx ON schedule_transitions (schedule_id, created_at);
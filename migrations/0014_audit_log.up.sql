@@ -0,0 +1,17 @@
+-- Who changed what: every write to classes, teachers, subjects, classrooms and schedules
+-- with the state of the entity before and after it and the request it was made in.
+
+CREATE TABLE audit_log (
+    id         UUID PRIMARY KEY DEFAULT gen_random_uuid(),
+    actor_id   UUID REFERENCES users (id) ON DELETE SET NULL,
+    action     TEXT        NOT NULL,
+    entity     TEXT        NOT NULL,
+    entity_id  UUID,
+    before     JSONB,
+    after      JSONB,
+    request_id TEXT,
+    created_at TIMESTAMPTZ NOT NULL DEFAULT now()
+);
+
+CREATE INDEX audit_log_entity_idx ON audit_log (entity, entity_id, created_at);
+CREATE INDEX audit_log_created_idx ON audit_log (created_at);
@@ -0,0 +1,17 @@
+-- Calendar subscriptions: a secret URL a calendar app polls for the timetable of one teacher,
+-- class or room. Only a hash of the token is stored; the URL is shown once when the feed is created.
+
+CREATE TABLE calendar_feeds (
+    id           UUID PRIMARY KEY DEFAULT gen_random_uuid(),
+    user_id      UUID        NOT NULL REFERENCES users (id) ON DELETE CASCADE,
+    token_hash   TEXT        NOT NULL UNIQUE,
+    term_id      UUID REFERENCES academic_terms (id) ON DELETE CASCADE, -- NULL: the current term when polled
+    teacher_id   UUID REFERENCES teachers (id) ON DELETE CASCADE,
+    class_id     UUID REFERENCES classes (id) ON DELETE CASCADE,
+    classroom_id UUID REFERENCES classrooms (id) ON DELETE CASCADE,
+    created_at   TIMESTAMPTZ NOT NULL DEFAULT now(),
+    last_used_at TIMESTAMPTZ,
+    CHECK (num_nonnulls(teacher_id, class_id, classroom_id) = 1)
+);
+
+CREATE INDEX calendar_feeds_user_idx ON calendar_feeds (user_id);
@@ -0,0 +1 @@
+-- short_name may predate 0016 (it is part of the Subject model), so it is kept
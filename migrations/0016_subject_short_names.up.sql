@@ -0,0 +1,2 @@
+-- Short subject names ("Физ-ра") for printed timetables, where the full name does not fit a cell
+ALTER TABLE subjects ADD COLUMN IF NOT EXISTS short_name TEXT;
@@ -0,0 +1 @@
+-- Which archived schedules were active is not kept, nothing to restore
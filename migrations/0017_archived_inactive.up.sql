@@ -0,0 +1,3 @@
+-- An archived schedule is no longer its owner's active one; publishing now clears the flag
+-- when it archives, this fixes schedules archived before
+UPDATE schedules SET is_active = false WHERE status = 'archived' AND is_active;